@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -15,12 +17,24 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/aura-chain/aura/faucet/pkg/abuse"
 	"github.com/aura-chain/aura/faucet/pkg/api"
+	"github.com/aura-chain/aura/faucet/pkg/captcha"
+	"github.com/aura-chain/aura/faucet/pkg/challenge"
 	"github.com/aura-chain/aura/faucet/pkg/config"
 	"github.com/aura-chain/aura/faucet/pkg/database"
+	"github.com/aura-chain/aura/faucet/pkg/emailotp"
+	"github.com/aura-chain/aura/faucet/pkg/events"
 	"github.com/aura-chain/aura/faucet/pkg/faucet"
+	"github.com/aura-chain/aura/faucet/pkg/geo"
+	tracking "github.com/aura-chain/aura/faucet/pkg/metrics"
+	"github.com/aura-chain/aura/faucet/pkg/pow"
 	metrics "github.com/aura-chain/aura/faucet/pkg/prometheus"
 	"github.com/aura-chain/aura/faucet/pkg/ratelimit"
+	"github.com/aura-chain/aura/faucet/pkg/receipt"
+	"github.com/aura-chain/aura/faucet/pkg/redact"
+	"github.com/aura-chain/aura/faucet/pkg/report"
+	"github.com/aura-chain/aura/faucet/pkg/webhook"
 )
 
 func init() {
@@ -60,12 +74,22 @@ func main() {
 		log.Fatalf("Invalid configuration: %v", err)
 	}
 
+	if cfg.RedactLogs {
+		log.SetFormatter(redact.NewFormatter(redact.New(cfg.Secrets()...), &log.JSONFormatter{}))
+	}
+	cfg.LogEffectiveConfig()
+
 	log.WithFields(log.Fields{
-		"port":              cfg.Port,
-		"chain_id":          cfg.ChainID,
+		"port":               cfg.Port,
+		"chain_id":           cfg.ChainID,
 		"amount_per_request": cfg.AmountPerRequest,
 	}).Info("Configuration loaded")
 
+	// Amounts that are not whole display units show as fractions or rounded
+	for _, amount := range cfg.UnalignedAmounts() {
+		log.Warnf("Configured amount does not align to whole display units: %s", amount)
+	}
+
 	// Initialize database (optional)
 	var db *database.DB
 	if cfg.DatabaseURL != "" {
@@ -74,6 +98,12 @@ func main() {
 			log.Warnf("Failed to connect to database: %v (continuing without database)", err)
 		} else {
 			defer db.Close()
+			// Stats and recent listings read from the replica when configured
+			if cfg.DatabaseReadURL != "" {
+				if err := db.AttachReadReplica(cfg.DatabaseReadURL); err != nil {
+					log.Warnf("Failed to connect to read replica: %v (reading from primary)", err)
+				}
+			}
 			// Run database migrations
 			if err := db.Migrate(); err != nil {
 				log.Warnf("Failed to run database migrations: %v", err)
@@ -85,18 +115,49 @@ func main() {
 		log.Info("No DATABASE_URL configured, running without database")
 	}
 
-	// Initialize Redis for rate limiting (optional)
-	var rateLimiter *ratelimit.RateLimiter
-	if cfg.RedisURL != "" {
-		redisClient, err := ratelimit.NewRedisClient(cfg.RedisURL)
-		if err != nil {
-			log.Warnf("Failed to connect to Redis: %v (continuing without Redis rate limiting)", err)
+	// Initialize rate limiting (optional)
+	var rateLimiter api.RateLimiter
+	var deduper *ratelimit.Deduper
+	switch cfg.RateLimitBackend {
+	case "memory":
+		rateLimiter = ratelimit.NewMemoryLimiter(cfg.RateLimitConfig())
+		log.Info("Using in-memory rate limiting")
+	case "db":
+		if db == nil {
+			log.Warn("RATE_LIMIT_BACKEND=db but no database is available; running without rate limiting")
 		} else {
-			defer redisClient.Close()
-			rateLimiter = ratelimit.NewRateLimiter(redisClient, cfg.RateLimitConfig())
+			rateLimiter = ratelimit.NewDBLimiter(db, cfg.RateLimitConfig())
+			log.Info("Using database rate limiting")
+		}
+	default:
+		if cfg.RedisURL != "" {
+			redisClient, err := ratelimit.NewRedisClient(cfg.RedisURL)
+			if err != nil {
+				log.Warnf("Failed to connect to Redis: %v (continuing without Redis rate limiting)", err)
+			} else {
+				defer redisClient.Close()
+				rateLimiter = ratelimit.NewRateLimiter(redisClient, cfg.RateLimitConfig())
+				if cfg.RequestDedupWindow > 0 {
+					deduper = ratelimit.NewDeduper(redisClient, cfg.RequestDedupWindow)
+				}
+			}
+		} else {
+			log.Info("No REDIS_URL configured, running without Redis rate limiting")
+		}
+	}
+
+	// Scale per-IP limits by country when multipliers are configured
+	if len(cfg.CountryRateLimitMultipliers) > 0 {
+		if limiter, ok := rateLimiter.(interface {
+			SetCountryLimits(*ratelimit.CountryLimits)
+		}); ok {
+			resolver, err := geo.LoadCIDRFile(cfg.GeoIPFile)
+			if err != nil {
+				log.Fatalf("Failed to load GEOIP_CIDR_FILE: %v", err)
+			}
+			limiter.SetCountryLimits(ratelimit.NewCountryLimits(resolver, cfg.CountryRateLimitMultipliers))
+			log.WithField("countries", len(cfg.CountryRateLimitMultipliers)).Info("Per-country rate limits enabled")
 		}
-	} else {
-		log.Info("No REDIS_URL configured, running without Redis rate limiting")
 	}
 
 	// Initialize faucet service
@@ -112,21 +173,124 @@ func main() {
 	} else {
 		log.WithField("balance", balance).Info("Faucet initialized")
 	}
+	checkNodeVersion(cfg, faucetService)
+	if err := faucetService.CheckFeeBalance(); err != nil {
+		log.WithError(err).Warnf("Faucet may be unable to pay fees in %s", cfg.FeeDenom())
+	}
 
 	// Initialize Prometheus metrics
+	if len(cfg.RequestDurationBuckets) > 0 {
+		if err := metrics.SetRequestDurationBuckets(cfg.RequestDurationBuckets); err != nil {
+			log.Fatalf("Failed to configure request duration buckets: %v", err)
+		}
+	}
 	metrics.SetInfo(cfg.Version, cfg.ChainID, cfg.Denom)
 
-	// Start balance and node status monitor goroutine
-	go monitorBalanceAndNode(cfg, faucetService)
+	// Background goroutines run until backgroundCtx is cancelled on shutdown;
+	// the wait group lets shutdown wait for them before closing DB and Redis
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	var background sync.WaitGroup
+	runBackground := func(run func(ctx context.Context)) {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			run(backgroundCtx)
+		}()
+	}
+
+	// Start balance and node status monitor goroutine; it also keeps the
+	// balance cache served by /faucet/info warm
+	balanceCache := api.NewBalanceCache(cfg.BalanceCacheMaxAge)
+	heightTracker := api.NewHeightTracker(cfg.NodeStallTicks)
+	runBackground(func(ctx context.Context) {
+		monitorBalanceAndNode(ctx, cfg, faucetService, balanceCache, heightTracker)
+	})
+
+	// Start the payout webhook sender; undelivered events survive restarts in the outbox
+	if cfg.PayoutWebhookURL != "" {
+		if db == nil {
+			log.Warn("PAYOUT_WEBHOOK_URL is set but no database is available; webhooks are disabled")
+		} else {
+			sender := webhook.NewSender(db, webhook.SenderConfig{
+				URL:         cfg.PayoutWebhookURL,
+				MaxAttempts: cfg.WebhookMaxAttempts,
+				RetryBase:   time.Duration(cfg.WebhookRetryBaseSeconds) * time.Second,
+			})
+			runBackground(sender.Run)
+		}
+	}
+
+	// Post the daily digest; the tracker supplies the day's counts and
+	// error breakdown and is only kept when there is a report to send
+	var tracker *tracking.MetricsTracker
+	if cfg.DailyReportWebhookURL != "" {
+		if db == nil {
+			log.Warn("DAILY_REPORT_WEBHOOK_URL is set but no database is available; the daily report is disabled")
+		} else {
+			hour, minute, location, err := cfg.DailyReportSchedule()
+			if err != nil {
+				log.Fatalf("Invalid daily report schedule: %v", err)
+			}
+			tracker = tracking.NewMetricsTracker()
+			if cfg.MetricsSnapshotInterval > 0 {
+				instance := cfg.InstanceID
+				if instance == "" {
+					instance, _ = os.Hostname()
+				}
+				snapshotter := tracking.NewSnapshotter(tracker, db, "tracker:"+instance, cfg.MetricsSnapshotInterval)
+				if err := snapshotter.Restore(); err != nil {
+					log.WithError(err).Warn("Failed to restore metrics snapshot; analytics start from zero")
+				}
+				runBackground(snapshotter.Run)
+			}
+			reporter := report.NewReporter(db, tracker, faucetService.GetBalance, report.Config{
+				URL:              cfg.DailyReportWebhookURL,
+				Hour:             hour,
+				Minute:           minute,
+				Location:         location,
+				Denom:            cfg.Denom,
+				AmountPerRequest: cfg.AmountPerRequest,
+				Reserve:          cfg.BalanceReserve.Saturated(),
+			})
+			runBackground(reporter.Run)
+		}
+	}
+
+	// Replay failed payouts in the background; each chain's service replays its own denom
+	const payoutRetryInterval = 15 * time.Second
+	retryPayouts := cfg.PayoutRetryAttempts > 0
+	if retryPayouts && db == nil {
+		log.Warn("PAYOUT_RETRY_ATTEMPTS is set but no database is available; payout retries are disabled")
+		retryPayouts = false
+	}
+	if retryPayouts {
+		runBackground(func(ctx context.Context) {
+			faucetService.RunRetries(ctx, payoutRetryInterval)
+		})
+	}
+	runBackground(faucetService.RunConfirmations)
+	runBackground(faucetService.RunPostPayouts)
 
 	// Setup Gin router
 	if cfg.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
+	if !cfg.PublicStats && cfg.AdminToken == "" {
+		log.Warn("PUBLIC_STATS is false and ADMIN_TOKEN is empty; statistics are not served to anyone")
+	}
+	if cfg.TestBypassToken != "" {
+		log.Warn("TEST_BYPASS_TOKEN is set; requests presenting it skip captcha and proof of work")
+	}
 
 	router := gin.New()
 	router.Use(gin.Recovery())
-	router.Use(loggingMiddleware())
+	if cfg.TrustedProxyDepth > 0 {
+		// The middleware resolves the client from X-Forwarded-For itself
+		router.ForwardedByClientIP = false
+		router.Use(api.ForwardedForMiddleware(cfg.TrustedProxyDepth, cfg.MaxForwardedHops))
+	}
+	router.Use(loggingMiddleware(cfg.RedactLogs))
 
 	// CORS configuration
 	corsConfig := cors.Config{
@@ -141,35 +305,151 @@ func main() {
 
 	// Initialize API handlers
 	apiHandler := api.NewHandler(cfg, faucetService, rateLimiter, db)
-
-	// Prometheus metrics endpoint
-	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
-
-	// API routes
-	v1 := router.Group("/api/v1")
-	{
-		// Health check endpoints (Kubernetes-compatible)
-		v1.GET("/health", apiHandler.Health)
-		v1.GET("/ready", apiHandler.Ready)
-		v1.GET("/live", apiHandler.Live)
-
-		// Faucet endpoints
-		faucetGroup := v1.Group("/faucet")
-		{
-			faucetGroup.GET("/info", apiHandler.GetFaucetInfo)
-			faucetGroup.GET("/recent", apiHandler.GetRecentTransactions)
-			faucetGroup.POST("/request", apiHandler.RequestTokens)
-			faucetGroup.GET("/stats", apiHandler.GetStatistics)
+	apiHandler.SetBackgroundRunner(runBackground)
+	apiHandler.SetBalanceCache(balanceCache)
+	if cfg.StatsCacheTTL > 0 && db != nil {
+		statsCache := api.NewStatsCache(db.GetStatistics, cfg.StatsCacheTTL)
+		runBackground(statsCache.Run)
+		apiHandler.SetStatsCache(statsCache)
+	}
+	apiHandler.SetHeightTracker(heightTracker)
+	if deduper != nil {
+		apiHandler.SetDeduper(deduper)
+	}
+	if tracker != nil {
+		apiHandler.SetMetricsTracker(tracker)
+	}
+	if cfg.EventsNATSURL != "" {
+		var natsTLS *tls.Config
+		if cfg.EventsNATSCAFile != "" {
+			var err error
+			if natsTLS, err = events.NATSTLSConfig(cfg.EventsNATSCAFile); err != nil {
+				log.Fatalf("Invalid EVENTS_NATS_CA_FILE: %v", err)
+			}
+		}
+		nats, err := events.NewNATSPublisher(cfg.EventsNATSURL, cfg.EventsSubject, natsTLS)
+		if err != nil {
+			log.Fatalf("Invalid EVENTS_NATS_URL: %v", err)
+		}
+		publisher := events.NewAsync(nats, cfg.EventsBufferSize)
+		defer publisher.Close()
+		apiHandler.SetEventPublisher(publisher)
+		log.WithField("subject", cfg.EventsSubject).Info("Publishing faucet events to NATS")
+	}
+	if cfg.AddressBlocklistFile != "" {
+		blocklist, err := config.LoadAddressBlocklist(cfg.AddressBlocklistFile)
+		if err != nil {
+			log.Fatalf("Failed to load ADDRESS_BLOCKLIST_FILE: %v", err)
+		}
+		apiHandler.SetAddressBlocklist(blocklist)
+		log.WithField("entries", len(blocklist)).Info("Address blocklist enabled")
+	}
+	if cfg.AllowlistURL != "" {
+		allowlistSync := api.NewAllowlistSync(cfg.AllowlistURL, apiHandler)
+		runBackground(func(ctx context.Context) {
+			allowlistSync.Run(ctx, cfg.AllowlistRefresh)
+		})
+		log.WithField("refresh", cfg.AllowlistRefresh.String()).Info("Syncing allowlist from ALLOWLIST_URL")
+	}
+	if cfg.ReceiptSigningKey != "" {
+		signer, err := receipt.NewSigner([]byte(cfg.ReceiptSigningKey))
+		if err != nil {
+			log.Fatalf("Invalid RECEIPT_SIGNING_KEY: %v", err)
 		}
+		signer.SetMaxClockSkew(cfg.MaxClockSkew)
+		apiHandler.SetReceiptSigner(signer)
+	}
+	for _, chain := range cfg.Chains {
+		chainCfg := cfg.ForChain(chain)
+		chainService, err := faucet.NewService(chainCfg, db)
+		if err != nil {
+			log.Fatalf("Failed to initialize faucet service for chain %s: %v", chain.ChainID, err)
+		}
+		apiHandler.AddChain(chainCfg, chainService)
+		if retryPayouts {
+			runBackground(func(ctx context.Context) {
+				chainService.RunRetries(ctx, payoutRetryInterval)
+			})
+		}
+		runBackground(chainService.RunConfirmations)
+		runBackground(chainService.RunPostPayouts)
+		log.WithField("chain_id", chain.ChainID).Info("Additional chain configured")
+	}
+	if cfg.GlobalRateLimitPerMinute > 0 {
+		apiHandler.SetGlobalLimiter(ratelimit.NewGlobalLimiter(cfg.GlobalRateLimitPerMinute, cfg.SlowStartDuration, cfg.SlowStartInitialPercent))
 	}
+	challengeLimits := challenge.Limits{PerOwner: cfg.ChallengeMaxPerIP, Total: cfg.ChallengeMaxOutstanding}
+	if cfg.RequirePoW {
+		proofOfWork := pow.NewProofOfWork(cfg.PoWDifficulty)
+		defer proofOfWork.Close()
+		proofOfWork.SetMinSolveTime(cfg.PoWMinSolveTime)
+		proofOfWork.SetMaxSolutionAge(cfg.MaxSolutionAge)
+		proofOfWork.SetLimits(challengeLimits)
+		apiHandler.SetProofOfWork(proofOfWork)
+	}
+	if cfg.RequireRequestNonce {
+		nonceStore := challenge.NewMemoryStore[*challenge.RequestNonce](0)
+		nonceStore.SetLimits(challengeLimits)
+		nonces := challenge.NewNonces(nonceStore, cfg.RequestNonceTTL)
+		defer nonces.Close()
+		nonces.SetMaxClockSkew(cfg.MaxClockSkew)
+		apiHandler.SetRequestNonces(nonces)
+	}
+	if cfg.RequireEmailOTP {
+		// Per-email limits are counted from the sent codes in the database
+		if db == nil {
+			log.Fatal("EMAIL_OTP_REQUIRED is set but no database is available")
+		}
+		mailer := emailotp.NewSMTPMailer(cfg.EmailOTPSMTPHost, cfg.EmailOTPSMTPPort,
+			cfg.EmailOTPSMTPUsername, cfg.EmailOTPSMTPPassword, cfg.EmailOTPFrom)
+		emailOTP := emailotp.New(db, mailer, emailotp.Options{
+			CodeTTL:     cfg.EmailOTPCodeTTL,
+			TokenTTL:    cfg.EmailOTPTokenTTL,
+			MaxPerEmail: cfg.EmailOTPMaxPerEmail,
+			Window:      cfg.EmailOTPWindow,
+			MaxAttempts: cfg.EmailOTPMaxAttempts,
+		})
+		apiHandler.SetEmailOTP(emailOTP)
+	}
+	detectorConfig := abuse.DetectorConfig{
+		Weights: cfg.AbuseRiskWeights,
+		Actions: cfg.AbuseRiskActions,
+	}
+	if cfg.IPReputationProvider != "" {
+		client, err := faucet.NewHTTPClient(cfg.HTTPProxy, cfg.IPReputationTimeout)
+		if err != nil {
+			log.Fatalf("Invalid proxy for IP reputation lookups: %v", err)
+		}
+		var provider abuse.ReputationProvider
+		if cfg.IPReputationProvider == "abuseipdb" {
+			provider = abuse.NewAbuseIPDBProvider(cfg.IPReputationAPIKey, "", client)
+		} else {
+			provider = abuse.NewHTTPScoreProvider(cfg.IPReputationURL, cfg.IPReputationAPIKey, client)
+		}
+		detectorConfig.Reputation = abuse.NewReputationChecker(provider, cfg.IPReputationCacheTTL, cfg.IPReputationTimeout)
+		detectorConfig.ReputationThreshold = cfg.IPReputationThreshold
+		detectorConfig.ReputationCaptcha = cfg.IPReputationCaptcha
+		log.WithField("provider", cfg.IPReputationProvider).Info("IP reputation scoring enabled")
+	}
+	detector := abuse.NewAbuseDetector(detectorConfig)
+	defer detector.Close()
+	apiHandler.SetAbuseDetector(detector)
+	captchaService := captcha.NewCaptchaService(captcha.CaptchaOptions{
+		MinDifficulty:   cfg.CaptchaMinDifficulty,
+		MaxDifficulty:   cfg.CaptchaMaxDifficulty,
+		CaseInsensitive: &cfg.CaptchaCaseInsensitive,
+		Limits:          challengeLimits,
+		MaxSolutionAge:  cfg.MaxSolutionAge,
+	})
+	defer captchaService.Close()
+	apiHandler.SetCaptchaService(captchaService)
 
-	// Serve static frontend files
-	router.Static("/assets", "./frontend/assets")
-	router.StaticFile("/", "./frontend/index.html")
-	router.StaticFile("/wallet.html", "./frontend/wallet.html")
-	router.StaticFile("/styles.css", "./frontend/styles.css")
-	router.StaticFile("/app.js", "./frontend/app.js")
-	router.StaticFile("/wallet.js", "./frontend/wallet.js")
+	// Every route lives under BASE_PATH when served from a sub-path
+	base := router.Group(cfg.BasePath)
+	registerAPIRoutes(base, cfg, apiHandler)
+
+	// Serve static frontend files unless the UI is hosted separately
+	registerFrontendRoutes(base, cfg)
 
 	// 404 handler
 	router.NoRoute(func(c *gin.Context) {
@@ -210,11 +490,103 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// Stop background work and wait for in-flight iterations to finish
+	stopBackground()
+	background.Wait()
+
 	log.Info("Server exited")
 }
 
-// loggingMiddleware logs HTTP requests
-func loggingMiddleware() gin.HandlerFunc {
+// registerAPIRoutes registers the metrics endpoint and the /api/v1 routes
+// on router, which is the BASE_PATH group in production
+func registerAPIRoutes(router gin.IRouter, cfg *config.Config, apiHandler *api.Handler) {
+	// Prometheus metrics endpoint
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	// API routes
+	v1 := router.Group("/api/v1")
+	v1.Use(api.GzipMiddleware())
+	{
+		// Health check endpoints (Kubernetes-compatible)
+		v1.GET("/health", apiHandler.Health)
+		v1.GET("/ready", apiHandler.Ready)
+		v1.GET("/live", apiHandler.Live)
+
+		// Built-in image captcha
+		v1.GET("/captcha", apiHandler.GetCaptcha)
+		v1.GET("/pow/challenge", apiHandler.GetPoWChallenge)
+		v1.GET("/nonce", apiHandler.GetRequestNonce)
+
+		// Read endpoints get a generous per-IP limit; health probes are exempt
+		readLimit := func(c *gin.Context) { c.Next() }
+		if cfg.ReadRateLimitPerMinute > 0 {
+			readLimit = api.ReadRateLimitMiddleware(ratelimit.NewMemoryLimiter(map[string]interface{}{
+				"per_ip":      cfg.ReadRateLimitPerMinute,
+				"per_address": 0,
+				"window":      time.Minute,
+			}))
+		}
+
+		// Each email start sends mail, so those endpoints get a tighter
+		// per-IP limit
+		emailLimit := func(c *gin.Context) { c.Next() }
+		if cfg.EmailOTPPerIPPerHour > 0 {
+			emailLimit = api.EmailRateLimitMiddleware(ratelimit.NewMemoryLimiter(map[string]interface{}{
+				"per_ip":      cfg.EmailOTPPerIPPerHour,
+				"per_address": 0,
+				"window":      time.Hour,
+			}))
+		}
+
+		// Faucet endpoints
+		faucetGroup := v1.Group("/faucet")
+		{
+			faucetGroup.GET("/info", readLimit, apiHandler.GetFaucetInfo)
+			faucetGroup.GET("/config", apiHandler.GetFaucetConfig)
+			faucetGroup.GET("/chain.json", readLimit, apiHandler.GetChainDescriptor)
+			faucetGroup.GET("/eligibility", readLimit, apiHandler.CheckEligibility)
+			faucetGroup.POST("/validate", readLimit, apiHandler.ValidateAddresses)
+			faucetGroup.GET("/recent", readLimit, apiHandler.GetRecentTransactions)
+			faucetGroup.POST("/request", apiHandler.RequestTokens)
+			faucetGroup.POST("/email/start", emailLimit, apiHandler.StartEmailVerification)
+			faucetGroup.POST("/email/verify", emailLimit, apiHandler.VerifyEmail)
+			faucetGroup.GET("/stats", readLimit, apiHandler.GetStatistics)
+			faucetGroup.GET("/status/:tx_hash", readLimit, apiHandler.GetRequestStatus)
+			faucetGroup.GET("/queue", readLimit, apiHandler.GetQueueStatus)
+		}
+
+		// Admin endpoints are only served when a token is configured
+		if cfg.AdminToken != "" {
+			adminGroup := v1.Group("/admin", api.AdminAuthMiddleware(cfg.AdminToken))
+			{
+				adminGroup.POST("/requests/:id/replay", apiHandler.AdminReplayRequest)
+				adminGroup.POST("/batch", apiHandler.AdminBatchSend)
+				adminGroup.GET("/batch/:id", apiHandler.AdminBatchStatus)
+			}
+		}
+	}
+}
+
+// registerFrontendRoutes serves the bundled static frontend. With
+// SERVE_FRONTEND=false nothing is registered, leaving unknown paths to the
+// API's JSON 404 handler.
+func registerFrontendRoutes(router gin.IRoutes, cfg *config.Config) {
+	if !cfg.ServeFrontend {
+		return
+	}
+
+	router.Static("/assets", "./frontend/assets")
+	router.StaticFile("/", "./frontend/index.html")
+	router.StaticFile("/wallet.html", "./frontend/wallet.html")
+	router.StaticFile("/styles.css", "./frontend/styles.css")
+	router.StaticFile("/app.js", "./frontend/app.js")
+	router.StaticFile("/wallet.js", "./frontend/wallet.js")
+}
+
+// loggingMiddleware logs HTTP requests. With redactPaths it logs the route
+// pattern instead of the raw URL: path parameters and query strings carry
+// addresses, tx hashes and tokens that the redacting formatter can't pick out.
+func loggingMiddleware(redactPaths bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
@@ -225,7 +597,11 @@ func loggingMiddleware() gin.HandlerFunc {
 		latency := time.Since(start)
 		statusCode := c.Writer.Status()
 
-		if raw != "" {
+		if redactPaths {
+			if route := c.FullPath(); route != "" {
+				path = route
+			}
+		} else if raw != "" {
 			path = path + "?" + raw
 		}
 
@@ -240,34 +616,80 @@ func loggingMiddleware() gin.HandlerFunc {
 	}
 }
 
-// monitorBalanceAndNode periodically updates balance and node status metrics
-func monitorBalanceAndNode(cfg *config.Config, svc *faucet.Service) {
+// checkNodeVersion compares the node's version with the supported range.
+// Chain binaries change their tx JSON output between versions, which can
+// break tx hash parsing, so a mismatch is loud (or fatal in strict mode).
+func checkNodeVersion(cfg *config.Config, svc *faucet.Service) {
+	if cfg.NodeVersionMin == "" && cfg.NodeVersionMax == "" {
+		return
+	}
+
+	status, err := svc.GetNodeStatus(context.Background())
+	if err != nil {
+		log.WithError(err).Warn("Could not query node version for compatibility check")
+		return
+	}
+
+	version := status.NodeInfo.Version
+	if err := faucet.CheckNodeVersion(version, cfg.NodeVersionMin, cfg.NodeVersionMax); err != nil {
+		if cfg.NodeVersionStrict {
+			log.Fatalf("Unsupported node version: %v", err)
+		}
+		log.WithFields(log.Fields{
+			"node_version": version,
+			"min":          cfg.NodeVersionMin,
+			"max":          cfg.NodeVersionMax,
+		}).WithError(err).Warn("UNSUPPORTED NODE VERSION: transaction output parsing may fail")
+		return
+	}
+	log.WithField("node_version", version).Info("Node version is supported")
+}
+
+// monitorBalanceAndNode periodically updates balance and node status
+// metrics, and runs the auto-refill check, until ctx is cancelled
+func monitorBalanceAndNode(ctx context.Context, cfg *config.Config, svc *faucet.Service, balances *api.BalanceCache, heights *api.HeightTracker) {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
 	// Initial update
-	updateMetrics(cfg, svc)
-
-	for range ticker.C {
-		updateMetrics(cfg, svc)
+	updateMetrics(cfg, svc, balances, heights)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			updateMetrics(cfg, svc, balances, heights)
+		}
 	}
 }
 
-func updateMetrics(cfg *config.Config, svc *faucet.Service) {
+func updateMetrics(cfg *config.Config, svc *faucet.Service, balances *api.BalanceCache, heights *api.HeightTracker) {
 	// Update balance
 	balance, err := svc.GetBalance()
 	if err != nil {
 		log.WithError(err).Debug("Failed to get faucet balance for metrics")
 	} else {
 		metrics.UpdateBalance(cfg.Denom, balance)
+		balances.Set(cfg.Denom, balance)
 	}
 
+	// Top the wallet up here rather than on the payout path, so a slow
+	// treasury transfer never holds up a request
+	svc.MaybeRefill()
+
 	// Update node status
-	status, err := svc.GetNodeStatus()
+	status, err := svc.GetNodeStatus(context.Background())
 	if err != nil {
 		log.WithError(err).Debug("Failed to get node status for metrics")
 		metrics.UpdateNodeStatus(cfg.ChainID, false, false)
 	} else {
 		metrics.UpdateNodeStatus(cfg.ChainID, true, !status.SyncInfo.CatchingUp)
+		// An unparseable height is skipped rather than counted as a stall
+		if height, err := status.Height(); err != nil {
+			log.WithError(err).Warn("Failed to parse node block height")
+		} else {
+			heights.Observe(height)
+		}
 	}
 }
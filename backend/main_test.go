@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aura-chain/aura/faucet/pkg/api"
+	"github.com/aura-chain/aura/faucet/pkg/config"
+	"github.com/aura-chain/aura/faucet/pkg/faucet"
+)
+
+func routePaths(router *gin.Engine) []string {
+	var paths []string
+	for _, route := range router.Routes() {
+		paths = append(paths, route.Path)
+	}
+	return paths
+}
+
+func TestRegisterFrontendRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	enabled := gin.New()
+	registerFrontendRoutes(enabled, &config.Config{ServeFrontend: true})
+	assert.Contains(t, routePaths(enabled), "/")
+	assert.Contains(t, routePaths(enabled), "/app.js")
+
+	disabled := gin.New()
+	registerFrontendRoutes(disabled, &config.Config{ServeFrontend: false})
+	assert.Empty(t, disabled.Routes())
+}
+
+func TestMonitorBalanceAndNodeStopsOnCancel(t *testing.T) {
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer node.Close()
+
+	cfg := &config.Config{NodeRPC: node.URL, NodeREST: node.URL, ChainID: "test-chain", Denom: "uaura"}
+	svc, err := faucet.NewService(cfg, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		monitorBalanceAndNode(ctx, cfg, svc, api.NewBalanceCache(time.Minute), api.NewHeightTracker(0))
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("monitor did not stop after the context was cancelled")
+	}
+}
+
+func TestRoutesServedUnderBasePath(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{BasePath: "/faucet", ServeFrontend: true}
+	apiHandler := api.NewHandler(cfg, nil, nil, nil)
+	router := gin.New()
+	base := router.Group(cfg.BasePath)
+	registerAPIRoutes(base, cfg, apiHandler)
+	registerFrontendRoutes(base, cfg)
+
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, get("/faucet/api/v1/live"))
+	assert.Equal(t, http.StatusOK, get("/faucet/metrics"))
+	assert.Equal(t, http.StatusNotFound, get("/api/v1/live"))
+	assert.Contains(t, routePaths(router), "/faucet/")
+	assert.Contains(t, routePaths(router), "/faucet/app.js")
+}
+
+func TestLoggingMiddlewarePaths(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logged := func(redactPaths bool) string {
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer log.SetOutput(os.Stderr)
+
+		router := gin.New()
+		router.Use(loggingMiddleware(redactPaths))
+		router.GET("/faucet/status/:tx_hash", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/faucet/status/ABC123?token=secret", nil)
+		router.ServeHTTP(w, req)
+		return buf.String()
+	}
+
+	redacted := logged(true)
+	assert.Contains(t, redacted, "/faucet/status/:tx_hash")
+	assert.NotContains(t, redacted, "ABC123")
+	assert.NotContains(t, redacted, "secret")
+
+	assert.Contains(t, logged(false), "/faucet/status/ABC123?token=secret", "without REDACT_LOGS the full URL is kept")
+}
@@ -15,16 +15,34 @@ type AbuseDetector struct {
 	blockedAddrs    map[string]time.Time
 	mu              sync.RWMutex
 	config          DetectorConfig
+
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 // DetectorConfig configures the abuse detector
 type DetectorConfig struct {
-	MaxAttemptsPerHour   int
-	MaxAttemptsPerDay    int
-	BlockDuration        time.Duration
-	SubnetCheckEnabled   bool
-	VPNDetectionEnabled  bool
-	SuspiciousThreshold  int
+	MaxAttemptsPerHour  int
+	MaxAttemptsPerDay   int
+	BlockDuration       time.Duration
+	SubnetCheckEnabled  bool
+	VPNDetectionEnabled bool
+	SuspiciousThreshold int
+
+	// Weights sets the points each risk signal adds to the score; nil uses
+	// DefaultRiskWeights. A zero weight disables that signal.
+	Weights *RiskWeights
+	// Actions maps score bands to the action taken; nil uses
+	// DefaultActionPolicy
+	Actions []ActionBand
+
+	// Reputation scores IPs with an external reputation service; nil
+	// disables the check. IPs scoring at least ReputationThreshold add the
+	// Reputation weight, and with ReputationCaptcha must also solve a
+	// captcha.
+	Reputation          *ReputationChecker
+	ReputationThreshold int
+	ReputationCaptcha   bool
 }
 
 // AttemptTracker tracks attempts from an IP or address
@@ -34,7 +52,11 @@ type AttemptTracker struct {
 	LastAttempt     time.Time
 	SuccessfulCount int
 	FailedCount     int
-	Addresses       map[string]int // IP -> addresses requested
+	// DailyCount counts attempts since DayStart, restarting every 24 hours
+	DailyCount int
+	DayStart   time.Time
+	Addresses  map[string]int // IP -> addresses requested
+	Funded     map[string]int // IP -> addresses successfully funded
 }
 
 // DetectionResult contains detection results
@@ -44,6 +66,7 @@ type DetectionResult struct {
 	RiskScore        int
 	BlockedUntil     *time.Time
 	RecommendedDelay time.Duration
+	Action           Action
 }
 
 // NewAbuseDetector creates a new abuse detector
@@ -60,6 +83,13 @@ func NewAbuseDetector(config DetectorConfig) *AbuseDetector {
 	if config.SuspiciousThreshold == 0 {
 		config.SuspiciousThreshold = 5
 	}
+	if config.Weights == nil {
+		weights := DefaultRiskWeights()
+		config.Weights = &weights
+	}
+	if config.Actions == nil {
+		config.Actions = DefaultActionPolicy()
+	}
 
 	detector := &AbuseDetector{
 		ipAttempts:      make(map[string]*AttemptTracker),
@@ -67,22 +97,37 @@ func NewAbuseDetector(config DetectorConfig) *AbuseDetector {
 		blockedIPs:      make(map[string]time.Time),
 		blockedAddrs:    make(map[string]time.Time),
 		config:          config,
+		done:            make(chan struct{}),
 	}
 
 	// Start cleanup goroutine
 	go detector.cleanup()
+	if config.Reputation != nil {
+		go config.Reputation.sweepEvery(detector.done)
+	}
 
 	return detector
 }
 
+// Close stops the hourly tracker cleanup and the reputation cache sweep;
+// later calls are no-ops
+func (ad *AbuseDetector) Close() {
+	ad.closeOnce.Do(func() { close(ad.done) })
+}
+
 // CheckRequest checks if a request should be allowed
 func (ad *AbuseDetector) CheckRequest(ip, address string) *DetectionResult {
+	// Looked up before taking the lock; a slow provider must not hold up
+	// other requests
+	badReputation := ad.badReputation(ip)
+
 	ad.mu.Lock()
 	defer ad.mu.Unlock()
 
 	result := &DetectionResult{
 		Allowed:   true,
 		RiskScore: 0,
+		Action:    ActionAllow,
 	}
 
 	// Check if IP is blocked
@@ -91,6 +136,7 @@ func (ad *AbuseDetector) CheckRequest(ip, address string) *DetectionResult {
 			result.Allowed = false
 			result.Reason = "IP address is temporarily blocked"
 			result.BlockedUntil = &blockedUntil
+			result.Action = ActionBlock
 			return result
 		}
 		// Unblock expired
@@ -103,6 +149,7 @@ func (ad *AbuseDetector) CheckRequest(ip, address string) *DetectionResult {
 			result.Allowed = false
 			result.Reason = "Address is temporarily blocked"
 			result.BlockedUntil = &blockedUntil
+			result.Action = ActionBlock
 			return result
 		}
 		delete(ad.blockedAddrs, address)
@@ -120,6 +167,7 @@ func (ad *AbuseDetector) CheckRequest(ip, address string) *DetectionResult {
 		if ipTracker.Count >= ad.config.MaxAttemptsPerHour {
 			result.Allowed = false
 			result.Reason = "Too many requests from this IP (hourly limit exceeded)"
+			result.Action = ActionBlock
 			ad.blockIP(ip)
 			return result
 		}
@@ -130,45 +178,103 @@ func (ad *AbuseDetector) CheckRequest(ip, address string) *DetectionResult {
 	}
 
 	// Check daily limit
-	if ipTracker.SuccessfulCount+ipTracker.FailedCount >= ad.config.MaxAttemptsPerDay {
+	if now.Sub(ipTracker.DayStart) >= 24*time.Hour {
+		ipTracker.DailyCount = 0
+		ipTracker.DayStart = now
+	}
+	if ipTracker.DailyCount >= ad.config.MaxAttemptsPerDay {
 		result.Allowed = false
 		result.Reason = "Daily request limit exceeded"
+		result.Action = ActionBlock
 		ad.blockIP(ip)
 		return result
 	}
 
-	// Check for subnet abuse
-	if ad.config.SubnetCheckEnabled {
+	// Check for subnet abuse; a zero subnet weight turns the block off
+	if ad.config.SubnetCheckEnabled && ad.config.Weights.Subnet > 0 {
 		if ad.checkSubnetAbuse(ip) {
 			result.Allowed = false
 			result.Reason = "Multiple requests detected from your subnet"
-			result.RiskScore += 30
+			result.RiskScore += ad.config.Weights.Subnet
+			result.Action = ActionBlock
 			return result
 		}
 	}
 
 	// Check for VPN/proxy (basic check)
 	if ad.config.VPNDetectionEnabled {
-		if ad.isLikelyVPN(ip) {
-			result.RiskScore += 20
+		if ad.isLikelyVPN(ip) && ad.config.Weights.VPN > 0 {
+			result.RiskScore += ad.config.Weights.VPN
 			result.RecommendedDelay = 30 * time.Second
 		}
 	}
 
 	// Check if requesting too many different addresses
-	if len(ipTracker.Addresses) > ad.config.SuspiciousThreshold {
-		result.RiskScore += 25
+	if len(ipTracker.Addresses) > ad.config.SuspiciousThreshold && ad.config.Weights.MultiAddress > 0 {
+		result.RiskScore += ad.config.Weights.MultiAddress
 		result.Reason = "Suspicious: Multiple addresses requested from same IP"
 	}
 
-	// High risk score requires additional verification
-	if result.RiskScore > 50 {
-		result.RecommendedDelay = time.Duration(result.RiskScore) * time.Second
+	if badReputation {
+		result.RiskScore += ad.config.Weights.Reputation
+		result.Reason = "Suspicious: IP has a poor reputation"
+	}
+
+	// Apply the action configured for the score's band
+	band := actionFor(ad.config.Actions, result.RiskScore)
+	result.Action = band.Action
+	switch band.Action {
+	case ActionDelay:
+		result.RecommendedDelay = band.delayFor(result.RiskScore)
+	case ActionBlock:
+		result.Allowed = false
+		result.Reason = "Risk score too high"
+	}
+	if badReputation && ad.config.ReputationCaptcha && result.Allowed {
+		result.Action = ActionCaptcha
 	}
 
 	return result
 }
 
+// AssessRisk returns the current risk score for an IP/address pair without
+// recording an attempt or applying any blocks
+func (ad *AbuseDetector) AssessRisk(ip, address string) int {
+	score := 0
+	if ad.badReputation(ip) {
+		score += ad.config.Weights.Reputation
+	}
+
+	ad.mu.RLock()
+	defer ad.mu.RUnlock()
+
+	if tracker, ok := ad.ipAttempts[ip]; ok {
+		score += ad.calculateRiskScore(tracker, ip, address)
+	}
+
+	if ad.config.VPNDetectionEnabled && ad.isLikelyVPN(ip) {
+		score += ad.config.Weights.VPN
+	}
+
+	return score
+}
+
+// RequiresCaptcha reports whether ip must solve a captcha because of its
+// reputation, without recording an attempt or applying any blocks
+func (ad *AbuseDetector) RequiresCaptcha(ip string) bool {
+	return ad.config.ReputationCaptcha && ad.badReputation(ip)
+}
+
+// badReputation reports whether the reputation service scores ip at or
+// above the threshold; unknown IPs and lookup failures are not bad
+func (ad *AbuseDetector) badReputation(ip string) bool {
+	if ad.config.Reputation == nil {
+		return false
+	}
+	score, known := ad.config.Reputation.Score(ip)
+	return known && score >= ad.config.ReputationThreshold
+}
+
 // RecordAttempt records a faucet request attempt
 func (ad *AbuseDetector) RecordAttempt(ip, address string, success bool) {
 	ad.mu.Lock()
@@ -177,6 +283,7 @@ func (ad *AbuseDetector) RecordAttempt(ip, address string, success bool) {
 	// Update IP tracker
 	ipTracker := ad.getOrCreateTracker(ad.ipAttempts, ip)
 	ipTracker.Count++
+	ipTracker.DailyCount++
 	ipTracker.LastAttempt = time.Now()
 
 	if ipTracker.Addresses == nil {
@@ -186,6 +293,10 @@ func (ad *AbuseDetector) RecordAttempt(ip, address string, success bool) {
 
 	if success {
 		ipTracker.SuccessfulCount++
+		if ipTracker.Funded == nil {
+			ipTracker.Funded = make(map[string]int)
+		}
+		ipTracker.Funded[address]++
 	} else {
 		ipTracker.FailedCount++
 	}
@@ -202,6 +313,24 @@ func (ad *AbuseDetector) RecordAttempt(ip, address string, success bool) {
 	}
 }
 
+// FundedOtherAddress reports whether ip is known to have funded an address
+// other than address
+func (ad *AbuseDetector) FundedOtherAddress(ip, address string) bool {
+	ad.mu.RLock()
+	defer ad.mu.RUnlock()
+
+	tracker, ok := ad.ipAttempts[ip]
+	if !ok {
+		return false
+	}
+	for funded := range tracker.Funded {
+		if funded != address {
+			return true
+		}
+	}
+	return false
+}
+
 // BlockIP blocks an IP address
 func (ad *AbuseDetector) BlockIP(ip string, duration time.Duration) {
 	ad.mu.Lock()
@@ -256,39 +385,40 @@ func (ad *AbuseDetector) GetStats() map[string]interface{} {
 	}
 
 	return map[string]interface{}{
-		"tracked_ips":        len(ad.ipAttempts),
-		"tracked_addresses":  len(ad.addressAttempts),
-		"blocked_ips":        len(ad.blockedIPs),
-		"blocked_addresses":  len(ad.blockedAddrs),
-		"total_attempts":     totalAttempts,
+		"tracked_ips":         len(ad.ipAttempts),
+		"tracked_addresses":   len(ad.addressAttempts),
+		"blocked_ips":         len(ad.blockedIPs),
+		"blocked_addresses":   len(ad.blockedAddrs),
+		"total_attempts":      totalAttempts,
 		"successful_attempts": totalSuccess,
-		"failed_attempts":    totalFailed,
-		"config":             ad.config,
+		"failed_attempts":     totalFailed,
+		"config":              ad.config,
 	}
 }
 
 // calculateRiskScore calculates a risk score for a request
 func (ad *AbuseDetector) calculateRiskScore(tracker *AttemptTracker, ip, address string) int {
 	score := 0
+	weights := ad.config.Weights
 
 	// High frequency
 	if tracker.Count > ad.config.SuspiciousThreshold {
-		score += 20
+		score += weights.Frequency
 	}
 
 	// High failure rate
 	if tracker.FailedCount > tracker.SuccessfulCount*2 {
-		score += 15
+		score += weights.FailureRatio
 	}
 
 	// Multiple addresses from same IP
 	if len(tracker.Addresses) > 3 {
-		score += 10 * (len(tracker.Addresses) - 3)
+		score += weights.PerExtraAddress * (len(tracker.Addresses) - 3)
 	}
 
 	// Recent rapid attempts
 	if time.Since(tracker.LastAttempt) < 1*time.Minute && tracker.Count > 3 {
-		score += 25
+		score += weights.RapidAttempts
 	}
 
 	return score
@@ -342,9 +472,9 @@ func (ad *AbuseDetector) isLikelyVPN(ip string) bool {
 	// Check for common VPN/cloud provider ranges
 	// This is just an example - you'd need a comprehensive list
 	commonVPNRanges := []string{
-		"10.0.0.0/8",      // Private
-		"172.16.0.0/12",   // Private
-		"192.168.0.0/16",  // Private
+		"10.0.0.0/8",     // Private
+		"172.16.0.0/12",  // Private
+		"192.168.0.0/16", // Private
 	}
 
 	for _, cidr := range commonVPNRanges {
@@ -366,8 +496,10 @@ func (ad *AbuseDetector) blockIP(ip string) {
 func (ad *AbuseDetector) getOrCreateTracker(trackers map[string]*AttemptTracker, key string) *AttemptTracker {
 	tracker, exists := trackers[key]
 	if !exists {
+		now := time.Now()
 		tracker = &AttemptTracker{
-			FirstAttempt: time.Now(),
+			FirstAttempt: now,
+			DayStart:     now,
 			Addresses:    make(map[string]int),
 		}
 		trackers[key] = tracker
@@ -380,7 +512,13 @@ func (ad *AbuseDetector) cleanup() {
 	ticker := time.NewTicker(1 * time.Hour)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-ad.done:
+			return
+		case <-ticker.C:
+		}
+
 		ad.mu.Lock()
 
 		now := time.Now()
@@ -1,6 +1,7 @@
 package abuse
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -30,6 +31,27 @@ func TestHourlyLimitBlocksAndUnblocks(t *testing.T) {
 	assert.Equal(t, "Too many requests from this IP (hourly limit exceeded)", result.Reason)
 }
 
+func TestDailyLimitResetsAfterADay(t *testing.T) {
+	detector := NewAbuseDetector(DetectorConfig{MaxAttemptsPerHour: 100, MaxAttemptsPerDay: 2})
+	defer detector.Close()
+
+	ip := "192.0.2.1"
+	for i := 0; i < 2; i++ {
+		require.True(t, detector.CheckRequest(ip, "aura1test").Allowed)
+		detector.RecordAttempt(ip, "aura1test", true)
+	}
+	result := detector.CheckRequest(ip, "aura1test")
+	assert.False(t, result.Allowed)
+	assert.Equal(t, "Daily request limit exceeded", result.Reason)
+
+	// A day later the count starts over
+	detector.UnblockIP(ip)
+	detector.mu.Lock()
+	detector.ipAttempts[ip].DayStart = time.Now().Add(-25 * time.Hour)
+	detector.mu.Unlock()
+	assert.True(t, detector.CheckRequest(ip, "aura1test").Allowed)
+}
+
 func TestVPNAndSubnetRiskScoring(t *testing.T) {
 	cfg := DetectorConfig{
 		SubnetCheckEnabled:  true,
@@ -50,6 +72,26 @@ func TestVPNAndSubnetRiskScoring(t *testing.T) {
 	assert.True(t, result.RecommendedDelay >= 0)
 }
 
+func TestZeroSubnetWeightDisablesSubnetBlock(t *testing.T) {
+	busySubnet := func(weights *RiskWeights) *AbuseDetector {
+		detector := NewAbuseDetector(DetectorConfig{SubnetCheckEnabled: true, Weights: weights})
+		for i := 1; i <= 6; i++ {
+			detector.RecordAttempt(fmt.Sprintf("198.51.100.%d", i), fmt.Sprintf("aura1addr%d", i), true)
+		}
+		return detector
+	}
+
+	blocked := busySubnet(nil).CheckRequest("198.51.100.7", "aura1fresh")
+	assert.False(t, blocked.Allowed)
+	assert.Equal(t, ActionBlock, blocked.Action)
+
+	weights, err := ParseRiskWeights("subnet:0")
+	require.NoError(t, err)
+	result := busySubnet(weights).CheckRequest("198.51.100.7", "aura1fresh")
+	assert.True(t, result.Allowed, result.Reason)
+	assert.NotEqual(t, ActionBlock, result.Action)
+}
+
 func TestAddressBlock(t *testing.T) {
 	cfg := DetectorConfig{
 		BlockDuration: time.Minute,
@@ -66,3 +108,85 @@ func TestAddressBlock(t *testing.T) {
 	assert.False(t, result.Allowed)
 	assert.Equal(t, "Address is temporarily blocked", result.Reason)
 }
+
+func TestAssessRiskDoesNotRecord(t *testing.T) {
+	detector := NewAbuseDetector(DetectorConfig{VPNDetectionEnabled: true})
+
+	assert.Equal(t, 20, detector.AssessRisk("10.0.0.7", "aura1x"))
+	assert.Equal(t, 0, detector.AssessRisk("203.0.113.7", "aura1x"))
+	assert.Empty(t, detector.ipAttempts)
+}
+
+func TestFundedOtherAddress(t *testing.T) {
+	detector := NewAbuseDetector(DetectorConfig{})
+
+	detector.RecordAttempt("192.0.2.1", "aura1failed", false)
+	assert.False(t, detector.FundedOtherAddress("192.0.2.1", "aura1second"))
+
+	detector.RecordAttempt("192.0.2.1", "aura1first", true)
+	assert.True(t, detector.FundedOtherAddress("192.0.2.1", "aura1second"))
+	assert.False(t, detector.FundedOtherAddress("192.0.2.1", "aura1first"))
+	assert.False(t, detector.FundedOtherAddress("192.0.2.2", "aura1second"))
+}
+
+func TestConfiguredWeightsChangeScore(t *testing.T) {
+	weights, err := ParseRiskWeights("vpn:45")
+	require.NoError(t, err)
+	assert.Equal(t, 45, weights.VPN)
+	assert.Equal(t, DefaultRiskWeights().Subnet, weights.Subnet)
+
+	defaults := NewAbuseDetector(DetectorConfig{VPNDetectionEnabled: true})
+	tuned := NewAbuseDetector(DetectorConfig{VPNDetectionEnabled: true, Weights: weights})
+
+	assert.Equal(t, 20, defaults.CheckRequest("10.0.0.7", "aura1x").RiskScore)
+	assert.Equal(t, 45, tuned.CheckRequest("10.0.0.7", "aura1x").RiskScore)
+
+	disabled := NewAbuseDetector(DetectorConfig{VPNDetectionEnabled: true, Weights: &RiskWeights{}})
+	result := disabled.CheckRequest("10.0.0.7", "aura1x")
+	assert.Equal(t, 0, result.RiskScore)
+	assert.Zero(t, result.RecommendedDelay)
+}
+
+func TestActionPolicyBands(t *testing.T) {
+	actions, err := ParseActionPolicy("60:block, 20:captcha, 40:delay:30s")
+	require.NoError(t, err)
+	weights := func(vpn int) *RiskWeights { return &RiskWeights{VPN: vpn} }
+	check := func(vpn int) *DetectionResult {
+		detector := NewAbuseDetector(DetectorConfig{VPNDetectionEnabled: true, Weights: weights(vpn), Actions: actions})
+		return detector.CheckRequest("10.0.0.7", "aura1x")
+	}
+
+	result := check(10)
+	assert.Equal(t, ActionAllow, result.Action)
+	assert.True(t, result.Allowed)
+
+	result = check(20)
+	assert.Equal(t, ActionCaptcha, result.Action)
+	assert.True(t, result.Allowed)
+
+	result = check(45)
+	assert.Equal(t, ActionDelay, result.Action)
+	assert.Equal(t, 30*time.Second, result.RecommendedDelay)
+
+	result = check(70)
+	assert.Equal(t, ActionBlock, result.Action)
+	assert.False(t, result.Allowed)
+}
+
+func TestDefaultActionPolicyDelaysHighScores(t *testing.T) {
+	detector := NewAbuseDetector(DetectorConfig{VPNDetectionEnabled: true, Weights: &RiskWeights{VPN: 60}})
+	result := detector.CheckRequest("10.0.0.7", "aura1x")
+	assert.Equal(t, ActionDelay, result.Action)
+	assert.Equal(t, 60*time.Second, result.RecommendedDelay)
+}
+
+func TestParsePolicyRejectsInvalidInput(t *testing.T) {
+	for _, raw := range []string{"vpn", "unknown:5", "vpn:-1", "vpn:x"} {
+		_, err := ParseRiskWeights(raw)
+		assert.Error(t, err, raw)
+	}
+	for _, raw := range []string{"50", "x:block", "50:ban", "50:block:30s", "50:delay:soon"} {
+		_, err := ParseActionPolicy(raw)
+		assert.Error(t, err, raw)
+	}
+}
@@ -0,0 +1,152 @@
+package abuse
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Action is what the detector recommends for a request
+type Action string
+
+const (
+	ActionAllow   Action = "allow"
+	ActionDelay   Action = "delay"
+	ActionCaptcha Action = "captcha"
+	ActionBlock   Action = "block"
+)
+
+// RiskWeights are the points each risk signal adds to a request's score
+type RiskWeights struct {
+	Frequency       int // more attempts than SuspiciousThreshold
+	FailureRatio    int // failures outnumber successes two to one
+	PerExtraAddress int // per address requested beyond three from one IP
+	RapidAttempts   int // several attempts within the last minute
+	MultiAddress    int // more addresses than SuspiciousThreshold from one IP
+	VPN             int // IP in a known VPN/proxy range
+	Subnet          int // many IPs active from the same subnet
+	Reputation      int // IP scored at or above ReputationThreshold
+}
+
+// DefaultRiskWeights returns the built-in weights
+func DefaultRiskWeights() RiskWeights {
+	return RiskWeights{
+		Frequency:       20,
+		FailureRatio:    15,
+		PerExtraAddress: 10,
+		RapidAttempts:   25,
+		MultiAddress:    25,
+		VPN:             20,
+		Subnet:          30,
+		Reputation:      30,
+	}
+}
+
+// ActionBand applies Action to scores of at least MinScore, up to the next
+// band. Delay bands wait Delay, or one second per risk point when unset.
+type ActionBand struct {
+	MinScore int
+	Action   Action
+	Delay    time.Duration
+}
+
+// DefaultActionPolicy delays requests scoring over 50
+func DefaultActionPolicy() []ActionBand {
+	return []ActionBand{{MinScore: 51, Action: ActionDelay}}
+}
+
+// actionFor returns the band with the highest MinScore not above score;
+// scores below every band are allowed
+func actionFor(bands []ActionBand, score int) ActionBand {
+	match := ActionBand{Action: ActionAllow}
+	found := false
+	for _, band := range bands {
+		if score >= band.MinScore && (!found || band.MinScore > match.MinScore) {
+			match = band
+			found = true
+		}
+	}
+	return match
+}
+
+func (b ActionBand) delayFor(score int) time.Duration {
+	if b.Delay > 0 {
+		return b.Delay
+	}
+	return time.Duration(score) * time.Second
+}
+
+// ParseRiskWeights parses comma-separated signal:points pairs such as
+// "vpn:40,subnet:0" over the default weights
+func ParseRiskWeights(raw string) (*RiskWeights, error) {
+	weights := DefaultRiskWeights()
+	fields := map[string]*int{
+		"frequency":         &weights.Frequency,
+		"failure_ratio":     &weights.FailureRatio,
+		"per_extra_address": &weights.PerExtraAddress,
+		"rapid_attempts":    &weights.RapidAttempts,
+		"multi_address":     &weights.MultiAddress,
+		"vpn":               &weights.VPN,
+		"subnet":            &weights.Subnet,
+		"reputation":        &weights.Reputation,
+	}
+
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, ":")
+		field, known := fields[strings.ToLower(strings.TrimSpace(name))]
+		if !ok || !known {
+			return nil, fmt.Errorf("unknown risk weight %q", part)
+		}
+		points, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || points < 0 {
+			return nil, fmt.Errorf("weight for %s must be a non-negative integer", name)
+		}
+		*field = points
+	}
+	return &weights, nil
+}
+
+// ParseActionPolicy parses comma-separated min_score:action bands such as
+// "40:captcha,60:delay:30s,90:block". Delay bands take an optional duration.
+func ParseActionPolicy(raw string) ([]ActionBand, error) {
+	var bands []ActionBand
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		pieces := strings.Split(part, ":")
+		if len(pieces) < 2 || len(pieces) > 3 {
+			return nil, fmt.Errorf("%q is not min_score:action", part)
+		}
+		minScore, err := strconv.Atoi(strings.TrimSpace(pieces[0]))
+		if err != nil {
+			return nil, fmt.Errorf("%q: score must be an integer", part)
+		}
+
+		band := ActionBand{MinScore: minScore, Action: Action(strings.ToLower(strings.TrimSpace(pieces[1])))}
+		switch band.Action {
+		case ActionAllow, ActionDelay, ActionCaptcha, ActionBlock:
+		default:
+			return nil, fmt.Errorf("%q: unknown action %s", part, band.Action)
+		}
+		if len(pieces) == 3 {
+			if band.Action != ActionDelay {
+				return nil, fmt.Errorf("%q: only delay takes a duration", part)
+			}
+			if band.Delay, err = time.ParseDuration(strings.TrimSpace(pieces[2])); err != nil || band.Delay <= 0 {
+				return nil, fmt.Errorf("%q: invalid delay", part)
+			}
+		}
+		bands = append(bands, band)
+	}
+
+	sort.Slice(bands, func(i, j int) bool { return bands[i].MinScore < bands[j].MinScore })
+	return bands, nil
+}
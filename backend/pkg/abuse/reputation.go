@@ -0,0 +1,223 @@
+package abuse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxReputationBody bounds a reputation API response
+const maxReputationBody = 1 << 20
+
+// ReputationProvider scores how abusive an IP is known to be, from 0
+// (clean) to 100 (certainly abusive)
+type ReputationProvider interface {
+	Score(ctx context.Context, ip string) (int, error)
+}
+
+// AbuseIPDBProvider scores IPs with AbuseIPDB's abuse confidence score
+type AbuseIPDBProvider struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+// NewAbuseIPDBProvider creates an AbuseIPDB provider. An empty baseURL
+// uses the public API.
+func NewAbuseIPDBProvider(apiKey, baseURL string, client *http.Client) *AbuseIPDBProvider {
+	if baseURL == "" {
+		baseURL = "https://api.abuseipdb.com/api/v2"
+	}
+	return &AbuseIPDBProvider{apiKey: apiKey, baseURL: baseURL, client: client}
+}
+
+// Score returns the IP's abuseConfidenceScore over the last 90 days
+func (p *AbuseIPDBProvider) Score(ctx context.Context, ip string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		p.baseURL+"/check?maxAgeInDays=90&ipAddress="+url.QueryEscape(ip), nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Key", p.apiKey)
+	req.Header.Set("Accept", "application/json")
+
+	var body struct {
+		Data struct {
+			AbuseConfidenceScore int `json:"abuseConfidenceScore"`
+		} `json:"data"`
+	}
+	if err := getReputationJSON(p.client, req, &body); err != nil {
+		return 0, err
+	}
+	return body.Data.AbuseConfidenceScore, nil
+}
+
+// HTTPScoreProvider queries a generic scoring API: GET <url>?ip=<ip>
+// answering {"score": 0-100}. A non-empty token is sent as a bearer token.
+type HTTPScoreProvider struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewHTTPScoreProvider creates a generic HTTP scoring provider
+func NewHTTPScoreProvider(scoreURL, token string, client *http.Client) *HTTPScoreProvider {
+	return &HTTPScoreProvider{url: scoreURL, token: token, client: client}
+}
+
+// Score returns the API's score for ip
+func (p *HTTPScoreProvider) Score(ctx context.Context, ip string) (int, error) {
+	target, err := url.Parse(p.url)
+	if err != nil {
+		return 0, err
+	}
+	query := target.Query()
+	query.Set("ip", ip)
+	target.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	var body struct {
+		Score *int `json:"score"`
+	}
+	if err := getReputationJSON(p.client, req, &body); err != nil {
+		return 0, err
+	}
+	if body.Score == nil {
+		return 0, fmt.Errorf("reputation response has no score")
+	}
+	return *body.Score, nil
+}
+
+func getReputationJSON(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("reputation API returned status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxReputationBody)).Decode(v); err != nil {
+		return fmt.Errorf("invalid reputation response: %w", err)
+	}
+	return nil
+}
+
+// reputationFailureTTL is how long a failed lookup reads as unknown before
+// the IP is looked up again, so an outage doesn't cost every request a
+// provider timeout
+const reputationFailureTTL = 30 * time.Second
+
+type reputationEntry struct {
+	score     int
+	failed    bool
+	expiresAt time.Time
+}
+
+// ReputationChecker caches a provider's scores per IP for a TTL. Lookups
+// that fail read as unknown, so a provider outage never blocks requests,
+// and are cached for reputationFailureTTL (or the TTL, if shorter).
+type ReputationChecker struct {
+	provider ReputationProvider
+	ttl      time.Duration
+	timeout  time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]reputationEntry
+}
+
+// NewReputationChecker caches provider's scores for ttl, bounding each
+// lookup by timeout
+func NewReputationChecker(provider ReputationProvider, ttl, timeout time.Duration) *ReputationChecker {
+	return &ReputationChecker{
+		provider: provider,
+		ttl:      ttl,
+		timeout:  timeout,
+		now:      time.Now,
+		entries:  make(map[string]reputationEntry),
+	}
+}
+
+// Score returns ip's score and whether it is known. Private and loopback
+// addresses are never looked up.
+func (rc *ReputationChecker) Score(ip string) (int, bool) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.IsPrivate() || parsed.IsLoopback() || parsed.IsUnspecified() {
+		return 0, false
+	}
+
+	now := rc.now()
+	rc.mu.Lock()
+	entry, ok := rc.entries[ip]
+	rc.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.score, !entry.failed
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rc.timeout)
+	defer cancel()
+	score, err := rc.provider.Score(ctx, ip)
+	if err != nil {
+		log.WithError(err).WithField("ip", ip).Warn("IP reputation lookup failed; treating the IP as unknown")
+		rc.mu.Lock()
+		rc.entries[ip] = reputationEntry{failed: true, expiresAt: now.Add(min(rc.ttl, reputationFailureTTL))}
+		rc.mu.Unlock()
+		return 0, false
+	}
+
+	rc.mu.Lock()
+	rc.entries[ip] = reputationEntry{score: score, expiresAt: now.Add(rc.ttl)}
+	rc.mu.Unlock()
+	return score, true
+}
+
+// sweep drops expired entries, so the cache stays bounded by the IPs seen
+// within one TTL
+func (rc *ReputationChecker) sweep() {
+	now := rc.now()
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for key, e := range rc.entries {
+		if !now.Before(e.expiresAt) {
+			delete(rc.entries, key)
+		}
+	}
+}
+
+// sweepEvery sweeps once per TTL until done is closed
+func (rc *ReputationChecker) sweepEvery(done <-chan struct{}) {
+	interval := rc.ttl
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			rc.sweep()
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package abuse
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubProvider returns fixed scores and counts lookups
+type stubProvider struct {
+	scores map[string]int
+	err    error
+	calls  int
+}
+
+func (p *stubProvider) Score(ctx context.Context, ip string) (int, error) {
+	p.calls++
+	if p.err != nil {
+		return 0, p.err
+	}
+	return p.scores[ip], nil
+}
+
+func TestReputationRaisesRiskScore(t *testing.T) {
+	provider := &stubProvider{scores: map[string]int{"203.0.113.5": 90, "203.0.113.6": 10}}
+	detector := NewAbuseDetector(DetectorConfig{
+		Reputation:          NewReputationChecker(provider, time.Hour, time.Second),
+		ReputationThreshold: 75,
+	})
+
+	result := detector.CheckRequest("203.0.113.5", "aura1x")
+	assert.Equal(t, 30, result.RiskScore)
+	assert.Equal(t, "Suspicious: IP has a poor reputation", result.Reason)
+	assert.Equal(t, ActionAllow, result.Action)
+	assert.Equal(t, 30, detector.AssessRisk("203.0.113.5", "aura1x"))
+
+	result = detector.CheckRequest("203.0.113.6", "aura1x")
+	assert.Equal(t, 0, result.RiskScore)
+	assert.Empty(t, result.Reason)
+}
+
+func TestReputationCaptchaAndBands(t *testing.T) {
+	provider := &stubProvider{scores: map[string]int{"203.0.113.5": 80}}
+	checker := NewReputationChecker(provider, time.Hour, time.Second)
+
+	captcha := NewAbuseDetector(DetectorConfig{Reputation: checker, ReputationThreshold: 75, ReputationCaptcha: true})
+	result := captcha.CheckRequest("203.0.113.5", "aura1x")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, ActionCaptcha, result.Action)
+
+	actions, err := ParseActionPolicy("50:block")
+	require.NoError(t, err)
+	blocking := NewAbuseDetector(DetectorConfig{
+		Reputation:          checker,
+		ReputationThreshold: 75,
+		ReputationCaptcha:   true,
+		Weights:             &RiskWeights{Reputation: 60},
+		Actions:             actions,
+	})
+	result = blocking.CheckRequest("203.0.113.5", "aura1x")
+	assert.False(t, result.Allowed, "a heavy reputation weight can reach the block band")
+	assert.Equal(t, ActionBlock, result.Action)
+}
+
+func TestReputationCheckerCachesAndDegradesOpen(t *testing.T) {
+	provider := &stubProvider{scores: map[string]int{"203.0.113.5": 40}}
+	checker := NewReputationChecker(provider, time.Minute, time.Second)
+	now := time.Now()
+	checker.now = func() time.Time { return now }
+
+	score, ok := checker.Score("203.0.113.5")
+	assert.True(t, ok)
+	assert.Equal(t, 40, score)
+	checker.Score("203.0.113.5")
+	assert.Equal(t, 1, provider.calls, "scores are cached within the TTL")
+
+	now = now.Add(2 * time.Minute)
+	checker.Score("203.0.113.5")
+	assert.Equal(t, 2, provider.calls, "expired scores are looked up again")
+
+	for _, ip := range []string{"10.0.0.1", "127.0.0.1", "not-an-ip"} {
+		_, ok := checker.Score(ip)
+		assert.False(t, ok, ip)
+	}
+	assert.Equal(t, 2, provider.calls, "private addresses are never looked up")
+
+	provider.err = errors.New("provider down")
+	_, ok = checker.Score("203.0.113.9")
+	assert.False(t, ok)
+	_, ok = checker.Score("203.0.113.9")
+	assert.False(t, ok)
+	assert.Equal(t, 3, provider.calls, "failed lookups are cached briefly")
+
+	now = now.Add(reputationFailureTTL)
+	_, ok = checker.Score("203.0.113.9")
+	assert.False(t, ok)
+	assert.Equal(t, 4, provider.calls, "a failed lookup is retried once its short TTL passes")
+
+	detector := NewAbuseDetector(DetectorConfig{Reputation: checker, ReputationThreshold: 1, ReputationCaptcha: true})
+	result := detector.CheckRequest("203.0.113.9", "aura1x")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, ActionAllow, result.Action, "a provider outage does not gate requests")
+}
+
+func TestReputationCheckerSweepDropsExpiredEntries(t *testing.T) {
+	provider := &stubProvider{scores: map[string]int{"203.0.113.5": 40, "203.0.113.6": 10}}
+	checker := NewReputationChecker(provider, time.Minute, time.Second)
+	now := time.Now()
+	checker.now = func() time.Time { return now }
+
+	checker.Score("203.0.113.5")
+	now = now.Add(30 * time.Second)
+	checker.Score("203.0.113.6")
+
+	now = now.Add(45 * time.Second)
+	checker.sweep()
+	assert.NotContains(t, checker.entries, "203.0.113.5")
+	assert.Contains(t, checker.entries, "203.0.113.6", "entries within their TTL are kept")
+}
+
+func TestReputationProviders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/check":
+			if r.Header.Get("Key") != "secret" || r.URL.Query().Get("ipAddress") != "203.0.113.5" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":{"ipAddress":"203.0.113.5","abuseConfidenceScore":87}}`))
+		case "/score":
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("ip") == "203.0.113.6" {
+				_, _ = w.Write([]byte(`{}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"score":12}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	score, err := NewAbuseIPDBProvider("secret", server.URL+"/api/v2", server.Client()).Score(ctx, "203.0.113.5")
+	require.NoError(t, err)
+	assert.Equal(t, 87, score)
+
+	_, err = NewAbuseIPDBProvider("wrong", server.URL+"/api/v2", server.Client()).Score(ctx, "203.0.113.5")
+	assert.Error(t, err)
+
+	generic := NewHTTPScoreProvider(server.URL+"/score", "token", server.Client())
+	score, err = generic.Score(ctx, "203.0.113.5")
+	require.NoError(t, err)
+	assert.Equal(t, 12, score)
+
+	_, err = generic.Score(ctx, "203.0.113.6")
+	assert.Error(t, err, "a response without a score is an error")
+}
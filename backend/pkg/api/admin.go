@@ -0,0 +1,145 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aura-chain/aura/faucet/pkg/database"
+	"github.com/aura-chain/aura/faucet/pkg/faucet"
+)
+
+// AdminAuthMiddleware guards admin endpoints with a static bearer token
+func AdminAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !adminAuthorized(c, token) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Unauthorized",
+				"code":  "UNAUTHORIZED",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// adminAuthorized reports whether the request carries token as its bearer
+// token. An empty token authorizes nobody.
+func adminAuthorized(c *gin.Context, token string) bool {
+	provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	return token != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
+
+// statsAuthorized reports whether the caller may see distribution
+// statistics: anyone when stats are public, otherwise admins only
+func (h *Handler) statsAuthorized(c *gin.Context) bool {
+	return h.cfg.PublicStats || adminAuthorized(c, h.cfg.AdminToken)
+}
+
+// AdminReplayRequest re-broadcasts the payout of a failed request. The
+// faucet refuses replays that could pay a recipient twice.
+func (h *Handler) AdminReplayRequest(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request id",
+			"code":  "INVALID_REQUEST_ID",
+		})
+		return
+	}
+
+	if h.db == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Request history is not available",
+			"code":  "DATABASE_UNAVAILABLE",
+		})
+		return
+	}
+
+	req, err := h.db.GetRequest(id)
+	if errors.Is(err, database.ErrRequestNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Request not found",
+			"code":  "REQUEST_NOT_FOUND",
+		})
+		return
+	}
+	if err != nil {
+		log.WithError(err).WithField("request_id", id).Error("Failed to load request for replay")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load request",
+			"code":  "INTERNAL_ERROR",
+		})
+		return
+	}
+
+	route := h.routeForDenom(req.Denom)
+	if route == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "No configured chain pays out " + req.Denom,
+			"code":  "NOT_REPLAYABLE",
+		})
+		return
+	}
+
+	resp, err := route.faucet.ReplayRequest(id)
+	switch {
+	case errors.Is(err, faucet.ErrAlreadyPaid):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+			"code":  "ALREADY_PAID",
+		})
+		return
+	case errors.Is(err, faucet.ErrNotReplayable):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+			"code":  "NOT_REPLAYABLE",
+		})
+		return
+	case errors.Is(err, faucet.ErrAmountOutOfRange), errors.Is(err, faucet.ErrInsufficientFeeBalance),
+		errors.Is(err, faucet.ErrBalanceReserve), errors.Is(err, faucet.ErrSupplyGuard):
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": err.Error(),
+			"code":  "REPLAY_REFUSED",
+		})
+		return
+	case err != nil:
+		log.WithError(err).WithField("request_id", id).Error("Payout replay failed")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Replay failed",
+			"code":  "REPLAY_FAILED",
+		})
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"request_id": id,
+		"tx_hash":    resp.TxHash,
+	}).Info("Payout replayed by admin")
+
+	c.JSON(http.StatusOK, gin.H{
+		"request_id": id,
+		"tx_hash":    resp.TxHash,
+		"recipient":  resp.Recipient,
+		"amount":     resp.Amount,
+	})
+}
+
+// routeForDenom returns the chain paying out denom; rows written before
+// denoms were recorded belong to the default chain
+func (h *Handler) routeForDenom(denom string) *chainRoute {
+	if denom == "" || denom == h.cfg.Denom {
+		return &chainRoute{cfg: h.cfg, faucet: h.faucet}
+	}
+	for _, id := range h.chainOrder {
+		if route := h.chains[id]; route.cfg.Denom == denom {
+			return route
+		}
+	}
+	return nil
+}
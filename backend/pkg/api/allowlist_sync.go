@@ -0,0 +1,201 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aura-chain/aura/faucet/pkg/config"
+)
+
+// maxAllowlistBytes bounds the allowlist document fetched from ALLOWLIST_URL
+const maxAllowlistBytes = 4 << 20
+
+// allowlist is a swapped-in replacement for the configured allowlists
+type allowlist struct {
+	addresses []string
+	patterns  []config.AddressPattern
+	ips       []string
+}
+
+// SetAllowlist adds address and IP entries to the allowlists set by
+// FAUCET_ALLOWED_ADDRESSES and FAUCET_ALLOWED_IPS, replacing entries from
+// an earlier call. A nil list keeps the current entries of that kind.
+// Requests in flight see either the old lists or the new ones. An invalid
+// address pattern leaves the current lists in place.
+func (h *Handler) SetAllowlist(addresses, ips []string) error {
+	next := &allowlist{
+		addresses: h.cfg.AllowedAddresses,
+		patterns:  h.addressPatterns,
+		ips:       h.cfg.AllowedIPs,
+	}
+	if current := h.synced.Load(); current != nil {
+		*next = *current
+	}
+
+	if addresses != nil {
+		merged := mergeEntries(h.cfg.AllowedAddresses, addresses)
+		patterns, err := config.CompileAddressPatterns(merged)
+		if err != nil {
+			return err
+		}
+		next.addresses, next.patterns = merged, patterns
+	}
+	if ips != nil {
+		next.ips = mergeEntries(h.cfg.AllowedIPs, ips)
+	}
+	h.synced.Store(next)
+	return nil
+}
+
+// mergeEntries returns configured followed by the entries of extra it
+// doesn't already hold
+func mergeEntries(configured, extra []string) []string {
+	merged := append([]string{}, configured...)
+	seen := make(map[string]bool, len(configured))
+	for _, entry := range configured {
+		seen[entry] = true
+	}
+	for _, entry := range extra {
+		if !seen[entry] {
+			seen[entry] = true
+			merged = append(merged, entry)
+		}
+	}
+	return merged
+}
+
+// allowedIPs returns the IP allowlist in effect; empty allows everyone
+func (h *Handler) allowedIPs() []string {
+	if list := h.synced.Load(); list != nil {
+		return list.ips
+	}
+	return h.cfg.AllowedIPs
+}
+
+// allowlistDocument is the JSON served at ALLOWLIST_URL. An absent key
+// keeps the current entries of that kind; a document with neither is
+// rejected.
+type allowlistDocument struct {
+	Addresses []string `json:"addresses"`
+	IPs       []string `json:"ips"`
+}
+
+// validate rejects a document that sets nothing or lists an IP entry that
+// is neither an IP nor a CIDR
+func (doc *allowlistDocument) validate() error {
+	if doc.Addresses == nil && doc.IPs == nil {
+		return errors.New("document has neither addresses nor ips")
+	}
+	for _, entry := range doc.IPs {
+		if strings.Contains(entry, "/") {
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				return fmt.Errorf("invalid IP range %q", entry)
+			}
+		} else if net.ParseIP(entry) == nil {
+			return fmt.Errorf("invalid IP %q", entry)
+		}
+	}
+	return nil
+}
+
+// AllowlistSync periodically fetches the allowlists from a central service
+// into a Handler. Fetches revalidate with ETag and Last-Modified, so an
+// unchanged list costs a 304.
+type AllowlistSync struct {
+	url     string
+	client  *http.Client
+	handler *Handler
+
+	mu           sync.Mutex
+	etag         string
+	lastModified string
+}
+
+// NewAllowlistSync creates a sync from url into h
+func NewAllowlistSync(url string, h *Handler) *AllowlistSync {
+	return &AllowlistSync{
+		url:     url,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		handler: h,
+	}
+}
+
+// Refresh fetches the allowlists and applies them when they changed. On
+// any error the handler keeps its last good lists.
+func (s *AllowlistSync) Refresh(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build allowlist request: %w", err)
+	}
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	if s.lastModified != "" {
+		req.Header.Set("If-Modified-Since", s.lastModified)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch allowlist: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil
+	case http.StatusOK:
+	default:
+		return fmt.Errorf("failed to fetch allowlist: status %d", resp.StatusCode)
+	}
+
+	var doc allowlistDocument
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxAllowlistBytes)).Decode(&doc); err != nil {
+		return fmt.Errorf("failed to parse allowlist: %w", err)
+	}
+	if err := doc.validate(); err != nil {
+		return fmt.Errorf("invalid allowlist: %w", err)
+	}
+	if err := s.handler.SetAllowlist(doc.Addresses, doc.IPs); err != nil {
+		return fmt.Errorf("invalid allowlist: %w", err)
+	}
+
+	s.etag = resp.Header.Get("ETag")
+	s.lastModified = resp.Header.Get("Last-Modified")
+	log.WithFields(log.Fields{
+		"addresses": len(doc.Addresses),
+		"ips":       len(doc.IPs),
+	}).Info("Allowlist updated")
+	return nil
+}
+
+// Run refreshes the allowlists immediately and then every interval until
+// ctx is cancelled
+func (s *AllowlistSync) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Refresh(ctx); err != nil {
+			log.WithError(err).Warn("Allowlist sync failed; keeping the last good allowlist")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowlistSync(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		body     = `{"addresses":["aura1good","aura1team*"],"ips":["10.0.0.0/8"]}`
+		status   = 0
+		requests []*http.Request
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r)
+		if status != 0 {
+			w.WriteHeader(status)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Wed, 14 Oct 2026 08:00:00 GMT")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	cfg := defaultConfig()
+	cfg.AllowedAddresses = []string{"aura1configured"}
+	cfg.AllowedIPs = []string{"192.0.2.1"}
+	h := newTestHandler(cfg, &mockFaucet{}, nil)
+	assert.True(t, h.addressAllowed("aura1configured"), "configured lists apply until the first fetch")
+
+	syncer := NewAllowlistSync(server.URL, h)
+	require.NoError(t, syncer.Refresh(context.Background()))
+	assert.True(t, h.addressAllowed("aura1good"))
+	assert.True(t, h.addressAllowed("aura1team42"))
+	assert.True(t, h.addressAllowed("aura1configured"), "the fetched list adds to the configured one")
+	assert.False(t, h.addressAllowed("aura1other"))
+	assert.Equal(t, []string{"192.0.2.1", "10.0.0.0/8"}, h.allowedIPs())
+
+	t.Run("revalidates and keeps the list on 304", func(t *testing.T) {
+		require.NoError(t, syncer.Refresh(context.Background()))
+		mu.Lock()
+		last := requests[len(requests)-1]
+		mu.Unlock()
+		assert.Equal(t, `"v1"`, last.Header.Get("If-None-Match"))
+		assert.Equal(t, "Wed, 14 Oct 2026 08:00:00 GMT", last.Header.Get("If-Modified-Since"))
+		assert.True(t, h.addressAllowed("aura1good"))
+	})
+
+	t.Run("keeps the last good list on fetch error", func(t *testing.T) {
+		mu.Lock()
+		status = http.StatusInternalServerError
+		mu.Unlock()
+		assert.Error(t, syncer.Refresh(context.Background()))
+		assert.True(t, h.addressAllowed("aura1good"))
+		assert.Equal(t, []string{"192.0.2.1", "10.0.0.0/8"}, h.allowedIPs())
+	})
+
+	for _, tc := range []struct{ name, body string }{
+		{"empty document", `{}`},
+		{"unparseable document", `not json`},
+		{"invalid ip entry", `{"ips":["10.0.0.0/99"]}`},
+	} {
+		t.Run("rejects "+tc.name, func(t *testing.T) {
+			mu.Lock()
+			status, body = 0, tc.body
+			mu.Unlock()
+			syncer := NewAllowlistSync(server.URL, h)
+			assert.Error(t, syncer.Refresh(context.Background()))
+			assert.True(t, h.addressAllowed("aura1good"))
+			assert.Equal(t, []string{"192.0.2.1", "10.0.0.0/8"}, h.allowedIPs())
+		})
+	}
+
+	t.Run("an absent key keeps the current entries", func(t *testing.T) {
+		mu.Lock()
+		status, body = 0, `{"addresses":["aura1new"]}`
+		mu.Unlock()
+		syncer := NewAllowlistSync(server.URL, h)
+		require.NoError(t, syncer.Refresh(context.Background()))
+		assert.True(t, h.addressAllowed("aura1new"))
+		assert.False(t, h.addressAllowed("aura1good"))
+		assert.True(t, h.addressAllowed("aura1configured"))
+		assert.Equal(t, []string{"192.0.2.1", "10.0.0.0/8"}, h.allowedIPs())
+	})
+}
+
+func TestSetAllowlistRejectsInvalidPattern(t *testing.T) {
+	h := newTestHandler(defaultConfig(), &mockFaucet{}, nil)
+	require.NoError(t, h.SetAllowlist([]string{"aura1good"}, nil))
+
+	assert.Error(t, h.SetAllowlist([]string{"/[unclosed/"}, nil))
+	assert.True(t, h.addressAllowed("aura1good"))
+	assert.False(t, h.addressAllowed("aura1other"))
+}
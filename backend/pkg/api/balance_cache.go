@@ -0,0 +1,49 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+type cachedBalance struct {
+	amount int64
+	asOf   time.Time
+}
+
+// BalanceCache holds the faucet's own balance per denom, refreshed by the
+// background monitor so /faucet/info doesn't query the node on every call
+type BalanceCache struct {
+	mu       sync.RWMutex
+	balances map[string]cachedBalance
+	maxAge   time.Duration
+	now      func() time.Time
+}
+
+// NewBalanceCache creates a cache whose entries are served for up to maxAge
+func NewBalanceCache(maxAge time.Duration) *BalanceCache {
+	return &BalanceCache{
+		balances: make(map[string]cachedBalance),
+		maxAge:   maxAge,
+		now:      time.Now,
+	}
+}
+
+// Set records a freshly queried balance for denom
+func (bc *BalanceCache) Set(denom string, amount int64) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.balances[denom] = cachedBalance{amount: amount, asOf: bc.now()}
+}
+
+// Get returns the cached balance for denom and when it was fetched. ok is
+// false when there is no entry or it is older than maxAge.
+func (bc *BalanceCache) Get(denom string) (amount int64, asOf time.Time, ok bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	entry, exists := bc.balances[denom]
+	if !exists || bc.now().Sub(entry.asOf) > bc.maxAge {
+		return 0, time.Time{}, false
+	}
+	return entry.amount, entry.asOf, true
+}
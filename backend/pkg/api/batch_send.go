@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aura-chain/aura/faucet/pkg/faucet"
+)
+
+// batchSender is implemented by faucet services that can pay several
+// recipients, recording each payout separately
+type batchSender interface {
+	SendBatch(reqs []*faucet.SendRequest) []faucet.BatchResult
+}
+
+// BatchSendRequest lists recipients for an admin batch payout
+type BatchSendRequest struct {
+	Addresses []string `json:"addresses"`
+	ChainID   string   `json:"chain_id"`
+}
+
+// maxBatchJobs bounds how many finished batch jobs are kept for status
+// lookups; the oldest is dropped first
+const maxBatchJobs = 20
+
+// batchJob is an admin batch payout running (or finished) in the background
+type batchJob struct {
+	ID       string
+	ChainID  string
+	Done     bool
+	Results  []faucet.BatchResult
+	Started  time.Time
+	Finished time.Time
+}
+
+// batchJobs tracks admin batch payouts. Only one batch runs at a time, so
+// payouts from concurrent batches never race for the signer's sequence.
+type batchJobs struct {
+	mu      sync.Mutex
+	jobs    map[string]*batchJob
+	order   []string
+	running bool
+}
+
+// start registers a new job, or returns nil while another is running
+func (b *batchJobs) start(chainID string, results []faucet.BatchResult) (*batchJob, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.running {
+		return nil, nil
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	job := &batchJob{ID: hex.EncodeToString(id), ChainID: chainID, Results: results, Started: time.Now()}
+	if b.jobs == nil {
+		b.jobs = make(map[string]*batchJob)
+	}
+	b.jobs[job.ID] = job
+	b.order = append(b.order, job.ID)
+	if len(b.order) > maxBatchJobs {
+		delete(b.jobs, b.order[0])
+		b.order = b.order[1:]
+	}
+	b.running = true
+	return job, nil
+}
+
+// finish records a job's results and lets the next batch start
+func (b *batchJobs) finish(job *batchJob, results []faucet.BatchResult) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	job.Results = results
+	job.Done = true
+	job.Finished = time.Now()
+	b.running = false
+}
+
+// get returns a copy of the job, so callers can read it without the lock
+func (b *batchJobs) get(id string) (batchJob, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	job, ok := b.jobs[id]
+	if !ok {
+		return batchJob{}, false
+	}
+	copied := *job
+	copied.Results = append([]faucet.BatchResult(nil), job.Results...)
+	return copied, true
+}
+
+// AdminBatchSend starts paying the drip amount to each address and answers
+// 202 with a job id straight away; a batch of sequential payouts can take
+// far longer than the server's write timeout. AdminBatchStatus reports each
+// address as funded (with its tx hash), failed (with a reason code) or
+// skipped. Each payout is recorded on its own, so after a partial failure
+// the batch can be retried with just the failed addresses.
+func (h *Handler) AdminBatchSend(c *gin.Context) {
+	decoder := json.NewDecoder(io.LimitReader(c.Request.Body, maxValidateBodyBytes+1))
+	decoder.DisallowUnknownFields()
+
+	var req BatchSendRequest
+	if err := decoder.Decode(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Invalid request format",
+			"fields": []FieldError{decodeFieldError(err)},
+		})
+		return
+	}
+	if fieldErrs := validateAddressList(req.Addresses); len(fieldErrs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Invalid request format",
+			"fields": fieldErrs,
+		})
+		return
+	}
+
+	chain, ok := h.routeChain(req.ChainID)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Unknown chain_id %q", req.ChainID),
+			"code":  "UNKNOWN_CHAIN",
+		})
+		return
+	}
+	sender, ok := chain.faucet.(batchSender)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Batch payouts are not supported for this chain",
+			"code":  "BATCH_UNSUPPORTED",
+		})
+		return
+	}
+
+	// Malformed addresses are skipped up front; the rest go out in order
+	clientIP := c.ClientIP()
+	results := make([]faucet.BatchResult, len(req.Addresses))
+	var sends []*faucet.SendRequest
+	var sendIndexes []int
+	for i, address := range req.Addresses {
+		if err := chain.faucet.ValidateAddress(address); err != nil {
+			results[i] = faucet.BatchResult{Address: address, Status: faucet.BatchSkipped, Reason: "invalid_address"}
+			continue
+		}
+		sends = append(sends, &faucet.SendRequest{
+			Recipient: address,
+			Amount:    chain.cfg.RequestAmount(),
+			IPAddress: clientIP,
+		})
+		sendIndexes = append(sendIndexes, i)
+	}
+
+	// Until a payout finishes its address reads as pending
+	pending := append([]faucet.BatchResult(nil), results...)
+	for _, i := range sendIndexes {
+		pending[i] = faucet.BatchResult{Address: req.Addresses[i], Amount: chain.cfg.RequestAmount(), Status: faucet.BatchPending}
+	}
+	job, err := h.batches.start(chain.cfg.ChainID, pending)
+	if err != nil {
+		log.WithError(err).Error("Failed to start batch payout")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to start batch payout",
+			"code":  "INTERNAL_ERROR",
+		})
+		return
+	}
+	if job == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Another batch payout is still running",
+			"code":  "BATCH_IN_PROGRESS",
+		})
+		return
+	}
+
+	// Tracked as background work, so shutdown waits for the payouts in
+	// flight to be recorded
+	h.goBackground(func(context.Context) {
+		if len(sends) > 0 {
+			for j, result := range sender.SendBatch(sends) {
+				results[sendIndexes[j]] = result
+			}
+		}
+		h.batches.finish(job, results)
+
+		counts := countBatchResults(results)
+		log.WithFields(log.Fields{
+			"job_id":  job.ID,
+			"funded":  counts[faucet.BatchFunded],
+			"failed":  counts[faucet.BatchFailed],
+			"skipped": counts[faucet.BatchSkipped],
+		}).Info("Admin batch payout finished")
+	})
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id":   job.ID,
+		"chain_id": job.ChainID,
+		"status":   "running",
+	})
+}
+
+// AdminBatchStatus reports a batch payout started by AdminBatchSend
+func (h *Handler) AdminBatchStatus(c *gin.Context) {
+	job, ok := h.batches.get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Batch not found",
+			"code":  "NOT_FOUND",
+		})
+		return
+	}
+
+	status := "running"
+	if job.Done {
+		status = "done"
+	}
+	counts := countBatchResults(job.Results)
+	c.JSON(http.StatusOK, gin.H{
+		"job_id":   job.ID,
+		"chain_id": job.ChainID,
+		"status":   status,
+		"results":  job.Results,
+		"pending":  counts[faucet.BatchPending],
+		"funded":   counts[faucet.BatchFunded],
+		"failed":   counts[faucet.BatchFailed],
+		"skipped":  counts[faucet.BatchSkipped],
+	})
+}
+
+// countBatchResults tallies results by status
+func countBatchResults(results []faucet.BatchResult) map[string]int {
+	counts := map[string]int{faucet.BatchPending: 0, faucet.BatchFunded: 0, faucet.BatchFailed: 0, faucet.BatchSkipped: 0}
+	for _, result := range results {
+		counts[result.Status]++
+	}
+	return counts
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxValidateAddresses caps the addresses checked by one validate call
+	maxValidateAddresses = 100
+
+	// maxValidateBodyBytes bounds the validate request body before decoding
+	maxValidateBodyBytes = 64 << 10
+)
+
+// ValidateAddressesRequest lists addresses to pre-validate
+type ValidateAddressesRequest struct {
+	Addresses []string `json:"addresses"`
+}
+
+// AddressValidation is the outcome of the read-only checks for one address
+type AddressValidation struct {
+	Address           string   `json:"address"`
+	Valid             bool     `json:"valid"`   // well-formed for the default chain
+	Allowed           bool     `json:"allowed"` // passes the address allowlist and blocklist
+	Eligible          bool     `json:"eligible"`
+	Reasons           []string `json:"reasons"`
+	RetryAfterSeconds int64    `json:"retry_after_seconds"`
+}
+
+// ValidateAddresses runs the eligibility checks for a list of addresses
+// without sending anything, so integrators can vet a funding run up front
+func (h *Handler) ValidateAddresses(c *gin.Context) {
+	decoder := json.NewDecoder(io.LimitReader(c.Request.Body, maxValidateBodyBytes+1))
+	decoder.DisallowUnknownFields()
+
+	var req ValidateAddressesRequest
+	if err := decoder.Decode(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Invalid request format",
+			"fields": []FieldError{decodeFieldError(err)},
+		})
+		return
+	}
+	if fieldErrs := validateAddressList(req.Addresses); len(fieldErrs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Invalid request format",
+			"fields": fieldErrs,
+		})
+		return
+	}
+
+	ctx := context.Background()
+	clientIP := c.ClientIP()
+	results := make([]AddressValidation, 0, len(req.Addresses))
+	eligible := 0
+	for _, address := range req.Addresses {
+		reasons, retryAfter := h.checkEligibility(ctx, address, clientIP)
+		result := AddressValidation{
+			Address:           address,
+			Valid:             !containsReason(reasons, "invalid_address"),
+			Allowed:           !containsReason(reasons, "invalid_address") && !containsReason(reasons, "address_not_allowed") && !containsReason(reasons, "address_blocked"),
+			Eligible:          len(reasons) == 0,
+			Reasons:           reasons,
+			RetryAfterSeconds: int64(retryAfter.Round(time.Second) / time.Second),
+		}
+		if result.Eligible {
+			eligible++
+		}
+		results = append(results, result)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results":  results,
+		"total":    len(results),
+		"eligible": eligible,
+	})
+}
+
+// validateAddressList checks the list is non-empty, within the cap, and
+// holds only plausibly sized addresses
+func validateAddressList(addresses []string) []FieldError {
+	if len(addresses) == 0 {
+		return []FieldError{{Field: "addresses", Message: "is required"}}
+	}
+	if len(addresses) > maxValidateAddresses {
+		return []FieldError{{Field: "addresses", Message: fmt.Sprintf("must contain at most %d addresses", maxValidateAddresses)}}
+	}
+
+	var errs []FieldError
+	for i, address := range addresses {
+		if address == "" {
+			errs = append(errs, FieldError{Field: fmt.Sprintf("addresses[%d]", i), Message: "is required"})
+		} else if len(address) > maxAddressLength {
+			errs = append(errs, FieldError{Field: fmt.Sprintf("addresses[%d]", i), Message: fmt.Sprintf("must be at most %d characters", maxAddressLength)})
+		}
+	}
+	return errs
+}
+
+// containsReason reports whether reasons includes reason
+func containsReason(reasons []string, reason string) bool {
+	for _, r := range reasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
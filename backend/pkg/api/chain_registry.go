@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// metricPrefixes maps a denom exponent to the SI prefix base denoms
+// conventionally carry, e.g. uaura (6) displays as aura
+var metricPrefixes = map[int]string{3: "m", 6: "u", 9: "n", 12: "p", 18: "a"}
+
+// GetChainDescriptor serves the default chain in the shape of a Cosmos
+// chain-registry chain.json, with its assetlist entry under "assets", so
+// wallets can configure themselves from the faucet. Only the configured
+// public endpoints are listed.
+func (h *Handler) GetChainDescriptor(c *gin.Context) {
+	chainName := h.cfg.ChainName
+	if chainName == "" {
+		chainName = h.cfg.ChainID
+	}
+	display := h.displayDenom()
+
+	descriptor := gin.H{
+		"chain_name":    chainName,
+		"chain_id":      h.cfg.ChainID,
+		"network_type":  h.cfg.NetworkType,
+		"bech32_prefix": h.cfg.AddressPrefix,
+		"fees": gin.H{
+			"fee_tokens": []gin.H{{
+				"denom":             h.cfg.FeeDenom(),
+				"average_gas_price": h.cfg.GasPriceAmount(),
+			}},
+		},
+		"assets": []gin.H{{
+			"base":    h.cfg.Denom,
+			"display": display,
+			"symbol":  strings.ToUpper(display),
+			"denom_units": []gin.H{
+				{"denom": h.cfg.Denom, "exponent": 0},
+				{"denom": display, "exponent": h.cfg.DenomExponent},
+			},
+		}},
+	}
+
+	apis := gin.H{}
+	if h.cfg.PublicRPC != "" {
+		apis["rpc"] = []gin.H{{"address": h.cfg.PublicRPC}}
+	}
+	if h.cfg.PublicREST != "" {
+		apis["rest"] = []gin.H{{"address": h.cfg.PublicREST}}
+	}
+	descriptor["apis"] = apis
+
+	if h.cfg.ExposeFaucetAddress && h.cfg.FaucetAddress != "" {
+		descriptor["faucet"] = gin.H{"address": h.cfg.FaucetAddress}
+	}
+
+	c.JSON(http.StatusOK, descriptor)
+}
+
+// displayDenom returns DISPLAY_DENOM, or DENOM without the SI prefix its
+// exponent implies
+func (h *Handler) displayDenom() string {
+	if h.cfg.DisplayDenom != "" {
+		return h.cfg.DisplayDenom
+	}
+	denom := h.cfg.Denom
+	if prefix, ok := metricPrefixes[h.cfg.DenomExponent]; ok && len(denom) > len(prefix) && strings.HasPrefix(denom, prefix) {
+		return strings.TrimPrefix(denom, prefix)
+	}
+	return denom
+}
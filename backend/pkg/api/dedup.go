@@ -0,0 +1,95 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	metrics "github.com/aura-chain/aura/faucet/pkg/prometheus"
+)
+
+// dedupResponse is the first request's response, replayed to repeats
+type dedupResponse struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"`
+}
+
+// recordingWriter keeps a copy of the response body written through it
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// dedupRequest collapses a rapid repeat of a token request for the same
+// chain and address into the first request's response. When duplicate is
+// true the response has been written; otherwise the caller must defer
+// finish, which stores a successful response for repeats and releases the
+// claim on any other outcome. Call it only once the caller has passed the
+// captcha and proof of work, so nobody can hold another's address.
+func (h *Handler) dedupRequest(c *gin.Context, chain *chainRoute, address string) (finish func(), duplicate bool) {
+	if h.deduper == nil {
+		return func() {}, false
+	}
+
+	key := chain.cfg.ChainID + ":" + address
+	claimed, err := h.deduper.Claim(c.Request.Context(), key)
+	if err != nil {
+		// Deduplication is best effort; the cooldown checks still apply
+		log.WithError(err).Warn("Request deduplication unavailable")
+		return func() {}, false
+	}
+
+	if claimed {
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		return func() {
+			// Only a payout is worth replaying; after a rejection the next
+			// request is checked again
+			if writer.Status() < 200 || writer.Status() > 299 {
+				if err := h.deduper.Release(context.Background(), key); err != nil {
+					log.WithError(err).Warn("Failed to release duplicate request claim")
+				}
+				return
+			}
+			result, err := json.Marshal(dedupResponse{Status: writer.Status(), Body: writer.body.Bytes()})
+			if err == nil {
+				err = h.deduper.Complete(context.Background(), key, result)
+			}
+			if err != nil {
+				log.WithError(err).Warn("Failed to store response for duplicate requests")
+			}
+		}, false
+	}
+
+	metrics.RateLimitHits.WithLabelValues("duplicate").Inc()
+	result, err := h.deduper.Wait(c.Request.Context(), key)
+	var first dedupResponse
+	if err == nil && result != nil {
+		err = json.Unmarshal(result, &first)
+	}
+	if err != nil || result == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "A request for this address is already being processed.",
+			"code":  "DUPLICATE_REQUEST",
+		})
+		return nil, true
+	}
+
+	c.Header("X-Duplicate-Request", "true")
+	c.Data(first.Status, "application/json; charset=utf-8", first.Body)
+	return nil, true
+}
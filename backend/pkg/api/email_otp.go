@@ -0,0 +1,138 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aura-chain/aura/faucet/pkg/emailotp"
+	metrics "github.com/aura-chain/aura/faucet/pkg/prometheus"
+)
+
+// maxEmailTokenLength bounds the email_token field; issued tokens are 64
+// hex characters
+const maxEmailTokenLength = 128
+
+// EmailStartRequest asks for a code to be sent to Email
+type EmailStartRequest struct {
+	Email string `json:"email"`
+}
+
+// EmailVerifyRequest exchanges the Code sent to Email for an email token
+type EmailVerifyRequest struct {
+	Email string `json:"email"`
+	Code  string `json:"code"`
+}
+
+// SetEmailOTP enables the email verification EMAIL_OTP_REQUIRED demands
+// with each token request
+func (h *Handler) SetEmailOTP(service *emailotp.Service) {
+	h.emailOTP = service
+}
+
+// decodeEmailBody strictly decodes a small JSON body into v, writing a 400
+// and returning false when it doesn't decode
+func decodeEmailBody(c *gin.Context, v interface{}) bool {
+	decoder := json.NewDecoder(io.LimitReader(c.Request.Body, maxRequestBodyBytes+1))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Invalid request format",
+			"fields": []FieldError{decodeFieldError(err)},
+		})
+		return false
+	}
+	return true
+}
+
+// StartEmailVerification sends a one-time code to the given email
+func (h *Handler) StartEmailVerification(c *gin.Context) {
+	if h.emailOTP == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Email verification not configured",
+		})
+		return
+	}
+
+	var req EmailStartRequest
+	if !decodeEmailBody(c, &req) {
+		return
+	}
+
+	expiresAt, err := h.emailOTP.Start(req.Email, c.ClientIP())
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{
+			"sent":       true,
+			"expires_at": expiresAt,
+		})
+	case errors.Is(err, emailotp.ErrInvalidEmail):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid email address",
+			"code":  "INVALID_EMAIL",
+		})
+	case errors.Is(err, emailotp.ErrTooManyCodes):
+		metrics.RateLimitHits.WithLabelValues("email").Inc()
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "Too many codes sent to this email. Try again later.",
+			"code":  "EMAIL_LIMIT_REACHED",
+		})
+	case errors.Is(err, emailotp.ErrSendFailed):
+		log.WithError(err).Error("Failed to send email verification code")
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error": "Failed to send verification email",
+			"code":  "EMAIL_SEND_FAILED",
+		})
+	default:
+		log.WithError(err).Error("Failed to start email verification")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to start email verification",
+		})
+	}
+}
+
+// VerifyEmail exchanges a code sent by StartEmailVerification for an email
+// token, good for one token request
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	if h.emailOTP == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Email verification not configured",
+		})
+		return
+	}
+
+	var req EmailVerifyRequest
+	if !decodeEmailBody(c, &req) {
+		return
+	}
+
+	token, err := h.emailOTP.Verify(req.Email, req.Code)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, token)
+	case errors.Is(err, emailotp.ErrInvalidEmail):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid email address",
+			"code":  "INVALID_EMAIL",
+		})
+	case errors.Is(err, emailotp.ErrInvalidCode):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Code is wrong, expired or already used",
+			"code":  "CODE_INVALID",
+		})
+	case errors.Is(err, emailotp.ErrTooManyAttempts):
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "Too many wrong codes. Request a new code.",
+			"code":  "TOO_MANY_ATTEMPTS",
+		})
+	default:
+		log.WithError(err).Error("Failed to verify email code")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to verify code",
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aura-chain/aura/faucet/pkg/emailotp"
+	"github.com/aura-chain/aura/faucet/pkg/faucet"
+	"github.com/aura-chain/aura/faucet/pkg/units"
+)
+
+// stubMailer captures the codes the faucet would have emailed
+type stubMailer struct {
+	bodies []string
+}
+
+func (m *stubMailer) Send(to, subject, body string) error {
+	m.bodies = append(m.bodies, body)
+	return nil
+}
+
+func (m *stubMailer) lastCode(t *testing.T) string {
+	require.NotEmpty(t, m.bodies)
+	code := regexp.MustCompile(`\b\d{6}\b`).FindString(m.bodies[len(m.bodies)-1])
+	require.NotEmpty(t, code)
+	return code
+}
+
+func TestEmailOTPFlow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := &mockFaucet{sendResp: &faucet.SendResponse{TxHash: "tx1", Recipient: "aura1ok", Amount: units.New(100)}}
+	rl := &mockRateLimiter{}
+	h, mock := newHandlerWithDB(t, f, rl)
+	h.cfg.RequireEmailOTP = true
+	mailer := &stubMailer{}
+	service := emailotp.New(h.db, mailer, emailotp.Options{MaxPerEmail: 2})
+	h.SetEmailOTP(service)
+
+	post := func(handler gin.HandlerFunc, body string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		handler(c)
+
+		var resp map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+	pendingRows := func(code string, attempts int) *sqlmock.Rows {
+		sum := sha256.Sum256([]byte("user@example.com:" + code))
+		return sqlmock.NewRows([]string{"id", "email", "code_hash", "attempts", "expires_at"}).
+			AddRow(int64(1), "user@example.com", hex.EncodeToString(sum[:]), attempts, time.Now().Add(time.Minute))
+	}
+
+	expectCreate := func(inserted int64) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO email_verifications`)).
+			WithArgs("user@example.com", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 2).
+			WillReturnResult(sqlmock.NewResult(1, inserted))
+		mock.ExpectCommit()
+	}
+
+	// Send a code
+	expectCreate(1)
+	code, resp := post(h.StartEmailVerification, `{"email":"User@Example.com"}`)
+	require.Equal(t, http.StatusOK, code, resp)
+	sent := mailer.lastCode(t)
+
+	// The per-email limit is reached
+	expectCreate(0)
+	code, resp = post(h.StartEmailVerification, `{"email":"user@example.com"}`)
+	assert.Equal(t, http.StatusTooManyRequests, code)
+	assert.Equal(t, "EMAIL_LIMIT_REACHED", resp["code"])
+
+	code, resp = post(h.StartEmailVerification, `{"email":"not-an-email"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "INVALID_EMAIL", resp["code"])
+
+	// A wrong code counts an attempt
+	wrong := "000000"
+	if sent == wrong {
+		wrong = "111111"
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM email_verifications`)).
+		WithArgs("user@example.com").
+		WillReturnRows(pendingRows(sent, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`SET attempts = attempts + 1`)).
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	code, resp = post(h.VerifyEmail, `{"email":"user@example.com","code":"`+wrong+`"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "CODE_INVALID", resp["code"])
+
+	// The right code yields a token
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM email_verifications`)).
+		WithArgs("user@example.com").
+		WillReturnRows(pendingRows(sent, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`SET verified_at = CURRENT_TIMESTAMP`)).
+		WithArgs(int64(1), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	code, resp = post(h.VerifyEmail, `{"email":"user@example.com","code":"`+sent+`"}`)
+	require.Equal(t, http.StatusOK, code, resp)
+	token, _ := resp["email_token"].(string)
+	require.NotEmpty(t, token)
+	require.NoError(t, mock.ExpectationsWereMet())
+	sum := sha256.Sum256([]byte(token))
+	tokenHash := hex.EncodeToString(sum[:])
+
+	code, resp = post(h.RequestTokens, `{"address":"aura1ok"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "EMAIL_TOKEN_REQUIRED", resp["code"])
+
+	// A request rejected by a later check keeps its token
+	request := `{"address":"aura1ok","email_token":"` + token + `"}`
+	rl.ipLimited = true
+	code, _ = post(h.RequestTokens, request)
+	assert.Equal(t, http.StatusTooManyRequests, code)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	requestColumns := []string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}
+	history := regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)
+	redeem := regexp.QuoteMeta(`SET token_redeemed_at = CURRENT_TIMESTAMP`)
+	rl.ipLimited = false
+	mock.ExpectQuery(history).WillReturnRows(sqlmock.NewRows(requestColumns))
+	mock.ExpectQuery(redeem).WithArgs(tokenHash).
+		WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("user@example.com"))
+	code, resp = post(h.RequestTokens, request)
+	assert.Equal(t, http.StatusOK, code, resp)
+
+	mock.ExpectQuery(history).WillReturnRows(sqlmock.NewRows(requestColumns))
+	mock.ExpectQuery(redeem).WithArgs(tokenHash).WillReturnRows(sqlmock.NewRows([]string{"email"}))
+	code, resp = post(h.RequestTokens, request)
+	assert.Equal(t, http.StatusForbidden, code, "a token is good for one request")
+	assert.Equal(t, "EMAIL_TOKEN_INVALID", resp["code"])
+	assert.Len(t, f.sent, 1)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEmailOTPTooManyAttempts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, mock := newHandlerWithDB(t, &mockFaucet{}, nil)
+	service := emailotp.New(h.db, &stubMailer{}, emailotp.Options{MaxAttempts: 3})
+	h.SetEmailOTP(service)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM email_verifications`)).
+		WithArgs("user@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "code_hash", "attempts", "expires_at"}).
+			AddRow(int64(1), "user@example.com", "hash", 3, time.Now().Add(time.Minute)))
+
+	req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"email":"user@example.com","code":"123456"}`))
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	h.VerifyEmail(c)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), "TOO_MANY_ATTEMPTS")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
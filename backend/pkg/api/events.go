@@ -0,0 +1,56 @@
+package api
+
+import (
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aura-chain/aura/faucet/pkg/events"
+	"github.com/aura-chain/aura/faucet/pkg/faucet"
+	metrics "github.com/aura-chain/aura/faucet/pkg/prometheus"
+)
+
+// publishEvent fills in the request details of event and publishes it when
+// a publisher is configured. Publishing errors never fail the request.
+func (h *Handler) publishEvent(chain *chainRoute, req *TokenRequest, event events.Event) {
+	if h.events == nil {
+		return
+	}
+
+	event.Timestamp = time.Now().UTC()
+	event.ChainID = chain.cfg.ChainID
+	event.Denom = chain.cfg.Denom
+	event.Recipient = req.Address
+	event.Source = req.Source
+	if err := h.events.Publish(event); err != nil {
+		log.WithError(err).Warn("Failed to publish event")
+	}
+}
+
+// recordBlocked counts a request blocked for reason and publishes it
+func (h *Handler) recordBlocked(chain *chainRoute, req *TokenRequest, reason string) {
+	metrics.BlockedRequests.WithLabelValues(reason).Inc()
+	h.publishEvent(chain, req, events.Event{Type: events.RequestBlocked, Reason: reason})
+}
+
+// sendFailureReason classifies a SendTokens error for failure events
+func sendFailureReason(err error) string {
+	var simErr *faucet.SimulationError
+	switch {
+	case errors.As(err, &simErr):
+		return "simulation_" + simErr.Category
+	case errors.Is(err, faucet.ErrBroadcastTimeout):
+		return "broadcast_timeout"
+	case errors.Is(err, faucet.ErrTxRejected):
+		return "tx_rejected"
+	case errors.Is(err, faucet.ErrSupplyGuard):
+		return "supply_guard"
+	case errors.Is(err, faucet.ErrInsufficientFeeBalance):
+		return "fee_balance_low"
+	case errors.Is(err, faucet.ErrBalanceReserve):
+		return "balance_reserve"
+	default:
+		return "send_failed"
+	}
+}
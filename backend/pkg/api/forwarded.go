@@ -0,0 +1,89 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	metrics "github.com/aura-chain/aura/faucet/pkg/prometheus"
+)
+
+// ForwardedForMiddleware resolves the client IP from X-Forwarded-For for a
+// faucet behind exactly proxyDepth reverse proxies. Each proxy appends the
+// address it received the connection from, so the client is the entry
+// proxyDepth hops from the right; anything further left was supplied by the
+// client and is ignored. The resolved IP replaces the request's RemoteAddr,
+// so the router must not also read forwarding headers
+// (ForwardedByClientIP = false) for c.ClientIP() to return it.
+//
+// Chains longer than maxHops (0 for no limit) are rejected as spoofing
+// attempts. Chains shorter than proxyDepth did not pass through every proxy
+// and fall back to the connecting address.
+func ForwardedForMiddleware(proxyDepth, maxHops int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hops := forwardedHops(c.Request.Header.Values("X-Forwarded-For"))
+		if len(hops) == 0 {
+			c.Next()
+			return
+		}
+
+		if maxHops > 0 && len(hops) > maxHops {
+			metrics.BlockedRequests.WithLabelValues("forwarded_for_chain").Inc()
+			log.WithFields(log.Fields{
+				"remote_addr": c.Request.RemoteAddr,
+				"hops":        len(hops),
+				"max_hops":    maxHops,
+			}).Warn("Rejected request with an overlong X-Forwarded-For chain")
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": "Too many X-Forwarded-For hops",
+				"code":  "INVALID_FORWARDED_FOR",
+			})
+			return
+		}
+
+		if len(hops) < proxyDepth {
+			log.WithFields(log.Fields{
+				"remote_addr": c.Request.RemoteAddr,
+				"hops":        len(hops),
+				"proxy_depth": proxyDepth,
+			}).Warn("X-Forwarded-For chain is shorter than the proxy depth; using the connecting address")
+			c.Next()
+			return
+		}
+
+		clientIP := net.ParseIP(hops[len(hops)-proxyDepth])
+		if clientIP == nil {
+			metrics.BlockedRequests.WithLabelValues("forwarded_for_chain").Inc()
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": "Malformed X-Forwarded-For header",
+				"code":  "INVALID_FORWARDED_FOR",
+			})
+			return
+		}
+
+		// gin's ClientIP needs a host:port, so keep the original port
+		_, port, err := net.SplitHostPort(c.Request.RemoteAddr)
+		if err != nil {
+			port = "0"
+		}
+		c.Request.RemoteAddr = net.JoinHostPort(clientIP.String(), port)
+		c.Next()
+	}
+}
+
+// forwardedHops splits X-Forwarded-For values, which may be repeated
+// headers or comma-separated lists, into trimmed entries in order
+func forwardedHops(values []string) []string {
+	var hops []string
+	for _, value := range values {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
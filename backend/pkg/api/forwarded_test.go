@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForwardedForMiddlewareSelectsClientIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	resolve := func(proxyDepth, maxHops int, remoteAddr string, xff ...string) (int, string) {
+		router := gin.New()
+		router.ForwardedByClientIP = false
+		router.Use(ForwardedForMiddleware(proxyDepth, maxHops))
+		router.GET("/", func(c *gin.Context) {
+			c.String(http.StatusOK, c.ClientIP())
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		for _, value := range xff {
+			req.Header.Add("X-Forwarded-For", value)
+		}
+		router.ServeHTTP(w, req)
+		return w.Code, w.Body.String()
+	}
+
+	tests := []struct {
+		name       string
+		proxyDepth int
+		maxHops    int
+		xff        []string
+		wantCode   int
+		wantIP     string
+	}{
+		{"single proxy", 1, 0, []string{"203.0.113.7"}, http.StatusOK, "203.0.113.7"},
+		{"single proxy ignores spoofed prefix", 1, 0, []string{"1.2.3.4, 5.6.7.8, 203.0.113.7"}, http.StatusOK, "203.0.113.7"},
+		{"two proxies", 2, 0, []string{"1.2.3.4, 203.0.113.7, 10.0.0.2"}, http.StatusOK, "203.0.113.7"},
+		{"repeated headers form one chain", 2, 0, []string{"1.2.3.4", "203.0.113.7, 10.0.0.2"}, http.StatusOK, "203.0.113.7"},
+		{"ipv6 client", 1, 0, []string{" 2001:db8::1 "}, http.StatusOK, "2001:db8::1"},
+		{"no header uses the connection", 1, 0, nil, http.StatusOK, "10.0.0.1"},
+		{"short chain uses the connection", 3, 0, []string{"203.0.113.7"}, http.StatusOK, "10.0.0.1"},
+		{"chain within the limit", 1, 3, []string{"1.2.3.4, 5.6.7.8, 203.0.113.7"}, http.StatusOK, "203.0.113.7"},
+		{"overlong chain is rejected", 1, 3, []string{"1.1.1.1, 2.2.2.2, 3.3.3.3, 203.0.113.7"}, http.StatusBadRequest, ""},
+		{"malformed client entry is rejected", 1, 0, []string{"1.2.3.4, not-an-ip"}, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := resolve(tt.proxyDepth, tt.maxHops, "10.0.0.1:4321", tt.xff...)
+			assert.Equal(t, tt.wantCode, code)
+			if tt.wantCode == http.StatusOK {
+				assert.Equal(t, tt.wantIP, body)
+			} else {
+				assert.Contains(t, body, "INVALID_FORWARDED_FOR")
+			}
+		})
+	}
+}
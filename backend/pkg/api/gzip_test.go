@@ -0,0 +1,92 @@
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGzipRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(GzipMiddleware())
+	router.GET("/list", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"transactions": strings.Repeat("x", 1024)})
+	})
+	router.GET("/image", func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", []byte("png-bytes"))
+	})
+	router.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/csv")
+		c.Writer.WriteString("a,b\n")
+		c.Writer.Flush()
+		c.Writer.WriteString("1,2\n")
+	})
+	return router
+}
+
+func TestGzipMiddlewareCompressesWhenAccepted(t *testing.T) {
+	router := newGzipRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/list", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+
+	reader, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "transactions")
+}
+
+func TestGzipMiddlewarePlainWhenNotAccepted(t *testing.T) {
+	router := newGzipRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/list", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Contains(t, w.Body.String(), "transactions")
+}
+
+func TestGzipMiddlewareSkipsCompressedContent(t *testing.T) {
+	router := newGzipRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/image", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	router.ServeHTTP(w, req)
+
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "png-bytes", w.Body.String())
+}
+
+func TestGzipMiddlewareStreams(t *testing.T) {
+	router := newGzipRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/stream", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.True(t, w.Flushed)
+
+	reader, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "a,b\n1,2\n", string(body))
+}
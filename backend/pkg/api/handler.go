@@ -2,30 +2,72 @@ package api
 
 import (
 	"context"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/aura-chain/aura/faucet/pkg/abuse"
+	"github.com/aura-chain/aura/faucet/pkg/captcha"
+	challengestore "github.com/aura-chain/aura/faucet/pkg/challenge"
 	"github.com/aura-chain/aura/faucet/pkg/config"
 	"github.com/aura-chain/aura/faucet/pkg/database"
+	"github.com/aura-chain/aura/faucet/pkg/emailotp"
+	"github.com/aura-chain/aura/faucet/pkg/events"
 	"github.com/aura-chain/aura/faucet/pkg/faucet"
+	"github.com/aura-chain/aura/faucet/pkg/health"
+	tracking "github.com/aura-chain/aura/faucet/pkg/metrics"
+	"github.com/aura-chain/aura/faucet/pkg/pow"
 	metrics "github.com/aura-chain/aura/faucet/pkg/prometheus"
+	"github.com/aura-chain/aura/faucet/pkg/ratelimit"
+	"github.com/aura-chain/aura/faucet/pkg/receipt"
+	"github.com/aura-chain/aura/faucet/pkg/skew"
+	"github.com/aura-chain/aura/faucet/pkg/units"
 )
 
+// pendingRequestMaxAge bounds how long a pending row blocks new requests, so a
+// crash mid-broadcast doesn't lock the address out indefinitely
+const pendingRequestMaxAge = 10 * time.Minute
+
+// nodeStatusRetryDelay separates the health probes' node status attempts
+const nodeStatusRetryDelay = 100 * time.Millisecond
+
+// turnstileVerifyURL is Cloudflare's siteverify endpoint; a var so tests can
+// point it at a plain-HTTP host behind a stub proxy
+var turnstileVerifyURL = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+
+// turnstileTokenTTL is how long a Turnstile token stays valid after its
+// challenge_ts
+const turnstileTokenTTL = 5 * time.Minute
+
 // FaucetService describes the faucet behaviors required by the API layer.
 // Using an interface makes the handler easier to unit test.
 type FaucetService interface {
 	ValidateAddress(address string) error
-	GetNodeStatus() (*faucet.NodeStatus, error)
+	GetNodeStatus(ctx context.Context) (*faucet.NodeStatus, error)
 	GetBalance() (int64, error)
 	GetAddressBalance(address string) (int64, error)
+	GetExactAddressBalance(address string) (units.Amount, error)
 	SendTokens(req *faucet.SendRequest) (*faucet.SendResponse, error)
+	ReplayRequest(requestID int64) (*faucet.SendResponse, error)
+	CheckSigner() error
+}
+
+// supplyChecker is implemented by faucet services that can report their
+// share of the payout denom's total supply
+type supplyChecker interface {
+	CheckSupply() (*faucet.SupplyStatus, error)
 }
 
 // RateLimiter abstracts the redis-backed rate limiter so we can stub it in tests.
@@ -35,6 +77,7 @@ type RateLimiter interface {
 	IncrementIPCounter(ctx context.Context, ip string) error
 	IncrementAddressCounter(ctx context.Context, address string) error
 	GetCurrentCount(ctx context.Context, key string) (int, error)
+	GetRemainingTime(ctx context.Context, key string) (time.Duration, error)
 }
 
 // Handler handles HTTP requests
@@ -43,12 +86,57 @@ type Handler struct {
 	faucet      FaucetService
 	rateLimiter RateLimiter
 	db          *database.DB
+	captcha     *captcha.CaptchaService
+	detector    *abuse.AbuseDetector
+	global      *ratelimit.GlobalLimiter
+	pow         *pow.ProofOfWork
+	nonces      *challengestore.Nonces
+	emailOTP    *emailotp.Service
+	balances    *BalanceCache
+	stats       *StatsCache
+	heights     *HeightTracker
+	deduper     *ratelimit.Deduper
+	receipts    *receipt.Signer
+	events      events.Publisher
+	tracker     *tracking.MetricsTracker
+	health      *health.Registry
+	batches     batchJobs
+
+	addressPatterns []config.AddressPattern
+	blockedPatterns []config.AddressPattern
+	captchaClient   *http.Client
+	captchaProbe    cachedProbe
+
+	// runBackground starts work that must finish before shutdown; see
+	// SetBackgroundRunner
+	runBackground func(run func(ctx context.Context))
+
+	// synced replaces the configured allowlists once ALLOWLIST_URL has
+	// been fetched (see SetAllowlist)
+	synced atomic.Pointer[allowlist]
+
+	// chains are additional chains selectable by chain_id, in config order
+	chains     map[string]*chainRoute
+	chainOrder []string
+}
+
+// chainRoute pairs a chain's derived config with its faucet service
+type chainRoute struct {
+	cfg    *config.Config
+	faucet FaucetService
 }
 
 // TokenRequest represents a faucet token request
 type TokenRequest struct {
-	Address      string `json:"address" binding:"required"`
-	CaptchaToken string `json:"captcha_token" binding:"required"`
+	Address        string `json:"address"`
+	ChainID        string `json:"chain_id"`
+	CaptchaToken   string `json:"captcha_token"`
+	PoWChallengeID string `json:"pow_challenge_id"`
+	PoWSolution    string `json:"pow_solution"`
+	Nonce          string `json:"nonce"`
+	EmailToken     string `json:"email_token"`
+	Source         string `json:"source"`
+	Memo           string `json:"memo"` // trusted callers only
 }
 
 // TurnstileResponse represents Turnstile verification response
@@ -61,94 +149,192 @@ type TurnstileResponse struct {
 
 // NewHandler creates a new API handler
 func NewHandler(cfg *config.Config, faucetService FaucetService, rateLimiter RateLimiter, db *database.DB) *Handler {
-	return &Handler{
-		cfg:         cfg,
-		faucet:      faucetService,
-		rateLimiter: rateLimiter,
-		db:          db,
+	// Load compiles the allowlist; configs built by hand are compiled here
+	patterns := cfg.AllowedAddressPatterns
+	if patterns == nil && len(cfg.AllowedAddresses) > 0 {
+		var err error
+		patterns, err = config.CompileAddressPatterns(cfg.AllowedAddresses)
+		if err != nil {
+			log.WithError(err).Error("Invalid address allowlist; denying all addresses")
+			patterns = []config.AddressPattern{}
+		}
 	}
-}
-
-// Health returns the comprehensive health status of the service (Kubernetes-compatible)
-func (h *Handler) Health(c *gin.Context) {
-	ctx := context.Background()
 
-	checks := map[string]bool{
-		"node_reachable": false,
-		"node_synced":    false,
-		"redis_ready":    false,
-		"database_ready": false,
+	captchaClient, err := faucet.NewHTTPClient(cfg.CaptchaProxyURL(), 10*time.Second)
+	if err != nil {
+		log.WithError(err).Error("Invalid captcha proxy; connecting directly")
+		captchaClient = &http.Client{Timeout: 10 * time.Second}
 	}
 
-	var nodeNetwork string
-	var nodeHeight string
+	h := &Handler{
+		cfg:             cfg,
+		faucet:          faucetService,
+		rateLimiter:     rateLimiter,
+		db:              db,
+		health:          health.NewRegistry(),
+		addressPatterns: patterns,
+		captchaClient:   captchaClient,
+	}
+	h.registerHealthChecks()
+	return h
+}
 
-	// Check node status
-	status, err := h.faucet.GetNodeStatus()
-	if err == nil {
-		checks["node_reachable"] = true
-		nodeNetwork = status.NodeInfo.Network
-		nodeHeight = status.SyncInfo.LatestBlockHeight
-		checks["node_synced"] = !status.SyncInfo.CatchingUp
+// AddChain registers an additional chain. cfg is the chain's derived config
+// (see config.Config.ForChain) and svc the faucet service built from it.
+func (h *Handler) AddChain(cfg *config.Config, svc FaucetService) {
+	if h.chains == nil {
+		h.chains = make(map[string]*chainRoute)
 	}
+	if _, exists := h.chains[cfg.ChainID]; !exists {
+		h.chainOrder = append(h.chainOrder, cfg.ChainID)
+	}
+	h.chains[cfg.ChainID] = &chainRoute{cfg: cfg, faucet: svc}
+}
 
-	// Check Redis (if configured)
-	if h.rateLimiter != nil {
-		if _, err := h.rateLimiter.GetCurrentCount(ctx, "health_check"); err == nil {
-			checks["redis_ready"] = true
-		}
-	} else {
-		checks["redis_ready"] = true // Not configured, so not required
+// chainIDs lists the served chains, default first
+func (h *Handler) chainIDs() []string {
+	return append([]string{h.cfg.ChainID}, h.chainOrder...)
+}
+
+// routeChain resolves a request's chain_id; empty selects the default chain
+func (h *Handler) routeChain(chainID string) (*chainRoute, bool) {
+	if chainID == "" || chainID == h.cfg.ChainID {
+		return &chainRoute{cfg: h.cfg, faucet: h.faucet}, true
 	}
+	route, ok := h.chains[chainID]
+	return route, ok
+}
 
-	// Check database
-	if h.db != nil {
-		if _, err := h.db.GetStatistics(); err == nil {
-			checks["database_ready"] = true
-		}
+// SetCaptchaService enables the built-in image captcha endpoint
+func (h *Handler) SetCaptchaService(svc *captcha.CaptchaService) {
+	h.captcha = svc
+}
+
+// SetAbuseDetector enables risk-aware behavior such as harder captchas
+func (h *Handler) SetAbuseDetector(detector *abuse.AbuseDetector) {
+	h.detector = detector
+}
+
+// SetGlobalLimiter enables the faucet-wide request limit
+func (h *Handler) SetGlobalLimiter(limiter *ratelimit.GlobalLimiter) {
+	h.global = limiter
+}
+
+// SetProofOfWork enables proof-of-work challenges
+func (h *Handler) SetProofOfWork(p *pow.ProofOfWork) {
+	h.pow = p
+}
+
+// SetRequestNonces issues the one-time nonces REQUEST_NONCE_REQUIRED
+// demands with each token request
+func (h *Handler) SetRequestNonces(nonces *challengestore.Nonces) {
+	h.nonces = nonces
+}
+
+// SetReceiptSigner attaches signed drip receipts to successful payouts
+func (h *Handler) SetReceiptSigner(signer *receipt.Signer) {
+	h.receipts = signer
+}
+
+// SetDeduper collapses rapid repeats of a token request, such as double
+// clicks, into the first request's response
+func (h *Handler) SetDeduper(deduper *ratelimit.Deduper) {
+	h.deduper = deduper
+}
+
+// SetHeightTracker reports the node as stalled in /health when tracker
+// sees the block height stop advancing
+func (h *Handler) SetHeightTracker(tracker *HeightTracker) {
+	h.heights = tracker
+}
+
+// SetBalanceCache serves the faucet balance in /faucet/info from cache
+func (h *Handler) SetBalanceCache(cache *BalanceCache) {
+	h.balances = cache
+}
+
+// SetStatsCache serves the statistics in /faucet/info and /faucet/stats
+// from cache
+func (h *Handler) SetStatsCache(cache *StatsCache) {
+	h.stats = cache
+}
+
+// statistics returns the overall statistics and when they were computed,
+// from the stats cache when one is set
+func (h *Handler) statistics() (*database.Statistics, time.Time, error) {
+	if h.stats != nil {
+		return h.stats.Get()
 	}
+	stats, err := h.db.GetStatistics()
+	return stats, time.Now(), err
+}
 
-	// Determine overall status
-	criticalChecks := []string{"node_reachable"}
-	warningChecks := []string{"node_synced", "redis_ready", "database_ready"}
+// SetEventPublisher publishes payout outcomes to a message bus. publisher
+// must not block; wrap slow transports in events.NewAsync.
+func (h *Handler) SetEventPublisher(publisher events.Publisher) {
+	h.events = publisher
+}
 
-	criticalFailed := false
-	for _, check := range criticalChecks {
-		if !checks[check] {
-			criticalFailed = true
-			break
-		}
+// SetBackgroundRunner starts handler work that outlives its request, such
+// as batch payouts, through run, so shutdown waits for it
+func (h *Handler) SetBackgroundRunner(run func(run func(ctx context.Context))) {
+	h.runBackground = run
+}
+
+// goBackground runs work through the background runner, or on a plain
+// goroutine when none is set
+func (h *Handler) goBackground(work func(ctx context.Context)) {
+	if h.runBackground == nil {
+		go work(context.Background())
+		return
 	}
+	h.runBackground(work)
+}
+
+// SetMetricsTracker records payout outcomes in tracker for reporting
+func (h *Handler) SetMetricsTracker(tracker *tracking.MetricsTracker) {
+	h.tracker = tracker
+}
 
-	warningFailed := false
-	for _, check := range warningChecks {
-		if !checks[check] {
-			warningFailed = true
-			break
+// SetAddressBlocklist rejects recipients matching any of patterns, such as
+// known exchange, contract and bridge addresses
+func (h *Handler) SetAddressBlocklist(patterns []config.AddressPattern) {
+	h.blockedPatterns = patterns
+}
+
+// probeNodeStatus queries the node for the health probes, retrying
+// NODE_STATUS_RETRIES times so a single transient failure doesn't flip
+// readiness and churn pods
+func (h *Handler) probeNodeStatus() (*faucet.NodeStatus, error) {
+	var err error
+	for attempt := 0; attempt <= h.cfg.NodeStatusRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(nodeStatusRetryDelay)
 		}
+		var status *faucet.NodeStatus
+		if status, err = h.nodeStatusWithin(h.cfg.NodeStatusTimeout); err == nil {
+			return status, nil
+		}
+		log.WithError(err).WithField("attempt", attempt+1).Debug("Node status query failed")
 	}
+	return nil, err
+}
 
-	var overallStatus string
-	var httpStatus int
-	if criticalFailed {
-		overallStatus = "unhealthy"
-		httpStatus = http.StatusServiceUnavailable
-	} else if warningFailed {
-		overallStatus = "degraded"
-		httpStatus = http.StatusOK
-	} else {
-		overallStatus = "healthy"
-		httpStatus = http.StatusOK
+// nodeStatusWithin queries the node status, cancelling the query after
+// timeout. A zero timeout waits for the client.
+func (h *Handler) nodeStatusWithin(timeout time.Duration) (*faucet.NodeStatus, error) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	c.JSON(httpStatus, gin.H{
-		"status":  overallStatus,
-		"version": "1.0.0",
-		"network": nodeNetwork,
-		"height":  nodeHeight,
-		"checks":  checks,
-		"timestamp": time.Now().UTC().Format(time.RFC3339),
-	})
+	status, err := h.faucet.GetNodeStatus(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("node status timed out after %s", timeout)
+	}
+	return status, err
 }
 
 // Ready returns the readiness status (Kubernetes readiness probe)
@@ -161,32 +347,56 @@ func (h *Handler) Ready(c *gin.Context) {
 	}
 
 	// Check node is reachable (doesn't need to be synced for readiness)
-	if _, err := h.faucet.GetNodeStatus(); err == nil {
+	if _, err := h.probeNodeStatus(); err == nil {
 		checks["node_reachable"] = true
 	}
 
-	// Check Redis (if configured)
-	if h.rateLimiter != nil {
-		if _, err := h.rateLimiter.GetCurrentCount(ctx, "health_check"); err == nil {
-			checks["redis_ready"] = true
-		}
-	} else {
-		checks["redis_ready"] = true
-	}
+	// Check Redis (if configured); slow is still ready
+	redisReady, redisLatency := h.checkRedis(ctx)
+	checks["redis_ready"] = redisReady
 
+	// With a reserve, a faucet that can no longer afford one payout above
+	// it is not ready for traffic
 	isReady := checks["node_reachable"] && checks["redis_ready"]
+	if h.cfg.BalanceReserve.Sign() > 0 {
+		balance, asOf := h.faucetBalance()
+		checks["balance_above_reserve"] = !asOf.IsZero() && h.cfg.SpendableBalance(balance) >= h.cfg.AmountPerRequest
+		isReady = isReady && checks["balance_above_reserve"]
+	}
 	httpStatus := http.StatusOK
 	if !isReady {
 		httpStatus = http.StatusServiceUnavailable
 	}
 
 	c.JSON(httpStatus, gin.H{
-		"ready":     isReady,
-		"checks":    checks,
-		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"ready":            isReady,
+		"redis_latency_ms": latencyMillis(redisLatency),
+		"checks":           checks,
+		"timestamp":        time.Now().UTC().Format(time.RFC3339),
 	})
 }
 
+// checkRedis probes the rate limiter store and measures the round trip.
+// Without a rate limiter Redis is not required and reports ready.
+func (h *Handler) checkRedis(ctx context.Context) (bool, time.Duration) {
+	if h.rateLimiter == nil {
+		return true, 0
+	}
+	start := time.Now()
+	_, err := h.rateLimiter.GetCurrentCount(ctx, "health_check")
+	return err == nil, time.Since(start)
+}
+
+// redisTooSlow reports whether a Redis round trip exceeds the configured threshold
+func (h *Handler) redisTooSlow(latency time.Duration) bool {
+	return h.cfg.RedisLatencyThreshold > 0 && latency > h.cfg.RedisLatencyThreshold
+}
+
+// latencyMillis converts a latency to fractional milliseconds for responses
+func latencyMillis(latency time.Duration) float64 {
+	return float64(latency.Microseconds()) / 1000
+}
+
 // Live returns the liveness status (Kubernetes liveness probe)
 func (h *Handler) Live(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
@@ -195,14 +405,11 @@ func (h *Handler) Live(c *gin.Context) {
 	})
 }
 
-// GetFaucetInfo returns faucet information
+// GetFaucetInfo returns faucet information. Distribution totals are
+// left out unless stats are public or the caller presents the admin token.
 func (h *Handler) GetFaucetInfo(c *gin.Context) {
-	// Get faucet balance
-	balance, err := h.faucet.GetBalance()
-	if err != nil {
-		log.WithError(err).Error("Failed to get faucet balance")
-		balance = 0 // Continue with 0 balance
-	}
+	// Get faucet balance, preferring the monitor's cached value
+	balance, asOf := h.faucetBalance()
 
 	if h.db == nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
@@ -211,30 +418,90 @@ func (h *Handler) GetFaucetInfo(c *gin.Context) {
 		return
 	}
 
-	// Get statistics
-	stats, err := h.db.GetStatistics()
-	if err != nil {
-		log.WithError(err).Error("Failed to get statistics")
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get faucet information",
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"amount_per_request":    h.cfg.AmountPerRequest,
+	info := gin.H{
+		"amount_per_request":    h.cfg.RequestAmount(),
+		"amount_display":        h.cfg.DisplayAmount(h.cfg.RequestAmount()),
 		"denom":                 h.cfg.Denom,
+		"denom_exponent":        h.cfg.DenomExponent,
+		"fee_denom":             h.cfg.FeeDenom(),
 		"balance":               balance,
-		"max_recipient_balance": h.cfg.MaxRecipientBalance,
-		"total_distributed":     stats.TotalDistributed,
-		"unique_recipients":     stats.UniqueRecipients,
-		"requests_last_24h":     stats.RequestsLast24h,
+		"max_recipient_balance": h.cfg.RecipientBalanceCap(h.cfg.Denom),
 		"chain_id":              h.cfg.ChainID,
-	})
+	}
+	if !asOf.IsZero() {
+		info["balance_as_of"] = asOf.UTC()
+	}
+	chains := h.chainInfo(balance)
+	info["chains"] = chains
+
+	if h.cfg.MaxUniqueRecipients > 0 {
+		served, err := h.db.CountUniqueRecipients()
+		if err != nil {
+			log.WithError(err).Error("Failed to count unique recipients")
+		} else {
+			info["unique_recipient_slots_remaining"] = max(h.cfg.MaxUniqueRecipients-served, 0)
+		}
+	}
+
+	detailed := h.statsAuthorized(c)
+	if detailed {
+		stats, asOf, err := h.statistics()
+		if err != nil {
+			log.WithError(err).Error("Failed to get statistics")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to get faucet information",
+			})
+			return
+		}
+		info["total_distributed"] = stats.TotalDistributed
+		info["unique_recipients"] = stats.UniqueRecipients
+		info["requests_last_24h"] = stats.RequestsLast24h
+		info["stats_as_of"] = asOf.UTC()
+	}
+
+	// With several denoms, also report balances and payouts keyed by denom;
+	// the single-value fields above keep describing the default chain
+	if h.multiDenom() {
+		balances := make(map[string]int64)
+		for _, chain := range chains {
+			balances[chain["denom"].(string)] += chain["balance"].(int64)
+		}
+		info["balances"] = balances
+
+		if detailed {
+			denomStats, err := h.statisticsByDenom()
+			if err != nil {
+				log.WithError(err).Error("Failed to get statistics by denom")
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Failed to get faucet information",
+				})
+				return
+			}
+			distributed := make(map[string]int64)
+			for denom, s := range denomStats {
+				distributed[denom] = s.TotalDistributed
+			}
+			info["distributed"] = distributed
+		}
+	}
+	if h.cfg.ExposeFaucetAddress && h.cfg.FaucetAddress != "" {
+		info["faucet_address"] = h.cfg.FaucetAddress
+	}
+
+	c.JSON(http.StatusOK, info)
 }
 
-// GetRecentTransactions returns recent faucet transactions
+// GetRecentTransactions returns recent faucet transactions. Like the
+// statistics, it requires the admin token when PUBLIC_STATS=false.
 func (h *Handler) GetRecentTransactions(c *gin.Context) {
+	if !h.statsAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+			"code":  "UNAUTHORIZED",
+		})
+		return
+	}
+
 	if h.db == nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
 			"error": "Database not configured",
@@ -242,7 +509,20 @@ func (h *Handler) GetRecentTransactions(c *gin.Context) {
 		return
 	}
 
-	requests, err := h.db.GetRecentRequests(50)
+	var requests []*database.FaucetRequest
+	var err error
+	if source := c.Query("source"); source != "" {
+		if !h.sourceKnown(source) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Unknown source %q", source),
+				"code":  "UNKNOWN_SOURCE",
+			})
+			return
+		}
+		requests, err = h.db.GetRecentRequestsBySource(source, 50)
+	} else {
+		requests, err = h.db.GetRecentRequests(50)
+	}
 	if err != nil {
 		log.WithError(err).Error("Failed to get recent transactions")
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -273,187 +553,1314 @@ func (h *Handler) RequestTokens(c *gin.Context) {
 	ctx := context.Background()
 	start := time.Now()
 
-	var req TokenRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	req, fieldErrs := decodeTokenRequest(c.Request.Body)
+	if len(fieldErrs) > 0 {
+		metrics.ValidationRejections.WithLabelValues("invalid_request").Inc()
 		metrics.RecordRequest("failed", h.cfg.Denom, 0, time.Since(start).Seconds())
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request format",
+			"error":  "Invalid request format",
+			"fields": fieldErrs,
 		})
 		return
 	}
 
-	// Get client IP
-	clientIP := c.ClientIP()
-
-	log.WithFields(log.Fields{
-		"address": req.Address,
-		"ip":      clientIP,
-	}).Info("Token request received")
-
-	// Validate address
-	if err := h.faucet.ValidateAddress(req.Address); err != nil {
+	if req.ChainID == "" && h.cfg.RequireChainID {
+		metrics.ValidationRejections.WithLabelValues("chain_id_missing").Inc()
 		metrics.RecordRequest("failed", h.cfg.Denom, 0, time.Since(start).Seconds())
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid address format",
+			"error": fmt.Sprintf("chain_id is required (this faucet serves %s)", strings.Join(h.chainIDs(), ", ")),
+			"code":  "CHAIN_ID_REQUIRED",
 		})
 		return
 	}
 
-	// Enforce allowlists when configured (devnet access control)
-	if !addressAllowed(req.Address, h.cfg.AllowedAddresses) {
-		metrics.BlockedRequests.WithLabelValues("allowlist").Inc()
+	chain, ok := h.routeChain(req.ChainID)
+	if !ok {
+		metrics.ValidationRejections.WithLabelValues("unknown_chain").Inc()
 		metrics.RecordRequest("failed", h.cfg.Denom, 0, time.Since(start).Seconds())
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "Address is not allowed to use this faucet",
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Unknown chain_id %q", req.ChainID),
+			"code":  "UNKNOWN_CHAIN",
 		})
 		return
 	}
-	if !ipAllowed(clientIP, h.cfg.AllowedIPs) {
-		metrics.BlockedRequests.WithLabelValues("ip").Inc()
-		metrics.RecordRequest("failed", h.cfg.Denom, 0, time.Since(start).Seconds())
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "IP is not allowed to use this faucet",
+
+	if req.Source != "" && !h.sourceKnown(req.Source) {
+		metrics.ValidationRejections.WithLabelValues("unknown_source").Inc()
+		metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Unknown source %q", req.Source),
+			"code":  "UNKNOWN_SOURCE",
 		})
 		return
 	}
 
-	// Verify captcha when required
-	if h.cfg.RequireCaptcha {
-		if !h.verifyCaptcha(req.CaptchaToken, clientIP) {
-			metrics.CaptchaAttempts.WithLabelValues("fail").Inc()
-			metrics.RecordRequest("failed", h.cfg.Denom, 0, time.Since(start).Seconds())
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Captcha verification failed",
-			})
-			return
+	// Time-boxed programs only serve requests while a campaign is open
+	if open, _, next := h.cfg.CampaignStatus(start); !open {
+		reason := "ended"
+		body := gin.H{
+			"error": "The faucet campaign has ended",
+			"code":  "CAMPAIGN_ENDED",
 		}
-		metrics.CaptchaAttempts.WithLabelValues("pass").Inc()
+		if next != nil {
+			reason = "not_open"
+			body = gin.H{
+				"error":            fmt.Sprintf("Campaign %s is not yet open", next.Name),
+				"code":             "CAMPAIGN_NOT_OPEN",
+				"opens_at":         next.Start.UTC(),
+				"opens_in_seconds": int64(next.Start.Sub(start).Seconds()),
+			}
+		}
+		metrics.CampaignClosedRequests.WithLabelValues(reason).Inc()
+		metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		c.JSON(http.StatusForbidden, body)
+		return
 	}
 
-	if h.rateLimiter == nil || h.db == nil {
-		metrics.RecordRequest("failed", h.cfg.Denom, 0, time.Since(start).Seconds())
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "Service dependencies not configured",
+	// Get client IP
+	clientIP := c.ClientIP()
+
+	log.WithFields(log.Fields{
+		"address": req.Address,
+		"ip":      clientIP,
+	}).Info("Token request received")
+
+	// Reject addresses meant for a different chain before format checks so
+	// the error names the mismatch
+	if prefix := chain.cfg.AddressPrefix; prefix != "" && !strings.HasPrefix(req.Address, prefix+"1") {
+		metrics.ValidationRejections.WithLabelValues("chain_prefix_mismatch").Inc()
+		metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Address prefix does not match chain %s (expected %s1...)", chain.cfg.ChainID, prefix),
+			"code":  "CHAIN_PREFIX_MISMATCH",
 		})
 		return
 	}
 
-	// Check IP rate limit
-	ipLimited, err := h.rateLimiter.CheckIPLimit(ctx, clientIP)
-	if err != nil {
-		log.WithError(err).Error("Failed to check IP rate limit")
-		metrics.RecordRequest("failed", h.cfg.Denom, 0, time.Since(start).Seconds())
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Internal server error",
+	// Validate address
+	if err := chain.faucet.ValidateAddress(req.Address); err != nil {
+		metrics.ValidationRejections.WithLabelValues("invalid_address").Inc()
+		metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid address format",
 		})
 		return
 	}
 
-	if ipLimited {
-		metrics.RateLimitHits.WithLabelValues("ip").Inc()
-		metrics.RecordRequest("rate_limited", h.cfg.Denom, 0, time.Since(start).Seconds())
-		c.JSON(http.StatusTooManyRequests, gin.H{
-			"error": "Too many requests from your IP address. Please try again later.",
+	// Sending to a faucet wallet only burns fees and skews stats
+	if h.isFaucetAddress(req.Address) {
+		metrics.ValidationRejections.WithLabelValues("self_transfer").Inc()
+		metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Recipient cannot be a faucet address",
+			"code":  "SELF_TRANSFER",
 		})
 		return
 	}
 
-	// Check address rate limit
-	addressLimited, err := h.rateLimiter.CheckAddressLimit(ctx, req.Address)
-	if err != nil {
-		log.WithError(err).Error("Failed to check address rate limit")
-		metrics.RecordRequest("failed", h.cfg.Denom, 0, time.Since(start).Seconds())
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Internal server error",
+	// Keep tokens away from exchanges, contracts and bridges
+	if h.addressBlocked(req.Address) {
+		h.recordBlocked(chain, req, "blocklist")
+		metrics.ValidationRejections.WithLabelValues("blocklisted_addr").Inc()
+		metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Address cannot receive faucet tokens",
+			"code":  "ADDRESS_BLOCKED",
 		})
 		return
 	}
 
-	if addressLimited {
-		metrics.RateLimitHits.WithLabelValues("address").Inc()
-		metrics.RecordRequest("rate_limited", h.cfg.Denom, 0, time.Since(start).Seconds())
-		c.JSON(http.StatusTooManyRequests, gin.H{
-			"error": "This address has already received tokens recently. Please wait 24 hours.",
+	// Enforce allowlists when configured (devnet access control)
+	if !h.addressAllowed(req.Address) {
+		h.recordBlocked(chain, req, "allowlist")
+		metrics.ValidationRejections.WithLabelValues("not_allowlisted_addr").Inc()
+		metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Address is not allowed to use this faucet",
+		})
+		return
+	}
+	if !ipAllowed(clientIP, h.allowedIPs()) {
+		h.recordBlocked(chain, req, "ip")
+		metrics.ValidationRejections.WithLabelValues("not_allowlisted_ip").Inc()
+		metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "IP is not allowed to use this faucet",
 		})
 		return
 	}
 
-	// Check if address has recent requests in database
-	since := time.Now().Add(-24 * time.Hour)
-	dbRequests, err := h.db.GetRequestsByAddress(req.Address, since)
-	if err != nil {
-		log.WithError(err).Error("Failed to check address history")
-	} else if len(dbRequests) > 0 {
-		metrics.RateLimitHits.WithLabelValues("daily").Inc()
-		metrics.RecordRequest("rate_limited", h.cfg.Denom, 0, time.Since(start).Seconds())
-		c.JSON(http.StatusTooManyRequests, gin.H{
-			"error": "This address has already received tokens in the last 24 hours.",
+	// Integrators tag their payouts with their own reference; anyone else
+	// could use the faucet to publish arbitrary text on chain
+	if req.Memo != "" && !h.memoAllowed(c, clientIP) {
+		h.recordBlocked(chain, req, "memo_not_allowed")
+		metrics.ValidationRejections.WithLabelValues("memo_not_allowed").Inc()
+		metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Custom memos are only accepted from trusted callers",
+			"code":  "MEMO_NOT_ALLOWED",
 		})
 		return
 	}
 
-	// Check recipient balance cap
-	if h.cfg.MaxRecipientBalance > 0 {
-		balance, err := h.faucet.GetAddressBalance(req.Address)
-		if err != nil {
-			log.WithError(err).Error("Failed to check recipient balance")
-			metrics.RecordRequest("failed", h.cfg.Denom, 0, time.Since(start).Seconds())
+	// A request nonce is good for one request; a replayed request is
+	// refused outright rather than answered like a duplicate
+	if h.cfg.RequireRequestNonce {
+		if h.nonces == nil {
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
 			c.JSON(http.StatusServiceUnavailable, gin.H{
-				"error": "Unable to verify recipient balance at this time",
+				"error": "Request nonces not configured",
 			})
 			return
 		}
-		if balance >= h.cfg.MaxRecipientBalance {
-			metrics.BlockedRequests.WithLabelValues("balance_cap").Inc()
-			metrics.RecordRequest("failed", h.cfg.Denom, 0, time.Since(start).Seconds())
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "Address balance is above faucet eligibility threshold",
+		if req.Nonce == "" {
+			metrics.ValidationRejections.WithLabelValues("nonce_missing").Inc()
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Request nonce is required",
+				"code":  "NONCE_REQUIRED",
+			})
+			return
+		}
+		if err := h.nonces.Redeem(req.Nonce); err != nil {
+			h.recordBlocked(chain, req, "nonce")
+			metrics.ValidationRejections.WithLabelValues("nonce_invalid").Inc()
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Request nonce is unknown, expired or already used",
+				"code":  "NONCE_INVALID",
 			})
 			return
 		}
 	}
 
-	// Send tokens
-	sendReq := &faucet.SendRequest{
-		Recipient: req.Address,
-		Amount:    h.cfg.AmountPerRequest,
-		IPAddress: clientIP,
+	// The email token proves the caller received a code at an address
+	// they control; like the nonce it is good for one request. It is
+	// redeemed just before the payout, so a rejection doesn't burn it.
+	if h.cfg.RequireEmailOTP {
+		if h.emailOTP == nil {
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Email verification not configured",
+			})
+			return
+		}
+		if req.EmailToken == "" {
+			metrics.ValidationRejections.WithLabelValues("email_token_missing").Inc()
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Email verification is required",
+				"code":  "EMAIL_TOKEN_REQUIRED",
+			})
+			return
+		}
 	}
 
-	resp, err := h.faucet.SendTokens(sendReq)
-	if err != nil {
-		log.WithError(err).Error("Failed to send tokens")
-		metrics.RecordRequest("failed", h.cfg.Denom, 0, time.Since(start).Seconds())
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to send tokens. Please try again later.",
-		})
-		return
+	// Consult the abuse detector; risky requests may need a captcha even
+	// when captchas are otherwise optional. Blocking is opt-in.
+	requireCaptcha := h.cfg.RequireCaptcha
+	if h.detector != nil && !h.cfg.AbuseEnforcement {
+		requireCaptcha = requireCaptcha || h.detector.RequiresCaptcha(clientIP)
 	}
-
-	// Update rate limiters
-	if err := h.rateLimiter.IncrementIPCounter(ctx, clientIP); err != nil {
-		log.WithError(err).Error("Failed to increment IP counter")
+	if h.detector != nil && h.cfg.AbuseEnforcement {
+		result := h.detector.CheckRequest(clientIP, req.Address)
+		if !result.Allowed {
+			h.recordBlocked(chain, req, "abuse")
+			metrics.ValidationRejections.WithLabelValues("abuse_blocked").Inc()
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			body := gin.H{
+				"error": "Request blocked by abuse protection. Please try again later.",
+			}
+			if h.cfg.ExposeDetectionDetails {
+				body["detection"] = detectionDetails(result)
+			}
+			c.JSON(http.StatusForbidden, body)
+			return
+		}
+		if result.Action == abuse.ActionCaptcha {
+			requireCaptcha = true
+		}
 	}
 
-	if err := h.rateLimiter.IncrementAddressCounter(ctx, req.Address); err != nil {
-		log.WithError(err).Error("Failed to increment address counter")
+	// End-to-end tests outside production may skip captcha and proof of work
+	bypass := h.testBypass(c)
+	if bypass {
+		log.WithField("ip", clientIP).Debug("Test bypass token accepted; skipping captcha and proof of work")
+		requireCaptcha = false
 	}
 
-	// Record successful request
-	metrics.RecordRequest("success", h.cfg.Denom, h.cfg.AmountPerRequest, time.Since(start).Seconds())
-	metrics.UniqueAddresses.Inc()
-
-	c.JSON(http.StatusOK, gin.H{
-		"tx_hash":   resp.TxHash,
-		"recipient": resp.Recipient,
-		"amount":    resp.Amount,
-		"message":   "Tokens sent successfully",
-	})
-}
-
-// GetStatistics returns detailed statistics
-func (h *Handler) GetStatistics(c *gin.Context) {
-	stats, err := h.db.GetStatistics()
+	// Verify captcha and proof of work when required. Each failure carries
+	// its own code so clients know which check to redo.
+	if requireCaptcha {
+		if req.CaptchaToken == "" {
+			metrics.CaptchaAttempts.WithLabelValues("fail").Inc()
+			h.recordBlocked(chain, req, "captcha")
+			metrics.ValidationRejections.WithLabelValues("captcha_missing").Inc()
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Captcha token is required",
+				"code":  "CAPTCHA_REQUIRED",
+			})
+			return
+		}
+		if !h.verifyCaptcha(req.CaptchaToken, clientIP) {
+			metrics.CaptchaAttempts.WithLabelValues("fail").Inc()
+			h.recordBlocked(chain, req, "captcha")
+			metrics.ValidationRejections.WithLabelValues("captcha_failed").Inc()
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Captcha verification failed",
+				"code":  "CAPTCHA_FAILED",
+			})
+			return
+		}
+		metrics.CaptchaAttempts.WithLabelValues("pass").Inc()
+	}
+
+	if h.cfg.RequirePoW && !bypass {
+		if h.pow == nil {
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Proof of work not configured",
+			})
+			return
+		}
+		if req.PoWChallengeID == "" || req.PoWSolution == "" {
+			h.recordBlocked(chain, req, "pow")
+			metrics.ValidationRejections.WithLabelValues("pow_missing").Inc()
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Proof of work solution is required",
+				"code":  "POW_REQUIRED",
+			})
+			return
+		}
+		if valid, err := h.pow.Verify(req.PoWChallengeID, req.PoWSolution); err != nil || !valid {
+			h.recordBlocked(chain, req, "pow")
+			metrics.ValidationRejections.WithLabelValues("pow_failed").Inc()
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Proof of work verification failed",
+				"code":  "POW_FAILED",
+			})
+			return
+		}
+	}
+
+	// A repeat while the first request for this address is in flight, or
+	// just after its payout, gets the first response instead of a second
+	// payout
+	finishDedup, duplicate := h.dedupRequest(c, chain, req.Address)
+	if duplicate {
+		return
+	}
+	defer finishDedup()
+
+	if h.rateLimiter == nil || h.db == nil {
+		metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Service dependencies not configured",
+		})
+		return
+	}
+
+	// Check the faucet-wide limit (tighter during slow start)
+	if h.global != nil && !h.global.Allow() {
+		metrics.RateLimitHits.WithLabelValues("global").Inc()
+		h.recordBlocked(chain, req, "global_rate_limit")
+		metrics.ValidationRejections.WithLabelValues("global_rate_limit").Inc()
+		metrics.RecordRequest("rate_limited", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		retryAfter := ratelimit.Jitter(time.Minute, h.cfg.RateLimitJitter())
+		c.Header("Retry-After", strconv.Itoa(int(retryAfter.Round(time.Second)/time.Second)))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "The faucet is busy. Please try again in a minute.",
+		})
+		return
+	}
+
+	// Shed requests once the broadcast queue is full; every response
+	// carries the load so clients can slow down before that
+	if load, status, ok := h.queueLoad(chain); ok {
+		setLoadHeader(c, load)
+		if load >= 1 {
+			metrics.RateLimitHits.WithLabelValues("queue").Inc()
+			h.recordBlocked(chain, req, "queue_full")
+			metrics.ValidationRejections.WithLabelValues("queue_full").Inc()
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.Header("Retry-After", strconv.Itoa(int(queueRetryAfter(status)/time.Second)))
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "The faucet is busy. Please try again shortly.",
+				"code":  "QUEUE_FULL",
+			})
+			return
+		}
+	}
+
+	// In strict mode an IP may only ever fund one address. Repeats of that
+	// address fall through to the normal cooldown checks.
+	if h.cfg.StrictOneAddressPerIP {
+		fundedOther, err := h.fundedOtherAddress(clientIP, req.Address)
+		if err != nil {
+			log.WithError(err).Error("Failed to check IP funding history")
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Unable to verify request history at this time",
+			})
+			return
+		}
+		if fundedOther {
+			h.recordBlocked(chain, req, "one_address_per_ip")
+			metrics.ValidationRejections.WithLabelValues("one_address_per_ip").Inc()
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "This IP address has already funded a different address.",
+			})
+			return
+		}
+	}
+
+	// Cap the distinct addresses an IP funds per window. Unlike the abuse
+	// tracker this reads request history, so it survives restarts.
+	if h.cfg.MaxRecipientsPerIP > 0 {
+		others, err := h.db.CountDistinctRecipientsByIP(clientIP, req.Address, time.Now().Add(-h.cfg.RateLimitWindow))
+		if err != nil {
+			log.WithError(err).Error("Failed to count recipients for IP")
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Unable to verify request history at this time",
+			})
+			return
+		}
+		if others+1 > h.cfg.MaxRecipientsPerIP {
+			h.recordBlocked(chain, req, "recipients_per_ip")
+			metrics.ValidationRejections.WithLabelValues("too_many_recipients").Inc()
+			metrics.RecordRequest("rate_limited", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error": "This IP address has funded too many different addresses recently.",
+				"code":  "TOO_MANY_RECIPIENTS",
+			})
+			return
+		}
+	}
+
+	// Cap payouts to the client's subnet per window, so a block of
+	// addresses can't drain the faucet; also read from request history
+	if h.cfg.SubnetMaxPayouts > 0 {
+		subnet, ok := clientSubnet(clientIP, h.cfg.SubnetPrefixV4, h.cfg.SubnetPrefixV6)
+		if !ok {
+			metrics.ValidationRejections.WithLabelValues("invalid_ip").Inc()
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Unable to determine your IP address",
+				"code":  "INVALID_IP",
+			})
+			return
+		}
+		payouts, err := h.db.CountSuccessfulPayoutsBySubnet(subnet, time.Now().Add(-h.cfg.RateLimitWindow))
+		if err != nil {
+			log.WithError(err).Error("Failed to count payouts for subnet")
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Unable to verify request history at this time",
+			})
+			return
+		}
+		if payouts >= h.cfg.SubnetMaxPayouts {
+			h.recordBlocked(chain, req, "subnet_limit")
+			metrics.ValidationRejections.WithLabelValues("subnet_limit").Inc()
+			metrics.RecordRequest("rate_limited", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error": "Too many payouts to your network recently. Please try again later.",
+				"code":  "SUBNET_LIMIT",
+			})
+			return
+		}
+	}
+
+	// A limiter that reserves counts the request as it checks it, so
+	// concurrent requests can't all pass on the last slot; the slots go
+	// back unless the payout is sent
+	checkIP, checkAddress := h.rateLimiter.CheckIPLimit, h.rateLimiter.CheckAddressLimit
+	reserver, reserving := h.rateLimiter.(limitReserver)
+	if reserving {
+		checkIP, checkAddress = reserver.ReserveIP, reserver.ReserveAddress
+	}
+	var ipReserved, addressReserved, served bool
+	defer func() {
+		if served {
+			return
+		}
+		if ipReserved {
+			if err := reserver.ReleaseIP(ctx, clientIP); err != nil {
+				log.WithError(err).Error("Failed to release IP rate limit slot")
+			}
+		}
+		if addressReserved {
+			if err := reserver.ReleaseAddress(ctx, req.Address); err != nil {
+				log.WithError(err).Error("Failed to release address rate limit slot")
+			}
+		}
+	}()
+
+	// Check IP rate limit
+	ipLimited, err := checkIP(ctx, clientIP)
+	if err != nil {
+		log.WithError(err).Error("Failed to check IP rate limit")
+		metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	if ipLimited {
+		metrics.RateLimitHits.WithLabelValues("ip").Inc()
+		h.recordBlocked(chain, req, "ip_rate_limit")
+		metrics.ValidationRejections.WithLabelValues("ip_rate_limit").Inc()
+		metrics.RecordRequest("rate_limited", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "Too many requests from your IP address. Please try again later.",
+		})
+		return
+	}
+	ipReserved = reserving
+
+	// Check address rate limit
+	addressLimited, err := checkAddress(ctx, req.Address)
+	if err != nil {
+		log.WithError(err).Error("Failed to check address rate limit")
+		metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+	addressReserved = reserving && !addressLimited
+
+	// Within the top-up grace window a limited address may still be
+	// topped up to the drip amount
+	topUp := false
+	if addressLimited && h.cfg.TopUpGraceWindow > 0 {
+		history, err := h.db.GetRequestsByAddress(req.Address, time.Now().Add(-h.cooldownWindow()))
+		if err != nil {
+			log.WithError(err).Error("Failed to check address history")
+		} else {
+			topUp = h.withinTopUpGrace(history)
+		}
+	}
+
+	if addressLimited && !topUp {
+		metrics.RateLimitHits.WithLabelValues("address").Inc()
+		h.recordBlocked(chain, req, "address_rate_limit")
+		metrics.ValidationRejections.WithLabelValues("address_rate_limit").Inc()
+		metrics.RecordRequest("rate_limited", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": fmt.Sprintf("This address has already received tokens recently. Please wait %s.", formatWindow(h.cooldownWindow())),
+		})
+		return
+	}
+
+	// Reject double submits while an earlier request is still broadcasting
+	if h.cfg.RejectPendingRequests {
+		pending, err := h.db.HasPendingRequest(req.Address, time.Now().Add(-pendingRequestMaxAge))
+		if err != nil {
+			log.WithError(err).Error("Failed to check pending requests")
+		} else if pending {
+			metrics.RateLimitHits.WithLabelValues("pending").Inc()
+			h.recordBlocked(chain, req, "pending_request")
+			metrics.ValidationRejections.WithLabelValues("pending_request").Inc()
+			metrics.RecordRequest("rate_limited", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "A request for this address is already being processed.",
+			})
+			return
+		}
+	}
+
+	// Check if address has recent requests in database
+	window := h.cooldownWindow()
+	since := time.Now().Add(-window)
+	dbRequests, err := h.db.GetRequestsByAddress(req.Address, since)
+	if err != nil {
+		log.WithError(err).Error("Failed to check address history")
+	} else if len(dbRequests) > 0 {
+		if !topUp && !h.withinTopUpGrace(dbRequests) {
+			metrics.RateLimitHits.WithLabelValues("daily").Inc()
+			h.recordBlocked(chain, req, "address_cooldown")
+			metrics.ValidationRejections.WithLabelValues("address_cooldown").Inc()
+			metrics.RecordRequest("rate_limited", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error": fmt.Sprintf("This address has already received tokens in the last %s.", formatWindow(window)),
+			})
+			return
+		}
+		topUp = true
+	}
+
+	// Once the unique recipient cap is reached only addresses already paid
+	// are served
+	if h.cfg.MaxUniqueRecipients > 0 {
+		full, err := h.recipientCapReached(req.Address)
+		if err != nil {
+			log.WithError(err).Error("Failed to check unique recipient cap")
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Unable to verify faucet capacity at this time",
+			})
+			return
+		}
+		if full {
+			h.recordBlocked(chain, req, "recipient_cap")
+			metrics.ValidationRejections.WithLabelValues("recipient_cap").Inc()
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "This faucet is not accepting new addresses",
+				"code":  "RECIPIENT_CAP_REACHED",
+			})
+			return
+		}
+	}
+
+	// Funds sent to a contract or module account are usually a mistake or
+	// an attempt to feed a contract, so those take an explicit opt-in. A
+	// failed account query follows BALANCE_CHECK_FAIL_POLICY.
+	if detector, ok := chain.faucet.(contractDetector); ok && !h.cfg.AllowContractRecipients {
+		contract, err := detector.IsContractAccount(req.Address)
+		if err != nil && h.cfg.BalanceCheckFailsOpen() {
+			log.WithError(err).WithField("address", req.Address).Warn("Recipient account type check failed; allowing request (fail-open policy)")
+			metrics.BalanceCheckFailOpen.WithLabelValues("account_type").Inc()
+		} else if err != nil {
+			log.WithError(err).Error("Failed to check recipient account type")
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Unable to verify recipient account at this time",
+			})
+			return
+		} else if contract {
+			h.recordBlocked(chain, req, "contract")
+			metrics.ValidationRejections.WithLabelValues("contract_recipient").Inc()
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Recipient is a contract or module account",
+				"code":  "CONTRACT_RECIPIENT",
+			})
+			return
+		}
+	}
+
+	// Check recipient balance cap
+	if balanceCap := chain.cfg.RecipientBalanceCap(chain.cfg.Denom); !balanceCap.IsZero() {
+		balance, err := chain.faucet.GetExactAddressBalance(req.Address)
+		if err != nil && h.cfg.BalanceCheckFailsOpen() {
+			log.WithError(err).WithField("address", req.Address).Warn("Recipient balance check failed; allowing request (fail-open policy)")
+			metrics.BalanceCheckFailOpen.WithLabelValues("balance").Inc()
+		} else if err != nil {
+			log.WithError(err).Error("Failed to check recipient balance")
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Unable to verify recipient balance at this time",
+			})
+			return
+		} else if balance.Cmp(balanceCap) >= 0 {
+			h.recordBlocked(chain, req, "balance_cap")
+			metrics.ValidationRejections.WithLabelValues("balance_too_high").Inc()
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error": "Address balance is above faucet eligibility threshold",
+			})
+			return
+		}
+	}
+
+	// A top-up only sends the difference between the recipient's balance
+	// and the drip amount
+	amount := chain.cfg.RequestAmount()
+	if topUp {
+		balance, err := chain.faucet.GetExactAddressBalance(req.Address)
+		if err != nil {
+			log.WithError(err).Error("Failed to check recipient balance for top-up")
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Unable to verify recipient balance at this time",
+			})
+			return
+		}
+		amount = amount.Sub(balance)
+		if amount.Sign() <= 0 {
+			metrics.RateLimitHits.WithLabelValues("daily").Inc()
+			h.recordBlocked(chain, req, "topup_not_needed")
+			metrics.ValidationRejections.WithLabelValues("topup_not_needed").Inc()
+			metrics.RecordRequest("rate_limited", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error": "This address already holds the full drip amount.",
+				"code":  "TOPUP_NOT_NEEDED",
+			})
+			return
+		}
+		log.WithFields(log.Fields{
+			"address": req.Address,
+			"balance": balance,
+			"amount":  amount,
+		}).Info("Topping up recipient within grace window")
+	}
+
+	// An address's very first request carries the one-time bonus
+	bonus := h.firstRequestBonus(chain, req.Address)
+	amount = amount.Add(bonus)
+
+	// Nothing else can reject the request now, so spend the email token
+	if h.cfg.RequireEmailOTP {
+		if _, err := h.emailOTP.Redeem(req.EmailToken); err != nil {
+			if !errors.Is(err, emailotp.ErrInvalidToken) {
+				log.WithError(err).Error("Failed to redeem email token")
+				metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+				c.JSON(http.StatusServiceUnavailable, gin.H{
+					"error": "Unable to verify email token at this time",
+				})
+				return
+			}
+			h.recordBlocked(chain, req, "email_token")
+			metrics.ValidationRejections.WithLabelValues("email_token_invalid").Inc()
+			metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Email token is unknown, expired or already used",
+				"code":  "EMAIL_TOKEN_INVALID",
+			})
+			return
+		}
+	}
+
+	// Send tokens
+	sendReq := &faucet.SendRequest{
+		Recipient: req.Address,
+		Amount:    amount,
+		IPAddress: clientIP,
+		Source:    req.Source,
+		Bonus:     bonus,
+		Memo:      req.Memo,
+	}
+
+	resp, err := chain.faucet.SendTokens(sendReq)
+	if h.detector != nil && h.cfg.AbuseEnforcement {
+		h.detector.RecordAttempt(clientIP, req.Address, err == nil)
+	}
+	if err != nil {
+		h.publishEvent(chain, req, events.Event{Type: events.PayoutFailed, Amount: amount, Reason: sendFailureReason(err)})
+	}
+	if h.tracker != nil {
+		outcome := tracking.RequestMetrics{
+			IP:           clientIP,
+			Address:      req.Address,
+			Amount:       amount.Saturated(),
+			Success:      err == nil,
+			ResponseTime: time.Since(start),
+			Timestamp:    time.Now(),
+		}
+		if err != nil {
+			outcome.ErrorType = sendFailureReason(err)
+		}
+		h.tracker.RecordRequest(outcome)
+	}
+	if errors.Is(err, faucet.ErrBroadcastTimeout) {
+		log.WithError(err).Error("Token broadcast timed out")
+		metrics.RecordRequest("timeout", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error": "Transaction broadcast timed out. It may still be processed; check your balance before retrying.",
+			"code":  "BROADCAST_TIMEOUT",
+		})
+		return
+	}
+	if errors.Is(err, faucet.ErrSupplyGuard) {
+		log.WithError(err).Warn("Payouts paused by supply guard")
+		metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Payouts are temporarily paused. Please try again later.",
+			"code":  "SUPPLY_GUARD",
+		})
+		return
+	}
+	if errors.Is(err, faucet.ErrInsufficientFeeBalance) {
+		log.WithError(err).Error("Faucet cannot cover transaction fees")
+		metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Faucet is temporarily unable to pay transaction fees. Please try again later.",
+			"code":  "FEE_BALANCE_LOW",
+		})
+		return
+	}
+	if errors.Is(err, faucet.ErrBalanceReserve) {
+		log.WithError(err).Warn("Payout refused to protect the balance reserve")
+		metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "The faucet is low on funds. Please try again later.",
+			"code":  "BALANCE_RESERVE",
+		})
+		return
+	}
+	var simErr *faucet.SimulationError
+	if errors.As(err, &simErr) {
+		metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		c.JSON(simulationStatus(simErr.Category), gin.H{
+			"error":  simulationMessage(simErr.Category),
+			"code":   "SIMULATION_FAILED",
+			"reason": simErr.Category,
+		})
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("Failed to send tokens")
+		metrics.RecordRequest("failed", chain.cfg.Denom, 0, time.Since(start).Seconds())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to send tokens. Please try again later.",
+		})
+		return
+	}
+
+	// Update rate limiters; reserved slots were counted when checked
+	served = true
+	if !ipReserved {
+		if err := h.rateLimiter.IncrementIPCounter(ctx, clientIP); err != nil {
+			log.WithError(err).Error("Failed to increment IP counter")
+		}
+	}
+
+	if !addressReserved {
+		if err := h.rateLimiter.IncrementAddressCounter(ctx, req.Address); err != nil {
+			log.WithError(err).Error("Failed to increment address counter")
+		}
+	}
+
+	// Record successful request
+	metrics.RecordRequest("success", chain.cfg.Denom, amount.Float64(), time.Since(start).Seconds())
+	h.publishEvent(chain, req, events.Event{Type: events.PayoutSucceeded, Amount: amount, TxHash: resp.TxHash})
+	if !topUp {
+		metrics.UniqueAddresses.Inc()
+	}
+
+	body := gin.H{
+		"tx_hash":   resp.TxHash,
+		"recipient": resp.Recipient,
+		"amount":    resp.Amount,
+		"message":   "Tokens sent successfully",
+	}
+	if topUp {
+		body["top_up"] = true
+	}
+	if resp.Bonus.Sign() > 0 {
+		body["bonus"] = resp.Bonus
+	}
+	if resp.EstimatedConfirmation > 0 {
+		body["estimated_confirmation_seconds"] = resp.EstimatedConfirmation.Seconds()
+	}
+	// The id lets users reference the request in support tickets and look
+	// it up at the status endpoint
+	if h.cfg.ExposeRequestID && resp.RequestID > 0 {
+		body["request_id"] = resp.RequestID
+		body["created_at"] = resp.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	// The address itself is done for the window, so the requests left are
+	// the client's per-IP quota
+	if reporter, ok := h.rateLimiter.(ipQuotaReporter); ok {
+		if remaining, err := reporter.RemainingIPRequests(ctx, clientIP); err != nil {
+			log.WithError(err).Warn("Failed to read remaining IP quota")
+		} else {
+			body["remaining_user_quota"] = remaining
+		}
+	}
+	if h.receipts != nil {
+		// Sign from the stored row so the receipt matches the one served
+		// by the status endpoint later
+		if row, err := h.db.GetRequestByTxHash(resp.TxHash); err != nil {
+			log.WithError(err).Warn("Failed to load payout for receipt")
+		} else if signed := h.issueReceipt(row); signed != nil {
+			body["receipt"] = signed
+		}
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// recipientCapReached reports whether address is a new recipient and
+// MAX_UNIQUE_RECIPIENTS addresses have already been paid. Concurrent first
+// requests may each take the last slot, so the cap can be exceeded by a few.
+func (h *Handler) recipientCapReached(address string) (bool, error) {
+	served, err := h.db.CountUniqueRecipients()
+	if err != nil || served < h.cfg.MaxUniqueRecipients {
+		return false, err
+	}
+	paid, err := h.db.HasSuccessfulPayoutSince(address, time.Time{})
+	if err != nil {
+		return false, err
+	}
+	return !paid, nil
+}
+
+// fundedOtherAddress reports whether ip has funded an address other than
+// address, consulting the in-memory abuse tracker before the full DB history
+func (h *Handler) fundedOtherAddress(ip, address string) (bool, error) {
+	if h.detector != nil && h.detector.FundedOtherAddress(ip, address) {
+		return true, nil
+	}
+
+	return h.db.HasFundedOtherAddress(ip, address)
+}
+
+// withinTopUpGrace reports whether an address with the given recent
+// requests may be topped up: all of them fall inside the grace window and
+// at most the original payout has succeeded, so each address gets one top-up
+func (h *Handler) withinTopUpGrace(requests []*database.FaucetRequest) bool {
+	if h.cfg.TopUpGraceWindow <= 0 || len(requests) == 0 {
+		return false
+	}
+
+	graceStart := time.Now().Add(-h.cfg.TopUpGraceWindow)
+	paid := 0
+	for _, r := range requests {
+		if r.CreatedAt.Before(graceStart) {
+			return false
+		}
+		if r.Status == "success" {
+			paid++
+		}
+	}
+	return paid < 2
+}
+
+// clientSubnet returns the CIDR of the /v4Prefix or /v6Prefix network
+// containing ip; false when ip does not parse
+func clientSubnet(ip string, v4Prefix, v6Prefix int) (string, bool) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return "", false
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(v4Prefix, 32)), Mask: net.CIDRMask(v4Prefix, 32)}).String(), true
+	}
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(v6Prefix, 128)), Mask: net.CIDRMask(v6Prefix, 128)}).String(), true
+}
+
+// simulationStatus maps a simulation failure category to an HTTP status:
+// the recipient's fault is a client error, the faucet's an outage
+func simulationStatus(category string) int {
+	switch category {
+	case faucet.SimInvalidRecipient:
+		return http.StatusBadRequest
+	case faucet.SimRecipientBlocked:
+		return http.StatusForbidden
+	default:
+		return http.StatusServiceUnavailable
+	}
+}
+
+// simulationMessage explains a simulation failure category to users
+func simulationMessage(category string) string {
+	switch category {
+	case faucet.SimInvalidRecipient:
+		return "The chain rejected the recipient address."
+	case faucet.SimRecipientBlocked:
+		return "The recipient address cannot receive funds on this chain."
+	case faucet.SimInsufficientFunds:
+		return "Faucet has insufficient funds. Please try again later."
+	default:
+		return "Faucet is temporarily unable to send tokens. Please try again later."
+	}
+}
+
+// firstRequestBonus returns the bonus owed to an address that has never
+// been paid on any chain. The bonus is paid in the default chain's denom
+// only, and SendTokens claims it atomically.
+func (h *Handler) firstRequestBonus(chain *chainRoute, address string) units.Amount {
+	if h.cfg.FirstRequestBonus.Sign() <= 0 || chain.cfg != h.cfg {
+		return units.Amount{}
+	}
+
+	claimed, err := h.db.HasClaimedBonus(address)
+	if err != nil {
+		log.WithError(err).Error("Failed to check address history for first-request bonus")
+		return units.Amount{}
+	}
+	if claimed {
+		return units.Amount{}
+	}
+	return h.cfg.FirstRequestBonus
+}
+
+// detectionDetails renders an abuse detection result for debug responses
+func detectionDetails(result *abuse.DetectionResult) gin.H {
+	details := gin.H{
+		"risk_score":                result.RiskScore,
+		"reason":                    result.Reason,
+		"action":                    result.Action,
+		"recommended_delay_seconds": int(result.RecommendedDelay.Seconds()),
+	}
+	if result.BlockedUntil != nil {
+		details["blocked_until"] = result.BlockedUntil.UTC().Format(time.RFC3339)
+	}
+	return details
+}
+
+// chainInfo lists every chain the faucet funds, default chain first
+func (h *Handler) chainInfo(defaultBalance int64) []gin.H {
+	describe := func(cfg *config.Config, balance int64) gin.H {
+		return gin.H{
+			"chain_id":              cfg.ChainID,
+			"denom":                 cfg.Denom,
+			"address_prefix":        cfg.AddressPrefix,
+			"amount_per_request":    cfg.RequestAmount(),
+			"amount_display":        cfg.DisplayAmount(cfg.RequestAmount()),
+			"denom_exponent":        cfg.DenomExponent,
+			"balance":               balance,
+			"max_recipient_balance": cfg.RecipientBalanceCap(cfg.Denom),
+		}
+	}
+
+	chains := []gin.H{describe(h.cfg, defaultBalance)}
+	for _, id := range h.chainOrder {
+		route := h.chains[id]
+		balance, err := route.faucet.GetBalance()
+		if err != nil {
+			log.WithError(err).WithField("chain_id", id).Error("Failed to get faucet balance")
+		}
+		chains = append(chains, describe(route.cfg, balance))
+	}
+	return chains
+}
+
+// faucetBalance returns the faucet balance and when it was observed. Cached
+// values within the freshness window are used as-is; otherwise the node is
+// queried and the cache refreshed. A zero time means the balance is unknown.
+func (h *Handler) faucetBalance() (int64, time.Time) {
+	if h.balances != nil {
+		if balance, asOf, ok := h.balances.Get(h.cfg.Denom); ok {
+			return balance, asOf
+		}
+	}
+
+	balance, err := h.faucet.GetBalance()
+	if err != nil {
+		log.WithError(err).Error("Failed to get faucet balance")
+		return 0, time.Time{} // Continue with 0 balance
+	}
+	if h.balances != nil {
+		h.balances.Set(h.cfg.Denom, balance)
+	}
+	return balance, time.Now()
+}
+
+// GetFaucetConfig reports the chains served and which challenges a token
+// request must satisfy
+func (h *Handler) GetFaucetConfig(c *gin.Context) {
+	config := gin.H{
+		"chain_id":         h.cfg.ChainID,
+		"chain_ids":        h.chainIDs(),
+		"require_chain_id": h.cfg.RequireChainID,
+		"require_captcha":  h.cfg.RequireCaptcha,
+		"require_pow":      h.cfg.RequirePoW,
+		"base_path":        h.cfg.BasePath,
+	}
+	if h.cfg.RequirePoW {
+		config["pow_difficulty"] = h.cfg.PoWDifficulty
+	}
+	if h.cfg.RequireRequestNonce {
+		config["require_request_nonce"] = true
+	}
+	if h.cfg.RequireEmailOTP {
+		config["require_email_otp"] = true
+	}
+	if len(h.cfg.Campaigns) > 0 {
+		config["campaign"] = h.campaignInfo(time.Now())
+	}
+
+	c.JSON(http.StatusOK, config)
+}
+
+// campaignInfo describes the open campaign and when it closes, or else the
+// next campaign and when it opens
+func (h *Handler) campaignInfo(now time.Time) gin.H {
+	open, current, next := h.cfg.CampaignStatus(now)
+	info := gin.H{"open": open}
+	switch {
+	case current != nil:
+		info["name"] = current.Name
+		info["closes_at"] = current.End.UTC()
+		info["closes_in_seconds"] = int64(current.End.Sub(now).Seconds())
+	case next != nil:
+		info["name"] = next.Name
+		info["opens_at"] = next.Start.UTC()
+		info["opens_in_seconds"] = int64(next.Start.Sub(now).Seconds())
+	}
+	return info
+}
+
+// GetRequestNonce issues a one-time nonce for the caller's next token request
+func (h *Handler) GetRequestNonce(c *gin.Context) {
+	if h.nonces == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Request nonces not configured",
+		})
+		return
+	}
+
+	nonce, err := h.nonces.Issue(c.ClientIP())
+	if errors.Is(err, challengestore.ErrTooManyOutstanding) {
+		metrics.RateLimitHits.WithLabelValues("challenges").Inc()
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "Too many unused nonces. Use or let one expire before requesting another.",
+			"code":  "TOO_MANY_NONCES",
+		})
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("Failed to issue request nonce")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to issue nonce",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, nonce)
+}
+
+// GetPoWChallenge issues a proof-of-work challenge
+func (h *Handler) GetPoWChallenge(c *gin.Context) {
+	if h.pow == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Proof of work not configured",
+		})
+		return
+	}
+
+	challenge, err := h.pow.GenerateChallengeFor(c.ClientIP())
+	if errors.Is(err, challengestore.ErrTooManyOutstanding) {
+		metrics.RateLimitHits.WithLabelValues("challenges").Inc()
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "Too many unsolved challenges. Solve or let one expire before requesting another.",
+			"code":  "TOO_MANY_CHALLENGES",
+		})
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("Failed to generate proof-of-work challenge")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate challenge",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"challenge_id": challenge.ID,
+		"nonce":        challenge.Nonce,
+		"difficulty":   challenge.Difficulty,
+		"not_before":   challenge.NotBefore,
+		"expires_at":   challenge.ExpiresAt,
+	})
+}
+
+// GetCaptcha issues an image captcha whose difficulty scales with the caller's risk
+func (h *Handler) GetCaptcha(c *gin.Context) {
+	if h.captcha == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Captcha not configured",
+		})
+		return
+	}
+
+	riskScore := 0
+	if h.detector != nil {
+		riskScore = h.detector.AssessRisk(c.ClientIP(), c.Query("address"))
+	}
+
+	challenge, err := h.captcha.GenerateForClient(c.ClientIP(), riskScore)
+	if errors.Is(err, challengestore.ErrTooManyOutstanding) {
+		metrics.RateLimitHits.WithLabelValues("challenges").Inc()
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "Too many unsolved captchas. Solve or let one expire before requesting another.",
+			"code":  "TOO_MANY_CHALLENGES",
+		})
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("Failed to generate captcha")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate captcha",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"captcha_id": challenge.ID,
+		"image":      "data:image/png;base64," + base64.StdEncoding.EncodeToString(challenge.ImageData),
+		"difficulty": challenge.Difficulty,
+		"expires_at": challenge.ExpiresAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// CheckEligibility reports whether an address could currently receive tokens.
+// It runs the same checks as RequestTokens read-only: no counters are
+// incremented and nothing is sent.
+func (h *Handler) CheckEligibility(c *gin.Context) {
+	ctx := context.Background()
+	address := c.Query("address")
+	if address == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "address query parameter is required",
+		})
+		return
+	}
+
+	reasons := []string{}
+	if denom := c.Query("denom"); denom != "" && denom != h.cfg.Denom {
+		reasons = append(reasons, "unsupported_denom")
+	}
+
+	eligibility, retryAfter := h.checkEligibility(ctx, address, c.ClientIP())
+	reasons = append(reasons, eligibility...)
+
+	body := gin.H{
+		"address":             address,
+		"eligible":            len(reasons) == 0,
+		"reasons":             reasons,
+		"retry_after_seconds": int64(retryAfter.Round(time.Second) / time.Second),
+	}
+	// Report whether the address is new to the chain when the faucet can tell
+	if lookup, ok := h.faucet.(balanceLookup); ok && h.faucet.ValidateAddress(address) == nil {
+		if balance, err := lookup.LookupAddressBalance(address); err == nil {
+			body["new_account"] = balance.New
+		}
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// balanceLookup is implemented by faucet services that can tell a new
+// address from one holding no tokens of the faucet denom
+type balanceLookup interface {
+	LookupAddressBalance(address string) (*faucet.AddressBalance, error)
+}
+
+// limitReserver is implemented by rate limiters that can check a limit and
+// count the request in one step, and give the slot back
+type limitReserver interface {
+	ReserveIP(ctx context.Context, ip string) (bool, error)
+	ReserveAddress(ctx context.Context, address string) (bool, error)
+	ReleaseIP(ctx context.Context, ip string) error
+	ReleaseAddress(ctx context.Context, address string) error
+}
+
+// ipQuotaReporter is implemented by rate limiters that can tell how many
+// more requests an IP may make in the current window
+type ipQuotaReporter interface {
+	RemainingIPRequests(ctx context.Context, ip string) (int, error)
+}
+
+// contractDetector is implemented by faucet services that can tell
+// contract and module accounts from ordinary ones
+type contractDetector interface {
+	IsContractAccount(address string) (bool, error)
+}
+
+// checkEligibility runs the read-only request checks for address and
+// returns the reasons it would be rejected and how long until it may retry.
+// A malformed address yields only "invalid_address".
+func (h *Handler) checkEligibility(ctx context.Context, address, clientIP string) ([]string, time.Duration) {
+	if err := h.faucet.ValidateAddress(address); err != nil {
+		// Nothing else is meaningful for a malformed address
+		return []string{"invalid_address"}, 0
+	}
+
+	reasons := []string{}
+	var retryAfter time.Duration
+
+	if h.addressBlocked(address) {
+		reasons = append(reasons, "address_blocked")
+	}
+	if !h.addressAllowed(address) {
+		reasons = append(reasons, "address_not_allowed")
+	}
+	if !ipAllowed(clientIP, h.allowedIPs()) {
+		reasons = append(reasons, "ip_not_allowed")
+	}
+
+	// Cooldown from the rate limiter and request history
+	coolingDown := false
+	if h.rateLimiter != nil {
+		limited, err := h.rateLimiter.CheckAddressLimit(ctx, address)
+		if err != nil {
+			log.WithError(err).Error("Failed to check address rate limit")
+		} else if limited {
+			coolingDown = true
+			if ttl, err := h.rateLimiter.GetRemainingTime(ctx, "ratelimit:address:"+address); err == nil && ttl > retryAfter {
+				retryAfter = ttl
+			}
+		}
+	}
+
+	if h.db != nil {
+		window := h.cooldownWindow()
+		dbRequests, err := h.db.GetRequestsByAddress(address, time.Now().Add(-window))
+		if err != nil {
+			log.WithError(err).Error("Failed to check address history")
+		} else if len(dbRequests) > 0 {
+			coolingDown = true
+			// Requests are ordered newest first
+			if wait := time.Until(dbRequests[0].CreatedAt.Add(window)); wait > retryAfter {
+				retryAfter = wait
+			}
+		}
+	}
+	if coolingDown {
+		reasons = append(reasons, "cooldown")
+		retryAfter = ratelimit.Jitter(retryAfter, h.cfg.RateLimitJitter())
+	}
+
+	// Recipient balance cap
+	if balanceCap := h.cfg.RecipientBalanceCap(h.cfg.Denom); !balanceCap.IsZero() {
+		balance, err := h.faucet.GetExactAddressBalance(address)
+		if err != nil {
+			log.WithError(err).Error("Failed to check recipient balance")
+			if !h.cfg.BalanceCheckFailsOpen() {
+				reasons = append(reasons, "balance_check_unavailable")
+			}
+		} else if balance.Cmp(balanceCap) >= 0 {
+			reasons = append(reasons, "balance_above_cap")
+		}
+	}
+
+	return reasons, retryAfter
+}
+
+// GetStatistics returns detailed statistics, optionally for a single
+// ?source=. Per-source totals are included when sources are configured.
+// With PUBLIC_STATS=false it requires the admin token.
+func (h *Handler) GetStatistics(c *gin.Context) {
+	if !h.statsAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+			"code":  "UNAUTHORIZED",
+		})
+		return
+	}
+
+	source := c.Query("source")
+	if source != "" {
+		if !h.sourceKnown(source) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Unknown source %q", source),
+				"code":  "UNKNOWN_SOURCE",
+			})
+			return
+		}
+		stats, err := h.db.GetStatisticsBySource(source)
+		if err != nil {
+			log.WithError(err).Error("Failed to get statistics")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to get statistics",
+			})
+			return
+		}
+		c.JSON(http.StatusOK, stats)
+		return
+	}
+
+	stats, asOf, err := h.statistics()
 	if err != nil {
 		log.WithError(err).Error("Failed to get statistics")
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -462,7 +1869,110 @@ func (h *Handler) GetStatistics(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, stats)
+	resp := struct {
+		*database.Statistics
+		AsOf    time.Time                            `json:"as_of"`
+		Sources []*database.SourceTotal              `json:"sources,omitempty"`
+		Denoms  map[string]*database.DenomStatistics `json:"denoms,omitempty"`
+	}{Statistics: stats, AsOf: asOf.UTC()}
+
+	if len(h.cfg.RequestSources) > 0 {
+		resp.Sources, err = h.db.GetSourceTotals()
+		if err != nil {
+			log.WithError(err).Error("Failed to get source totals")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to get statistics",
+			})
+			return
+		}
+	}
+
+	if h.multiDenom() {
+		resp.Denoms, err = h.statisticsByDenom()
+		if err != nil {
+			log.WithError(err).Error("Failed to get statistics by denom")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to get statistics",
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// multiDenom reports whether the configured chains pay out more than one denom
+func (h *Handler) multiDenom() bool {
+	for _, route := range h.chains {
+		if route.cfg.Denom != h.cfg.Denom {
+			return true
+		}
+	}
+	return false
+}
+
+// statisticsByDenom returns per-denom statistics. Requests recorded before
+// the denom column existed were all paid in the default denom.
+func (h *Handler) statisticsByDenom() (map[string]*database.DenomStatistics, error) {
+	stats, err := h.db.GetStatisticsByDenom()
+	if err != nil {
+		return nil, err
+	}
+
+	if legacy, ok := stats[""]; ok {
+		delete(stats, "")
+		if current, ok := stats[h.cfg.Denom]; ok {
+			current.TotalRequests += legacy.TotalRequests
+			current.SuccessfulRequests += legacy.SuccessfulRequests
+			current.TotalDistributed += legacy.TotalDistributed
+		} else {
+			legacy.Denom = h.cfg.Denom
+			stats[h.cfg.Denom] = legacy
+		}
+	}
+	return stats, nil
+}
+
+// isFaucetAddress reports whether address is the faucet wallet of any
+// configured chain. Bech32 is case-insensitive, so comparison ignores case.
+func (h *Handler) isFaucetAddress(address string) bool {
+	address = normalizeAddress(address)
+	if h.cfg.FaucetAddress != "" && address == normalizeAddress(h.cfg.FaucetAddress) {
+		return true
+	}
+	for _, route := range h.chains {
+		if route.cfg.FaucetAddress != "" && address == normalizeAddress(route.cfg.FaucetAddress) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeAddress canonicalizes a bech32 address for comparison
+func normalizeAddress(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
+
+// sourceKnown reports whether source is one of the configured request sources
+func (h *Handler) sourceKnown(source string) bool {
+	for _, known := range h.cfg.RequestSources {
+		if source == known {
+			return true
+		}
+	}
+	return false
+}
+
+// testBypass reports whether the request carries the configured test
+// bypass token. The token is never honored in production, even if
+// configuration validation was skipped.
+func (h *Handler) testBypass(c *gin.Context) bool {
+	token := h.cfg.TestBypassToken
+	if token == "" || h.cfg.IsProduction() {
+		return false
+	}
+	provided := c.GetHeader("X-Test-Bypass-Token")
+	return provided != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
 }
 
 // verifyCaptcha verifies Turnstile token
@@ -472,9 +1982,7 @@ func (h *Handler) verifyCaptcha(token, remoteIP string) bool {
 		return true
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	resp, err := client.PostForm("https://challenges.cloudflare.com/turnstile/v0/siteverify", map[string][]string{
+	resp, err := h.captchaClient.PostForm(turnstileVerifyURL, map[string][]string{
 		"secret":   {h.cfg.TurnstileSecret},
 		"response": {token},
 		"remoteip": {remoteIP},
@@ -503,22 +2011,81 @@ func (h *Handler) verifyCaptcha(token, remoteIP string) bool {
 		return false
 	}
 
+	// Turnstile tokens are only valid for a few minutes after the
+	// challenge was solved
+	if issued, err := time.Parse(time.RFC3339, captchaResp.ChallengeTS); err == nil &&
+		!skew.Within(issued, time.Now(), turnstileTokenTTL, h.cfg.MaxClockSkew) {
+		log.WithField("challenge_ts", captchaResp.ChallengeTS).Warn("Captcha challenge timestamp outside the accepted window")
+		return false
+	}
+
 	return true
 }
 
-func addressAllowed(address string, allowlist []string) bool {
-	if len(allowlist) == 0 {
+// cooldownWindow returns the configured per-address cooldown, defaulting to 24h
+func (h *Handler) cooldownWindow() time.Duration {
+	if h.cfg.RateLimitWindow > 0 {
+		return h.cfg.RateLimitWindow
+	}
+	return 24 * time.Hour
+}
+
+// formatWindow renders a duration as "24 hours", "1 hour" or "30 minutes"
+func formatWindow(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		hours := int(d / time.Hour)
+		if hours == 1 {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", hours)
+	}
+
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	if minutes == 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
+
+// addressAllowed checks the address against the allowlist patterns; an
+// unconfigured allowlist allows everything
+func (h *Handler) addressAllowed(address string) bool {
+	entries, patterns := h.cfg.AllowedAddresses, h.addressPatterns
+	if list := h.synced.Load(); list != nil {
+		entries, patterns = list.addresses, list.patterns
+	}
+	if len(entries) == 0 {
 		return true
 	}
 
-	for _, allowed := range allowlist {
-		if address == allowed {
+	for _, pattern := range patterns {
+		if pattern.Match(address) {
+			return true
+		}
+	}
+	return false
+}
+
+// addressBlocked reports whether the address matches the blocklist
+func (h *Handler) addressBlocked(address string) bool {
+	for _, pattern := range h.blockedPatterns {
+		if pattern.Match(address) {
 			return true
 		}
 	}
 	return false
 }
 
+// memoAllowed reports whether the caller may override the transaction
+// memo: admins, and callers on a configured IP allowlist
+func (h *Handler) memoAllowed(c *gin.Context, clientIP string) bool {
+	if adminAuthorized(c, h.cfg.AdminToken) {
+		return true
+	}
+	allowed := h.allowedIPs()
+	return len(allowed) > 0 && ipAllowed(clientIP, allowed)
+}
+
 func ipAllowed(ip string, allowlist []string) bool {
 	if len(allowlist) == 0 {
 		return true
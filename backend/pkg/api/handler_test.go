@@ -3,41 +3,83 @@ package api
 import (
 	"bytes"
 	"context"
+	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	miniredis "github.com/alicebob/miniredis/v2"
 	"github.com/gin-gonic/gin"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/aura-chain/aura/faucet/pkg/abuse"
+	"github.com/aura-chain/aura/faucet/pkg/captcha"
+	challengestore "github.com/aura-chain/aura/faucet/pkg/challenge"
 	"github.com/aura-chain/aura/faucet/pkg/config"
 	"github.com/aura-chain/aura/faucet/pkg/database"
+	"github.com/aura-chain/aura/faucet/pkg/events"
 	"github.com/aura-chain/aura/faucet/pkg/faucet"
+	healthpkg "github.com/aura-chain/aura/faucet/pkg/health"
+	"github.com/aura-chain/aura/faucet/pkg/pow"
+	metrics "github.com/aura-chain/aura/faucet/pkg/prometheus"
+	"github.com/aura-chain/aura/faucet/pkg/ratelimit"
+	"github.com/aura-chain/aura/faucet/pkg/receipt"
+	"github.com/aura-chain/aura/faucet/pkg/units"
 )
 
 // --- test doubles ---
 type mockFaucet struct {
-	validateErr     error
+	validateErr    error
 	status         *faucet.NodeStatus
 	statusErr      error
 	balance        int64
 	balanceErr     error
 	addressBalance int64
+	exactBalance   units.Amount // overrides addressBalance for exact lookups
 	addressErr     error
 	sendResp       *faucet.SendResponse
 	sendErr        error
+	signerErr      error
+	replayResp     *faucet.SendResponse
+	replayErr      error
+	replayed       []int64
+	sent           []*faucet.SendRequest
 }
 
-func (m *mockFaucet) ValidateAddress(address string) error                     { return m.validateErr }
-func (m *mockFaucet) GetNodeStatus() (*faucet.NodeStatus, error)               { return m.status, m.statusErr }
-func (m *mockFaucet) GetBalance() (int64, error)                               { return m.balance, m.balanceErr }
-func (m *mockFaucet) GetAddressBalance(address string) (int64, error)         { return m.addressBalance, m.addressErr }
-func (m *mockFaucet) SendTokens(req *faucet.SendRequest) (*faucet.SendResponse, error) { return m.sendResp, m.sendErr }
+func (m *mockFaucet) ValidateAddress(address string) error { return m.validateErr }
+func (m *mockFaucet) GetNodeStatus(ctx context.Context) (*faucet.NodeStatus, error) {
+	return m.status, m.statusErr
+}
+func (m *mockFaucet) GetBalance() (int64, error) { return m.balance, m.balanceErr }
+func (m *mockFaucet) GetAddressBalance(address string) (int64, error) {
+	return m.addressBalance, m.addressErr
+}
+func (m *mockFaucet) GetExactAddressBalance(address string) (units.Amount, error) {
+	if m.exactBalance.Sign() > 0 {
+		return m.exactBalance, m.addressErr
+	}
+	return units.New(m.addressBalance), m.addressErr
+}
+func (m *mockFaucet) SendTokens(req *faucet.SendRequest) (*faucet.SendResponse, error) {
+	m.sent = append(m.sent, req)
+	return m.sendResp, m.sendErr
+}
+func (m *mockFaucet) CheckSigner() error { return m.signerErr }
+func (m *mockFaucet) ReplayRequest(requestID int64) (*faucet.SendResponse, error) {
+	m.replayed = append(m.replayed, requestID)
+	return m.replayResp, m.replayErr
+}
 
 type mockRateLimiter struct {
 	ipLimited        bool
@@ -46,13 +88,29 @@ type mockRateLimiter struct {
 	addrErr          error
 	incrementIPErr   error
 	incrementAddrErr error
+	remaining        time.Duration
+	countDelay       time.Duration
 }
 
-func (m *mockRateLimiter) CheckIPLimit(ctx context.Context, ip string) (bool, error)      { return m.ipLimited, m.ipErr }
-func (m *mockRateLimiter) CheckAddressLimit(ctx context.Context, address string) (bool, error) { return m.addressLimited, m.addrErr }
-func (m *mockRateLimiter) IncrementIPCounter(ctx context.Context, ip string) error        { return m.incrementIPErr }
-func (m *mockRateLimiter) IncrementAddressCounter(ctx context.Context, address string) error { return m.incrementAddrErr }
-func (m *mockRateLimiter) GetCurrentCount(ctx context.Context, key string) (int, error)   { return 0, nil }
+func (m *mockRateLimiter) CheckIPLimit(ctx context.Context, ip string) (bool, error) {
+	return m.ipLimited, m.ipErr
+}
+func (m *mockRateLimiter) CheckAddressLimit(ctx context.Context, address string) (bool, error) {
+	return m.addressLimited, m.addrErr
+}
+func (m *mockRateLimiter) IncrementIPCounter(ctx context.Context, ip string) error {
+	return m.incrementIPErr
+}
+func (m *mockRateLimiter) IncrementAddressCounter(ctx context.Context, address string) error {
+	return m.incrementAddrErr
+}
+func (m *mockRateLimiter) GetCurrentCount(ctx context.Context, key string) (int, error) {
+	time.Sleep(m.countDelay)
+	return 0, nil
+}
+func (m *mockRateLimiter) GetRemainingTime(ctx context.Context, key string) (time.Duration, error) {
+	return m.remaining, nil
+}
 
 // --- helpers ---
 func newTestHandler(cfg *config.Config, f FaucetService, rl RateLimiter) *Handler {
@@ -60,11 +118,12 @@ func newTestHandler(cfg *config.Config, f FaucetService, rl RateLimiter) *Handle
 }
 func defaultConfig() *config.Config {
 	return &config.Config{
-		Denom:              "uaura",
-		ChainID:            "aura-test",
-		AmountPerRequest:   100,
-		FaucetAddress:      "aura1faucet",
-		MaxRecipientBalance: 0,
+		Denom:               "uaura",
+		ChainID:             "aura-test",
+		AmountPerRequest:    100,
+		FaucetAddress:       "aura1faucet",
+		MaxRecipientBalance: units.New(0),
+		PublicStats:         true,
 	}
 }
 
@@ -74,6 +133,28 @@ func newHandlerWithDB(t *testing.T, f FaucetService, rl RateLimiter) (*Handler,
 	return NewHandler(defaultConfig(), f, rl, database.NewWithSQL(dbConn)), mock
 }
 
+// expectStatistics queues the queries issued by database.GetStatistics
+func expectStatistics(mock sqlmock.Sqlmock) {
+	for i := 0; i < 7; i++ {
+		mock.ExpectQuery(`SELECT`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(1)))
+	}
+}
+
+// sinceAround matches a time argument within a second of time.Now().Add(-window)
+type sinceAround struct {
+	window time.Duration
+}
+
+func (a sinceAround) Match(v driver.Value) bool {
+	ts, ok := v.(time.Time)
+	if !ok {
+		return false
+	}
+	expected := time.Now().Add(-a.window)
+	diff := ts.Sub(expected)
+	return diff > -time.Second && diff < time.Second
+}
+
 // --- tests ---
 func TestHealthStatuses(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -114,7 +195,7 @@ func TestHealthStatuses(t *testing.T) {
 		}{LatestBlockHeight: "20", CatchingUp: false}, NodeInfo: struct {
 			Network string "json:\"network\""
 			Version string "json:\"version\""
-		}{Network: "aura-test"}}}
+		}{Network: "aura-test", Version: "v0.50.3"}}}
 		h := newTestHandler(defaultConfig(), f, r)
 
 		w := httptest.NewRecorder()
@@ -122,6 +203,119 @@ func TestHealthStatuses(t *testing.T) {
 		h.Health(c)
 
 		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"node_version":"v0.50.3"`)
+	})
+
+	t.Run("degraded when signer unusable", func(t *testing.T) {
+		f := &mockFaucet{status: &faucet.NodeStatus{}, signerErr: errors.New("key not found")}
+		h := newTestHandler(defaultConfig(), f, &mockRateLimiter{})
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		h.Health(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "degraded", resp["status"])
+		assert.Equal(t, false, resp["checks"].(map[string]interface{})["signer_ready"])
+	})
+}
+
+func TestHealthRunsRegisteredChecks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	healthyNode := &faucet.NodeStatus{}
+
+	health := func(h *Handler) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		h.Health(c)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w.Code, resp
+	}
+
+	t.Run("registered warning check degrades", func(t *testing.T) {
+		h, mock := newHandlerWithDB(t, &mockFaucet{status: healthyNode}, &mockRateLimiter{})
+		expectStatistics(mock)
+		h.RegisterHealthCheck("webhook_outbox", healthpkg.Warning, func(ctx context.Context) error {
+			return errors.New("outbox backlog")
+		})
+
+		code, resp := health(h)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "degraded", resp["status"])
+		assert.Equal(t, false, resp["checks"].(map[string]interface{})["webhook_outbox"])
+		assert.Equal(t, true, resp["checks"].(map[string]interface{})["database_ready"])
+	})
+
+	t.Run("registered critical check makes unhealthy", func(t *testing.T) {
+		h, mock := newHandlerWithDB(t, &mockFaucet{status: healthyNode}, &mockRateLimiter{})
+		expectStatistics(mock)
+		h.RegisterHealthCheck("keyring", healthpkg.Critical, func(ctx context.Context) error {
+			return errors.New("locked")
+		})
+
+		code, resp := health(h)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "unhealthy", resp["status"])
+	})
+
+	t.Run("built-in checks can be replaced", func(t *testing.T) {
+		h := newTestHandler(defaultConfig(), &mockFaucet{status: healthyNode}, &mockRateLimiter{})
+		h.RegisterHealthCheck("database_ready", healthpkg.Warning, func(ctx context.Context) error { return nil })
+
+		_, resp := health(h)
+		assert.Equal(t, "healthy", resp["status"])
+		assert.Equal(t, true, resp["checks"].(map[string]interface{})["database_ready"])
+	})
+
+	t.Run("optional subsystems are only checked when enabled", func(t *testing.T) {
+		h := newTestHandler(defaultConfig(), &mockFaucet{status: healthyNode}, &mockRateLimiter{})
+		_, resp := health(h)
+		checks := resp["checks"].(map[string]interface{})
+		for _, name := range []string{"supply_ok", "height_advancing", "queue_ok", "captcha_ready"} {
+			assert.NotContains(t, checks, name)
+		}
+
+		cfg := defaultConfig()
+		cfg.MaxQueueDepth = 2
+		cfg.RequireCaptcha = true
+		f := &queueingFaucet{mockFaucet: mockFaucet{status: healthyNode}, status: faucet.QueueStatus{Depth: 2}}
+		h = newTestHandler(cfg, f, &mockRateLimiter{})
+		_, resp = health(h)
+		checks = resp["checks"].(map[string]interface{})
+		assert.Equal(t, false, checks["queue_ok"], "a full queue sheds requests")
+		assert.Equal(t, false, checks["captcha_ready"], "required captchas go unverified without a secret")
+
+		// With a secret, Turnstile itself is probed
+		verifyErrors := `["invalid-input-response"]`
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "secret", r.PostForm.Get("secret"))
+			fmt.Fprintf(w, `{"success":false,"error-codes":%s}`, verifyErrors)
+		}))
+		defer server.Close()
+		original := turnstileVerifyURL
+		turnstileVerifyURL = server.URL
+		defer func() { turnstileVerifyURL = original }()
+
+		cfg.TurnstileSecret = "secret"
+		f.status.Depth = 1
+		_, resp = health(h)
+		checks = resp["checks"].(map[string]interface{})
+		assert.Equal(t, true, checks["queue_ok"])
+		assert.Equal(t, true, checks["captcha_ready"], "a rejected placeholder token means the secret works")
+
+		verifyErrors = `["invalid-input-secret"]`
+		h = newTestHandler(cfg, f, &mockRateLimiter{})
+		_, resp = health(h)
+		assert.Equal(t, false, resp["checks"].(map[string]interface{})["captcha_ready"], "a refused secret fails the check")
+
+		server.Close()
+		h = newTestHandler(cfg, f, &mockRateLimiter{})
+		_, resp = health(h)
+		assert.Equal(t, false, resp["checks"].(map[string]interface{})["captcha_ready"], "an unreachable provider fails the check")
 	})
 }
 
@@ -130,12 +324,295 @@ func TestGetFaucetInfo(t *testing.T) {
 
 	// Missing DB should 503
 	f := &mockFaucet{balance: 50}
-		h := NewHandler(defaultConfig(), f, nil, nil)
+	h := NewHandler(defaultConfig(), f, nil, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	h.GetFaucetInfo(c)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestGetFaucetInfoUsesBalanceCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	getInfo := func(h *Handler, mock sqlmock.Sqlmock) map[string]interface{} {
+		expectStatistics(mock)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		h.GetFaucetInfo(c)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	dbConn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	f := &mockFaucet{balance: 50}
+	h := NewHandler(defaultConfig(), f, nil, database.NewWithConn(dbConn))
+
+	now := time.Now()
+	cache := NewBalanceCache(time.Minute)
+	cache.now = func() time.Time { return now }
+	cache.Set("uaura", 1000)
+	h.SetBalanceCache(cache)
+
+	// Within the freshness window the cached value wins over the node
+	resp := getInfo(h, mock)
+	assert.Equal(t, float64(1000), resp["balance"])
+	assert.Equal(t, now.UTC().Format(time.RFC3339Nano), resp["balance_as_of"])
+
+	// Once stale, info falls back to a live query and refreshes the cache
+	now = now.Add(2 * time.Minute)
+	resp = getInfo(h, mock)
+	assert.Equal(t, float64(50), resp["balance"])
+	cached, _, ok := cache.Get("uaura")
+	assert.True(t, ok)
+	assert.Equal(t, int64(50), cached)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFaucetInfoFaucetAddress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, expose := range []bool{true, false} {
+		cfg := defaultConfig()
+		cfg.GasPrice = "0.025ufee"
+		cfg.ExposeFaucetAddress = expose
+		dbConn, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		h := NewHandler(cfg, &mockFaucet{balance: 50}, nil, database.NewWithConn(dbConn))
+		expectStatistics(mock)
 
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
 		h.GetFaucetInfo(c)
-	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "ufee", resp["fee_denom"])
+		if expose {
+			assert.Equal(t, "aura1faucet", resp["faucet_address"])
+		} else {
+			assert.NotContains(t, resp, "faucet_address")
+		}
+		require.NoError(t, mock.ExpectationsWereMet())
+	}
+}
+
+func TestPrivateStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newHandler := func(public bool) (*Handler, sqlmock.Sqlmock) {
+		cfg := defaultConfig()
+		cfg.PublicStats = public
+		cfg.AdminToken = "admin-secret"
+		dbConn, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		return NewHandler(cfg, &mockFaucet{balance: 50}, nil, database.NewWithConn(dbConn)), mock
+	}
+	get := func(handle gin.HandlerFunc, path, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", path, nil)
+		if token != "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		handle(c)
+		return w
+	}
+	info := func(w *httptest.ResponseRecorder) map[string]interface{} {
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	t.Run("public stats are served to anyone", func(t *testing.T) {
+		h, mock := newHandler(true)
+		expectStatistics(mock)
+		resp := info(get(h.GetFaucetInfo, "/faucet/info", ""))
+		assert.Contains(t, resp, "total_distributed")
+
+		expectStatistics(mock)
+		assert.Equal(t, http.StatusOK, get(h.GetStatistics, "/faucet/stats", "").Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("private stats need the admin token", func(t *testing.T) {
+		h, mock := newHandler(false)
+
+		w := get(h.GetStatistics, "/faucet/stats", "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		w = get(h.GetStatistics, "/faucet/stats", "guess")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		w = get(h.GetRecentTransactions, "/faucet/recent", "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		// Info keeps the public fields but drops distribution totals
+		resp := info(get(h.GetFaucetInfo, "/faucet/info", ""))
+		assert.Equal(t, float64(50), resp["balance"])
+		assert.Equal(t, "aura-test", resp["chain_id"])
+		assert.NotContains(t, resp, "total_distributed")
+		assert.NotContains(t, resp, "unique_recipients")
+		assert.NotContains(t, resp, "requests_last_24h")
+		require.NoError(t, mock.ExpectationsWereMet(), "no statistics are queried")
+
+		expectStatistics(mock)
+		resp = info(get(h.GetFaucetInfo, "/faucet/info", "admin-secret"))
+		assert.Contains(t, resp, "total_distributed")
+
+		expectStatistics(mock)
+		assert.Equal(t, http.StatusOK, get(h.GetStatistics, "/faucet/stats", "admin-secret").Code)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`FROM faucet_requests`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}))
+		assert.Equal(t, http.StatusOK, get(h.GetRecentTransactions, "/faucet/recent", "admin-secret").Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCheckEligibility(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	historyQuery := regexp.QuoteMeta(`
+		SELECT id, recipient, amount, tx_hash, ip_address, status, created_at, completed_at
+		FROM faucet_requests
+		WHERE recipient = $1 AND created_at >= $2
+		ORDER BY created_at DESC
+	`)
+	historyCols := []string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}
+
+	run := func(h *Handler) map[string]interface{} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/?address=aura1ok", nil)
+		h.CheckEligibility(c)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	t.Run("eligible", func(t *testing.T) {
+		cfg := defaultConfig()
+		cfg.MaxRecipientBalance = units.New(10)
+		dbConn, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		h := NewHandler(cfg, &mockFaucet{addressBalance: 5}, &mockRateLimiter{}, database.NewWithConn(dbConn))
+		mock.ExpectQuery(historyQuery).WithArgs("aura1ok", sqlmock.AnyArg()).WillReturnRows(sqlmock.NewRows(historyCols))
+
+		resp := run(h)
+		assert.Equal(t, true, resp["eligible"])
+		assert.Empty(t, resp["reasons"])
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("over balance cap", func(t *testing.T) {
+		cfg := defaultConfig()
+		cfg.MaxRecipientBalance = units.New(10)
+		h := NewHandler(cfg, &mockFaucet{addressBalance: 50}, &mockRateLimiter{}, nil)
+
+		resp := run(h)
+		assert.Equal(t, false, resp["eligible"])
+		assert.Contains(t, resp["reasons"], "balance_above_cap")
+	})
+
+	t.Run("18-decimal balance under a whole-token cap", func(t *testing.T) {
+		cfg := defaultConfig()
+		cfg.DenomExponent = 18
+		cfg.MaxRecipientBalance = units.New(100)
+		cfg.MaxRecipientBalanceUnit = config.BalanceUnitWhole
+		fifty, err := units.ParseDisplay("50", 18)
+		require.NoError(t, err)
+		h := NewHandler(cfg, &mockFaucet{addressBalance: math.MaxInt64, exactBalance: fifty}, &mockRateLimiter{}, nil)
+
+		resp := run(h)
+		assert.NotContains(t, resp["reasons"], "balance_above_cap")
+	})
+
+	t.Run("cooling down", func(t *testing.T) {
+		cfg := defaultConfig()
+		cfg.RateLimitWindow = time.Hour
+		dbConn, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		h := NewHandler(cfg, &mockFaucet{}, &mockRateLimiter{addressLimited: true, remaining: 10 * time.Minute}, database.NewWithConn(dbConn))
+		mock.ExpectQuery(historyQuery).WithArgs("aura1ok", sqlmock.AnyArg()).WillReturnRows(
+			sqlmock.NewRows(historyCols).AddRow(int64(1), "aura1ok", int64(100), "tx", "127.0.0.1", "success", time.Now().Add(-30*time.Minute), time.Now()))
+
+		resp := run(h)
+		assert.Equal(t, false, resp["eligible"])
+		assert.Equal(t, []interface{}{"cooldown"}, resp["reasons"])
+		retry := resp["retry_after_seconds"].(float64)
+		assert.InDelta(t, 1800, retry, 5)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("reports a new account", func(t *testing.T) {
+		f := &lookupFaucet{balance: &faucet.AddressBalance{New: true}}
+		resp := run(NewHandler(defaultConfig(), f, &mockRateLimiter{}, nil))
+		assert.Equal(t, true, resp["new_account"])
+
+		f.balance = &faucet.AddressBalance{Amount: 5}
+		resp = run(NewHandler(defaultConfig(), f, &mockRateLimiter{}, nil))
+		assert.Equal(t, false, resp["new_account"])
+
+		resp = run(NewHandler(defaultConfig(), &mockFaucet{}, &mockRateLimiter{}, nil))
+		assert.NotContains(t, resp, "new_account", "omitted when the faucet cannot tell")
+	})
+}
+
+// lookupFaucet is a mockFaucet that can tell new addresses apart
+type lookupFaucet struct {
+	mockFaucet
+	balance *faucet.AddressBalance
+}
+
+func (f *lookupFaucet) LookupAddressBalance(address string) (*faucet.AddressBalance, error) {
+	return f.balance, nil
+}
+
+func TestAddressAllowlistPatterns(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.AllowedAddresses = []string{"aura1plain", "aura1pre*", "/^aura1re[0-9]+$/"}
+	h := newTestHandler(cfg, &mockFaucet{}, nil)
+
+	assert.True(t, h.addressAllowed("aura1plain"))
+	assert.True(t, h.addressAllowed("aura1prefixed"))
+	assert.True(t, h.addressAllowed("aura1re42"))
+	assert.False(t, h.addressAllowed("aura1other"))
+
+	open := newTestHandler(defaultConfig(), &mockFaucet{}, nil)
+	assert.True(t, open.addressAllowed("aura1anything"))
+}
+
+func TestGetCaptcha(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("unavailable when not configured", func(t *testing.T) {
+		h := newTestHandler(defaultConfig(), &mockFaucet{}, nil)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/", nil)
+		h.GetCaptcha(c)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	t.Run("returns captcha image", func(t *testing.T) {
+		h := newTestHandler(defaultConfig(), &mockFaucet{}, nil)
+		h.SetCaptchaService(captcha.NewCaptchaService(captcha.CaptchaOptions{}))
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/", nil)
+		h.GetCaptcha(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.NotEmpty(t, resp["captcha_id"])
+		assert.Equal(t, "easy", resp["difficulty"])
+	})
 }
 
 func TestRequestTokensValidationAndDependencies(t *testing.T) {
@@ -182,7 +659,7 @@ func TestRequestTokensValidationAndDependencies(t *testing.T) {
 
 	t.Run("enforces rate limits and balance cap", func(t *testing.T) {
 		cfg := defaultConfig()
-		cfg.MaxRecipientBalance = 10
+		cfg.MaxRecipientBalance = units.New(10)
 		f := &mockFaucet{addressBalance: 11}
 		rl := &mockRateLimiter{}
 		dbConn, mock, err := sqlmock.New()
@@ -213,7 +690,7 @@ func TestRequestTokensValidationAndDependencies(t *testing.T) {
 		cfg := defaultConfig()
 		cfg.RequireCaptcha = false
 		rl := &mockRateLimiter{}
-		f := &mockFaucet{sendResp: &faucet.SendResponse{TxHash: "tx1", Recipient: "a", Amount: 100}}
+		f := &mockFaucet{sendResp: &faucet.SendResponse{TxHash: "tx1", Recipient: "a", Amount: units.New(100)}}
 		dbConn, mock, err := sqlmock.New()
 		require.NoError(t, err)
 		h := NewHandler(cfg, f, rl, database.NewWithConn(dbConn))
@@ -242,4 +719,2810 @@ func TestRequestTokensValidationAndDependencies(t *testing.T) {
 		assert.Equal(t, "tx1", resp["tx_hash"])
 		require.NoError(t, mock.ExpectationsWereMet())
 	})
+	t.Run("broadcast timeout returns gateway timeout", func(t *testing.T) {
+		rl := &mockRateLimiter{}
+		f := &mockFaucet{sendErr: fmt.Errorf("failed to broadcast transaction: %w", faucet.ErrBroadcastTimeout)}
+		dbConn, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		h := NewHandler(defaultConfig(), f, rl, database.NewWithConn(dbConn))
+
+		payload := map[string]string{"address": "aura1ok", "captcha_token": "tok"}
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, recipient, amount, tx_hash, ip_address, status, created_at, completed_at
+		FROM faucet_requests
+		WHERE recipient = $1 AND created_at >= $2
+		ORDER BY created_at DESC
+	`)).WithArgs("aura1ok", sqlmock.AnyArg()).WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}))
+
+		h.RequestTokens(c)
+
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "BROADCAST_TIMEOUT", resp["code"])
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("failed simulation surfaces its category", func(t *testing.T) {
+		tests := map[string]int{
+			faucet.SimInvalidRecipient:  http.StatusBadRequest,
+			faucet.SimRecipientBlocked:  http.StatusForbidden,
+			faucet.SimInsufficientFunds: http.StatusServiceUnavailable,
+		}
+		for category, status := range tests {
+			f := &mockFaucet{sendErr: &faucet.SimulationError{Category: category, Log: "rejected"}}
+			h, mock := newHandlerWithDB(t, f, &mockRateLimiter{})
+			mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}))
+
+			body, _ := json.Marshal(map[string]string{"address": "aura1ok", "captcha_token": "tok"})
+			req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			h.RequestTokens(c)
+
+			assert.Equal(t, status, w.Code, category)
+			var resp map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, "SIMULATION_FAILED", resp["code"])
+			assert.Equal(t, category, resp["reason"])
+		}
+	})
+	t.Run("cooldown uses configured window", func(t *testing.T) {
+		cfg := defaultConfig()
+		cfg.RateLimitWindow = time.Hour
+		rl := &mockRateLimiter{}
+		dbConn, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		h := NewHandler(cfg, &mockFaucet{}, rl, database.NewWithConn(dbConn))
+
+		payload := map[string]string{"address": "aura1ok", "captcha_token": "tok"}
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, recipient, amount, tx_hash, ip_address, status, created_at, completed_at
+		FROM faucet_requests
+		WHERE recipient = $1 AND created_at >= $2
+		ORDER BY created_at DESC
+	`)).WithArgs("aura1ok", sinceAround{window: time.Hour}).WillReturnRows(
+			sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}).
+				AddRow(int64(1), "aura1ok", int64(100), "tx", "127.0.0.1", "success", time.Now(), time.Now()))
+
+		h.RequestTokens(c)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Contains(t, w.Body.String(), "last 1 hour")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("rejects while a request is pending", func(t *testing.T) {
+		cfg := defaultConfig()
+		cfg.RejectPendingRequests = true
+		f := &mockFaucet{}
+		dbConn, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		h := NewHandler(cfg, f, &mockRateLimiter{}, database.NewWithConn(dbConn))
+
+		payload := map[string]string{"address": "aura1ok", "captcha_token": "tok"}
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS`)).
+			WithArgs("aura1ok", sinceAround{window: pendingRequestMaxAge}).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		h.RequestTokens(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("abuse detector does not block unless enforcement is on", func(t *testing.T) {
+		h := newTestHandler(defaultConfig(), &mockFaucet{}, &mockRateLimiter{})
+		detector := abuse.NewAbuseDetector(abuse.DetectorConfig{})
+		detector.BlockIP("127.0.0.1", time.Hour)
+		h.SetAbuseDetector(detector)
+
+		payload := map[string]string{"address": "aura1ok", "captcha_token": "tok"}
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "127.0.0.1:1234"
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		h.RequestTokens(c)
+
+		assert.NotEqual(t, http.StatusForbidden, w.Code)
+		assert.NotContains(t, w.Body.String(), "abuse protection")
+	})
+	t.Run("abuse block hides detection details by default", func(t *testing.T) {
+		cfg := defaultConfig()
+		cfg.AbuseEnforcement = true
+		h := newTestHandler(cfg, &mockFaucet{}, &mockRateLimiter{})
+		detector := abuse.NewAbuseDetector(abuse.DetectorConfig{})
+		detector.BlockIP("127.0.0.1", time.Hour)
+		h.SetAbuseDetector(detector)
+
+		payload := map[string]string{"address": "aura1ok", "captcha_token": "tok"}
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "127.0.0.1:1234"
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		h.RequestTokens(c)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.NotContains(t, w.Body.String(), "detection")
+		assert.NotContains(t, w.Body.String(), "temporarily blocked")
+	})
+	t.Run("abuse block exposes detection details when enabled", func(t *testing.T) {
+		cfg := defaultConfig()
+		cfg.AbuseEnforcement = true
+		cfg.ExposeDetectionDetails = true
+		h := newTestHandler(cfg, &mockFaucet{}, &mockRateLimiter{})
+		detector := abuse.NewAbuseDetector(abuse.DetectorConfig{})
+		detector.BlockIP("127.0.0.1", time.Hour)
+		h.SetAbuseDetector(detector)
+
+		payload := map[string]string{"address": "aura1ok", "captcha_token": "tok"}
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "127.0.0.1:1234"
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		h.RequestTokens(c)
+
+		require.Equal(t, http.StatusForbidden, w.Code)
+		var resp struct {
+			Detection map[string]interface{} `json:"detection"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "IP address is temporarily blocked", resp.Detection["reason"])
+		assert.Contains(t, resp.Detection, "risk_score")
+		assert.Contains(t, resp.Detection, "recommended_delay_seconds")
+		assert.Contains(t, resp.Detection, "blocked_until")
+	})
+}
+
+func TestRequestTokensRequiresCaptchaAndPoW(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newHandler := func() (*Handler, *pow.ProofOfWork) {
+		cfg := defaultConfig()
+		cfg.RequireCaptcha = true
+		cfg.RequirePoW = true
+		cfg.PoWDifficulty = 1
+		h := newTestHandler(cfg, &mockFaucet{}, nil)
+		p := pow.NewProofOfWork(1)
+		h.SetProofOfWork(p)
+		return h, p
+	}
+
+	send := func(h *Handler, payload map[string]string) (int, map[string]string) {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+
+		var resp map[string]string
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	t.Run("captcha only is rejected for missing pow", func(t *testing.T) {
+		h, _ := newHandler()
+		code, resp := send(h, map[string]string{"address": "aura1ok", "captcha_token": "tok"})
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, "POW_REQUIRED", resp["code"])
+	})
+
+	t.Run("pow only is rejected for missing captcha", func(t *testing.T) {
+		h, p := newHandler()
+		challenge, err := p.GenerateChallenge()
+		require.NoError(t, err)
+		solution, err := pow.SolveChallenge(challenge.Nonce, challenge.Difficulty)
+		require.NoError(t, err)
+
+		code, resp := send(h, map[string]string{"address": "aura1ok", "pow_challenge_id": challenge.ID, "pow_solution": solution})
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, "CAPTCHA_REQUIRED", resp["code"])
+	})
+
+	t.Run("wrong pow solution is attributed to pow", func(t *testing.T) {
+		h, p := newHandler()
+		// At difficulty 1 a wrong solution still passes 1 time in 16
+		p.SetDifficulty(8)
+		challenge, err := p.GenerateChallenge()
+		require.NoError(t, err)
+
+		code, resp := send(h, map[string]string{"address": "aura1ok", "captcha_token": "tok", "pow_challenge_id": challenge.ID, "pow_solution": "nope"})
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, "POW_FAILED", resp["code"])
+	})
+
+	t.Run("both satisfied reaches dependency checks", func(t *testing.T) {
+		h, p := newHandler()
+		challenge, err := p.GenerateChallenge()
+		require.NoError(t, err)
+		solution, err := pow.SolveChallenge(challenge.Nonce, challenge.Difficulty)
+		require.NoError(t, err)
+
+		code, _ := send(h, map[string]string{"address": "aura1ok", "captcha_token": "tok", "pow_challenge_id": challenge.ID, "pow_solution": solution})
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+	})
+}
+
+func TestRequestTokensTestBypassToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	send := func(environment, header string) (int, map[string]string) {
+		cfg := defaultConfig()
+		cfg.Environment = environment
+		cfg.RequireCaptcha = true
+		cfg.RequirePoW = true
+		cfg.TestBypassToken = "e2e-secret"
+		h := newTestHandler(cfg, &mockFaucet{}, nil)
+		h.SetProofOfWork(pow.NewProofOfWork(1))
+
+		req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"address":"aura1ok"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if header != "" {
+			req.Header.Set("X-Test-Bypass-Token", header)
+		}
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+
+		var resp map[string]string
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	t.Run("skips captcha and pow in development", func(t *testing.T) {
+		// Reaching the dependency checks means both were skipped
+		code, _ := send("development", "e2e-secret")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+	})
+
+	t.Run("wrong token is ignored", func(t *testing.T) {
+		code, resp := send("development", "guess")
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, "CAPTCHA_REQUIRED", resp["code"])
+	})
+
+	t.Run("ignored in production", func(t *testing.T) {
+		code, resp := send("Production", "e2e-secret")
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, "CAPTCHA_REQUIRED", resp["code"])
+	})
+}
+
+func TestRequestTokensRequestNonce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := defaultConfig()
+	cfg.RequireRequestNonce = true
+	h := newTestHandler(cfg, &mockFaucet{}, nil)
+	nonces := challengestore.NewNonces(challengestore.NewMemoryStore[*challengestore.RequestNonce](0), time.Minute)
+	defer nonces.Close()
+	h.SetRequestNonces(nonces)
+
+	send := func(body string) (int, map[string]string) {
+		req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+
+		var resp map[string]string
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/nonce", nil)
+	h.GetRequestNonce(c)
+	require.Equal(t, http.StatusOK, w.Code)
+	var issued struct {
+		Nonce string `json:"nonce"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &issued))
+	require.NotEmpty(t, issued.Nonce)
+
+	code, resp := send(`{"address":"aura1ok"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "NONCE_REQUIRED", resp["code"])
+
+	// Reaching the dependency checks means the fresh nonce was accepted
+	request := `{"address":"aura1ok","nonce":"` + issued.Nonce + `"}`
+	code, _ = send(request)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	code, resp = send(request)
+	assert.Equal(t, http.StatusConflict, code, "a replayed request is refused")
+	assert.Equal(t, "NONCE_INVALID", resp["code"])
+}
+
+func TestRequestTokensChainID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	send := func(requireChainID bool, body string) (int, map[string]string) {
+		cfg := defaultConfig()
+		cfg.RequireChainID = requireChainID
+		h := newTestHandler(cfg, &mockFaucet{}, nil)
+
+		req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+
+		var resp map[string]string
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	// Reaching the dependency checks means the chain was accepted
+	code, _ := send(true, `{"address":"aura1ok","chain_id":"aura-test"}`)
+	assert.Equal(t, http.StatusServiceUnavailable, code, "matching chain_id")
+
+	code, resp := send(false, `{"address":"aura1ok","chain_id":"aura-mainnet-1"}`)
+	assert.Equal(t, http.StatusBadRequest, code, "mismatching chain_id")
+	assert.Equal(t, "UNKNOWN_CHAIN", resp["code"])
+
+	code, resp = send(true, `{"address":"aura1ok"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "CHAIN_ID_REQUIRED", resp["code"])
+	assert.Contains(t, resp["error"], "aura-test")
+
+	code, _ = send(false, `{"address":"aura1ok"}`)
+	assert.Equal(t, http.StatusServiceUnavailable, code, "a missing chain_id uses the default chain unless required")
+}
+
+func TestUniqueRecipientCap(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	historyCols := []string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}
+
+	request := func(served int64, paid *bool) (*httptest.ResponseRecorder, sqlmock.Sqlmock) {
+		f := &mockFaucet{sendResp: &faucet.SendResponse{TxHash: "TX", Recipient: "aura1ok", Amount: units.New(100)}}
+		h, mock := newHandlerWithDB(t, f, &mockRateLimiter{})
+		h.cfg.MaxUniqueRecipients = 5
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)).
+			WillReturnRows(sqlmock.NewRows(historyCols))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(DISTINCT recipient) FROM faucet_requests WHERE status = 'success'`)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(served))
+		if paid != nil {
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS`)).
+				WithArgs("aura1ok", time.Time{}).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(*paid))
+		}
+
+		body, _ := json.Marshal(map[string]string{"address": "aura1ok"})
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+		return w, mock
+	}
+
+	t.Run("new address is served below the cap", func(t *testing.T) {
+		w, mock := request(4, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("new address is rejected at the cap", func(t *testing.T) {
+		paid := false
+		w, mock := request(5, &paid)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "RECIPIENT_CAP_REACHED")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("address already paid is still served at the cap", func(t *testing.T) {
+		paid := true
+		w, mock := request(5, &paid)
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("info reports remaining slots", func(t *testing.T) {
+		h, mock := newHandlerWithDB(t, &mockFaucet{}, nil)
+		h.cfg.MaxUniqueRecipients = 5
+		h.cfg.PublicStats = false
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(DISTINCT recipient)`)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(3)))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/", nil)
+		h.GetFaucetInfo(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, float64(2), resp["unique_recipient_slots_remaining"])
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetFaucetConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := defaultConfig()
+	cfg.RequireCaptcha = true
+	cfg.RequirePoW = true
+	cfg.PoWDifficulty = 5
+	cfg.BasePath = "/faucet"
+	h := newTestHandler(cfg, &mockFaucet{}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/", nil)
+	h.GetFaucetConfig(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"chain_id":"aura-test","chain_ids":["aura-test"],"require_chain_id":false,"require_captcha":true,"require_pow":true,"base_path":"/faucet","pow_difficulty":5}`, w.Body.String())
+}
+
+func TestRequestTokensWithDBLimiter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dbConn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db := database.NewWithConn(dbConn)
+	cfg := defaultConfig()
+	limiter := ratelimit.NewDBLimiter(db, map[string]interface{}{
+		"per_ip":      2,
+		"per_address": 1,
+		"window":      time.Hour,
+	})
+	h := NewHandler(cfg, &mockFaucet{}, limiter, db)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT COUNT(*) FROM faucet_requests
+		WHERE ip_address = $1 AND created_at >= $2 AND status != 'failed'
+	`)).WithArgs("127.0.0.1", sinceAround{window: time.Hour}).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count, expires_at FROM rate_limit_counters`)).
+		WithArgs("ratelimit:ip:127.0.0.1").WillReturnRows(sqlmock.NewRows([]string{"count", "expires_at"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT COUNT(*) FROM faucet_requests
+		WHERE recipient = $1 AND created_at >= $2 AND status != 'failed'
+	`)).WithArgs("aura1ok", sinceAround{window: time.Hour}).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count, expires_at FROM rate_limit_counters`)).
+		WithArgs("ratelimit:address:aura1ok").WillReturnRows(sqlmock.NewRows([]string{"count", "expires_at"}))
+
+	payload := map[string]string{"address": "aura1ok", "captcha_token": "tok"}
+	body, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "127.0.0.1:1234"
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	h.RequestTokens(c)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), "already received tokens recently")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVerifyCaptchaUsesCaptchaProxy(t *testing.T) {
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost = r.URL.Host
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}))
+	defer proxy.Close()
+
+	original := turnstileVerifyURL
+	turnstileVerifyURL = "http://turnstile.invalid/siteverify"
+	defer func() { turnstileVerifyURL = original }()
+
+	cfg := defaultConfig()
+	cfg.TurnstileSecret = "secret"
+	cfg.CaptchaProxy = proxy.URL
+	h := newTestHandler(cfg, &mockFaucet{}, nil)
+
+	assert.True(t, h.verifyCaptcha("tok", "192.0.2.1"))
+	assert.Equal(t, "turnstile.invalid", proxiedHost)
+}
+
+func TestRequestTokensRoutesByChain(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newHandler := func(t *testing.T) (*Handler, sqlmock.Sqlmock) {
+		dbConn, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defaultFaucet := &mockFaucet{sendResp: &faucet.SendResponse{TxHash: "aura-tx", Amount: units.New(100)}}
+		h := NewHandler(defaultConfig(), defaultFaucet, &mockRateLimiter{}, database.NewWithConn(dbConn))
+
+		osmoCfg := defaultConfig().ForChain(config.ChainConfig{
+			ChainID:          "osmo-test-1",
+			Denom:            "uosmo",
+			AddressPrefix:    "osmo",
+			AmountPerRequest: units.New(7),
+		})
+		h.AddChain(osmoCfg, &mockFaucet{balance: 70, sendResp: &faucet.SendResponse{TxHash: "osmo-tx", Amount: units.New(7)}})
+		return h, mock
+	}
+
+	send := func(h *Handler, payload map[string]string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+		return w
+	}
+
+	t.Run("routes to the requested chain", func(t *testing.T) {
+		h, mock := newHandler(t)
+		mock.ExpectQuery(regexp.QuoteMeta(`FROM faucet_requests`)).WithArgs("osmo1recipient", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}))
+
+		w := send(h, map[string]string{"address": "osmo1recipient", "chain_id": "osmo-test-1", "captcha_token": "tok"})
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "osmo-tx", resp["tx_hash"])
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rejects address prefix for another chain", func(t *testing.T) {
+		h, _ := newHandler(t)
+		w := send(h, map[string]string{"address": "aura1recipient", "chain_id": "osmo-test-1", "captcha_token": "tok"})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "CHAIN_PREFIX_MISMATCH")
+	})
+
+	t.Run("rejects unknown chain", func(t *testing.T) {
+		h, _ := newHandler(t)
+		w := send(h, map[string]string{"address": "aura1recipient", "chain_id": "nope-1", "captcha_token": "tok"})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "UNKNOWN_CHAIN")
+	})
+
+	t.Run("info reports all chains", func(t *testing.T) {
+		h, mock := newHandler(t)
+		expectStatistics(mock)
+		mock.ExpectQuery(regexp.QuoteMeta(`GROUP BY denom`)).
+			WillReturnRows(sqlmock.NewRows([]string{"denom", "count", "successful", "sum"}).
+				AddRow("", int64(4), int64(4), int64(400)).
+				AddRow("uaura", int64(2), int64(1), int64(100)).
+				AddRow("uosmo", int64(3), int64(3), int64(21)))
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		h.GetFaucetInfo(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Chains      []map[string]interface{} `json:"chains"`
+			Balances    map[string]int64         `json:"balances"`
+			Distributed map[string]int64         `json:"distributed"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Chains, 2)
+		assert.Equal(t, "aura-test", resp.Chains[0]["chain_id"])
+		assert.Equal(t, "osmo-test-1", resp.Chains[1]["chain_id"])
+		assert.Equal(t, "uosmo", resp.Chains[1]["denom"])
+		assert.Equal(t, float64(70), resp.Chains[1]["balance"])
+		assert.Equal(t, map[string]int64{"uaura": 0, "uosmo": 70}, resp.Balances)
+		// Rows without a denom predate the column and count as the default denom
+		assert.Equal(t, map[string]int64{"uaura": 500, "uosmo": 21}, resp.Distributed)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("stats are keyed by denom", func(t *testing.T) {
+		h, mock := newHandler(t)
+		expectStatistics(mock)
+		mock.ExpectQuery(regexp.QuoteMeta(`GROUP BY denom`)).
+			WillReturnRows(sqlmock.NewRows([]string{"denom", "count", "successful", "sum"}).
+				AddRow("uosmo", int64(3), int64(3), int64(21)))
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/faucet/stats", nil)
+		h.GetStatistics(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"total_requests":1`)
+		assert.Contains(t, w.Body.String(), `"denoms":{"uosmo":{"denom":"uosmo","total_requests":3,"successful_requests":3,"total_distributed":21}}`)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRequestTokensStrictOneAddressPerIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	requestColumns := []string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}
+	byIPQuery := regexp.QuoteMeta(`WHERE ip_address = $1 AND recipient <> $2 AND status <> 'failed'`)
+	byAddressQuery := regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)
+
+	newHandler := func(t *testing.T) (*Handler, sqlmock.Sqlmock) {
+		cfg := defaultConfig()
+		cfg.StrictOneAddressPerIP = true
+		dbConn, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		return NewHandler(cfg, &mockFaucet{}, &mockRateLimiter{}, database.NewWithConn(dbConn)), mock
+	}
+
+	send := func(h *Handler, address string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"address": address, "captcha_token": "tok"})
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "192.0.2.1:1234"
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+		return w
+	}
+
+	t.Run("rejects a second address from the same IP", func(t *testing.T) {
+		h, mock := newHandler(t)
+		mock.ExpectQuery(byIPQuery).WithArgs("192.0.2.1", "aura1second").WillReturnRows(
+			sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		w := send(h, "aura1second")
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.NotContains(t, w.Body.String(), "aura1first")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("uses the abuse tracker before the database", func(t *testing.T) {
+		h, mock := newHandler(t)
+		detector := abuse.NewAbuseDetector(abuse.DetectorConfig{})
+		detector.RecordAttempt("192.0.2.1", "aura1first", true)
+		h.SetAbuseDetector(detector)
+
+		w := send(h, "aura1second")
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("repeat of the same address hits normal cooldown", func(t *testing.T) {
+		h, mock := newHandler(t)
+		mock.ExpectQuery(byIPQuery).WithArgs("192.0.2.1", "aura1first").WillReturnRows(
+			sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectQuery(byAddressQuery).WithArgs("aura1first", sqlmock.AnyArg()).WillReturnRows(
+			sqlmock.NewRows(requestColumns).AddRow(int64(1), "aura1first", int64(100), "tx", "192.0.2.1", "success", time.Now(), time.Now()))
+
+		w := send(h, "aura1first")
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRequestTokensBalanceCheckFailPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	run := func(t *testing.T, policy string) (*httptest.ResponseRecorder, sqlmock.Sqlmock) {
+		cfg := defaultConfig()
+		cfg.MaxRecipientBalance = units.New(10)
+		cfg.BalanceCheckFailPolicy = policy
+		f := &mockFaucet{addressErr: errors.New("node unavailable"), sendResp: &faucet.SendResponse{TxHash: "tx1", Amount: units.New(100)}}
+		dbConn, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		h := NewHandler(cfg, f, &mockRateLimiter{}, database.NewWithConn(dbConn))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)).WithArgs("aura1ok", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}))
+
+		body, _ := json.Marshal(map[string]string{"address": "aura1ok", "captcha_token": "tok"})
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+		return w, mock
+	}
+
+	t.Run("closed rejects when the balance query errors", func(t *testing.T) {
+		w, mock := run(t, "closed")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("open allows the request through", func(t *testing.T) {
+		w, mock := run(t, "open")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "tx1")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRequestTokensRejectsUnknownSource(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := defaultConfig()
+	cfg.RequestSources = []string{"hackathon", "docs"}
+	h := newTestHandler(cfg, &mockFaucet{}, &mockRateLimiter{})
+
+	body, _ := json.Marshal(map[string]string{"address": "aura1ok", "captcha_token": "tok", "source": "airdrop"})
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	h.RequestTokens(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "UNKNOWN_SOURCE")
+}
+
+func TestGetStatisticsBySource(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newHandler := func(t *testing.T) (*Handler, sqlmock.Sqlmock) {
+		dbConn, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		cfg := defaultConfig()
+		cfg.RequestSources = []string{"hackathon"}
+		return NewHandler(cfg, &mockFaucet{}, &mockRateLimiter{}, database.NewWithConn(dbConn)), mock
+	}
+
+	get := func(h *Handler, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", target, nil)
+		h.GetStatistics(c)
+		return w
+	}
+
+	t.Run("includes per-source totals", func(t *testing.T) {
+		h, mock := newHandler(t)
+		expectStatistics(mock)
+		mock.ExpectQuery(regexp.QuoteMeta(`GROUP BY source`)).
+			WillReturnRows(sqlmock.NewRows([]string{"source", "count", "sum"}).AddRow("hackathon", int64(3), int64(300)))
+
+		w := get(h, "/faucet/stats")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"sources":[{"source":"hackathon","requests":3,"total_distributed":300}]`)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("filters by source", func(t *testing.T) {
+		h, mock := newHandler(t)
+		for i := 0; i < 7; i++ {
+			mock.ExpectQuery(regexp.QuoteMeta(`source = $1`)).WithArgs("hackathon").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(1)))
+		}
+
+		w := get(h, "/faucet/stats?source=hackathon")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rejects unknown source", func(t *testing.T) {
+		h, _ := newHandler(t)
+		w := get(h, "/faucet/stats?source=airdrop")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestRequestTokensRejectsSelfTransfer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := newTestHandler(defaultConfig(), &mockFaucet{}, &mockRateLimiter{})
+	osmoCfg := defaultConfig().ForChain(config.ChainConfig{
+		ChainID:       "osmo-test-1",
+		Denom:         "uosmo",
+		AddressPrefix: "osmo",
+		FaucetAddress: "osmo1faucet",
+	})
+	h.AddChain(osmoCfg, &mockFaucet{})
+
+	for _, payload := range []map[string]string{
+		{"address": "aura1faucet", "captcha_token": "tok"},
+		{"address": "osmo1faucet", "chain_id": "osmo-test-1", "captcha_token": "tok"},
+	} {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, payload["address"])
+		assert.Contains(t, w.Body.String(), "SELF_TRANSFER")
+	}
+}
+
+func TestAdminReplayRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	requestRow := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "denom", "source", "retry_attempts", "created_at"}).
+			AddRow(int64(5), "aura1recipient", int64(100), "", "1.1.1.1", "failed", "uaura", "", 3, time.Now())
+	}
+	replay := func(h *Handler, token, id string) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.POST("/admin/requests/:id/replay", AdminAuthMiddleware("secret"), h.AdminReplayRequest)
+		req, _ := http.NewRequest("POST", "/admin/requests/"+id+"/replay", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("replays a failed request", func(t *testing.T) {
+		f := &mockFaucet{replayResp: &faucet.SendResponse{TxHash: "ABC", Recipient: "aura1recipient", Amount: units.New(100)}}
+		h, mock := newHandlerWithDB(t, f, &mockRateLimiter{})
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE id = $1`)).WithArgs(int64(5)).WillReturnRows(requestRow())
+
+		w := replay(h, "secret", "5")
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, []int64{5}, f.replayed)
+		assert.Contains(t, w.Body.String(), `"tx_hash":"ABC"`)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("refuses to pay twice", func(t *testing.T) {
+		f := &mockFaucet{replayErr: fmt.Errorf("%w: request 5 has tx ABC", faucet.ErrAlreadyPaid)}
+		h, mock := newHandlerWithDB(t, f, &mockRateLimiter{})
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE id = $1`)).WithArgs(int64(5)).WillReturnRows(requestRow())
+
+		w := replay(h, "secret", "5")
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "ALREADY_PAID")
+	})
+
+	t.Run("refused at the balance reserve", func(t *testing.T) {
+		f := &mockFaucet{replayErr: fmt.Errorf("%w: spendable 0uaura", faucet.ErrBalanceReserve)}
+		h, mock := newHandlerWithDB(t, f, &mockRateLimiter{})
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE id = $1`)).WithArgs(int64(5)).WillReturnRows(requestRow())
+
+		w := replay(h, "secret", "5")
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), "REPLAY_REFUSED")
+	})
+
+	t.Run("unknown request", func(t *testing.T) {
+		h, mock := newHandlerWithDB(t, &mockFaucet{}, &mockRateLimiter{})
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE id = $1`)).WithArgs(int64(6)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		w := replay(h, "secret", "6")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("requires the admin token", func(t *testing.T) {
+		f := &mockFaucet{}
+		h, _ := newHandlerWithDB(t, f, &mockRateLimiter{})
+
+		assert.Equal(t, http.StatusUnauthorized, replay(h, "", "5").Code)
+		assert.Equal(t, http.StatusUnauthorized, replay(h, "wrong", "5").Code)
+		assert.Empty(t, f.replayed)
+	})
+}
+
+func TestHealthReportsRedisLatency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	health := func(rl *mockRateLimiter) (int, map[string]interface{}) {
+		cfg := defaultConfig()
+		cfg.RedisLatencyThreshold = 20 * time.Millisecond
+		f := &mockFaucet{status: &faucet.NodeStatus{}}
+		h := newTestHandler(cfg, f, rl)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		h.Health(c)
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body
+	}
+
+	t.Run("fast redis", func(t *testing.T) {
+		_, body := health(&mockRateLimiter{})
+		checks := body["checks"].(map[string]interface{})
+		assert.Equal(t, true, checks["redis_latency_ok"])
+		assert.Contains(t, body, "redis_latency_ms")
+	})
+
+	t.Run("slow redis degrades health", func(t *testing.T) {
+		code, body := health(&mockRateLimiter{countDelay: 40 * time.Millisecond})
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "degraded", body["status"])
+		checks := body["checks"].(map[string]interface{})
+		assert.Equal(t, true, checks["redis_ready"])
+		assert.Equal(t, false, checks["redis_latency_ok"])
+		assert.GreaterOrEqual(t, body["redis_latency_ms"].(float64), 40.0)
+	})
+}
+
+func TestPayoutReceipts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	completed := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	payoutRow := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "status", "denom", "source", "created_at", "completed_at"}).
+			AddRow(int64(1), "aura1ok", int64(100), "tx1", "success", "uaura", "", completed, completed)
+	}
+	newHandler := func(t *testing.T, f *mockFaucet) (*Handler, sqlmock.Sqlmock, *receipt.Signer) {
+		cfg := defaultConfig()
+		cfg.RequireCaptcha = false
+		h, mock := newHandlerWithDB(t, f, &mockRateLimiter{})
+		h.cfg = cfg
+		signer, err := receipt.NewSigner([]byte("secret"))
+		require.NoError(t, err)
+		h.SetReceiptSigner(signer)
+		return h, mock, signer
+	}
+	decodeReceipt := func(t *testing.T, body []byte) *receipt.Signed {
+		var resp struct {
+			Receipt *receipt.Signed `json:"receipt"`
+		}
+		require.NoError(t, json.Unmarshal(body, &resp))
+		require.NotNil(t, resp.Receipt)
+		return resp.Receipt
+	}
+
+	t.Run("payout response carries a signed receipt", func(t *testing.T) {
+		f := &mockFaucet{sendResp: &faucet.SendResponse{TxHash: "tx1", Recipient: "aura1ok", Amount: units.New(100)}}
+		h, mock, signer := newHandler(t, f)
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}))
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE tx_hash = $1`)).WithArgs("tx1").WillReturnRows(payoutRow())
+
+		body, _ := json.Marshal(map[string]string{"address": "aura1ok"})
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		signed := decodeReceipt(t, w.Body.Bytes())
+		assert.Equal(t, receipt.New("aura1ok", units.New(100), "uaura", "tx1", "aura-test", completed), signed.Receipt)
+		assert.NoError(t, signer.Verify(signed))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("status endpoint reissues the same receipt", func(t *testing.T) {
+		h, mock, signer := newHandler(t, &mockFaucet{})
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE tx_hash = $1`)).WithArgs("tx1").WillReturnRows(payoutRow())
+
+		router := gin.New()
+		router.GET("/faucet/status/:tx_hash", h.GetRequestStatus)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/faucet/status/tx1", nil)
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"status":"success"`)
+		signed := decodeReceipt(t, w.Body.Bytes())
+		assert.NoError(t, signer.Verify(signed))
+
+		signed.Receipt.Amount = units.New(1000000)
+		assert.ErrorIs(t, signer.Verify(signed), receipt.ErrInvalidSignature)
+	})
+
+	t.Run("unknown transaction", func(t *testing.T) {
+		h, mock, _ := newHandler(t, &mockFaucet{})
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE tx_hash = $1`)).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		router := gin.New()
+		router.GET("/faucet/status/:tx_hash", h.GetRequestStatus)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/faucet/status/nope", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestRequestIDIsReturnedAndResolvable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	created := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	statusCols := []string{"id", "recipient", "amount", "tx_hash", "status", "denom", "source", "created_at", "completed_at"}
+
+	newHandler := func(t *testing.T, expose bool) (*Handler, sqlmock.Sqlmock, *gin.Engine) {
+		cfg := defaultConfig()
+		cfg.ExposeRequestID = expose
+		f := &mockFaucet{sendResp: &faucet.SendResponse{RequestID: 42, CreatedAt: created, TxHash: "tx1", Recipient: "aura1ok", Amount: units.New(100)}}
+		h, mock := newHandlerWithDB(t, f, &mockRateLimiter{})
+		h.cfg = cfg
+		router := gin.New()
+		router.POST("/faucet/request", h.RequestTokens)
+		router.GET("/faucet/status/:tx_hash", h.GetRequestStatus)
+		return h, mock, router
+	}
+	request := func(router *gin.Engine) map[string]interface{} {
+		body, _ := json.Marshal(map[string]string{"address": "aura1ok"})
+		req, _ := http.NewRequest("POST", "/faucet/request", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+	status := func(router *gin.Engine, key string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/faucet/status/"+key, nil)
+		router.ServeHTTP(w, req)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w.Code, resp
+	}
+
+	t.Run("success response carries the id and status resolves it", func(t *testing.T) {
+		_, mock, router := newHandler(t, true)
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}))
+
+		resp := request(router)
+		assert.Equal(t, float64(42), resp["request_id"])
+		assert.Equal(t, "2026-03-04T05:06:07Z", resp["created_at"])
+
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE id = $1`)).
+			WithArgs(int64(42)).
+			WillReturnRows(sqlmock.NewRows(statusCols).
+				AddRow(int64(42), "aura1ok", int64(100), "tx1", "success", "uaura", "", created, created))
+		code, body := status(router, "42")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, float64(42), body["request_id"])
+		assert.Equal(t, "tx1", body["tx_hash"])
+		assert.Equal(t, "success", body["status"])
+		assert.NotContains(t, body, "recipient", "walking ids must not list recipients")
+
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE id = $1`)).
+			WithArgs(int64(7)).
+			WillReturnRows(sqlmock.NewRows(statusCols))
+		code, _ = status(router, "7")
+		assert.Equal(t, http.StatusNotFound, code)
+
+		// Transaction hashes still resolve
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE tx_hash = $1`)).
+			WithArgs("tx1").
+			WillReturnRows(sqlmock.NewRows(statusCols).
+				AddRow(int64(42), "aura1ok", int64(100), "tx1", "success", "uaura", "", created, created))
+		code, body = status(router, "tx1")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, float64(42), body["request_id"])
+		assert.Equal(t, "aura1ok", body["recipient"])
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("disabled hides the id", func(t *testing.T) {
+		_, mock, router := newHandler(t, false)
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}))
+
+		resp := request(router)
+		assert.NotContains(t, resp, "request_id")
+		assert.NotContains(t, resp, "created_at")
+
+		// The key is looked up as a transaction hash instead
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE tx_hash = $1`)).
+			WithArgs("42").
+			WillReturnRows(sqlmock.NewRows(statusCols))
+		code, _ := status(router, "42")
+		assert.Equal(t, http.StatusNotFound, code)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRequestTokensMaxRecipientsPerIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	distinctQuery := regexp.QuoteMeta(`SELECT COUNT(DISTINCT recipient) FROM faucet_requests`)
+
+	send := func(t *testing.T, others int64) (*httptest.ResponseRecorder, sqlmock.Sqlmock) {
+		cfg := defaultConfig()
+		cfg.MaxRecipientsPerIP = 2
+		cfg.RateLimitWindow = time.Hour
+		f := &mockFaucet{sendResp: &faucet.SendResponse{TxHash: "tx1", Recipient: "aura1new", Amount: units.New(100)}}
+		h, mock := newHandlerWithDB(t, f, &mockRateLimiter{})
+		h.cfg = cfg
+		mock.ExpectQuery(distinctQuery).
+			WithArgs("192.0.2.1", sqlmock.AnyArg(), "aura1new").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(others))
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}))
+
+		body, _ := json.Marshal(map[string]string{"address": "aura1new"})
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "192.0.2.1:1234"
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+		return w, mock
+	}
+
+	t.Run("under the cap", func(t *testing.T) {
+		w, mock := send(t, 1)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("a new address over the cap is rejected", func(t *testing.T) {
+		w, _ := send(t, 2)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Contains(t, w.Body.String(), "TOO_MANY_RECIPIENTS")
+	})
+}
+
+func TestRequestTokensSubnetLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	subnetQuery := regexp.QuoteMeta(`ip_inet <<= $1::inet`)
+
+	send := func(t *testing.T, remoteAddr, subnet string, payouts int64) (*httptest.ResponseRecorder, sqlmock.Sqlmock) {
+		cfg := defaultConfig()
+		cfg.SubnetMaxPayouts = 3
+		cfg.SubnetPrefixV4 = 24
+		cfg.SubnetPrefixV6 = 64
+		cfg.RateLimitWindow = time.Hour
+		f := &mockFaucet{sendResp: &faucet.SendResponse{TxHash: "tx1", Recipient: "aura1new", Amount: units.New(100)}}
+		h, mock := newHandlerWithDB(t, f, &mockRateLimiter{})
+		h.cfg = cfg
+		mock.ExpectQuery(subnetQuery).
+			WithArgs(subnet, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(payouts))
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}))
+
+		body, _ := json.Marshal(map[string]string{"address": "aura1new"})
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+		return w, mock
+	}
+
+	t.Run("under the cap", func(t *testing.T) {
+		w, mock := send(t, "192.0.2.77:1234", "192.0.2.0/24", 2)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("at the cap is rejected", func(t *testing.T) {
+		before := rejections(t, "subnet_limit")
+		w, _ := send(t, "192.0.2.200:1234", "192.0.2.0/24", 3)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Contains(t, w.Body.String(), "SUBNET_LIMIT")
+		assert.Equal(t, before+1, rejections(t, "subnet_limit"))
+	})
+
+	t.Run("ipv6 clients are grouped by /64", func(t *testing.T) {
+		w, _ := send(t, "[2001:db8:1:2:aaaa::1]:1234", "2001:db8:1:2::/64", 3)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+
+	t.Run("unparseable client ip is rejected", func(t *testing.T) {
+		w, mock := send(t, "not-an-ip", "", 0)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "INVALID_IP")
+		// Neither query ran
+		assert.Error(t, mock.ExpectationsWereMet())
+	})
+}
+
+// blockingFaucet holds SendTokens until release is closed
+type blockingFaucet struct {
+	*mockFaucet
+	started chan struct{}
+	release chan struct{}
+}
+
+func (f *blockingFaucet) SendTokens(req *faucet.SendRequest) (*faucet.SendResponse, error) {
+	close(f.started)
+	<-f.release
+	return f.mockFaucet.SendTokens(req)
+}
+
+func TestRequestTokensDeduplicatesRapidRepeats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+	client, err := ratelimit.NewRedisClient("redis://" + mr.Addr())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	f := &blockingFaucet{
+		mockFaucet: &mockFaucet{sendResp: &faucet.SendResponse{TxHash: "tx1", Recipient: "aura1new", Amount: units.New(100)}},
+		started:    make(chan struct{}),
+		release:    make(chan struct{}),
+	}
+	h, mock := newHandlerWithDB(t, f, &mockRateLimiter{})
+	h.SetDeduper(ratelimit.NewDeduper(client, 5*time.Second))
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}))
+
+	send := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"address":"aura1new"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+		return w
+	}
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- send() }()
+	<-f.started
+
+	// The repeat arrives while the first payout is broadcasting
+	second := make(chan *httptest.ResponseRecorder)
+	go func() { second <- send() }()
+	time.Sleep(50 * time.Millisecond)
+	close(f.release)
+
+	w1, w2 := <-first, <-second
+	assert.Equal(t, http.StatusOK, w1.Code)
+	assert.Equal(t, http.StatusOK, w2.Code)
+	assert.JSONEq(t, w1.Body.String(), w2.Body.String())
+	assert.Equal(t, "true", w2.Header().Get("X-Duplicate-Request"))
+	assert.Len(t, f.sent, 1, "one payout for both requests")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRequestTokensReleasesReservedSlots(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+	client, err := ratelimit.NewRedisClient("redis://" + mr.Addr())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	for _, algorithm := range []string{ratelimit.AlgorithmFixed, ratelimit.AlgorithmTokenBucket} {
+		t.Run(algorithm, func(t *testing.T) {
+			mr.FlushAll()
+			rl := ratelimit.NewRateLimiter(client, map[string]interface{}{
+				"per_ip":      1,
+				"per_address": 5,
+				"window":      time.Hour,
+				"algorithm":   algorithm,
+			})
+			f := &mockFaucet{sendErr: errors.New("node unavailable")}
+			h, mock := newHandlerWithDB(t, f, rl)
+			send := func() *httptest.ResponseRecorder {
+				mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}))
+				req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"address":"aura1new"}`))
+				req.Header.Set("Content-Type", "application/json")
+				req.RemoteAddr = "192.0.2.1:1234"
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = req
+				h.RequestTokens(c)
+				return w
+			}
+
+			assert.Equal(t, http.StatusInternalServerError, send().Code)
+
+			// The failed payout gave its slot back
+			f.sendErr = nil
+			f.sendResp = &faucet.SendResponse{TxHash: "tx1", Recipient: "aura1new", Amount: units.New(100)}
+			assert.Equal(t, http.StatusOK, send().Code)
+
+			limited, err := rl.CheckIPLimit(context.Background(), "192.0.2.1")
+			require.NoError(t, err)
+			assert.True(t, limited, "the payout kept its slot")
+		})
+	}
+}
+
+func TestRequestTokensDedupOnlyKeepsPayouts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+	client, err := ratelimit.NewRedisClient("redis://" + mr.Addr())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	send := func(h *Handler, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+		return w
+	}
+
+	t.Run("failed captcha does not claim the address", func(t *testing.T) {
+		cfg := defaultConfig()
+		cfg.RequireCaptcha = true
+		h := newTestHandler(cfg, &mockFaucet{}, &mockRateLimiter{})
+		h.SetDeduper(ratelimit.NewDeduper(client, 5*time.Second))
+
+		w := send(h, `{"address":"aura1victim"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.False(t, mr.Exists("dedup:aura-test:aura1victim"))
+	})
+
+	t.Run("rejection releases the claim", func(t *testing.T) {
+		h, _ := newHandlerWithDB(t, &mockFaucet{}, &mockRateLimiter{ipLimited: true})
+		h.SetDeduper(ratelimit.NewDeduper(client, 5*time.Second))
+
+		w := send(h, `{"address":"aura1victim"}`)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.False(t, mr.Exists("dedup:aura-test:aura1victim"))
+	})
+}
+
+// batchFaucet pays a batch, failing the recipients in fail
+type batchFaucet struct {
+	*mockFaucet
+	fail    map[string]bool
+	batches [][]*faucet.SendRequest
+}
+
+func (f *batchFaucet) ValidateAddress(address string) error {
+	if !strings.HasPrefix(address, "aura1") {
+		return errors.New("invalid prefix")
+	}
+	return nil
+}
+
+func (f *batchFaucet) SendBatch(reqs []*faucet.SendRequest) []faucet.BatchResult {
+	f.batches = append(f.batches, reqs)
+	results := make([]faucet.BatchResult, 0, len(reqs))
+	for _, req := range reqs {
+		result := faucet.BatchResult{Address: req.Recipient, Amount: req.Amount, Status: faucet.BatchFunded, TxHash: "tx-" + req.Recipient}
+		if f.fail[req.Recipient] {
+			result = faucet.BatchResult{Address: req.Recipient, Amount: req.Amount, Status: faucet.BatchFailed, Reason: "send_failed"}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func TestAdminBatchSendReportsEachAddress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := &batchFaucet{mockFaucet: &mockFaucet{}, fail: map[string]bool{"aura1two": true}}
+	h := newTestHandler(defaultConfig(), f, nil)
+	router := gin.New()
+	router.POST("/admin/batch", h.AdminBatchSend)
+	router.GET("/admin/batch/:id", h.AdminBatchStatus)
+
+	req, _ := http.NewRequest("POST", "/admin/batch", bytes.NewBufferString(`{"addresses":["aura1one","cosmos1nope","aura1two","aura1three"]}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var started struct {
+		JobID string `json:"job_id"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &started))
+	require.NotEmpty(t, started.JobID)
+
+	var resp struct {
+		Status  string               `json:"status"`
+		Results []faucet.BatchResult `json:"results"`
+		Funded  int                  `json:"funded"`
+		Failed  int                  `json:"failed"`
+		Skipped int                  `json:"skipped"`
+	}
+	require.Eventually(t, func() bool {
+		req, _ := http.NewRequest("GET", "/admin/batch/"+started.JobID, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Status == "done"
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []faucet.BatchResult{
+		{Address: "aura1one", Amount: units.New(100), Status: faucet.BatchFunded, TxHash: "tx-aura1one"},
+		{Address: "cosmos1nope", Status: faucet.BatchSkipped, Reason: "invalid_address"},
+		{Address: "aura1two", Amount: units.New(100), Status: faucet.BatchFailed, Reason: "send_failed"},
+		{Address: "aura1three", Amount: units.New(100), Status: faucet.BatchFunded, TxHash: "tx-aura1three"},
+	}, resp.Results)
+	assert.Equal(t, 2, resp.Funded)
+	assert.Equal(t, 1, resp.Failed)
+	assert.Equal(t, 1, resp.Skipped)
+	require.Len(t, f.batches, 1)
+	assert.Len(t, f.batches[0], 3, "the invalid address is never sent")
+}
+
+func TestAdminBatchSendIsTrackedForShutdown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := &batchFaucet{mockFaucet: &mockFaucet{}}
+	h := newTestHandler(defaultConfig(), f, nil)
+	var background sync.WaitGroup
+	h.SetBackgroundRunner(func(run func(ctx context.Context)) {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			run(context.Background())
+		}()
+	})
+
+	req, _ := http.NewRequest("POST", "/admin/batch", bytes.NewBufferString(`{"addresses":["aura1one"]}`))
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	h.AdminBatchSend(c)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	// Shutdown waits on the same group; once it returns the batch is recorded
+	background.Wait()
+	require.Len(t, f.batches, 1)
+	job, err := h.batches.start("aura-test", nil)
+	require.NoError(t, err)
+	assert.NotNil(t, job, "the finished batch lets the next one start")
+}
+
+func TestAdminBatchSendRunsOneBatchAtATime(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := newTestHandler(defaultConfig(), &batchFaucet{mockFaucet: &mockFaucet{}}, nil)
+	_, err := h.batches.start("aura-test", nil)
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest("POST", "/admin/batch", bytes.NewBufferString(`{"addresses":["aura1one"]}`))
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	h.AdminBatchSend(c)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "BATCH_IN_PROGRESS")
+}
+
+// rejections reads the validation_rejections_total counter for reason
+func rejections(t *testing.T, reason string) float64 {
+	var metric dto.Metric
+	require.NoError(t, metrics.ValidationRejections.WithLabelValues(reason).Write(&metric))
+	return metric.GetCounter().GetValue()
+}
+
+func TestRequestTokensCountsValidationRejections(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	osmoCfg := defaultConfig().ForChain(config.ChainConfig{
+		ChainID:       "osmo-test-1",
+		Denom:         "uosmo",
+		AddressPrefix: "osmo",
+		FaucetAddress: "osmo1faucet",
+	})
+
+	cases := []struct {
+		reason  string
+		body    string
+		handler func(t *testing.T) *Handler
+	}{
+		{
+			reason:  "invalid_request",
+			body:    `{`,
+			handler: func(t *testing.T) *Handler { return newTestHandler(defaultConfig(), &mockFaucet{}, nil) },
+		},
+		{
+			reason:  "unknown_chain",
+			body:    `{"address":"aura1ok","chain_id":"nope-1"}`,
+			handler: func(t *testing.T) *Handler { return newTestHandler(defaultConfig(), &mockFaucet{}, nil) },
+		},
+		{
+			reason: "unknown_source",
+			body:   `{"address":"aura1ok","source":"airdrop"}`,
+			handler: func(t *testing.T) *Handler {
+				cfg := defaultConfig()
+				cfg.RequestSources = []string{"docs"}
+				return newTestHandler(cfg, &mockFaucet{}, nil)
+			},
+		},
+		{
+			reason: "chain_prefix_mismatch",
+			body:   `{"address":"aura1ok","chain_id":"osmo-test-1"}`,
+			handler: func(t *testing.T) *Handler {
+				h := newTestHandler(defaultConfig(), &mockFaucet{}, nil)
+				h.AddChain(osmoCfg, &mockFaucet{})
+				return h
+			},
+		},
+		{
+			reason: "invalid_address",
+			body:   `{"address":"aura1bad"}`,
+			handler: func(t *testing.T) *Handler {
+				return newTestHandler(defaultConfig(), &mockFaucet{validateErr: errors.New("bad")}, nil)
+			},
+		},
+		{
+			reason:  "self_transfer",
+			body:    `{"address":"aura1faucet"}`,
+			handler: func(t *testing.T) *Handler { return newTestHandler(defaultConfig(), &mockFaucet{}, nil) },
+		},
+		{
+			reason: "not_allowlisted_addr",
+			body:   `{"address":"aura1ok"}`,
+			handler: func(t *testing.T) *Handler {
+				cfg := defaultConfig()
+				cfg.AllowedAddresses = []string{"aura1other"}
+				return newTestHandler(cfg, &mockFaucet{}, nil)
+			},
+		},
+		{
+			reason: "not_allowlisted_ip",
+			body:   `{"address":"aura1ok"}`,
+			handler: func(t *testing.T) *Handler {
+				cfg := defaultConfig()
+				cfg.AllowedIPs = []string{"10.0.0.1"}
+				return newTestHandler(cfg, &mockFaucet{}, nil)
+			},
+		},
+		{
+			reason: "captcha_missing",
+			body:   `{"address":"aura1ok"}`,
+			handler: func(t *testing.T) *Handler {
+				cfg := defaultConfig()
+				cfg.RequireCaptcha = true
+				return newTestHandler(cfg, &mockFaucet{}, nil)
+			},
+		},
+		{
+			reason: "ip_rate_limit",
+			body:   `{"address":"aura1ok"}`,
+			handler: func(t *testing.T) *Handler {
+				h, _ := newHandlerWithDB(t, &mockFaucet{}, &mockRateLimiter{ipLimited: true})
+				return h
+			},
+		},
+		{
+			reason: "address_rate_limit",
+			body:   `{"address":"aura1ok"}`,
+			handler: func(t *testing.T) *Handler {
+				h, _ := newHandlerWithDB(t, &mockFaucet{}, &mockRateLimiter{addressLimited: true})
+				return h
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.reason, func(t *testing.T) {
+			before := rejections(t, tc.reason)
+
+			req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			tc.handler(t).RequestTokens(c)
+
+			assert.GreaterOrEqual(t, w.Code, 400)
+			assert.Equal(t, before+1, rejections(t, tc.reason))
+		})
+	}
+}
+
+func TestRequestTokensTopUpGraceWindow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	historyQuery := regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)
+	historyCols := []string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}
+
+	send := func(t *testing.T, grace time.Duration, balance int64, paidAgo time.Duration, limited bool) (*httptest.ResponseRecorder, *mockFaucet, sqlmock.Sqlmock) {
+		f := &mockFaucet{addressBalance: balance, sendResp: &faucet.SendResponse{TxHash: "tx2", Recipient: "aura1ok"}}
+		h, mock := newHandlerWithDB(t, f, &mockRateLimiter{addressLimited: limited})
+		h.cfg.TopUpGraceWindow = grace
+		history := func() *sqlmock.Rows {
+			return sqlmock.NewRows(historyCols).
+				AddRow(int64(1), "aura1ok", int64(100), "tx1", "192.0.2.1", "success", time.Now().Add(-paidAgo), nil)
+		}
+		if limited && grace > 0 {
+			mock.ExpectQuery(historyQuery).WillReturnRows(history())
+		}
+		mock.ExpectQuery(historyQuery).WillReturnRows(history())
+
+		body, _ := json.Marshal(map[string]string{"address": "aura1ok"})
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "192.0.2.1:1234"
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+		return w, f, mock
+	}
+
+	t.Run("partially spent recipient receives the difference", func(t *testing.T) {
+		w, f, mock := send(t, 30*time.Minute, 35, 5*time.Minute, true)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Len(t, f.sent, 1)
+		assert.Equal(t, units.New(65), f.sent[0].Amount)
+		assert.Contains(t, w.Body.String(), `"top_up":true`)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("recipient still holding the drip is rejected", func(t *testing.T) {
+		w, f, _ := send(t, 30*time.Minute, 100, 5*time.Minute, false)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Contains(t, w.Body.String(), "TOPUP_NOT_NEEDED")
+		assert.Empty(t, f.sent)
+	})
+
+	t.Run("outside the grace window the cooldown applies", func(t *testing.T) {
+		w, f, _ := send(t, 30*time.Minute, 35, time.Hour, false)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Empty(t, f.sent)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		w, f, _ := send(t, 0, 35, 5*time.Minute, false)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Empty(t, f.sent)
+	})
+
+	t.Run("18-decimal balances are topped up exactly", func(t *testing.T) {
+		drip, err := units.Parse("100000000000000000000")
+		require.NoError(t, err)
+		held, err := units.Parse("30000000000000000001")
+		require.NoError(t, err)
+		// The int64 view saturates, which would look like a full drip
+		f := &mockFaucet{addressBalance: math.MaxInt64, exactBalance: held, sendResp: &faucet.SendResponse{TxHash: "tx2", Recipient: "aura1ok"}}
+		h, mock := newHandlerWithDB(t, f, &mockRateLimiter{})
+		h.cfg.TopUpGraceWindow = 30 * time.Minute
+		h.cfg.SetRequestAmount(drip)
+		mock.ExpectQuery(historyQuery).WillReturnRows(sqlmock.NewRows(historyCols).
+			AddRow(int64(1), "aura1ok", "100000000000000000000", "tx1", "192.0.2.1", "success", time.Now().Add(-5*time.Minute), nil))
+
+		body, _ := json.Marshal(map[string]string{"address": "aura1ok"})
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Len(t, f.sent, 1)
+		assert.Equal(t, "69999999999999999999", f.sent[0].Amount.String())
+	})
+
+	t.Run("only one top-up per address", func(t *testing.T) {
+		f := &mockFaucet{addressBalance: 0}
+		h, mock := newHandlerWithDB(t, f, &mockRateLimiter{})
+		h.cfg.TopUpGraceWindow = 30 * time.Minute
+		mock.ExpectQuery(historyQuery).WillReturnRows(sqlmock.NewRows(historyCols).
+			AddRow(int64(2), "aura1ok", int64(60), "tx2", "192.0.2.1", "success", time.Now().Add(-time.Minute), nil).
+			AddRow(int64(1), "aura1ok", int64(100), "tx1", "192.0.2.1", "success", time.Now().Add(-5*time.Minute), nil))
+
+		body, _ := json.Marshal(map[string]string{"address": "aura1ok"})
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Empty(t, f.sent)
+	})
+}
+
+func TestVerifyCaptchaChallengeTimestampSkew(t *testing.T) {
+	var challengeTS string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"success":true,"challenge_ts":%q}`, challengeTS)
+	}))
+	defer server.Close()
+
+	original := turnstileVerifyURL
+	turnstileVerifyURL = server.URL
+	defer func() { turnstileVerifyURL = original }()
+
+	cfg := defaultConfig()
+	cfg.TurnstileSecret = "secret"
+	cfg.MaxClockSkew = 30 * time.Second
+	h := newTestHandler(cfg, &mockFaucet{}, nil)
+
+	for _, tc := range []struct {
+		name   string
+		offset time.Duration
+		valid  bool
+	}{
+		{"ahead within skew", 20 * time.Second, true},
+		{"ahead beyond skew", 40 * time.Second, false},
+		{"expired within skew", -turnstileTokenTTL - 20*time.Second, true},
+		{"expired beyond skew", -turnstileTokenTTL - 40*time.Second, false},
+	} {
+		challengeTS = time.Now().Add(tc.offset).UTC().Format(time.RFC3339Nano)
+		assert.Equal(t, tc.valid, h.verifyCaptcha("tok", "192.0.2.1"), tc.name)
+	}
+}
+
+// supplyFaucet is a mockFaucet that also reports its share of supply
+type supplyFaucet struct {
+	mockFaucet
+	supply *faucet.SupplyStatus
+}
+
+func (f *supplyFaucet) CheckSupply() (*faucet.SupplyStatus, error) { return f.supply, nil }
+
+func TestHealthReportsSupplyShare(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	health := func(supply *faucet.SupplyStatus) map[string]interface{} {
+		cfg := defaultConfig()
+		cfg.SupplyGuardMaxSharePercent = 5
+		f := &supplyFaucet{mockFaucet: mockFaucet{status: &faucet.NodeStatus{}}, supply: supply}
+		h := newTestHandler(cfg, f, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/health", nil)
+		h.Health(c)
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+
+	body := health(&faucet.SupplyStatus{Supply: units.New(100000), Balance: units.New(1000), Share: 0.01})
+	assert.Equal(t, 0.01, body["supply_share"])
+	assert.Equal(t, true, body["checks"].(map[string]interface{})["supply_ok"])
+
+	body = health(&faucet.SupplyStatus{Supply: units.New(10000), Balance: units.New(1000), Share: 0.1, Paused: true})
+	assert.Equal(t, 0.1, body["supply_share"])
+	assert.Equal(t, false, body["checks"].(map[string]interface{})["supply_ok"])
+	assert.Equal(t, "degraded", body["status"])
+}
+
+func TestReadyRequiresBalanceAboveReserve(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ready := func(balance int64) (int, map[string]interface{}) {
+		cfg := defaultConfig()
+		cfg.BalanceReserve = units.New(1000)
+		h := newTestHandler(cfg, &mockFaucet{status: &faucet.NodeStatus{}, balance: balance}, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/ready", nil)
+		h.Ready(c)
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body
+	}
+
+	code, body := ready(1100)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, body["checks"].(map[string]interface{})["balance_above_reserve"])
+
+	code, body = ready(1099)
+	assert.Equal(t, http.StatusServiceUnavailable, code, "one payout would dip into the reserve")
+	assert.Equal(t, false, body["checks"].(map[string]interface{})["balance_above_reserve"])
+}
+
+// prefixFaucet is a mockFaucet that only accepts addresses with prefix
+type prefixFaucet struct {
+	mockFaucet
+	prefix string
+}
+
+func (f *prefixFaucet) ValidateAddress(address string) error {
+	if !strings.HasPrefix(address, f.prefix) {
+		return errors.New("bad prefix")
+	}
+	return nil
+}
+
+func TestValidateAddresses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := defaultConfig()
+	cfg.AllowedAddresses = []string{"aura1good", "aura1cool"}
+	h := newTestHandler(cfg, &prefixFaucet{prefix: "aura1"}, &mockRateLimiter{})
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/v1/faucet/validate", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.ValidateAddresses(c)
+		return w
+	}
+
+	t.Run("mixed list", func(t *testing.T) {
+		w := post(`{"addresses":["aura1good","cosmos1bad","aura1other"]}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp struct {
+			Results  []AddressValidation `json:"results"`
+			Total    int                 `json:"total"`
+			Eligible int                 `json:"eligible"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Results, 3)
+		assert.Equal(t, 3, resp.Total)
+		assert.Equal(t, 1, resp.Eligible)
+
+		good, malformed, ineligible := resp.Results[0], resp.Results[1], resp.Results[2]
+		assert.True(t, good.Valid)
+		assert.True(t, good.Allowed)
+		assert.True(t, good.Eligible)
+		assert.Empty(t, good.Reasons)
+
+		assert.Equal(t, "cosmos1bad", malformed.Address)
+		assert.False(t, malformed.Valid)
+		assert.False(t, malformed.Eligible)
+		assert.Equal(t, []string{"invalid_address"}, malformed.Reasons)
+
+		assert.True(t, ineligible.Valid)
+		assert.False(t, ineligible.Allowed)
+		assert.False(t, ineligible.Eligible)
+		assert.Equal(t, []string{"address_not_allowed"}, ineligible.Reasons)
+	})
+
+	t.Run("cooling down address is ineligible", func(t *testing.T) {
+		limited := newTestHandler(cfg, &prefixFaucet{prefix: "aura1"}, &mockRateLimiter{addressLimited: true, remaining: time.Hour})
+		req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"addresses":["aura1good"]}`))
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		limited.ValidateAddresses(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"cooldown"`)
+		assert.Contains(t, w.Body.String(), `"eligible":0`)
+	})
+
+	t.Run("rejects an empty list", func(t *testing.T) {
+		w := post(`{"addresses":[]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("rejects lists over the cap", func(t *testing.T) {
+		addresses := make([]string, maxValidateAddresses+1)
+		for i := range addresses {
+			addresses[i] = fmt.Sprintf("aura1addr%d", i)
+		}
+		body, _ := json.Marshal(map[string][]string{"addresses": addresses})
+		w := post(string(body))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "at most 100 addresses")
+	})
+
+	t.Run("rejects malformed JSON", func(t *testing.T) {
+		w := post(`{"addresses":"aura1good"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestChallengeGenerationIsCappedPerIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limits := challengestore.Limits{PerOwner: 2}
+
+	get := func(handle gin.HandlerFunc) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/", nil)
+		c.Request.RemoteAddr = "192.0.2.1:1234"
+		handle(c)
+		return w
+	}
+
+	t.Run("pow", func(t *testing.T) {
+		p := pow.NewProofOfWork(1)
+		defer p.Close()
+		p.SetLimits(limits)
+		h := newTestHandler(defaultConfig(), &mockFaucet{}, nil)
+		h.SetProofOfWork(p)
+
+		var first map[string]interface{}
+		w := get(h.GetPoWChallenge)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &first))
+		require.Equal(t, http.StatusOK, get(h.GetPoWChallenge).Code)
+
+		w = get(h.GetPoWChallenge)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Contains(t, w.Body.String(), "TOO_MANY_CHALLENGES")
+
+		// Solving a challenge frees a slot
+		solution, err := pow.SolveChallenge(first["nonce"].(string), 1)
+		require.NoError(t, err)
+		valid, err := p.Verify(first["challenge_id"].(string), solution)
+		require.NoError(t, err)
+		require.True(t, valid)
+		assert.Equal(t, http.StatusOK, get(h.GetPoWChallenge).Code)
+	})
+
+	t.Run("captcha", func(t *testing.T) {
+		svc := captcha.NewCaptchaService(captcha.CaptchaOptions{Limits: limits})
+		defer svc.Close()
+		h := newTestHandler(defaultConfig(), &mockFaucet{}, nil)
+		h.SetCaptchaService(svc)
+
+		var first map[string]interface{}
+		w := get(h.GetCaptcha)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &first))
+		require.Equal(t, http.StatusOK, get(h.GetCaptcha).Code)
+		assert.Equal(t, http.StatusTooManyRequests, get(h.GetCaptcha).Code)
+
+		// A validation attempt claims the captcha, right or wrong
+		svc.Validate(first["captcha_id"].(string), "wrong")
+		assert.Equal(t, http.StatusOK, get(h.GetCaptcha).Code)
+	})
+}
+
+func TestRequestTokensRejectsBlocklistedAddresses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	historyQuery := regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)
+	historyCols := []string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}
+
+	blocklist, err := config.ParseAddressBlocklist(strings.NewReader("# exchanges\naura1exchange\naura1bridge*\n"))
+	require.NoError(t, err)
+
+	send := func(t *testing.T, address string) (*httptest.ResponseRecorder, *mockFaucet) {
+		f := &mockFaucet{sendResp: &faucet.SendResponse{TxHash: "tx", Recipient: address}}
+		h, mock := newHandlerWithDB(t, f, &mockRateLimiter{})
+		h.SetAddressBlocklist(blocklist)
+		mock.ExpectQuery(historyQuery).WillReturnRows(sqlmock.NewRows(historyCols))
+
+		body, _ := json.Marshal(map[string]string{"address": address})
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+		return w, f
+	}
+
+	for _, address := range []string{"aura1exchange", "aura1bridgevault"} {
+		w, f := send(t, address)
+		assert.Equal(t, http.StatusForbidden, w.Code, address)
+		assert.Contains(t, w.Body.String(), "ADDRESS_BLOCKED")
+		assert.Empty(t, f.sent)
+	}
+
+	w, f := send(t, "aura1developer")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Len(t, f.sent, 1)
+}
+
+// recordingPublisher captures published events
+type recordingPublisher struct {
+	events []events.Event
+}
+
+func (p *recordingPublisher) Publish(event events.Event) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *recordingPublisher) Close() error { return nil }
+
+func TestRequestTokensEighteenDecimalAmount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// 100 tokens of an 18-decimal denom is 1e20 base units, beyond int64
+	amount, err := units.Parse("100000000000000000000")
+	require.NoError(t, err)
+	cfg := defaultConfig()
+	cfg.DenomExponent = 18
+	cfg.SetRequestAmount(amount)
+
+	dbConn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	f := &mockFaucet{sendResp: &faucet.SendResponse{TxHash: "TX1", Recipient: "aura1ok", Amount: amount}}
+	h := NewHandler(cfg, f, &mockRateLimiter{}, database.NewWithSQL(dbConn))
+	publisher := &recordingPublisher{}
+	h.SetEventPublisher(publisher)
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}))
+
+	body, _ := json.Marshal(map[string]string{"address": "aura1ok"})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/", bytes.NewBuffer(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	h.RequestTokens(c)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, f.sent, 1)
+	assert.Equal(t, amount, f.sent[0].Amount)
+	assert.Contains(t, w.Body.String(), `"amount":100000000000000000000`)
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, amount, publisher.events[0].Amount)
+
+	info := h.chainInfo(0)
+	assert.Equal(t, amount, info[0]["amount_per_request"])
+	assert.Equal(t, "100", info[0]["amount_display"])
+}
+
+func TestRequestTokensPublishesEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	historyQuery := regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)
+	historyCols := []string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}
+
+	blocklist, err := config.ParseAddressBlocklist(strings.NewReader("aura1exchange"))
+	require.NoError(t, err)
+
+	send := func(t *testing.T, f *mockFaucet, address string) *recordingPublisher {
+		h, mock := newHandlerWithDB(t, f, &mockRateLimiter{})
+		h.SetAddressBlocklist(blocklist)
+		publisher := &recordingPublisher{}
+		h.SetEventPublisher(publisher)
+		mock.ExpectQuery(historyQuery).WillReturnRows(sqlmock.NewRows(historyCols))
+
+		body, _ := json.Marshal(map[string]string{"address": address})
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+		return publisher
+	}
+
+	t.Run("success", func(t *testing.T) {
+		p := send(t, &mockFaucet{sendResp: &faucet.SendResponse{TxHash: "TX1", Recipient: "aura1ok"}}, "aura1ok")
+		require.Len(t, p.events, 1)
+		assert.Equal(t, events.PayoutSucceeded, p.events[0].Type)
+		assert.Equal(t, "aura1ok", p.events[0].Recipient)
+		assert.Equal(t, "TX1", p.events[0].TxHash)
+		assert.Equal(t, units.New(100), p.events[0].Amount)
+		assert.Equal(t, "aura-test", p.events[0].ChainID)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		p := send(t, &mockFaucet{sendErr: faucet.ErrSupplyGuard}, "aura1ok")
+		require.Len(t, p.events, 1)
+		assert.Equal(t, events.PayoutFailed, p.events[0].Type)
+		assert.Equal(t, "supply_guard", p.events[0].Reason)
+	})
+
+	t.Run("blocked", func(t *testing.T) {
+		p := send(t, &mockFaucet{}, "aura1exchange")
+		require.Len(t, p.events, 1)
+		assert.Equal(t, events.RequestBlocked, p.events[0].Type)
+		assert.Equal(t, "blocklist", p.events[0].Reason)
+	})
+}
+
+func TestRequestTokensPublishesRateLimitAndCaptchaBlocks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	send := func(t *testing.T, rl *mockRateLimiter, requireCaptcha bool) *recordingPublisher {
+		h, _ := newHandlerWithDB(t, &mockFaucet{}, rl)
+		h.cfg.RequireCaptcha = requireCaptcha
+		publisher := &recordingPublisher{}
+		h.SetEventPublisher(publisher)
+
+		req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"address":"aura1ok"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+		return publisher
+	}
+
+	cases := []struct {
+		name    string
+		rl      *mockRateLimiter
+		captcha bool
+		reason  string
+	}{
+		{"ip rate limit", &mockRateLimiter{ipLimited: true}, false, "ip_rate_limit"},
+		{"address rate limit", &mockRateLimiter{addressLimited: true}, false, "address_rate_limit"},
+		{"captcha", &mockRateLimiter{}, true, "captcha"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := send(t, tc.rl, tc.captcha)
+			require.Len(t, p.events, 1)
+			assert.Equal(t, events.RequestBlocked, p.events[0].Type)
+			assert.Equal(t, tc.reason, p.events[0].Reason)
+		})
+	}
+}
+
+func TestRequestTokensFirstRequestBonus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	historyQuery := regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)
+	historyCols := []string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}
+	claimedQuery := regexp.QuoteMeta(`WHERE recipient = $1 AND (status = 'success' OR (bonus_granted AND status <> 'failed'))`)
+
+	send := func(t *testing.T, claimed bool, sendResp *faucet.SendResponse) (*httptest.ResponseRecorder, *mockFaucet, sqlmock.Sqlmock) {
+		f := &mockFaucet{sendResp: sendResp}
+		h, mock := newHandlerWithDB(t, f, &mockRateLimiter{})
+		h.cfg.FirstRequestBonus = units.New(50)
+		mock.ExpectQuery(historyQuery).WithArgs("aura1new", sqlmock.AnyArg()).WillReturnRows(sqlmock.NewRows(historyCols))
+		mock.ExpectQuery(claimedQuery).WithArgs("aura1new").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(claimed))
+
+		body, _ := json.Marshal(map[string]string{"address": "aura1new"})
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+		return w, f, mock
+	}
+
+	t.Run("first request receives the bonus", func(t *testing.T) {
+		w, f, mock := send(t, false, &faucet.SendResponse{TxHash: "tx", Recipient: "aura1new", Amount: units.New(150), Bonus: units.New(50)})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Len(t, f.sent, 1)
+		assert.Equal(t, units.New(150), f.sent[0].Amount)
+		assert.Equal(t, units.New(50), f.sent[0].Bonus)
+		assert.Contains(t, w.Body.String(), `"bonus":50`)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("bonus lost to a concurrent request is not reported", func(t *testing.T) {
+		w, f, mock := send(t, false, &faucet.SendResponse{TxHash: "tx", Recipient: "aura1new", Amount: units.New(100)})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Len(t, f.sent, 1)
+		assert.NotContains(t, w.Body.String(), `"bonus"`)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("paid address gets no bonus", func(t *testing.T) {
+		w, f, mock := send(t, true, &faucet.SendResponse{TxHash: "tx", Recipient: "aura1new", Amount: units.New(100)})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Len(t, f.sent, 1)
+		assert.Equal(t, units.New(100), f.sent[0].Amount)
+		assert.Zero(t, f.sent[0].Bonus)
+		assert.NotContains(t, w.Body.String(), `"bonus"`)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+type queueingFaucet struct {
+	mockFaucet
+	status faucet.QueueStatus
+}
+
+func (f *queueingFaucet) QueueStatus() faucet.QueueStatus {
+	return f.status
+}
+
+func TestGetQueueStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := &queueingFaucet{status: faucet.QueueStatus{Depth: 3, AverageBroadcast: 1500 * time.Millisecond}}
+	h := NewHandler(defaultConfig(), f, &mockRateLimiter{}, nil)
+
+	get := func(query string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/queue"+query, nil)
+		h.GetQueueStatus(c)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w, resp
+	}
+
+	w, resp := get("")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(3), resp["queue_depth"])
+	assert.NotContains(t, resp, "queue_position")
+	assert.Equal(t, float64(1500), resp["average_broadcast_ms"])
+	assert.Equal(t, 4.5, resp["estimated_wait_seconds"])
+
+	// The depth falls as the queue drains
+	f.status.Depth = 1
+	_, resp = get("")
+	assert.Equal(t, float64(1), resp["queue_depth"])
+	assert.Equal(t, 1.5, resp["estimated_wait_seconds"])
+
+	w, _ = get("?chain_id=unknown")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRequestTokensBackpressureHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	send := func(depth int) *httptest.ResponseRecorder {
+		f := &queueingFaucet{
+			mockFaucet: mockFaucet{sendResp: &faucet.SendResponse{TxHash: "TX"}},
+			status:     faucet.QueueStatus{Depth: depth, AverageBroadcast: 2 * time.Second},
+		}
+		h, mock := newHandlerWithDB(t, f, &mockRateLimiter{})
+		h.cfg.MaxQueueDepth = 4
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}))
+
+		body, _ := json.Marshal(map[string]string{"address": "aura1ok"})
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+		return w
+	}
+
+	w := send(1)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0.25", w.Header().Get("X-Faucet-Load"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	w = send(3)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0.75", w.Header().Get("X-Faucet-Load"), "the load rises with the queue")
+
+	w = send(4)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "QUEUE_FULL")
+	assert.Equal(t, "1.00", w.Header().Get("X-Faucet-Load"))
+	assert.Equal(t, "8", w.Header().Get("Retry-After"), "four queued broadcasts at 2s each")
+
+	w = send(8)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "2.00", w.Header().Get("X-Faucet-Load"))
+	assert.Equal(t, "16", w.Header().Get("Retry-After"), "the wait grows with the backlog")
+}
+
+func TestRequestTokensNoLoadHeaderWhenQueueUnbounded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := &queueingFaucet{
+		mockFaucet: mockFaucet{sendResp: &faucet.SendResponse{TxHash: "TX"}},
+		status:     faucet.QueueStatus{Depth: 100},
+	}
+	h, _ := newHandlerWithDB(t, f, &mockRateLimiter{})
+
+	body, _ := json.Marshal(map[string]string{"address": "aura1ok"})
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	h.RequestTokens(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("X-Faucet-Load"))
+}
+
+func TestGetChainDescriptor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := defaultConfig()
+	cfg.AddressPrefix = "aura"
+	cfg.DenomExponent = 6
+	cfg.GasPrice = "0.025uaura"
+	cfg.NetworkType = "testnet"
+	cfg.ExposeFaucetAddress = true
+	cfg.NodeRPC = "http://10.0.0.5:26657"
+	cfg.NodeREST = "http://10.0.0.5:1317"
+	cfg.PublicRPC = "https://rpc.testnet.aura.example"
+	cfg.PublicREST = "https://api.testnet.aura.example"
+	h := newTestHandler(cfg, &mockFaucet{}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/chain.json", nil)
+	h.GetChainDescriptor(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"chain_name": "aura-test",
+		"chain_id": "aura-test",
+		"network_type": "testnet",
+		"bech32_prefix": "aura",
+		"fees": {"fee_tokens": [{"denom": "uaura", "average_gas_price": 0.025}]},
+		"assets": [{
+			"base": "uaura",
+			"display": "aura",
+			"symbol": "AURA",
+			"denom_units": [{"denom": "uaura", "exponent": 0}, {"denom": "aura", "exponent": 6}]
+		}],
+		"apis": {
+			"rpc": [{"address": "https://rpc.testnet.aura.example"}],
+			"rest": [{"address": "https://api.testnet.aura.example"}]
+		},
+		"faucet": {"address": "aura1faucet"}
+	}`, w.Body.String())
+	assert.NotContains(t, w.Body.String(), "10.0.0.5", "private node endpoints are never published")
+
+	// Without public endpoints no API is listed rather than the node's
+	cfg.PublicRPC, cfg.PublicREST = "", ""
+	cfg.ExposeFaucetAddress = false
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/chain.json", nil)
+	h.GetChainDescriptor(c)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Empty(t, resp["apis"])
+	assert.NotContains(t, resp, "faucet")
+	assert.NotContains(t, w.Body.String(), "10.0.0.5")
+}
+
+// flakyStatusFaucet is a mockFaucet whose first node status queries fail,
+// or first query hangs for slow
+type flakyStatusFaucet struct {
+	mockFaucet
+	failures  int
+	slow      time.Duration
+	mu        sync.Mutex
+	calls     int
+	cancelled int
+}
+
+func (f *flakyStatusFaucet) GetNodeStatus(ctx context.Context) (*faucet.NodeStatus, error) {
+	f.mu.Lock()
+	f.calls++
+	call := f.calls
+	f.mu.Unlock()
+
+	if call == 1 && f.slow > 0 {
+		select {
+		case <-time.After(f.slow):
+		case <-ctx.Done():
+			f.mu.Lock()
+			f.cancelled++
+			f.mu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+	if call <= f.failures {
+		return nil, errors.New("connection reset")
+	}
+	return &faucet.NodeStatus{}, nil
+}
+
+func TestReadyRetriesNodeStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ready := func(retries int, f *flakyStatusFaucet) int {
+		cfg := defaultConfig()
+		cfg.NodeStatusRetries = retries
+		cfg.NodeStatusTimeout = 50 * time.Millisecond
+		h := newTestHandler(cfg, f, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/ready", nil)
+		h.Ready(c)
+		return w.Code
+	}
+
+	f := &flakyStatusFaucet{failures: 1}
+	assert.Equal(t, http.StatusOK, ready(1, f), "the second attempt succeeds")
+	assert.Equal(t, 2, f.calls)
+
+	assert.Equal(t, http.StatusServiceUnavailable, ready(0, &flakyStatusFaucet{failures: 1}), "no retries")
+	assert.Equal(t, http.StatusServiceUnavailable, ready(1, &flakyStatusFaucet{failures: 2}), "retries exhausted")
+
+	f = &flakyStatusFaucet{slow: time.Second}
+	start := time.Now()
+	assert.Equal(t, http.StatusOK, ready(1, f), "a hung attempt is abandoned at the timeout")
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 1, f.cancelled, "the abandoned query is cancelled, not left running")
+}
+
+func TestHealthRetriesNodeStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := defaultConfig()
+	cfg.NodeStatusRetries = 1
+	h := newTestHandler(cfg, &flakyStatusFaucet{failures: 1}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/health", nil)
+	h.Health(c)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, true, body["checks"].(map[string]interface{})["node_reachable"])
+}
+
+func TestRequestTokensMemoOverride(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	historyCols := []string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}
+
+	send := func(t *testing.T, allowedIPs []string, token string) (*httptest.ResponseRecorder, *mockFaucet) {
+		f := &mockFaucet{sendResp: &faucet.SendResponse{TxHash: "tx1", Recipient: "aura1ok"}}
+		h, mock := newHandlerWithDB(t, f, &mockRateLimiter{})
+		h.cfg.AdminToken = "admin-secret"
+		h.cfg.AllowedIPs = allowedIPs
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)).
+			WillReturnRows(sqlmock.NewRows(historyCols))
+
+		req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"address":"aura1ok","memo":" invoice-42\n"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		req.RemoteAddr = "192.0.2.1:1234"
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+		return w, f
+	}
+
+	t.Run("admin token overrides the memo", func(t *testing.T) {
+		w, f := send(t, nil, "admin-secret")
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Len(t, f.sent, 1)
+		assert.Equal(t, "invoice-42", f.sent[0].Memo)
+	})
+
+	t.Run("allowlisted IP overrides the memo", func(t *testing.T) {
+		w, f := send(t, []string{"192.0.2.0/24"}, "")
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Len(t, f.sent, 1)
+		assert.Equal(t, "invoice-42", f.sent[0].Memo)
+	})
+
+	t.Run("untrusted caller is rejected", func(t *testing.T) {
+		w, f := send(t, nil, "wrong-token")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "MEMO_NOT_ALLOWED")
+		assert.Empty(t, f.sent)
+	})
+}
+
+// contractFaucet is a mockFaucet that knows which recipients are contracts
+type contractFaucet struct {
+	mockFaucet
+	contracts   map[string]bool
+	contractErr error
+}
+
+func (f *contractFaucet) IsContractAccount(address string) (bool, error) {
+	return f.contracts[address], f.contractErr
+}
+
+func TestRequestTokensContractRecipient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	historyCols := []string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}
+
+	send := func(t *testing.T, address string, allowContracts bool) (*httptest.ResponseRecorder, *contractFaucet) {
+		f := &contractFaucet{
+			mockFaucet: mockFaucet{sendResp: &faucet.SendResponse{TxHash: "tx1", Recipient: address}},
+			contracts:  map[string]bool{"aura1contract": true},
+		}
+		h, mock := newHandlerWithDB(t, f, &mockRateLimiter{})
+		h.cfg.AllowContractRecipients = allowContracts
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)).
+			WillReturnRows(sqlmock.NewRows(historyCols))
+
+		req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"address":"`+address+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+		return w, f
+	}
+
+	t.Run("base account is paid", func(t *testing.T) {
+		w, f := send(t, "aura1user", false)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Len(t, f.sent, 1)
+	})
+
+	t.Run("contract account is rejected by default", func(t *testing.T) {
+		w, f := send(t, "aura1contract", false)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "CONTRACT_RECIPIENT")
+		assert.Empty(t, f.sent)
+	})
+
+	t.Run("contract account is paid with the opt-in", func(t *testing.T) {
+		w, f := send(t, "aura1contract", true)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Len(t, f.sent, 1)
+	})
+
+	t.Run("failed check fails open under the balance check policy", func(t *testing.T) {
+		failOpen := func() float64 {
+			var metric dto.Metric
+			require.NoError(t, metrics.BalanceCheckFailOpen.WithLabelValues("account_type").Write(&metric))
+			return metric.GetCounter().GetValue()
+		}
+		before := failOpen()
+
+		f := &contractFaucet{
+			mockFaucet:  mockFaucet{sendResp: &faucet.SendResponse{TxHash: "tx1", Recipient: "aura1user"}},
+			contractErr: errors.New("node unavailable"),
+		}
+		h, mock := newHandlerWithDB(t, f, &mockRateLimiter{})
+		h.cfg.BalanceCheckFailPolicy = "open"
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)).
+			WillReturnRows(sqlmock.NewRows(historyCols))
+
+		req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"address":"aura1user"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, before+1, failOpen())
+	})
+}
+
+func TestRequestTokensPerDenomBalanceCap(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	historyCols := []string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}
+
+	// Both recipients hold 500 base units of the chain's denom
+	send := func(t *testing.T, address, chainID string) *httptest.ResponseRecorder {
+		dbConn, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		cfg := defaultConfig()
+		cfg.MaxRecipientBalance = units.New(1000)
+		cfg.MaxRecipientBalanceByDenom = map[string]units.Amount{"uosmo": units.New(300)}
+		h := NewHandler(cfg, &mockFaucet{addressBalance: 500, sendResp: &faucet.SendResponse{TxHash: "aura-tx"}},
+			&mockRateLimiter{}, database.NewWithConn(dbConn))
+		osmoCfg := cfg.ForChain(config.ChainConfig{ChainID: "osmo-test-1", Denom: "uosmo", AddressPrefix: "osmo"})
+		h.AddChain(osmoCfg, &mockFaucet{addressBalance: 500, sendResp: &faucet.SendResponse{TxHash: "osmo-tx"}})
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)).
+			WillReturnRows(sqlmock.NewRows(historyCols))
+
+		body, _ := json.Marshal(map[string]string{"address": address, "chain_id": chainID})
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+		return w
+	}
+
+	w := send(t, "aura1recipient", "aura-test")
+	assert.Equal(t, http.StatusOK, w.Code, "under the default cap: %s", w.Body.String())
+
+	w = send(t, "osmo1recipient", "osmo-test-1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "over the uosmo cap")
+	assert.Contains(t, w.Body.String(), "above faucet eligibility threshold")
+}
+
+func TestRequestTokensEighteenDecimalBalanceCap(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	historyCols := []string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}
+
+	// Both balances are beyond int64 in base units; the cap is 100 tokens
+	send := func(t *testing.T, tokens string) *httptest.ResponseRecorder {
+		dbConn, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		cfg := defaultConfig()
+		cfg.DenomExponent = 18
+		cfg.MaxRecipientBalance = units.New(100)
+		cfg.MaxRecipientBalanceUnit = config.BalanceUnitWhole
+		balance, err := units.ParseDisplay(tokens, 18)
+		require.NoError(t, err)
+		f := &mockFaucet{addressBalance: math.MaxInt64, exactBalance: balance, sendResp: &faucet.SendResponse{TxHash: "tx"}}
+		h := NewHandler(cfg, f, &mockRateLimiter{}, database.NewWithConn(dbConn))
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)).
+			WillReturnRows(sqlmock.NewRows(historyCols))
+
+		req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"address":"aura1recipient"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+		return w
+	}
+
+	w := send(t, "50")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = send(t, "150")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), "above faucet eligibility threshold")
+}
+
+func TestRequestTokensBalanceCapUnits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	historyCols := []string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}
+
+	// The node reports the recipient's 500 AURA as 500000000uaura
+	send := func(t *testing.T, limit int64, unit string) *httptest.ResponseRecorder {
+		dbConn, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		cfg := defaultConfig()
+		cfg.DenomExponent = 6
+		cfg.MaxRecipientBalance = units.New(limit)
+		cfg.MaxRecipientBalanceUnit = unit
+		h := NewHandler(cfg, &mockFaucet{addressBalance: 500_000_000, sendResp: &faucet.SendResponse{TxHash: "tx"}},
+			&mockRateLimiter{}, database.NewWithConn(dbConn))
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)).
+			WillReturnRows(sqlmock.NewRows(historyCols))
+
+		req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"address":"aura1recipient"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+		return w
+	}
+
+	tests := []struct {
+		name  string
+		limit int64
+		unit  string
+		want  int
+	}{
+		{"whole-token cap above the balance", 1000, config.BalanceUnitWhole, http.StatusOK},
+		{"whole-token cap below the balance", 100, config.BalanceUnitWhole, http.StatusTooManyRequests},
+		{"base-unit cap above the balance", 1_000_000_000, config.BalanceUnitBase, http.StatusOK},
+		{"base-unit cap below the balance", 100_000_000, config.BalanceUnitBase, http.StatusTooManyRequests},
+		{"unset unit is base units", 1000, "", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := send(t, tt.limit, tt.unit)
+			assert.Equal(t, tt.want, w.Code, w.Body.String())
+			if tt.want == http.StatusTooManyRequests {
+				assert.Contains(t, w.Body.String(), "above faucet eligibility threshold")
+			}
+		})
+	}
+}
+
+func TestRequestTokensRemainingQuota(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	historyCols := []string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}
+
+	send := func(t *testing.T, rl RateLimiter, address string) map[string]interface{} {
+		dbConn, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		h := NewHandler(defaultConfig(), &mockFaucet{sendResp: &faucet.SendResponse{TxHash: "tx"}}, rl, database.NewWithConn(dbConn))
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND created_at >= $2`)).
+			WillReturnRows(sqlmock.NewRows(historyCols))
+
+		req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"address":"`+address+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "192.0.2.1:1234"
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	t.Run("reports the per-IP quota left", func(t *testing.T) {
+		rl := ratelimit.NewMemoryLimiter(map[string]interface{}{
+			"per_ip":      3,
+			"per_address": 1,
+			"window":      time.Hour,
+		})
+		resp := send(t, rl, "aura1first")
+		assert.Equal(t, float64(2), resp["remaining_user_quota"])
+
+		resp = send(t, rl, "aura1second")
+		assert.Equal(t, float64(1), resp["remaining_user_quota"])
+		assert.NotContains(t, resp, "remaining_daily_budget", "no daily budget is configured")
+	})
+
+	t.Run("omitted when the limiter cannot report it", func(t *testing.T) {
+		resp := send(t, &mockRateLimiter{}, "aura1first")
+		assert.NotContains(t, resp, "remaining_user_quota")
+	})
+}
+
+func TestRequestTokensCampaignWindows(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Now()
+	request := func(campaigns ...config.Campaign) (int, map[string]interface{}) {
+		cfg := defaultConfig()
+		cfg.Campaigns = campaigns
+		h := newTestHandler(cfg, &mockFaucet{}, nil)
+
+		req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"address":"aura1ok"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestTokens(c)
+
+		var resp map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+	past := config.Campaign{Name: "past", Start: now.Add(-48 * time.Hour), End: now.Add(-24 * time.Hour)}
+	current := config.Campaign{Name: "current", Start: now.Add(-time.Hour), End: now.Add(time.Hour)}
+	future := config.Campaign{Name: "future", Start: now.Add(2 * time.Hour), End: now.Add(4 * time.Hour)}
+
+	t.Run("before", func(t *testing.T) {
+		code, resp := request(past, future)
+		assert.Equal(t, http.StatusForbidden, code)
+		assert.Equal(t, "CAMPAIGN_NOT_OPEN", resp["code"])
+		assert.InDelta(t, 7200, resp["opens_in_seconds"], 5)
+	})
+
+	t.Run("within", func(t *testing.T) {
+		// Reaching the dependency checks means the campaign let it through
+		code, _ := request(past, current, future)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+	})
+
+	t.Run("after", func(t *testing.T) {
+		code, resp := request(past)
+		assert.Equal(t, http.StatusForbidden, code)
+		assert.Equal(t, "CAMPAIGN_ENDED", resp["code"])
+	})
+}
+
+func TestGetFaucetConfigCampaign(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Now()
+	campaign := func(campaigns ...config.Campaign) map[string]interface{} {
+		cfg := defaultConfig()
+		cfg.Campaigns = campaigns
+		h := newTestHandler(cfg, &mockFaucet{}, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/", nil)
+		h.GetFaucetConfig(c)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		info, _ := resp["campaign"].(map[string]interface{})
+		return info
+	}
+
+	info := campaign(config.Campaign{Name: "hackathon", Start: now.Add(-time.Hour), End: now.Add(time.Hour)})
+	assert.Equal(t, true, info["open"])
+	assert.Equal(t, "hackathon", info["name"])
+	assert.InDelta(t, 3600, info["closes_in_seconds"], 5)
+
+	info = campaign(config.Campaign{Name: "hackathon", Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)})
+	assert.Equal(t, false, info["open"])
+	assert.InDelta(t, 3600, info["opens_in_seconds"], 5)
+
+	info = campaign(config.Campaign{Name: "hackathon", Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)})
+	assert.Equal(t, map[string]interface{}{"open": false}, info)
+
+	assert.Nil(t, campaign(), "no campaign info without campaigns")
 }
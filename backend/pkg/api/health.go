@@ -0,0 +1,274 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aura-chain/aura/faucet/pkg/faucet"
+	"github.com/aura-chain/aura/faucet/pkg/health"
+)
+
+// RegisterHealthCheck adds a check to the health endpoint. A check
+// registered under a built-in name replaces the built-in one.
+func (h *Handler) RegisterHealthCheck(name string, severity health.Severity, check health.CheckFunc) {
+	h.health.Register(name, severity, check)
+}
+
+// registerHealthChecks registers the built-in checks. Checks for optional
+// subsystems return health.ErrSkipped while the subsystem is off, so
+// setters called after NewHandler take effect without re-registering.
+func (h *Handler) registerHealthChecks() {
+	h.health.Register("node_reachable", health.Critical, func(ctx context.Context) error {
+		_, err := h.healthProbe(ctx).nodeStatus()
+		return err
+	})
+	h.health.Register("node_synced", health.Warning, func(ctx context.Context) error {
+		status, err := h.healthProbe(ctx).nodeStatus()
+		if err != nil {
+			return err
+		}
+		if status.SyncInfo.CatchingUp {
+			return errors.New("node is catching up")
+		}
+		return nil
+	})
+	// A slow Redis delays every request, so latency over the threshold
+	// degrades health too
+	h.health.Register("redis_ready", health.Warning, func(ctx context.Context) error {
+		if ready, _ := h.healthProbe(ctx).redis(); !ready {
+			return errors.New("redis unreachable")
+		}
+		return nil
+	})
+	h.health.Register("redis_latency_ok", health.Warning, func(ctx context.Context) error {
+		ready, latency := h.healthProbe(ctx).redis()
+		if !ready || h.redisTooSlow(latency) {
+			return fmt.Errorf("redis round trip took %s", latency)
+		}
+		return nil
+	})
+	h.health.Register("database_ready", health.Warning, func(ctx context.Context) error {
+		if h.db == nil {
+			return errors.New("database not configured")
+		}
+		_, err := h.db.GetStatistics()
+		return err
+	})
+	// The signing key check is cached by the faucet service
+	h.health.Register("signer_ready", health.Warning, func(ctx context.Context) error {
+		return h.faucet.CheckSigner()
+	})
+	// A paused supply guard degrades health
+	h.health.Register("supply_ok", health.Warning, func(ctx context.Context) error {
+		supply, err := h.healthProbe(ctx).supply()
+		if err != nil {
+			return err
+		}
+		if supply.Paused {
+			return errors.New("supply guard paused payouts")
+		}
+		return nil
+	})
+	// A node whose height stopped advancing serves stale state
+	h.health.Register("height_advancing", health.Warning, func(ctx context.Context) error {
+		if h.heights == nil {
+			return health.ErrSkipped
+		}
+		if h.heights.Stalled() {
+			return errors.New("block height stalled")
+		}
+		return nil
+	})
+	// A full broadcast queue sheds requests
+	h.health.Register("queue_ok", health.Warning, func(ctx context.Context) error {
+		load, status, ok := h.queueLoad(&chainRoute{cfg: h.cfg, faucet: h.faucet})
+		if !ok {
+			return health.ErrSkipped
+		}
+		if load >= 1 {
+			return fmt.Errorf("broadcast queue full at %d payouts", status.Depth)
+		}
+		return nil
+	})
+	// Required captchas fail every request while Turnstile is unreachable
+	// or refuses the secret
+	h.health.Register("captcha_ready", health.Warning, func(ctx context.Context) error {
+		if !h.cfg.RequireCaptcha {
+			return health.ErrSkipped
+		}
+		if h.cfg.TurnstileSecret == "" {
+			return errors.New("turnstile secret not configured")
+		}
+		return h.captchaProbe.check(func() error { return h.probeTurnstile(ctx) })
+	})
+}
+
+// captchaProbeTTL is how long a Turnstile probe result is reused, so
+// frequent health checks don't each call Cloudflare
+const captchaProbeTTL = time.Minute
+
+// cachedProbe reuses a probe's result for captchaProbeTTL
+type cachedProbe struct {
+	mu      sync.Mutex
+	checked time.Time
+	err     error
+}
+
+func (p *cachedProbe) check(probe func() error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.checked.IsZero() || time.Since(p.checked) >= captchaProbeTTL {
+		p.err = probe()
+		p.checked = time.Now()
+	}
+	return p.err
+}
+
+// probeTurnstile verifies a placeholder token. Turnstile answers
+// invalid-input-response for it when the secret is good, so only an
+// unreachable endpoint or a refused secret fails the probe.
+func (h *Handler) probeTurnstile(ctx context.Context) error {
+	form := url.Values{
+		"secret":   {h.cfg.TurnstileSecret},
+		"response": {"health-probe"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, turnstileVerifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := h.captchaClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("turnstile unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("turnstile returned status %d", resp.StatusCode)
+	}
+
+	var verify TurnstileResponse
+	if err := json.NewDecoder(resp.Body).Decode(&verify); err != nil {
+		return fmt.Errorf("turnstile returned an invalid response: %w", err)
+	}
+	for _, code := range verify.ErrorCodes {
+		if code == "invalid-input-secret" || code == "missing-input-secret" {
+			return errors.New("turnstile refused the secret")
+		}
+	}
+	return nil
+}
+
+// Health returns the comprehensive health status of the service (Kubernetes-compatible)
+func (h *Handler) Health(c *gin.Context) {
+	probe := &healthProbe{h: h}
+	report := h.health.Run(context.WithValue(context.Background(), healthProbeKey{}, probe))
+
+	var nodeNetwork string
+	var nodeHeight string
+	var nodeVersion string
+	var blockHeight *int64
+	if status, err := probe.nodeStatus(); err == nil {
+		nodeNetwork = status.NodeInfo.Network
+		nodeHeight = status.SyncInfo.LatestBlockHeight
+		nodeVersion = status.NodeInfo.Version
+		if height, err := status.Height(); err != nil {
+			log.WithError(err).Debug("Node reported an unparseable block height")
+		} else {
+			blockHeight = &height
+		}
+	}
+	_, redisLatency := probe.redis()
+
+	httpStatus := http.StatusOK
+	if report.Status == health.StatusUnhealthy {
+		httpStatus = http.StatusServiceUnavailable
+	}
+
+	body := gin.H{
+		"status":           string(report.Status),
+		"version":          "1.0.0",
+		"network":          nodeNetwork,
+		"height":           nodeHeight,
+		"node_version":     nodeVersion,
+		"redis_latency_ms": latencyMillis(redisLatency),
+		"checks":           report.Checks,
+		"timestamp":        time.Now().UTC().Format(time.RFC3339),
+	}
+	body["stalled"] = h.heights != nil && h.heights.Stalled()
+	if blockHeight != nil {
+		body["block_height"] = *blockHeight
+	}
+	if supply, err := probe.supply(); err == nil {
+		body["supply_share"] = supply.Share
+	}
+	c.JSON(httpStatus, body)
+}
+
+type healthProbeKey struct{}
+
+// healthProbe memoizes the probes several checks share for one health
+// request, so the node and Redis are each queried once
+type healthProbe struct {
+	h *Handler
+
+	nodeOnce sync.Once
+	node     *faucet.NodeStatus
+	nodeErr  error
+
+	redisOnce    sync.Once
+	redisReady   bool
+	redisLatency time.Duration
+
+	supplyOnce   sync.Once
+	supplyStatus *faucet.SupplyStatus
+	supplyErr    error
+}
+
+// healthProbe returns the request's probe, or a fresh one when the checks
+// run outside Health
+func (h *Handler) healthProbe(ctx context.Context) *healthProbe {
+	if probe, ok := ctx.Value(healthProbeKey{}).(*healthProbe); ok {
+		return probe
+	}
+	return &healthProbe{h: h}
+}
+
+func (p *healthProbe) nodeStatus() (*faucet.NodeStatus, error) {
+	p.nodeOnce.Do(func() {
+		p.node, p.nodeErr = p.h.probeNodeStatus()
+	})
+	return p.node, p.nodeErr
+}
+
+func (p *healthProbe) redis() (bool, time.Duration) {
+	p.redisOnce.Do(func() {
+		p.redisReady, p.redisLatency = p.h.checkRedis(context.Background())
+	})
+	return p.redisReady, p.redisLatency
+}
+
+// supply reports the faucet's share of supply, skipped unless the supply
+// guard is on
+func (p *healthProbe) supply() (*faucet.SupplyStatus, error) {
+	p.supplyOnce.Do(func() {
+		checker, ok := p.h.faucet.(supplyChecker)
+		if !ok || !p.h.cfg.SupplyGuardEnabled() {
+			p.supplyErr = health.ErrSkipped
+			return
+		}
+		p.supplyStatus, p.supplyErr = checker.CheckSupply()
+	})
+	return p.supplyStatus, p.supplyErr
+}
@@ -0,0 +1,49 @@
+package api
+
+import "sync"
+
+// HeightTracker follows the node's block height across monitor ticks and
+// flags the node as stalled once the height stops advancing
+type HeightTracker struct {
+	mu         sync.RWMutex
+	height     int64
+	known      bool
+	unchanged  int
+	stallTicks int
+}
+
+// NewHeightTracker creates a tracker that reports a stall after the height
+// is unchanged for stallTicks consecutive observations; 0 never reports one
+func NewHeightTracker(stallTicks int) *HeightTracker {
+	return &HeightTracker{stallTicks: stallTicks}
+}
+
+// Observe records the height seen on one monitor tick
+func (ht *HeightTracker) Observe(height int64) {
+	ht.mu.Lock()
+	defer ht.mu.Unlock()
+
+	if ht.known && height == ht.height {
+		ht.unchanged++
+		return
+	}
+	ht.height = height
+	ht.known = true
+	ht.unchanged = 0
+}
+
+// Height returns the last observed height; ok is false before the first
+// observation
+func (ht *HeightTracker) Height() (height int64, ok bool) {
+	ht.mu.RLock()
+	defer ht.mu.RUnlock()
+	return ht.height, ht.known
+}
+
+// Stalled reports whether the height has been unchanged for stallTicks
+// consecutive ticks
+func (ht *HeightTracker) Stalled() bool {
+	ht.mu.RLock()
+	defer ht.mu.RUnlock()
+	return ht.stallTicks > 0 && ht.unchanged >= ht.stallTicks
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aura-chain/aura/faucet/pkg/faucet"
+)
+
+func TestHeightTrackerStall(t *testing.T) {
+	tracker := NewHeightTracker(3)
+	_, ok := tracker.Height()
+	assert.False(t, ok)
+
+	tracker.Observe(100)
+	tracker.Observe(100)
+	tracker.Observe(100)
+	assert.False(t, tracker.Stalled(), "unchanged for two ticks")
+	tracker.Observe(100)
+	assert.True(t, tracker.Stalled(), "unchanged for three ticks")
+
+	tracker.Observe(101)
+	assert.False(t, tracker.Stalled(), "advancing clears the stall")
+	height, ok := tracker.Height()
+	assert.True(t, ok)
+	assert.Equal(t, int64(101), height)
+
+	disabled := NewHeightTracker(0)
+	for i := 0; i < 10; i++ {
+		disabled.Observe(5)
+	}
+	assert.False(t, disabled.Stalled())
+}
+
+func TestHealthReportsHeightAndStall(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	health := func(h *Handler) map[string]interface{} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		h.Health(c)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+	nodeAt := func(height string) *mockFaucet {
+		status := &faucet.NodeStatus{}
+		status.SyncInfo.LatestBlockHeight = height
+		return &mockFaucet{status: status}
+	}
+
+	h := newTestHandler(defaultConfig(), nodeAt("42"), &mockRateLimiter{})
+	tracker := NewHeightTracker(2)
+	h.SetHeightTracker(tracker)
+	tracker.Observe(42)
+
+	resp := health(h)
+	assert.Equal(t, float64(42), resp["block_height"])
+	assert.Equal(t, "42", resp["height"])
+	assert.Equal(t, false, resp["stalled"])
+
+	tracker.Observe(42)
+	tracker.Observe(42)
+	resp = health(h)
+	assert.Equal(t, true, resp["stalled"])
+	assert.Equal(t, "degraded", resp["status"])
+	assert.Equal(t, false, resp["checks"].(map[string]interface{})["height_advancing"])
+
+	// An unparseable height is reported as-is without a numeric height
+	h = newTestHandler(defaultConfig(), nodeAt("not-a-height"), &mockRateLimiter{})
+	resp = health(h)
+	assert.Equal(t, "not-a-height", resp["height"])
+	assert.NotContains(t, resp, "block_height")
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/aura-chain/aura/faucet/pkg/faucet"
+)
+
+// queueReporter is implemented by faucet services that track their
+// broadcast queue
+type queueReporter interface {
+	QueueStatus() faucet.QueueStatus
+}
+
+// GetQueueStatus reports how many payouts are queued or broadcasting, which
+// is how many a request made now would wait behind, and roughly how long
+// that wait is from the average broadcast time
+func (h *Handler) GetQueueStatus(c *gin.Context) {
+	chain, ok := h.routeChain(c.Query("chain_id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Unknown chain_id %q", c.Query("chain_id")),
+			"code":  "UNKNOWN_CHAIN",
+		})
+		return
+	}
+
+	var status faucet.QueueStatus
+	if reporter, ok := chain.faucet.(queueReporter); ok {
+		status = reporter.QueueStatus()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"chain_id":               chain.cfg.ChainID,
+		"queue_depth":            status.Depth,
+		"average_broadcast_ms":   status.AverageBroadcast.Milliseconds(),
+		"estimated_wait_seconds": status.EstimatedWait().Seconds(),
+	})
+}
+
+// queueLoad returns chain's broadcast queue depth as a fraction of
+// MAX_QUEUE_DEPTH, with the queue status. ok is false when the queue is
+// unbounded or the faucet doesn't report it.
+func (h *Handler) queueLoad(chain *chainRoute) (load float64, status faucet.QueueStatus, ok bool) {
+	reporter, reports := chain.faucet.(queueReporter)
+	if h.cfg.MaxQueueDepth <= 0 || !reports {
+		return 0, faucet.QueueStatus{}, false
+	}
+	status = reporter.QueueStatus()
+	return float64(status.Depth) / float64(h.cfg.MaxQueueDepth), status, true
+}
+
+// setLoadHeader advertises the faucet's load so clients can back off in
+// proportion before the queue fills
+func setLoadHeader(c *gin.Context, load float64) {
+	c.Header("X-Faucet-Load", strconv.FormatFloat(load, 'f', 2, 64))
+}
+
+// queueRetryAfter is how long a shed request should wait: the time to drain
+// the queue at the average broadcast time, or a second per queued payout
+// before any broadcast has been timed
+func queueRetryAfter(status faucet.QueueStatus) time.Duration {
+	wait := status.EstimatedWait()
+	if status.AverageBroadcast == 0 {
+		wait = time.Duration(status.Depth) * time.Second
+	}
+	return time.Duration(math.Ceil(max(wait, time.Second).Seconds())) * time.Second
+}
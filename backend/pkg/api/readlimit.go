@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	metrics "github.com/aura-chain/aura/faucet/pkg/prometheus"
+)
+
+// ReadRateLimitMiddleware applies a per-IP limit to read-only endpoints so
+// info, stats and recent listings can't be scraped hard enough to load the
+// database or node. Only the IP counters of limiter are used. Limiter errors
+// fail open: these endpoints are not worth an outage.
+func ReadRateLimitMiddleware(limiter RateLimiter) gin.HandlerFunc {
+	return ipRateLimitMiddleware(limiter, "read")
+}
+
+// EmailRateLimitMiddleware applies a per-IP limit to the email
+// verification endpoints, so one client can't use the faucet's mail server
+// to reach any number of addresses or guess codes across many of them
+func EmailRateLimitMiddleware(limiter RateLimiter) gin.HandlerFunc {
+	return ipRateLimitMiddleware(limiter, "email_ip")
+}
+
+// ipRateLimitMiddleware counts each request against the client IP's
+// counter in limiter, reporting rejections under label
+func ipRateLimitMiddleware(limiter RateLimiter, label string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		ip := c.ClientIP()
+
+		limited, err := limiter.CheckIPLimit(ctx, ip)
+		if err != nil {
+			log.WithError(err).WithField("limit", label).Warn("Failed to check rate limit")
+			c.Next()
+			return
+		}
+		if limited {
+			metrics.RateLimitHits.WithLabelValues(label).Inc()
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": "Too many requests. Please slow down.",
+			})
+			return
+		}
+
+		if err := limiter.IncrementIPCounter(ctx, ip); err != nil {
+			log.WithError(err).WithField("limit", label).Warn("Failed to increment rate limit counter")
+		}
+		c.Next()
+	}
+}
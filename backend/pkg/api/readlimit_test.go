@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aura-chain/aura/faucet/pkg/database"
+	"github.com/aura-chain/aura/faucet/pkg/ratelimit"
+)
+
+func TestReadRateLimitMiddlewareLimitsStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dbConn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	h := NewHandler(defaultConfig(), &mockFaucet{}, nil, database.NewWithConn(dbConn))
+
+	limiter := ratelimit.NewMemoryLimiter(map[string]interface{}{
+		"per_ip":      3,
+		"per_address": 0,
+		"window":      time.Minute,
+	})
+	router := gin.New()
+	router.GET("/live", h.Live)
+	router.GET("/stats", ReadRateLimitMiddleware(limiter), h.GetStatistics)
+
+	get := func(path, remoteAddr string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		req.RemoteAddr = remoteAddr
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for i := 0; i < 3; i++ {
+		expectStatistics(mock)
+		assert.Equal(t, http.StatusOK, get("/stats", "192.0.2.1:1000"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, get("/stats", "192.0.2.1:1000"))
+	assert.Equal(t, http.StatusTooManyRequests, get("/stats", "192.0.2.1:1000"))
+
+	// Other clients and health probes are unaffected
+	expectStatistics(mock)
+	assert.Equal(t, http.StatusOK, get("/stats", "192.0.2.2:1000"))
+	assert.Equal(t, http.StatusOK, get("/live", "192.0.2.1:1000"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEmailRateLimitMiddlewareSharesBudget(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := ratelimit.NewMemoryLimiter(map[string]interface{}{
+		"per_ip":      2,
+		"per_address": 0,
+		"window":      time.Hour,
+	})
+	limit := EmailRateLimitMiddleware(limiter)
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router := gin.New()
+	router.POST("/email/start", limit, ok)
+	router.POST("/email/verify", limit, ok)
+
+	post := func(path string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, nil)
+		req.RemoteAddr = "192.0.2.1:1000"
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, post("/email/start"))
+	assert.Equal(t, http.StatusOK, post("/email/verify"))
+	assert.Equal(t, http.StatusTooManyRequests, post("/email/start"))
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aura-chain/aura/faucet/pkg/database"
+)
+
+// statsLoad is one in-flight statistics query that concurrent callers share
+type statsLoad struct {
+	done  chan struct{}
+	stats *database.Statistics
+	asOf  time.Time
+	err   error
+}
+
+// StatsCache holds the overall faucet statistics for a short TTL so that
+// /faucet/info and /faucet/stats don't run the statistics queries on
+// every scrape. Concurrent misses share a single query.
+type StatsCache struct {
+	load func() (*database.Statistics, error)
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu       sync.Mutex
+	stats    *database.Statistics
+	asOf     time.Time
+	inflight *statsLoad
+}
+
+// NewStatsCache creates a cache serving load's result for up to ttl
+func NewStatsCache(load func() (*database.Statistics, error), ttl time.Duration) *StatsCache {
+	return &StatsCache{load: load, ttl: ttl, now: time.Now}
+}
+
+// Get returns the statistics and when they were computed, querying them
+// when the cached copy is missing or older than the TTL
+func (sc *StatsCache) Get() (*database.Statistics, time.Time, error) {
+	sc.mu.Lock()
+	if sc.stats != nil && sc.now().Sub(sc.asOf) <= sc.ttl {
+		stats, asOf := sc.stats, sc.asOf
+		sc.mu.Unlock()
+		return stats, asOf, nil
+	}
+	sc.mu.Unlock()
+
+	return sc.refresh()
+}
+
+// refresh queries the statistics, joining a query already in flight
+func (sc *StatsCache) refresh() (*database.Statistics, time.Time, error) {
+	sc.mu.Lock()
+	if call := sc.inflight; call != nil {
+		sc.mu.Unlock()
+		<-call.done
+		return call.stats, call.asOf, call.err
+	}
+	call := &statsLoad{done: make(chan struct{})}
+	sc.inflight = call
+	sc.mu.Unlock()
+
+	call.stats, call.err = sc.load()
+	call.asOf = sc.now()
+
+	sc.mu.Lock()
+	if call.err == nil {
+		sc.stats, sc.asOf = call.stats, call.asOf
+	}
+	sc.inflight = nil
+	sc.mu.Unlock()
+	close(call.done)
+
+	return call.stats, call.asOf, call.err
+}
+
+// Run refreshes the statistics every TTL until ctx is cancelled, so
+// requests are normally served from cache
+func (sc *StatsCache) Run(ctx context.Context) {
+	ticker := time.NewTicker(sc.ttl)
+	defer ticker.Stop()
+
+	for {
+		if _, _, err := sc.refresh(); err != nil {
+			log.WithError(err).Debug("Failed to refresh cached statistics")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
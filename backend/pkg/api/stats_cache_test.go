@@ -0,0 +1,117 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aura-chain/aura/faucet/pkg/database"
+)
+
+func TestStatsCacheConcurrentRequestsQueryOnce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, mock := newHandlerWithDB(t, &mockFaucet{}, &mockRateLimiter{})
+	// The seven statistics queries are expected exactly once
+	expectStatistics(mock)
+	h.SetStatsCache(NewStatsCache(h.db.GetStatistics, time.Minute))
+
+	var wg sync.WaitGroup
+	codes := make([]int, 20)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest("GET", "/faucet/stats", nil)
+			h.GetStatistics(c)
+			codes[i] = w.Code
+		}(i)
+	}
+	wg.Wait()
+
+	for _, code := range codes {
+		assert.Equal(t, http.StatusOK, code)
+	}
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStatsCacheSingleFlight(t *testing.T) {
+	var loads atomic.Int32
+	release := make(chan struct{})
+	cache := NewStatsCache(func() (*database.Statistics, error) {
+		loads.Add(1)
+		<-release
+		return &database.Statistics{TotalRequests: 7}, nil
+	}, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stats, asOf, err := cache.Get()
+			assert.NoError(t, err)
+			assert.Equal(t, int64(7), stats.TotalRequests)
+			assert.False(t, asOf.IsZero())
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), loads.Load())
+}
+
+func TestStatsCacheExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	loads := 0
+	var loadErr error
+	cache := NewStatsCache(func() (*database.Statistics, error) {
+		loads++
+		return &database.Statistics{TotalRequests: int64(loads)}, loadErr
+	}, 10*time.Second)
+	cache.now = func() time.Time { return now }
+
+	stats, asOf, err := cache.Get()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.TotalRequests)
+	assert.Equal(t, now, asOf)
+
+	now = now.Add(5 * time.Second)
+	stats, _, _ = cache.Get()
+	assert.Equal(t, int64(1), stats.TotalRequests, "served from cache within the TTL")
+
+	now = now.Add(10 * time.Second)
+	stats, asOf, _ = cache.Get()
+	assert.Equal(t, int64(2), stats.TotalRequests, "queried again once expired")
+	assert.Equal(t, now, asOf)
+
+	now = now.Add(time.Minute)
+	loadErr = errors.New("database is down")
+	_, _, err = cache.Get()
+	assert.Error(t, err, "an expired entry is not served in place of an error")
+}
+
+func TestGetStatisticsIncludesAsOf(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, mock := newHandlerWithDB(t, &mockFaucet{}, &mockRateLimiter{})
+	expectStatistics(mock)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/faucet/stats", nil)
+	h.GetStatistics(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"as_of":"`)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
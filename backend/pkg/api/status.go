@@ -0,0 +1,110 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aura-chain/aura/faucet/pkg/database"
+	"github.com/aura-chain/aura/faucet/pkg/receipt"
+)
+
+// GetRequestStatus reports the status of a payout by transaction hash,
+// including its signed receipt once it has succeeded, or with
+// EXPOSE_REQUEST_ID by request id. Ids are sequential and easy to walk, so
+// an id lookup leaves out the recipient and the receipt that names it.
+func (h *Handler) GetRequestStatus(c *gin.Context) {
+	if h.db == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Database not configured",
+		})
+		return
+	}
+
+	var row *database.FaucetRequest
+	var err error
+	id, byID := h.requestIDKey(c.Param("tx_hash"))
+	if byID {
+		row, err = h.db.GetRequestStatusByID(id)
+	} else {
+		row, err = h.db.GetRequestByTxHash(c.Param("tx_hash"))
+	}
+	if errors.Is(err, database.ErrRequestNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Request not found",
+			"code":  "REQUEST_NOT_FOUND",
+		})
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("Failed to get request status")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get request status",
+		})
+		return
+	}
+
+	body := gin.H{
+		"tx_hash":    row.TxHash,
+		"status":     row.Status,
+		"amount":     row.Amount,
+		"denom":      h.rowDenom(row),
+		"created_at": row.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if h.cfg.ExposeRequestID {
+		body["request_id"] = row.ID
+	}
+	if row.CompletedAt != nil {
+		body["completed_at"] = row.CompletedAt.UTC().Format(time.RFC3339)
+	}
+	if !byID {
+		body["recipient"] = row.Recipient
+		if signed := h.issueReceipt(row); signed != nil {
+			body["receipt"] = signed
+		}
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// requestIDKey parses a status lookup key as a request id. Transaction
+// hashes are 64 hex digits, so a key that parses as an int64 is never one.
+func (h *Handler) requestIDKey(key string) (int64, bool) {
+	if !h.cfg.ExposeRequestID {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(key, 10, 64)
+	return id, err == nil && id > 0
+}
+
+// issueReceipt signs a receipt for a successful payout. It returns nil when
+// receipts are disabled or the payout hasn't completed.
+func (h *Handler) issueReceipt(row *database.FaucetRequest) *receipt.Signed {
+	if h.receipts == nil || row.Status != "success" || row.CompletedAt == nil {
+		return nil
+	}
+
+	chainID := h.cfg.ChainID
+	if route := h.routeForDenom(row.Denom); route != nil {
+		chainID = route.cfg.ChainID
+	}
+
+	signed, err := h.receipts.Sign(receipt.New(row.Recipient, row.Amount, h.rowDenom(row), row.TxHash, chainID, *row.CompletedAt))
+	if err != nil {
+		log.WithError(err).Error("Failed to sign receipt")
+		return nil
+	}
+	return signed
+}
+
+// rowDenom returns a request's denom; rows recorded before denoms were
+// stored belong to the default chain
+func (h *Handler) rowDenom(row *database.FaucetRequest) string {
+	if row.Denom == "" {
+		return h.cfg.Denom
+	}
+	return row.Denom
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+const (
+	// maxRequestBodyBytes bounds the token request body before decoding
+	maxRequestBodyBytes = 16 << 10
+
+	maxAddressLength      = 128
+	maxChainIDLength      = 64
+	maxCaptchaTokenLength = 2048
+	maxPoWFieldLength     = 128
+	maxSourceLength       = 64
+	// maxMemoLength matches the Cosmos SDK default MaxMemoCharacters
+	maxMemoLength = 256
+)
+
+// FieldError describes a single invalid field in a request body
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// decodeTokenRequest strictly decodes a token request: unknown fields and
+// trailing data are rejected, and field lengths are bounded. ShouldBindJSON
+// only checks required tags, so oversized values would otherwise reach the
+// address validation and database layers.
+func decodeTokenRequest(body io.Reader) (*TokenRequest, []FieldError) {
+	decoder := json.NewDecoder(io.LimitReader(body, maxRequestBodyBytes+1))
+	decoder.DisallowUnknownFields()
+
+	var req TokenRequest
+	if err := decoder.Decode(&req); err != nil {
+		return nil, []FieldError{decodeFieldError(err)}
+	}
+	if decoder.More() {
+		return nil, []FieldError{{Field: "body", Message: "must contain a single JSON object"}}
+	}
+
+	return &req, validateTokenRequest(&req)
+}
+
+// decodeFieldError maps a JSON decoding error onto the offending field
+func decodeFieldError(err error) FieldError {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return FieldError{Field: typeErr.Field, Message: fmt.Sprintf("must be a %s", typeErr.Type.Kind())}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return FieldError{Field: field, Message: "is not a recognized field"}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return FieldError{Field: "body", Message: fmt.Sprintf("must be valid JSON (or at most %d bytes)", maxRequestBodyBytes)}
+	case errors.Is(err, io.EOF):
+		return FieldError{Field: "body", Message: "is required"}
+	default:
+		return FieldError{Field: "body", Message: "must be a JSON object"}
+	}
+}
+
+// validateTokenRequest checks required fields and length limits
+func validateTokenRequest(req *TokenRequest) []FieldError {
+	var errs []FieldError
+
+	if req.Address == "" {
+		errs = append(errs, FieldError{Field: "address", Message: "is required"})
+	} else if len(req.Address) > maxAddressLength {
+		errs = append(errs, FieldError{Field: "address", Message: fmt.Sprintf("must be at most %d characters", maxAddressLength)})
+	}
+
+	if len(req.ChainID) > maxChainIDLength {
+		errs = append(errs, FieldError{Field: "chain_id", Message: fmt.Sprintf("must be at most %d characters", maxChainIDLength)})
+	}
+
+	// Presence of challenge fields is enforced by the handler, which knows
+	// whether captcha and proof of work are required
+	if len(req.CaptchaToken) > maxCaptchaTokenLength {
+		errs = append(errs, FieldError{Field: "captcha_token", Message: fmt.Sprintf("must be at most %d characters", maxCaptchaTokenLength)})
+	}
+	if len(req.PoWChallengeID) > maxPoWFieldLength {
+		errs = append(errs, FieldError{Field: "pow_challenge_id", Message: fmt.Sprintf("must be at most %d characters", maxPoWFieldLength)})
+	}
+	if len(req.PoWSolution) > maxPoWFieldLength {
+		errs = append(errs, FieldError{Field: "pow_solution", Message: fmt.Sprintf("must be at most %d characters", maxPoWFieldLength)})
+	}
+
+	if len(req.Nonce) > maxPoWFieldLength {
+		errs = append(errs, FieldError{Field: "nonce", Message: fmt.Sprintf("must be at most %d characters", maxPoWFieldLength)})
+	}
+
+	if len(req.EmailToken) > maxEmailTokenLength {
+		errs = append(errs, FieldError{Field: "email_token", Message: fmt.Sprintf("must be at most %d characters", maxEmailTokenLength)})
+	}
+
+	if len(req.Source) > maxSourceLength {
+		errs = append(errs, FieldError{Field: "source", Message: fmt.Sprintf("must be at most %d characters", maxSourceLength)})
+	}
+
+	req.Memo = sanitizeMemo(req.Memo)
+	if len(req.Memo) > maxMemoLength {
+		errs = append(errs, FieldError{Field: "memo", Message: fmt.Sprintf("must be at most %d characters", maxMemoLength)})
+	}
+
+	return errs
+}
+
+// sanitizeMemo drops control characters, which would end up verbatim in
+// the CLI arguments and the on-chain transaction, and trims surrounding
+// whitespace
+func sanitizeMemo(memo string) string {
+	memo = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, memo)
+	return strings.TrimSpace(memo)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeTokenRequest(t *testing.T) {
+	t.Run("accepts valid body", func(t *testing.T) {
+		req, errs := decodeTokenRequest(strings.NewReader(`{"address":"aura1ok","captcha_token":"tok"}`))
+		require.Empty(t, errs)
+		assert.Equal(t, "aura1ok", req.Address)
+	})
+
+	t.Run("rejects overly long address", func(t *testing.T) {
+		body := `{"address":"` + strings.Repeat("a", 10000) + `","captcha_token":"tok"}`
+		_, errs := decodeTokenRequest(strings.NewReader(body))
+		require.Len(t, errs, 1)
+		assert.Equal(t, "address", errs[0].Field)
+		assert.Equal(t, "must be at most 128 characters", errs[0].Message)
+	})
+
+	t.Run("rejects overly long captcha token", func(t *testing.T) {
+		body := `{"address":"aura1ok","captcha_token":"` + strings.Repeat("t", 3000) + `"}`
+		_, errs := decodeTokenRequest(strings.NewReader(body))
+		require.Len(t, errs, 1)
+		assert.Equal(t, "captcha_token", errs[0].Field)
+	})
+
+	t.Run("rejects unexpected fields", func(t *testing.T) {
+		_, errs := decodeTokenRequest(strings.NewReader(`{"address":"aura1ok","captcha_token":"tok","amount":999}`))
+		require.Len(t, errs, 1)
+		assert.Equal(t, FieldError{Field: "amount", Message: "is not a recognized field"}, errs[0])
+	})
+
+	t.Run("reports wrong types", func(t *testing.T) {
+		_, errs := decodeTokenRequest(strings.NewReader(`{"address":123,"captcha_token":"tok"}`))
+		require.Len(t, errs, 1)
+		assert.Equal(t, FieldError{Field: "address", Message: "must be a string"}, errs[0])
+	})
+
+	t.Run("requires address", func(t *testing.T) {
+		_, errs := decodeTokenRequest(strings.NewReader(`{}`))
+		assert.Equal(t, []FieldError{{Field: "address", Message: "is required"}}, errs)
+	})
+
+	t.Run("sanitizes and bounds memo", func(t *testing.T) {
+		req, errs := decodeTokenRequest(strings.NewReader(`{"address":"aura1ok","memo":" ref\u0000-1\n "}`))
+		require.Empty(t, errs)
+		assert.Equal(t, "ref-1", req.Memo)
+
+		_, errs = decodeTokenRequest(strings.NewReader(`{"address":"aura1ok","memo":"` + strings.Repeat("m", 300) + `"}`))
+		assert.Equal(t, []FieldError{{Field: "memo", Message: "must be at most 256 characters"}}, errs)
+	})
+
+	t.Run("rejects trailing data", func(t *testing.T) {
+		_, errs := decodeTokenRequest(strings.NewReader(`{"address":"a","captcha_token":"t"}{}`))
+		require.Len(t, errs, 1)
+		assert.Equal(t, "body", errs[0].Field)
+	})
+}
+
+func TestRequestTokensReturnsFieldErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := newTestHandler(defaultConfig(), &mockFaucet{}, &mockRateLimiter{})
+
+	req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"address":"aura1ok","captcha_token":"tok","admin":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	h.RequestTokens(c)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var resp struct {
+		Error  string       `json:"error"`
+		Fields []FieldError `json:"fields"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []FieldError{{Field: "admin", Message: "is not a recognized field"}}, resp.Fields)
+}
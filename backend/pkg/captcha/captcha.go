@@ -11,17 +11,20 @@ import (
 	"io"
 	"math"
 	"math/big"
+	"strings"
 	"sync"
 	"time"
 
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/basicfont"
 	"golang.org/x/image/math/fixed"
+
+	"github.com/aura-chain/aura/faucet/pkg/challenge"
 )
 
 // CaptchaService manages CAPTCHA generation and validation
 type CaptchaService struct {
-	store   *CaptchaStore
+	store   challenge.Store[*CaptchaData]
 	mu      sync.RWMutex
 	options CaptchaOptions
 }
@@ -33,73 +36,41 @@ type CaptchaOptions struct {
 	Height     int
 	TTL        time.Duration
 	Difficulty string // "easy", "medium", "hard"
-}
 
-// CaptchaData represents a CAPTCHA challenge
-type CaptchaData struct {
-	ID        string
-	Solution  string
-	ImageData []byte
-	CreatedAt time.Time
-	ExpiresAt time.Time
-}
+	// Risk tiers used by GenerateForRisk; scores at or above a threshold
+	// select that tier, and the result is clamped to [MinDifficulty, MaxDifficulty]
+	MediumRiskScore int
+	HighRiskScore   int
+	MinDifficulty   string
+	MaxDifficulty   string
 
-// CaptchaStore manages CAPTCHA storage
-type CaptchaStore struct {
-	captchas map[string]*CaptchaData
-	mu       sync.RWMutex
-}
+	// CaseInsensitive compares solutions with strings.EqualFold; nil means true
+	CaseInsensitive *bool
 
-// NewCaptchaStore creates a new CAPTCHA store
-func NewCaptchaStore() *CaptchaStore {
-	store := &CaptchaStore{
-		captchas: make(map[string]*CaptchaData),
-	}
-
-	// Start cleanup goroutine
-	go store.cleanup()
+	// FontScale multiplies the 7x13 bitmap font; glyphs are randomly drawn
+	// at FontScale or FontScale+1
+	FontScale int
 
-	return store
-}
-
-// cleanup periodically removes expired CAPTCHAs
-func (s *CaptchaStore) cleanup() {
-	ticker := time.NewTicker(5 * time.Minute)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		s.mu.Lock()
-		now := time.Now()
-		for id, captcha := range s.captchas {
-			if now.After(captcha.ExpiresAt) {
-				delete(s.captchas, id)
-			}
-		}
-		s.mu.Unlock()
-	}
-}
+	// Limits caps unclaimed CAPTCHAs per client and in total
+	Limits challenge.Limits
 
-// Set stores a CAPTCHA
-func (s *CaptchaStore) Set(captcha *CaptchaData) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.captchas[captcha.ID] = captcha
+	// MaxSolutionAge refuses solutions to CAPTCHAs issued longer ago,
+	// however long the store keeps them; zero disables the check
+	MaxSolutionAge time.Duration
 }
 
-// Get retrieves a CAPTCHA
-func (s *CaptchaStore) Get(id string) (*CaptchaData, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	captcha, ok := s.captchas[id]
-	return captcha, ok
+// CaptchaData represents a CAPTCHA challenge
+type CaptchaData struct {
+	ID         string
+	Solution   string
+	Difficulty string
+	ImageData  []byte
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
 }
 
-// Delete removes a CAPTCHA
-func (s *CaptchaStore) Delete(id string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	delete(s.captchas, id)
-}
+// difficultyLevels orders the supported difficulties from easiest to hardest
+var difficultyLevels = []string{"easy", "medium", "hard"}
 
 // NewCaptchaService creates a new CAPTCHA service
 func NewCaptchaService(options CaptchaOptions) *CaptchaService {
@@ -118,15 +89,90 @@ func NewCaptchaService(options CaptchaOptions) *CaptchaService {
 	if options.Difficulty == "" {
 		options.Difficulty = "medium"
 	}
+	if options.MediumRiskScore == 0 {
+		options.MediumRiskScore = 25
+	}
+	if options.HighRiskScore == 0 {
+		options.HighRiskScore = 50
+	}
+	if options.MinDifficulty == "" {
+		options.MinDifficulty = "easy"
+	}
+	if options.MaxDifficulty == "" {
+		options.MaxDifficulty = "hard"
+	}
+	if options.FontScale == 0 {
+		options.FontScale = 3
+	}
+	if options.CaseInsensitive == nil {
+		caseInsensitive := true
+		options.CaseInsensitive = &caseInsensitive
+	}
+
+	store := challenge.NewMemoryStore[*CaptchaData](0)
+	store.SetLimits(options.Limits)
 
 	return &CaptchaService{
-		store:   NewCaptchaStore(),
+		store:   store,
 		options: options,
 	}
 }
 
-// Generate creates a new CAPTCHA
+// Close stops the background work of the service's CAPTCHA store
+func (s *CaptchaService) Close() {
+	s.store.Close()
+}
+
+// Generate creates a new CAPTCHA at the configured difficulty
 func (s *CaptchaService) Generate() (*CaptchaData, error) {
+	return s.generate("", s.options.Difficulty)
+}
+
+// GenerateForRisk creates a new CAPTCHA whose difficulty scales with the
+// abuse detector risk score of the caller
+func (s *CaptchaService) GenerateForRisk(riskScore int) (*CaptchaData, error) {
+	return s.generate("", s.DifficultyForRisk(riskScore))
+}
+
+// GenerateForClient is GenerateForRisk on behalf of client (typically its
+// IP). It returns challenge.ErrTooManyOutstanding when the client or the
+// service holds too many unsolved CAPTCHAs.
+func (s *CaptchaService) GenerateForClient(client string, riskScore int) (*CaptchaData, error) {
+	return s.generate(client, s.DifficultyForRisk(riskScore))
+}
+
+// DifficultyForRisk maps a risk score to a difficulty tier, clamped to the
+// configured minimum and maximum
+func (s *CaptchaService) DifficultyForRisk(riskScore int) string {
+	difficulty := "easy"
+	switch {
+	case riskScore >= s.options.HighRiskScore:
+		difficulty = "hard"
+	case riskScore >= s.options.MediumRiskScore:
+		difficulty = "medium"
+	}
+
+	level := difficultyLevel(difficulty)
+	if floor := difficultyLevel(s.options.MinDifficulty); level < floor {
+		level = floor
+	}
+	if ceiling := difficultyLevel(s.options.MaxDifficulty); level > ceiling {
+		level = ceiling
+	}
+	return difficultyLevels[level]
+}
+
+// difficultyLevel returns the index of a difficulty, treating unknown values as medium
+func difficultyLevel(difficulty string) int {
+	for i, level := range difficultyLevels {
+		if level == difficulty {
+			return i
+		}
+	}
+	return 1
+}
+
+func (s *CaptchaService) generate(client, difficulty string) (*CaptchaData, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -137,64 +183,58 @@ func (s *CaptchaService) Generate() (*CaptchaData, error) {
 	}
 
 	// Generate solution
-	solution, err := s.generateSolution()
+	solution, err := s.generateSolution(difficulty)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate solution: %w", err)
 	}
 
 	// Generate image
-	imageData, err := s.generateImage(solution)
+	imageData, err := s.generateImage(solution, difficulty)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate image: %w", err)
 	}
 
 	now := time.Now()
 	captcha := &CaptchaData{
-		ID:        id,
-		Solution:  solution,
-		ImageData: imageData,
-		CreatedAt: now,
-		ExpiresAt: now.Add(s.options.TTL),
+		ID:         id,
+		Solution:   solution,
+		Difficulty: difficulty,
+		ImageData:  imageData,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(s.options.TTL),
 	}
 
-	s.store.Set(captcha)
+	if err := s.store.PutFor(client, captcha.ID, captcha, captcha.ExpiresAt); err != nil {
+		return nil, err
+	}
 
 	return captcha, nil
 }
 
 // Validate checks if a CAPTCHA solution is correct
 func (s *CaptchaService) Validate(id, solution string) bool {
-	captcha, ok := s.store.Get(id)
+	// Taking the CAPTCHA makes it one-time use, right or wrong
+	captcha, ok := s.store.Take(id)
 	if !ok {
 		return false
 	}
 
 	// Check expiration
-	if time.Now().After(captcha.ExpiresAt) {
-		s.store.Delete(id)
+	now := time.Now()
+	if now.After(captcha.ExpiresAt) || challenge.TooOld(captcha.CreatedAt, s.options.MaxSolutionAge, now) {
 		return false
 	}
 
-	// Check solution (case-insensitive)
-	valid := captcha.Solution == solution
-
-	// Delete after validation (one-time use)
-	s.store.Delete(id)
-
-	return valid
+	// Check solution
+	if *s.options.CaseInsensitive {
+		return strings.EqualFold(captcha.Solution, solution)
+	}
+	return captcha.Solution == solution
 }
 
 // generateSolution creates a random CAPTCHA solution
-func (s *CaptchaService) generateSolution() (string, error) {
-	var chars string
-	switch s.options.Difficulty {
-	case "easy":
-		chars = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // No confusing chars
-	case "hard":
-		chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
-	default: // medium
-		chars = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
-	}
+func (s *CaptchaService) generateSolution(difficulty string) (string, error) {
+	chars := charsetForDifficulty(difficulty)
 
 	result := make([]byte, s.options.Length)
 	for i := range result {
@@ -208,8 +248,20 @@ func (s *CaptchaService) generateSolution() (string, error) {
 	return string(result), nil
 }
 
+// charsetForDifficulty returns the solution alphabet for a difficulty
+func charsetForDifficulty(difficulty string) string {
+	switch difficulty {
+	case "easy":
+		return "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // No confusing chars
+	case "hard":
+		return "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	default: // medium
+		return "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	}
+}
+
 // generateImage creates a CAPTCHA image
-func (s *CaptchaService) generateImage(text string) ([]byte, error) {
+func (s *CaptchaService) generateImage(text, difficulty string) ([]byte, error) {
 	// Create image
 	img := image.NewRGBA(image.Rect(0, 0, s.options.Width, s.options.Height))
 
@@ -228,7 +280,7 @@ func (s *CaptchaService) generateImage(text string) ([]byte, error) {
 	}
 
 	// Add distortion based on difficulty
-	if s.options.Difficulty == "hard" {
+	if difficulty == "hard" {
 		s.addDistortion(img)
 	}
 
@@ -286,10 +338,12 @@ func (s *CaptchaService) addNoise(img *image.RGBA) error {
 	return nil
 }
 
-// drawText renders the CAPTCHA text
+// drawText renders the CAPTCHA text, scaling each glyph up from the
+// 7x13 bitmap font by FontScale plus a random extra step for size variety
 func (s *CaptchaService) drawText(img *image.RGBA, text string) error {
 	bounds := img.Bounds()
 	charWidth := bounds.Max.X / len(text)
+	face := basicfont.Face7x13
 
 	for i, ch := range text {
 		// Calculate position with some randomness
@@ -301,9 +355,11 @@ func (s *CaptchaService) drawText(img *image.RGBA, text string) error {
 		if err != nil {
 			return err
 		}
-
-		x := i*charWidth + int(offsetX.Int64())
-		y := bounds.Max.Y/2 + int(offsetY.Int64())
+		extraScale, err := rand.Int(rand.Reader, big.NewInt(2))
+		if err != nil {
+			return err
+		}
+		scale := s.options.FontScale + int(extraScale.Int64())
 
 		// Random color (dark)
 		rVal, _ := rand.Int(rand.Reader, big.NewInt(128))
@@ -317,25 +373,46 @@ func (s *CaptchaService) drawText(img *image.RGBA, text string) error {
 			A: 255,
 		}
 
-		// Draw character
-		point := fixed.Point26_6{
-			X: fixed.Int26_6(x * 64),
-			Y: fixed.Int26_6(y * 64),
-		}
-
+		// Render the glyph at native size, then scale it onto the canvas
+		glyph := image.NewRGBA(image.Rect(0, 0, face.Advance, face.Height))
 		d := &font.Drawer{
-			Dst:  img,
+			Dst:  glyph,
 			Src:  image.NewUniform(textColor),
-			Face: basicfont.Face7x13,
-			Dot:  point,
+			Face: face,
+			Dot:  fixed.P(0, face.Ascent),
 		}
-
 		d.DrawString(string(ch))
+
+		x := i*charWidth + int(offsetX.Int64())
+		y := (bounds.Max.Y-face.Height*scale)/2 + int(offsetY.Int64()) - 5
+		drawScaled(img, glyph, x, y, scale)
 	}
 
 	return nil
 }
 
+// drawScaled copies the opaque pixels of src onto dst at (x, y) using
+// nearest-neighbor scaling
+func drawScaled(dst *image.RGBA, src *image.RGBA, x, y, scale int) {
+	srcBounds := src.Bounds()
+	for sy := srcBounds.Min.Y; sy < srcBounds.Max.Y; sy++ {
+		for sx := srcBounds.Min.X; sx < srcBounds.Max.X; sx++ {
+			c := src.RGBAAt(sx, sy)
+			if c.A == 0 {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					px, py := x+sx*scale+dx, y+sy*scale+dy
+					if (image.Point{X: px, Y: py}).In(dst.Bounds()) {
+						dst.SetRGBA(px, py, c)
+					}
+				}
+			}
+		}
+	}
+}
+
 // drawLine draws a line on the image
 func (s *CaptchaService) drawLine(img *image.RGBA, x1, y1, x2, y2 int) {
 	lineColor := color.RGBA{R: 200, G: 200, B: 200, A: 255}
@@ -363,10 +440,33 @@ func (s *CaptchaService) drawLine(img *image.RGBA, x1, y1, x2, y2 int) {
 	}
 }
 
-// addDistortion adds wave distortion to the image
+// addDistortion applies a sine-wave displacement to the image, shifting each
+// column vertically and each row horizontally by a random-phase wave
 func (s *CaptchaService) addDistortion(img *image.RGBA) {
-	// Simple sine wave distortion
-	// In production, you'd implement more sophisticated distortion
+	bounds := img.Bounds()
+	src := image.NewRGBA(bounds)
+	draw.Draw(src, bounds, img, bounds.Min, draw.Src)
+
+	phase := 0.0
+	if n, err := rand.Int(rand.Reader, big.NewInt(1000)); err == nil {
+		phase = float64(n.Int64()) / 1000 * 2 * math.Pi
+	}
+
+	amplitude := float64(s.options.Height) / 16
+	period := float64(s.options.Width) / 2
+	background := color.RGBA{R: 240, G: 240, B: 245, A: 255}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			srcY := y + int(amplitude*math.Sin(2*math.Pi*float64(x)/period+phase))
+			srcX := x + int(amplitude/2*math.Sin(2*math.Pi*float64(y)/period+phase))
+			if (image.Point{X: srcX, Y: srcY}).In(bounds) {
+				img.SetRGBA(x, y, src.RGBAAt(srcX, srcY))
+			} else {
+				img.SetRGBA(x, y, background)
+			}
+		}
+	}
 }
 
 // generateRandomString generates a random string
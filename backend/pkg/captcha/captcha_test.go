@@ -1,6 +1,11 @@
 package captcha
 
 import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
 	"testing"
 	"time"
 
@@ -41,3 +46,107 @@ func TestCaptchaExpiration(t *testing.T) {
 	valid := svc.Validate(captcha.ID, captcha.Solution)
 	assert.False(t, valid)
 }
+
+func TestGenerateForRiskSelectsTier(t *testing.T) {
+	svc := NewCaptchaService(CaptchaOptions{
+		Length:          8,
+		MediumRiskScore: 25,
+		HighRiskScore:   50,
+	})
+
+	assert.Equal(t, "easy", svc.DifficultyForRisk(0))
+	assert.Equal(t, "medium", svc.DifficultyForRisk(30))
+	assert.Equal(t, "hard", svc.DifficultyForRisk(80))
+
+	captcha, err := svc.GenerateForRisk(80)
+	require.NoError(t, err)
+	assert.Equal(t, "hard", captcha.Difficulty)
+	hardChars := charsetForDifficulty("hard")
+	for _, ch := range captcha.Solution {
+		assert.True(t, strings.ContainsRune(hardChars, ch))
+	}
+	assert.True(t, svc.Validate(captcha.ID, captcha.Solution))
+}
+
+func TestGenerateForRiskClampsToBounds(t *testing.T) {
+	svc := NewCaptchaService(CaptchaOptions{
+		MinDifficulty: "medium",
+		MaxDifficulty: "medium",
+	})
+
+	assert.Equal(t, "medium", svc.DifficultyForRisk(0))
+	assert.Equal(t, "medium", svc.DifficultyForRisk(100))
+}
+
+func TestValidateCaseSensitivity(t *testing.T) {
+	t.Run("case-insensitive by default", func(t *testing.T) {
+		svc := NewCaptchaService(CaptchaOptions{Difficulty: "easy"})
+		captcha, err := svc.Generate()
+		require.NoError(t, err)
+		assert.True(t, svc.Validate(captcha.ID, strings.ToLower(captcha.Solution)))
+	})
+
+	t.Run("exact when disabled", func(t *testing.T) {
+		caseInsensitive := false
+		svc := NewCaptchaService(CaptchaOptions{Difficulty: "easy", CaseInsensitive: &caseInsensitive})
+		captcha, err := svc.Generate()
+		require.NoError(t, err)
+		// Force letters so lowercasing always changes the answer
+		captcha.Solution = "AB" + captcha.Solution[2:]
+		assert.False(t, svc.Validate(captcha.ID, strings.ToLower(captcha.Solution)))
+	})
+}
+
+func TestGeneratedImageIsPNGOfConfiguredSize(t *testing.T) {
+	for _, difficulty := range []string{"easy", "hard"} {
+		svc := NewCaptchaService(CaptchaOptions{Width: 240, Height: 90, Difficulty: difficulty})
+		captcha, err := svc.Generate()
+		require.NoError(t, err)
+
+		img, err := png.Decode(bytes.NewReader(captcha.ImageData))
+		require.NoError(t, err)
+		assert.Equal(t, 240, img.Bounds().Dx())
+		assert.Equal(t, 90, img.Bounds().Dy())
+	}
+}
+
+func TestAddDistortionChangesPixels(t *testing.T) {
+	svc := NewCaptchaService(CaptchaOptions{Width: 200, Height: 80})
+
+	img := image.NewRGBA(image.Rect(0, 0, 200, 80))
+	black := color.RGBA{A: 255}
+	for x := 0; x < 200; x++ {
+		img.SetRGBA(x, 40, black)
+	}
+	before := image.NewRGBA(img.Bounds())
+	copy(before.Pix, img.Pix)
+
+	svc.addDistortion(img)
+
+	assert.NotEqual(t, before.Pix, img.Pix)
+	assert.Equal(t, before.Bounds(), img.Bounds())
+}
+
+func TestValidateRejectsSolutionPastMaxAge(t *testing.T) {
+	for _, tc := range []struct {
+		age   time.Duration
+		valid bool
+	}{
+		{age: 59 * time.Second, valid: true},
+		{age: 61 * time.Second, valid: false},
+	} {
+		svc := NewCaptchaService(CaptchaOptions{
+			Length:         4,
+			TTL:            time.Hour,
+			MaxSolutionAge: time.Minute,
+		})
+		captcha, err := svc.Generate()
+		require.NoError(t, err)
+
+		// Backdate the issue time; the store would keep it for an hour
+		captcha.CreatedAt = time.Now().Add(-tc.age)
+
+		assert.Equal(t, tc.valid, svc.Validate(captcha.ID, captcha.Solution), "solved %s after issue", tc.age)
+		svc.Close()
+	}
+}
@@ -0,0 +1,89 @@
+package challenge
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotFound is returned for unknown, expired or already used nonces
+var ErrNotFound = errors.New("challenge not found")
+
+// RequestNonce is a server-issued value a client sends back with exactly
+// one payout request, so a captured request cannot be replayed
+type RequestNonce struct {
+	Nonce     string    `json:"nonce"`
+	CreatedAt time.Time `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Nonces issues and redeems single-use request nonces. Unlike an
+// idempotency key, which returns the original result to a repeat, a
+// redeemed nonce is refused outright.
+type Nonces struct {
+	store   Store[*RequestNonce]
+	ttl     time.Duration
+	maxSkew time.Duration
+	now     func() time.Time
+}
+
+// NewNonces creates a request nonce service backed by store; a zero ttl
+// defaults to five minutes
+func NewNonces(store Store[*RequestNonce], ttl time.Duration) *Nonces {
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	return &Nonces{store: store, ttl: ttl, now: time.Now}
+}
+
+// SetMaxClockSkew sets how long past ExpiresAt a nonce is still accepted,
+// for clients whose clocks run behind. It applies to nonces issued
+// afterwards.
+func (n *Nonces) SetMaxClockSkew(d time.Duration) {
+	n.maxSkew = d
+}
+
+// Issue creates a nonce on behalf of owner (typically the client IP). It
+// returns ErrTooManyOutstanding when the owner or the store is at its limit.
+func (n *Nonces) Issue(owner string) (*RequestNonce, error) {
+	value, err := randomHex(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	now := n.now()
+	nonce := &RequestNonce{
+		Nonce:     value,
+		CreatedAt: now,
+		ExpiresAt: now.Add(n.ttl),
+	}
+	if err := n.store.PutFor(owner, value, nonce, nonce.ExpiresAt.Add(n.maxSkew)); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+// Redeem consumes nonce. It returns ErrNotFound for nonces that were never
+// issued, have expired or were already redeemed.
+func (n *Nonces) Redeem(nonce string) error {
+	if _, ok := n.store.Take(nonce); !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Close releases the underlying store
+func (n *Nonces) Close() {
+	n.store.Close()
+}
+
+// randomHex returns n random bytes hex encoded
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,49 @@
+package challenge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestNonceIsSingleUse(t *testing.T) {
+	nonces := NewNonces(NewMemoryStore[*RequestNonce](0), time.Minute)
+	defer nonces.Close()
+
+	nonce, err := nonces.Issue("1.2.3.4")
+	require.NoError(t, err)
+
+	assert.NoError(t, nonces.Redeem(nonce.Nonce), "a fresh nonce is accepted")
+	assert.ErrorIs(t, nonces.Redeem(nonce.Nonce), ErrNotFound, "a reused nonce is rejected")
+	assert.ErrorIs(t, nonces.Redeem("never-issued"), ErrNotFound)
+}
+
+func TestRequestNonceExpires(t *testing.T) {
+	store := NewMemoryStore[*RequestNonce](0)
+	nonces := NewNonces(store, time.Minute)
+	defer nonces.Close()
+
+	nonce, err := nonces.Issue("1.2.3.4")
+	require.NoError(t, err)
+
+	store.now = func() time.Time { return nonce.ExpiresAt.Add(time.Second) }
+	assert.ErrorIs(t, nonces.Redeem(nonce.Nonce), ErrNotFound)
+}
+
+func TestRequestNonceLimitsOutstanding(t *testing.T) {
+	store := NewMemoryStore[*RequestNonce](0)
+	store.SetLimits(Limits{PerOwner: 1})
+	nonces := NewNonces(store, time.Minute)
+	defer nonces.Close()
+
+	nonce, err := nonces.Issue("1.2.3.4")
+	require.NoError(t, err)
+	_, err = nonces.Issue("1.2.3.4")
+	assert.ErrorIs(t, err, ErrTooManyOutstanding)
+
+	require.NoError(t, nonces.Redeem(nonce.Nonce))
+	_, err = nonces.Issue("1.2.3.4")
+	assert.NoError(t, err, "a redeemed nonce frees its slot")
+}
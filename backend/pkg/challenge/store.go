@@ -0,0 +1,232 @@
+package challenge
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultSweepInterval is how often a MemoryStore drops expired entries
+const DefaultSweepInterval = time.Minute
+
+// ErrTooManyOutstanding is returned by PutFor when storing another
+// challenge would exceed the owner's or the store's limit
+var ErrTooManyOutstanding = errors.New("too many outstanding challenges")
+
+// TooOld reports whether a challenge created at createdAt is older than
+// maxAge at now. It is checked when a solution is validated, independent of
+// store expiry, so a stale solution is refused even if the entry lingers;
+// a zero maxAge disables the check.
+func TooOld(createdAt time.Time, maxAge time.Duration, now time.Time) bool {
+	return maxAge > 0 && now.Sub(createdAt) > maxAge
+}
+
+// Limits caps unclaimed challenges; zero disables a limit
+type Limits struct {
+	PerOwner int // per owner, e.g. client IP
+	Total    int // across all owners
+}
+
+// Store holds short-lived challenges keyed by ID. Expired entries are
+// never returned, and Take removes an entry so it can be redeemed once.
+type Store[T any] interface {
+	// Put stores value under id until expiresAt, replacing any entry
+	Put(id string, value T, expiresAt time.Time)
+	// PutFor is Put on behalf of owner, enforcing the store's Limits
+	PutFor(owner, id string, value T, expiresAt time.Time) error
+	// SetLimits sets the caps PutFor enforces
+	SetLimits(limits Limits)
+	// Get returns the unexpired value for id without consuming it
+	Get(id string) (T, bool)
+	// Take returns the unexpired value for id and removes it. Of several
+	// concurrent callers only one receives the value.
+	Take(id string) (T, bool)
+	// Delete removes id if present
+	Delete(id string)
+	// Len reports how many entries are held, expired or not
+	Len() int
+	// Close releases background resources
+	Close()
+}
+
+type entry[T any] struct {
+	value     T
+	owner     string
+	expiresAt time.Time
+}
+
+// MemoryStore is an in-process Store with a background sweep of expired
+// entries
+type MemoryStore[T any] struct {
+	entries map[string]entry[T]
+	owners  map[string]int // outstanding entries per owner
+	limits  Limits
+	mu      sync.Mutex
+	now     func() time.Time
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewMemoryStore creates a MemoryStore sweeping every interval; zero uses
+// DefaultSweepInterval
+func NewMemoryStore[T any](interval time.Duration) *MemoryStore[T] {
+	if interval <= 0 {
+		interval = DefaultSweepInterval
+	}
+
+	s := &MemoryStore[T]{
+		entries: make(map[string]entry[T]),
+		owners:  make(map[string]int),
+		now:     time.Now,
+		done:    make(chan struct{}),
+	}
+
+	go s.sweep(interval)
+
+	return s
+}
+
+// sweep periodically removes expired entries
+func (s *MemoryStore[T]) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		s.pruneExpired()
+		s.mu.Unlock()
+	}
+}
+
+// SetLimits sets the caps PutFor enforces
+func (s *MemoryStore[T]) SetLimits(limits Limits) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limits = limits
+}
+
+// Close stops the expired entry sweep
+func (s *MemoryStore[T]) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// Put stores value under id until expiresAt
+func (s *MemoryStore[T]) Put(id string, value T, expiresAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store(entry[T]{value: value, expiresAt: expiresAt}, id)
+}
+
+// PutFor stores value for owner unless the owner or the store is at its
+// limit. Expired entries are pruned before refusing, so abandoned
+// challenges never hold a slot past their expiry.
+func (s *MemoryStore[T]) PutFor(owner, id string, value T, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.atLimit(owner) {
+		s.pruneExpired()
+		if s.atLimit(owner) {
+			return ErrTooManyOutstanding
+		}
+	}
+	s.store(entry[T]{value: value, owner: owner, expiresAt: expiresAt}, id)
+	return nil
+}
+
+// Get returns the unexpired value for id
+func (s *MemoryStore[T]) Get(id string) (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lookup(id)
+}
+
+// Take returns the unexpired value for id and removes it
+func (s *MemoryStore[T]) Take(id string) (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.lookup(id)
+	s.remove(id)
+	return value, ok
+}
+
+// Delete removes id
+func (s *MemoryStore[T]) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(id)
+}
+
+// Len reports how many entries are held
+func (s *MemoryStore[T]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// lookup returns the entry for id, dropping it if expired. Callers hold mu.
+func (s *MemoryStore[T]) lookup(id string) (T, bool) {
+	var zero T
+	e, ok := s.entries[id]
+	if !ok {
+		return zero, false
+	}
+	if s.now().After(e.expiresAt) {
+		s.remove(id)
+		return zero, false
+	}
+	return e.value, true
+}
+
+// atLimit reports whether owner may not store another entry. Callers hold mu.
+func (s *MemoryStore[T]) atLimit(owner string) bool {
+	if s.limits.Total > 0 && len(s.entries) >= s.limits.Total {
+		return true
+	}
+	return owner != "" && s.limits.PerOwner > 0 && s.owners[owner] >= s.limits.PerOwner
+}
+
+// store adds e under id, replacing any entry. Callers hold mu.
+func (s *MemoryStore[T]) store(e entry[T], id string) {
+	s.remove(id)
+	s.entries[id] = e
+	if e.owner != "" {
+		s.owners[e.owner]++
+	}
+}
+
+// remove deletes id and releases its owner's slot. Callers hold mu.
+func (s *MemoryStore[T]) remove(id string) {
+	e, ok := s.entries[id]
+	if !ok {
+		return
+	}
+	delete(s.entries, id)
+	if e.owner != "" {
+		if s.owners[e.owner] <= 1 {
+			delete(s.owners, e.owner)
+		} else {
+			s.owners[e.owner]--
+		}
+	}
+}
+
+// pruneExpired removes every expired entry. Callers hold mu.
+func (s *MemoryStore[T]) pruneExpired() {
+	now := s.now()
+	for id, e := range s.entries {
+		if now.After(e.expiresAt) {
+			s.remove(id)
+		}
+	}
+}
+
+var _ Store[struct{}] = (*MemoryStore[struct{}])(nil)
@@ -0,0 +1,136 @@
+package challenge
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreExpiresEntries(t *testing.T) {
+	s := NewMemoryStore[string](time.Hour)
+	defer s.Close()
+
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	s.Put("a", "alpha", now.Add(time.Minute))
+
+	value, ok := s.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, "alpha", value)
+
+	now = now.Add(2 * time.Minute)
+	_, ok = s.Get("a")
+	assert.False(t, ok)
+	_, ok = s.Take("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, s.Len(), "expired entries are dropped on lookup")
+}
+
+func TestMemoryStoreSweepsExpiredEntries(t *testing.T) {
+	s := NewMemoryStore[int](10 * time.Millisecond)
+	defer s.Close()
+
+	s.Put("old", 1, time.Now().Add(-time.Second))
+	s.Put("fresh", 2, time.Now().Add(time.Hour))
+
+	assert.Eventually(t, func() bool { return s.Len() == 1 }, time.Second, 5*time.Millisecond)
+	_, ok := s.Get("fresh")
+	assert.True(t, ok)
+}
+
+func TestMemoryStoreTakeIsOneTimeUse(t *testing.T) {
+	s := NewMemoryStore[string](0)
+	defer s.Close()
+
+	s.Put("a", "alpha", time.Now().Add(time.Minute))
+
+	value, ok := s.Take("a")
+	require.True(t, ok)
+	assert.Equal(t, "alpha", value)
+
+	_, ok = s.Take("a")
+	assert.False(t, ok)
+	_, ok = s.Get("a")
+	assert.False(t, ok)
+}
+
+func TestMemoryStoreConcurrentTake(t *testing.T) {
+	s := NewMemoryStore[int](0)
+	defer s.Close()
+
+	const ids = 50
+	for i := 0; i < ids; i++ {
+		s.Put(fmt.Sprint(i), i, time.Now().Add(time.Minute))
+	}
+
+	var taken int64
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < ids; i++ {
+				if _, ok := s.Take(fmt.Sprint(i)); ok {
+					atomic.AddInt64(&taken, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(ids), taken, "each entry is taken exactly once")
+	assert.Equal(t, 0, s.Len())
+}
+
+func TestMemoryStoreLimitsOutstandingPerOwner(t *testing.T) {
+	s := NewMemoryStore[int](time.Hour)
+	defer s.Close()
+	s.SetLimits(Limits{PerOwner: 2})
+	expires := time.Now().Add(time.Minute)
+
+	require.NoError(t, s.PutFor("192.0.2.1", "a", 1, expires))
+	require.NoError(t, s.PutFor("192.0.2.1", "b", 2, expires))
+	assert.ErrorIs(t, s.PutFor("192.0.2.1", "c", 3, expires), ErrTooManyOutstanding)
+	assert.NoError(t, s.PutFor("192.0.2.2", "d", 4, expires), "other owners are unaffected")
+
+	// Claiming a challenge frees its slot
+	_, ok := s.Take("a")
+	require.True(t, ok)
+	assert.NoError(t, s.PutFor("192.0.2.1", "c", 3, expires))
+}
+
+func TestMemoryStoreLimitsOutstandingTotal(t *testing.T) {
+	s := NewMemoryStore[int](time.Hour)
+	defer s.Close()
+	s.SetLimits(Limits{Total: 2})
+	expires := time.Now().Add(time.Minute)
+
+	require.NoError(t, s.PutFor("a", "1", 1, expires))
+	require.NoError(t, s.PutFor("b", "2", 2, expires))
+	assert.ErrorIs(t, s.PutFor("c", "3", 3, expires), ErrTooManyOutstanding)
+
+	s.Delete("1")
+	assert.NoError(t, s.PutFor("c", "3", 3, expires))
+}
+
+func TestMemoryStoreExpiredChallengesReleaseSlots(t *testing.T) {
+	s := NewMemoryStore[int](time.Hour)
+	defer s.Close()
+	s.SetLimits(Limits{PerOwner: 1, Total: 1})
+
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	require.NoError(t, s.PutFor("192.0.2.1", "old", 1, now.Add(time.Minute)))
+	assert.ErrorIs(t, s.PutFor("192.0.2.1", "new", 2, now.Add(time.Minute)), ErrTooManyOutstanding)
+
+	// The abandoned challenge is pruned at the cap instead of waiting for
+	// the next sweep
+	now = now.Add(2 * time.Minute)
+	assert.NoError(t, s.PutFor("192.0.2.1", "new", 2, now.Add(time.Minute)))
+	assert.Equal(t, 1, s.Len())
+}
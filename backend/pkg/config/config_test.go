@@ -1,12 +1,16 @@
 package config
 
 import (
+	"math"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/aura-chain/aura/faucet/pkg/units"
 )
 
 func TestLoad(t *testing.T) {
@@ -107,6 +111,136 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "invalid captcha difficulty",
+			config: &Config{
+				NodeRPC:              "http://localhost:26657",
+				ChainID:              "test-chain",
+				FaucetMnemonic:       "test mnemonic",
+				AmountPerRequest:     100,
+				CaptchaMaxDifficulty: "extreme",
+			},
+			wantErr: true,
+		},
+		{
+			name: "captcha min difficulty above max",
+			config: &Config{
+				NodeRPC:              "http://localhost:26657",
+				ChainID:              "test-chain",
+				FaucetMnemonic:       "test mnemonic",
+				AmountPerRequest:     100,
+				CaptchaMinDifficulty: "hard",
+				CaptchaMaxDifficulty: "medium",
+			},
+			wantErr: true,
+		},
+		{
+			name: "captcha min difficulty within default max",
+			config: &Config{
+				NodeRPC:              "http://localhost:26657",
+				ChainID:              "test-chain",
+				FaucetMnemonic:       "test mnemonic",
+				AmountPerRequest:     100,
+				CaptchaMinDifficulty: "medium",
+			},
+			wantErr: false,
+		},
+		{
+			name: "chain duplicates default chain",
+			config: &Config{
+				NodeRPC:          "http://localhost:26657",
+				ChainID:          "test-chain",
+				FaucetMnemonic:   "test mnemonic",
+				AmountPerRequest: 100,
+				Chains: []ChainConfig{
+					{ChainID: "test-chain", NodeRPC: "http://other:26657", Denom: "uother", AddressPrefix: "other"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "max forwarded hops below proxy depth",
+			config: &Config{
+				NodeRPC:           "http://localhost:26657",
+				ChainID:           "test-chain",
+				FaucetMnemonic:    "test mnemonic",
+				AmountPerRequest:  100,
+				TrustedProxyDepth: 2,
+				MaxForwardedHops:  1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid node proxy",
+			config: &Config{
+				NodeRPC:          "http://localhost:26657",
+				ChainID:          "test-chain",
+				FaucetMnemonic:   "test mnemonic",
+				AmountPerRequest: 100,
+				NodeProxy:        "proxy:3128",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown rate limit backend",
+			config: &Config{
+				NodeRPC:          "http://localhost:26657",
+				ChainID:          "test-chain",
+				FaucetMnemonic:   "test mnemonic",
+				AmountPerRequest: 100,
+				RateLimitBackend: "memcached",
+			},
+			wantErr: true,
+		},
+		{
+			name: "amount above maximum",
+			config: &Config{
+				NodeRPC:             "http://localhost:26657",
+				ChainID:             "test-chain",
+				FaucetMnemonic:      "test mnemonic",
+				AmountPerRequest:    1000,
+				MaxAmountPerRequest: units.New(999),
+			},
+			wantErr: true,
+		},
+		{
+			name: "amount plus first-request bonus above maximum",
+			config: &Config{
+				NodeRPC:             "http://localhost:26657",
+				ChainID:             "test-chain",
+				FaucetMnemonic:      "test mnemonic",
+				AmountPerRequest:    900,
+				FirstRequestBonus:   units.New(200),
+				MaxAmountPerRequest: units.New(1000),
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown recipient balance unit",
+			config: &Config{
+				NodeRPC:                 "http://localhost:26657",
+				ChainID:                 "test-chain",
+				FaucetMnemonic:          "test mnemonic",
+				AmountPerRequest:        100,
+				MaxRecipientBalance:     units.New(1000),
+				MaxRecipientBalanceUnit: "tokens",
+			},
+			wantErr: true,
+		},
+		{
+			name: "chain amount above maximum",
+			config: &Config{
+				NodeRPC:             "http://localhost:26657",
+				ChainID:             "test-chain",
+				FaucetMnemonic:      "test mnemonic",
+				AmountPerRequest:    100,
+				MaxAmountPerRequest: units.New(1000),
+				Chains: []ChainConfig{
+					{ChainID: "other-chain", NodeRPC: "http://other:26657", Denom: "uother", AddressPrefix: "other", AmountPerRequest: units.New(5000)},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "production without captcha",
 			config: &Config{
@@ -148,6 +282,61 @@ func TestRateLimitConfig(t *testing.T) {
 	assert.Equal(t, 24*time.Hour, rateLimitCfg["window"])
 }
 
+func TestFeeDenom(t *testing.T) {
+	cfg := &Config{Denom: "uaura", GasPrice: "0.025ufee"}
+	assert.Equal(t, "ufee", cfg.FeeDenom())
+
+	cfg.GasPrice = ""
+	assert.Equal(t, "uaura", cfg.FeeDenom())
+}
+
+func TestFeeDenomOverride(t *testing.T) {
+	cfg := &Config{Denom: "uusdc", GasPrice: "0.025", GasLimit: 200000, FeeDenomOverride: "uaura"}
+	assert.Equal(t, "uaura", cfg.FeeDenom())
+	assert.Equal(t, "0.025uaura", cfg.GasPrices())
+	assert.Equal(t, int64(5000), cfg.MinFeeBalance())
+
+	cfg.GasPrice = "0.025uaura"
+	assert.Equal(t, "0.025uaura", cfg.GasPrices())
+
+	cfg.NodeRPC = "http://localhost:26657"
+	cfg.ChainID = "test-chain"
+	cfg.FaucetMnemonic = "test mnemonic"
+	cfg.AmountPerRequest = 100
+	assert.NoError(t, cfg.Validate())
+
+	cfg.GasPrice = "0.025uother"
+	assert.EqualError(t, cfg.Validate(), "GAS_PRICE denom must match FEE_DENOM")
+}
+
+func TestAddressPatterns(t *testing.T) {
+	patterns, err := CompileAddressPatterns([]string{"aura1exact", "aura1vanity*", "/^aura1[0-9]+$/"})
+	require.NoError(t, err)
+
+	matches := func(address string) bool {
+		for _, p := range patterns {
+			if p.Match(address) {
+				return true
+			}
+		}
+		return false
+	}
+
+	assert.True(t, matches("aura1exact"))
+	assert.False(t, matches("aura1exactly"), "plain entries stay exact")
+	assert.True(t, matches("aura1vanityabc"))
+	assert.True(t, matches("aura1123456"))
+	assert.False(t, matches("aura1abc"))
+}
+
+func TestLoadRejectsInvalidAddressRegex(t *testing.T) {
+	os.Setenv("FAUCET_ALLOWED_ADDRESSES", "aura1ok,/[unclosed/")
+	defer os.Unsetenv("FAUCET_ALLOWED_ADDRESSES")
+
+	_, err := Load()
+	assert.Error(t, err)
+}
+
 func TestGetEnv(t *testing.T) {
 	os.Setenv("TEST_VAR", "test_value")
 	defer os.Unsetenv("TEST_VAR")
@@ -174,3 +363,379 @@ func TestGetEnvAsInt(t *testing.T) {
 	value = getEnvAsInt("INVALID_INT", 10)
 	assert.Equal(t, 10, value)
 }
+
+func TestForChainOverridesChainSettings(t *testing.T) {
+	base := &Config{
+		ChainID:          "aura-1",
+		NodeRPC:          "http://aura:26657",
+		NodeREST:         "http://aura:1317",
+		Denom:            "uaura",
+		AddressPrefix:    "aura",
+		FaucetKey:        "faucet",
+		AmountPerRequest: 100,
+		RequireCaptcha:   true,
+		Chains:           []ChainConfig{{ChainID: "osmo-1"}},
+	}
+
+	derived := base.ForChain(ChainConfig{
+		ChainID:       "osmo-1",
+		NodeRPC:       "http://osmo:26657",
+		Denom:         "uosmo",
+		AddressPrefix: "osmo",
+	})
+
+	assert.Equal(t, "osmo-1", derived.ChainID)
+	assert.Equal(t, "http://osmo:26657", derived.NodeRPC)
+	assert.Equal(t, "uosmo", derived.Denom)
+	assert.Equal(t, "osmo", derived.AddressPrefix)
+	// Unset fields inherit from the base config
+	assert.Equal(t, "http://aura:1317", derived.NodeREST)
+	assert.Equal(t, "faucet", derived.FaucetKey)
+	assert.Equal(t, int64(100), derived.AmountPerRequest)
+	assert.True(t, derived.RequireCaptcha)
+	assert.Nil(t, derived.Chains)
+	assert.Equal(t, "aura-1", base.ChainID)
+}
+
+func TestEighteenDecimalRequestAmount(t *testing.T) {
+	// 100 tokens of an 18-decimal denom does not fit in int64
+	t.Setenv("AMOUNT_PER_REQUEST", "100000000000000000000")
+	t.Setenv("DENOM_EXPONENT", "18")
+	t.Setenv("CHAINS", `[{"chain_id":"evm-1","denom":"aevm","amount_per_request":"250000000000000000000"},{"chain_id":"small-1","amount_per_request":5000000000000000000}]`)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "100000000000000000000", cfg.RequestAmount().String())
+	assert.Equal(t, int64(math.MaxInt64), cfg.AmountPerRequest, "the int64 view saturates")
+	assert.Equal(t, "100", cfg.DisplayAmount(cfg.RequestAmount()))
+	assert.Empty(t, cfg.UnalignedAmounts())
+
+	evm := cfg.ForChain(cfg.Chains[0])
+	assert.Equal(t, "250000000000000000000", evm.RequestAmount().String())
+	small := evm.ForChain(cfg.Chains[1])
+	assert.Equal(t, int64(5_000_000_000_000_000_000), small.AmountPerRequest)
+	assert.Equal(t, "5000000000000000000", small.RequestAmount().String(), "an int64 amount replaces a large one")
+
+	cfg.MaxAmountPerRequest = units.New(math.MaxInt64)
+	assert.Error(t, cfg.Validate(), "the exact amount is checked against the maximum")
+}
+
+func TestParseBuckets(t *testing.T) {
+	buckets, err := parseBuckets("0.005, 0.01,0.1,1")
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0.005, 0.01, 0.1, 1}, buckets)
+
+	_, err = parseBuckets("0.1,0.05")
+	assert.Error(t, err)
+	_, err = parseBuckets("0.1,fast")
+	assert.Error(t, err)
+	_, err = parseBuckets("0,1")
+	assert.Error(t, err)
+}
+
+func TestParseCountryMultipliers(t *testing.T) {
+	multipliers, err := parseCountryMultipliers("us:2, DE:0.5")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"US": 2, "DE": 0.5}, multipliers)
+
+	_, err = parseCountryMultipliers("US")
+	assert.Error(t, err)
+	_, err = parseCountryMultipliers("US:fast")
+	assert.Error(t, err)
+	_, err = parseCountryMultipliers("US:0")
+	assert.Error(t, err)
+}
+
+func TestRecipientBalanceCap(t *testing.T) {
+	caps, err := parseDenomCaps("uosmo:5000, uatom:0")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]units.Amount{"uosmo": units.New(5000), "uatom": {}}, caps)
+
+	cfg := &Config{MaxRecipientBalance: units.New(1000), MaxRecipientBalanceByDenom: caps}
+	assert.Equal(t, units.New(1000), cfg.RecipientBalanceCap("uaura"), "unlisted denoms use the default cap")
+	assert.Equal(t, units.New(5000), cfg.RecipientBalanceCap("uosmo"))
+	assert.True(t, cfg.RecipientBalanceCap("uatom").IsZero(), "a zero entry lifts the cap")
+
+	// 18-decimal caps beyond int64 parse exactly
+	caps, err = parseDenomCaps("aevm:100000000000000000000")
+	require.NoError(t, err)
+	assert.Equal(t, "100000000000000000000", caps["aevm"].String())
+
+	_, err = parseDenomCaps("uosmo")
+	assert.Error(t, err)
+	_, err = parseDenomCaps("uosmo:-1")
+	assert.Error(t, err)
+}
+
+func TestRecipientBalanceCapUnits(t *testing.T) {
+	// 1000 AURA, with the node reporting balances in uaura
+	base := &Config{MaxRecipientBalance: units.New(1_000_000_000), DenomExponent: 6}
+	whole := &Config{MaxRecipientBalance: units.New(1000), MaxRecipientBalanceUnit: BalanceUnitWhole, DenomExponent: 6}
+	for name, cfg := range map[string]*Config{"base units": base, "whole tokens": whole} {
+		assert.Equal(t, units.New(1_000_000_000), cfg.RecipientBalanceCap("uaura"), name)
+	}
+
+	// Per-denom caps use the same unit, and derived chains their own exponent
+	exponent := 18
+	whole.MaxRecipientBalanceByDenom = map[string]units.Amount{"aevm": units.New(5), "uosmo": {}}
+	evm := whole.ForChain(ChainConfig{ChainID: "evm-1", Denom: "aevm", DenomExponent: &exponent})
+	assert.Equal(t, units.New(5_000_000_000_000_000_000), evm.RecipientBalanceCap("aevm"))
+	assert.True(t, whole.RecipientBalanceCap("uosmo").IsZero(), "a zero entry still lifts the cap")
+
+	// Whole-token caps beyond int64 stay exact
+	evm.MaxRecipientBalanceByDenom = nil
+	evm.MaxRecipientBalance = units.New(1000)
+	assert.Equal(t, "1000000000000000000000", evm.RecipientBalanceCap("aevm").String())
+
+	assert.Empty(t, whole.UnalignedAmounts(), "whole-token caps are always aligned")
+}
+
+func TestLoadRecipientBalanceCapUnit(t *testing.T) {
+	t.Setenv("MAX_RECIPIENT_BALANCE", "1000")
+	t.Setenv("MAX_RECIPIENT_BALANCE_UNIT", "Whole")
+	t.Setenv("DENOM_EXPONENT", "6")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, BalanceUnitWhole, cfg.MaxRecipientBalanceUnit)
+	assert.Equal(t, units.New(1_000_000_000), cfg.RecipientBalanceCap(cfg.Denom))
+
+	t.Setenv("MAX_RECIPIENT_BALANCE_UNIT", "")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, units.New(1000), cfg.RecipientBalanceCap(cfg.Denom), "caps default to base units")
+}
+
+func TestLoadLargeAmounts(t *testing.T) {
+	t.Setenv("NODE_RPC", "http://localhost:26657")
+	t.Setenv("CHAIN_ID", "test-chain")
+	t.Setenv("FAUCET_MNEMONIC", "test mnemonic")
+	t.Setenv("DENOM", "aevm")
+	t.Setenv("AMOUNT_PER_REQUEST", "100000000000000000000")
+	t.Setenv("MAX_AMOUNT_PER_REQUEST", "500000000000000000000")
+	t.Setenv("BALANCE_RESERVE", "1000000000000000000000")
+	t.Setenv("FIRST_REQUEST_BONUS", "50000000000000000000")
+	cfg, err := Load()
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, "500000000000000000000", cfg.MaxAmountPerRequest.String())
+	assert.Equal(t, "1000000000000000000000", cfg.BalanceReserve.String())
+	assert.Equal(t, "50000000000000000000", cfg.FirstRequestBonus.String())
+
+	for _, key := range []string{"MAX_AMOUNT_PER_REQUEST", "BALANCE_RESERVE", "FIRST_REQUEST_BONUS", "MAX_RECIPIENT_BALANCE"} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, "-5")
+			cfg, err := Load()
+			require.NoError(t, err)
+			assert.EqualError(t, cfg.Validate(), key+" must be a whole number of base units")
+		})
+	}
+}
+
+func TestLoadAbuseRiskPolicy(t *testing.T) {
+	t.Setenv("NODE_RPC", "http://localhost:26657")
+	t.Setenv("CHAIN_ID", "test-chain")
+	t.Setenv("FAUCET_MNEMONIC", "test mnemonic")
+	t.Setenv("ABUSE_RISK_WEIGHTS", "subnet:0")
+	t.Setenv("ABUSE_RISK_ACTIONS", "40:captcha,90:block")
+	cfg, err := Load()
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+	require.NotNil(t, cfg.AbuseRiskWeights)
+	assert.Zero(t, cfg.AbuseRiskWeights.Subnet)
+	assert.Len(t, cfg.AbuseRiskActions, 2)
+
+	t.Setenv("ABUSE_RISK_WEIGHTS", "subnet:-1")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.ErrorContains(t, cfg.Validate(), "ABUSE_RISK_WEIGHTS")
+
+	t.Setenv("ABUSE_RISK_WEIGHTS", "")
+	t.Setenv("ABUSE_RISK_ACTIONS", "40:maybe")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.ErrorContains(t, cfg.Validate(), "ABUSE_RISK_ACTIONS")
+}
+
+func TestLoadAddressByteLengths(t *testing.T) {
+	os.Setenv("ADDRESS_BYTE_LENGTHS", "20, 32")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, []int{20, 32}, cfg.AddressByteLengths)
+
+	os.Setenv("ADDRESS_BYTE_LENGTHS", "20,wide")
+	defer os.Unsetenv("ADDRESS_BYTE_LENGTHS")
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func TestLoadCampaigns(t *testing.T) {
+	os.Setenv("CAMPAIGNS", `[{"name":"week1","start":"2026-11-01T00:00:00Z","end":"2026-11-08T00:00:00Z"},{"name":"week2","start":"2026-11-15T00:00:00Z","end":"2026-11-22T00:00:00Z"}]`)
+	defer os.Unsetenv("CAMPAIGNS")
+	cfg, err := Load()
+	require.NoError(t, err)
+	require.Len(t, cfg.Campaigns, 2)
+
+	at := func(value string) time.Time {
+		parsed, err := time.Parse(time.RFC3339, value)
+		require.NoError(t, err)
+		return parsed
+	}
+	open, current, next := cfg.CampaignStatus(at("2026-10-30T00:00:00Z"))
+	assert.False(t, open)
+	assert.Nil(t, current)
+	assert.Equal(t, "week1", next.Name)
+
+	open, current, _ = cfg.CampaignStatus(at("2026-11-02T00:00:00Z"))
+	assert.True(t, open)
+	assert.Equal(t, "week1", current.Name)
+
+	open, _, next = cfg.CampaignStatus(at("2026-11-10T00:00:00Z"))
+	assert.False(t, open)
+	assert.Equal(t, "week2", next.Name, "between campaigns the next one is reported")
+
+	open, current, next = cfg.CampaignStatus(at("2026-12-01T00:00:00Z"))
+	assert.False(t, open)
+	assert.Nil(t, current)
+	assert.Nil(t, next)
+
+	open, _, _ = (&Config{}).CampaignStatus(at("2026-12-01T00:00:00Z"))
+	assert.True(t, open, "no campaigns keeps the faucet open")
+
+	valid := &Config{NodeRPC: "http://localhost:26657", ChainID: "test-chain", FaucetMnemonic: "test mnemonic", AmountPerRequest: 100}
+	valid.Campaigns = cfg.Campaigns
+	require.NoError(t, valid.Validate())
+	valid.Campaigns = []Campaign{{Name: "week1", Start: at("2026-11-15T00:00:00Z"), End: at("2026-11-15T00:00:00Z")}}
+	assert.ErrorContains(t, valid.Validate(), "end must be after start")
+	valid.Campaigns = []Campaign{cfg.Campaigns[0], cfg.Campaigns[0]}
+	assert.ErrorContains(t, valid.Validate(), "duplicates campaign")
+
+	os.Setenv("CAMPAIGNS", `{"name":"week1"}`)
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func TestNormalizeBasePath(t *testing.T) {
+	for in, want := range map[string]string{
+		"":         "",
+		"/":        "",
+		"faucet":   "/faucet",
+		"/faucet/": "/faucet",
+		" /a/b/ ":  "/a/b",
+	} {
+		assert.Equal(t, want, NormalizeBasePath(in), in)
+	}
+}
+
+func TestParseAddressBlocklist(t *testing.T) {
+	patterns, err := ParseAddressBlocklist(strings.NewReader("# known exchanges\naura1exchange\n\n  aura1bridge*  \n"))
+	require.NoError(t, err)
+	require.Len(t, patterns, 2)
+
+	assert.True(t, patterns[0].Match("aura1exchange"))
+	assert.False(t, patterns[0].Match("aura1exchange2"), "plain entries stay exact")
+	assert.True(t, patterns[1].Match("aura1bridgevault"))
+
+	_, err = ParseAddressBlocklist(strings.NewReader("aura1a, aura1b\n"))
+	assert.Error(t, err)
+	_, err = ParseAddressBlocklist(strings.NewReader("*\n"))
+	assert.Error(t, err, "a bare wildcard would block everyone")
+}
+
+func TestFeeModeValidation(t *testing.T) {
+	base := func() *Config {
+		return &Config{
+			NodeRPC:          "http://localhost:26657",
+			ChainID:          "test-chain",
+			FaucetMnemonic:   "test mnemonic",
+			AmountPerRequest: 100,
+			Denom:            "uaura",
+			GasLimit:         200000,
+			GasPrice:         "0.025uaura",
+		}
+	}
+
+	cfg := base()
+	cfg.FeeAmount = "5000uaura"
+	assert.Error(t, cfg.Validate(), "a flat fee cannot be combined with gas prices")
+
+	cfg = base()
+	cfg.FeeMode = "flat"
+	assert.Error(t, cfg.Validate())
+
+	cfg = base()
+	cfg.FeeMode = FeeModeFees
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, "5000uaura", cfg.Fees())
+	assert.Equal(t, int64(5000), cfg.MinFeeBalance())
+
+	cfg.FeeAmount = "8000"
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, "8000uaura", cfg.Fees())
+	assert.Equal(t, int64(8000), cfg.MinFeeBalance())
+
+	for _, bad := range []string{"0", "1.5uaura", "8000uother"} {
+		cfg.FeeAmount = bad
+		assert.Error(t, cfg.Validate(), bad)
+	}
+}
+
+func TestAutoRefillValidation(t *testing.T) {
+	cfg := &Config{
+		NodeRPC:           "http://localhost:26657",
+		ChainID:           "test-chain",
+		FaucetMnemonic:    "test mnemonic",
+		AmountPerRequest:  100,
+		FaucetBinary:      "aurad",
+		FaucetKey:         "faucet",
+		FaucetAddress:     "aura1faucet",
+		AutoRefillEnabled: true,
+		RefillTreasuryKey: "treasury",
+		RefillThreshold:   1000,
+		RefillAmount:      50000,
+	}
+	require.NoError(t, cfg.Validate())
+
+	cfg.RefillTreasuryKey = "faucet"
+	assert.Error(t, cfg.Validate(), "refilling from the faucet key would loop")
+
+	cfg.RefillTreasuryKey = ""
+	assert.Error(t, cfg.Validate())
+
+	cfg.RefillTreasuryKey = "treasury"
+	cfg.RefillAmount = 0
+	assert.Error(t, cfg.Validate())
+}
+
+func TestTestBypassTokenRefusedInProduction(t *testing.T) {
+	cfg := &Config{
+		NodeRPC:          "http://localhost:26657",
+		ChainID:          "test-chain",
+		FaucetMnemonic:   "test mnemonic",
+		AmountPerRequest: 100,
+		Environment:      "development",
+		TestBypassToken:  "e2e-secret",
+	}
+	require.NoError(t, cfg.Validate())
+
+	cfg.Environment = "production"
+	assert.EqualError(t, cfg.Validate(), "TEST_BYPASS_TOKEN must not be set in production")
+
+	cfg.TestBypassToken = ""
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestBalanceReserve(t *testing.T) {
+	cfg := &Config{
+		NodeRPC:          "http://localhost:26657",
+		ChainID:          "test-chain",
+		FaucetMnemonic:   "test mnemonic",
+		AmountPerRequest: 100,
+		BalanceReserve:   units.New(1000),
+	}
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, int64(500), cfg.SpendableBalance(1500))
+	assert.Equal(t, int64(0), cfg.SpendableBalance(800), "a balance under the reserve has nothing to spend")
+
+	cfg.BalanceReserve = units.New(-1)
+	assert.EqualError(t, cfg.Validate(), "BALANCE_RESERVE must be zero or positive")
+}
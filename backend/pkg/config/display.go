@@ -0,0 +1,182 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/aura-chain/aura/faucet/pkg/units"
+)
+
+// DISPLAY_ROUNDING policies for showing base-unit amounts in display units
+const (
+	// RoundExact shows every base unit as a decimal fraction
+	RoundExact = "exact"
+	// RoundFloor drops any fraction of a display unit
+	RoundFloor = "floor"
+	// RoundHalfUp rounds to the nearest display unit, halves up
+	RoundHalfUp = "round"
+)
+
+// maxDenomExponent keeps 10^exponent within int64
+const maxDenomExponent = 18
+
+// ToDisplay converts a base-unit amount to display units, e.g. 1500000
+// with exponent 6 is "1.5" exactly, "1" with floor and "2" with round.
+// Unknown policies behave like RoundExact.
+func ToDisplay(amount int64, exponent int, rounding string) string {
+	if exponent <= 0 {
+		return strconv.FormatInt(amount, 10)
+	}
+
+	sign := ""
+	magnitude := uint64(amount)
+	if amount < 0 {
+		sign = "-"
+		magnitude = uint64(-(amount + 1)) + 1
+	}
+	unit := uint64(pow10(exponent))
+	whole, frac := magnitude/unit, magnitude%unit
+
+	switch rounding {
+	case RoundFloor:
+		frac = 0
+	case RoundHalfUp:
+		if frac*2 >= unit {
+			whole++
+		}
+		frac = 0
+	}
+
+	if frac == 0 {
+		if whole == 0 {
+			sign = ""
+		}
+		return sign + strconv.FormatUint(whole, 10)
+	}
+	fraction := strings.TrimRight(fmt.Sprintf("%0*d", exponent, frac), "0")
+	return sign + strconv.FormatUint(whole, 10) + "." + fraction
+}
+
+// ToDisplayAmount is ToDisplay for amounts of any size
+func ToDisplayAmount(amount units.Amount, exponent int, rounding string) string {
+	if n, ok := amount.Int64(); ok {
+		return ToDisplay(n, exponent, rounding)
+	}
+
+	exact := amount.Display(exponent)
+	if rounding != RoundFloor && rounding != RoundHalfUp {
+		return exact
+	}
+	sign := ""
+	if amount.Sign() < 0 {
+		sign = "-"
+	}
+	wholePart, frac, _ := strings.Cut(strings.TrimPrefix(exact, "-"), ".")
+	whole, _ := units.Parse(wholePart)
+	if rounding == RoundHalfUp && frac != "" && frac[0] >= '5' {
+		whole = whole.Add(units.New(1))
+	}
+	return sign + whole.String()
+}
+
+// FromDisplay parses a non-negative display amount such as "1.5" into base
+// units. It is the inverse of ToDisplay with RoundExact and rejects
+// fractions finer than one base unit.
+func FromDisplay(display string, exponent int) (int64, error) {
+	if exponent < 0 || exponent > maxDenomExponent {
+		return 0, fmt.Errorf("denom exponent %d out of range", exponent)
+	}
+
+	wholePart, fracPart, hasFrac := strings.Cut(strings.TrimSpace(display), ".")
+	if wholePart == "" && fracPart == "" {
+		return 0, errors.New("empty amount")
+	}
+	if hasFrac && fracPart == "" {
+		return 0, fmt.Errorf("invalid amount %q", display)
+	}
+	if !isDigits(wholePart) || !isDigits(fracPart) {
+		return 0, fmt.Errorf("invalid amount %q", display)
+	}
+	if len(fracPart) > exponent {
+		return 0, fmt.Errorf("amount %q has more than %d decimal places", display, exponent)
+	}
+
+	whole := int64(0)
+	if wholePart != "" {
+		var err error
+		whole, err = strconv.ParseInt(wholePart, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("amount %q out of range", display)
+		}
+	}
+	frac := int64(0)
+	if fracPart != "" {
+		frac, _ = strconv.ParseInt(fracPart+strings.Repeat("0", exponent-len(fracPart)), 10, 64)
+	}
+
+	unit := pow10(exponent)
+	if whole > (math.MaxInt64-frac)/unit {
+		return 0, fmt.Errorf("amount %q out of range", display)
+	}
+	return whole*unit + frac, nil
+}
+
+// ToDisplay converts amount to display units of the configured denom
+func (c *Config) ToDisplay(amount int64) string {
+	return ToDisplay(amount, c.DenomExponent, c.DisplayRounding)
+}
+
+// DisplayAmount converts an amount of any size to display units of the
+// configured denom
+func (c *Config) DisplayAmount(amount units.Amount) string {
+	return ToDisplayAmount(amount, c.DenomExponent, c.DisplayRounding)
+}
+
+// FromDisplay parses a display amount into base units of the configured denom
+func (c *Config) FromDisplay(display string) (int64, error) {
+	return FromDisplay(display, c.DenomExponent)
+}
+
+// UnalignedAmounts describes the configured amounts that are not whole
+// display units and so show as fractions or get rounded for display
+func (c *Config) UnalignedAmounts() []string {
+	var unaligned []string
+	check := func(name string, amount units.Amount, exponent int, denom string) {
+		exact := amount.Display(exponent)
+		if amount.Sign() > 0 && strings.Contains(exact, ".") {
+			unaligned = append(unaligned, fmt.Sprintf("%s %s%s is %s display units (shown as %s)",
+				name, amount, denom, exact, ToDisplayAmount(amount, exponent, c.DisplayRounding)))
+		}
+	}
+
+	check("AMOUNT_PER_REQUEST", c.RequestAmount(), c.DenomExponent, c.Denom)
+	check("FIRST_REQUEST_BONUS", c.FirstRequestBonus, c.DenomExponent, c.Denom)
+	check("MAX_RECIPIENT_BALANCE", c.balanceCapInBaseUnits(c.MaxRecipientBalance), c.DenomExponent, c.Denom)
+	for i, chain := range c.Chains {
+		derived := c.ForChain(chain)
+		check(fmt.Sprintf("CHAINS[%d] amount_per_request", i), chain.AmountPerRequest, derived.DenomExponent, derived.Denom)
+	}
+	return unaligned
+}
+
+// pow10 returns 10^exponent for exponents within maxDenomExponent
+func pow10(exponent int) int64 {
+	result := int64(1)
+	for i := 0; i < exponent; i++ {
+		result *= 10
+	}
+	return result
+}
+
+// isDigits reports whether s holds only ASCII digits; empty is allowed
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
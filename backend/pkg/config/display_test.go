@@ -0,0 +1,73 @@
+package config
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aura-chain/aura/faucet/pkg/units"
+)
+
+func TestToDisplayRoundingModes(t *testing.T) {
+	tests := []struct {
+		amount                int64
+		exact, floor, rounded string
+	}{
+		{100000000, "100", "100", "100"},
+		{1500000, "1.5", "1", "2"},
+		{1499999, "1.499999", "1", "1"},
+		{1, "0.000001", "0", "0"},
+		{0, "0", "0", "0"},
+		{-2500000, "-2.5", "-2", "-3"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.exact, ToDisplay(tt.amount, 6, RoundExact), "exact %d", tt.amount)
+		assert.Equal(t, tt.floor, ToDisplay(tt.amount, 6, RoundFloor), "floor %d", tt.amount)
+		assert.Equal(t, tt.rounded, ToDisplay(tt.amount, 6, RoundHalfUp), "round %d", tt.amount)
+	}
+
+	assert.Equal(t, "42", ToDisplay(42, 0, RoundHalfUp), "exponent 0 is already display units")
+	assert.Equal(t, "-9223372036854.775808", ToDisplay(math.MinInt64, 6, RoundExact))
+}
+
+func TestFromDisplayRoundTrip(t *testing.T) {
+	for _, amount := range []int64{0, 1, 1500000, 100000000, 123456789, math.MaxInt64} {
+		parsed, err := FromDisplay(ToDisplay(amount, 6, RoundExact), 6)
+		require.NoError(t, err)
+		assert.Equal(t, amount, parsed)
+	}
+
+	parsed, err := FromDisplay(".25", 6)
+	require.NoError(t, err)
+	assert.Equal(t, int64(250000), parsed)
+
+	for _, bad := range []string{"", ".", "1.", "-1", "1e6", "1.0000001", "9223372036855"} {
+		_, err := FromDisplay(bad, 6)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestUnalignedAmounts(t *testing.T) {
+	exponent := 2
+	cfg := &Config{
+		Denom:            "uaura",
+		AmountPerRequest: 1500000,
+		DenomExponent:    6,
+		DisplayRounding:  RoundHalfUp,
+		Chains: []ChainConfig{
+			{ChainID: "other", Denom: "uother", AmountPerRequest: units.New(250), DenomExponent: &exponent},
+			{ChainID: "whole", Denom: "uwhole", AmountPerRequest: units.New(3000000)},
+		},
+	}
+
+	unaligned := cfg.UnalignedAmounts()
+	require.Len(t, unaligned, 2)
+	assert.Equal(t, "AMOUNT_PER_REQUEST 1500000uaura is 1.5 display units (shown as 2)", unaligned[0])
+	assert.Contains(t, unaligned[1], "CHAINS[0] amount_per_request 250uother is 2.5")
+
+	cfg.AmountPerRequest = 100000000
+	cfg.Chains = nil
+	assert.Empty(t, cfg.UnalignedAmounts())
+}
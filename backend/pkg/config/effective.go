@@ -0,0 +1,140 @@
+package config
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Setting sources
+const (
+	// SourceEnv is a value set in the environment, including a .env file
+	SourceEnv = "env"
+	// SourceDefault is a built-in default, used when the variable is unset
+	// or unparseable
+	SourceDefault = "default"
+)
+
+// redactedValue replaces secret setting values
+const redactedValue = "[REDACTED]"
+
+// sensitiveKey matches variables whose values are secrets whatever they hold
+var sensitiveKey = regexp.MustCompile(`(?i)(SECRET|TOKEN|PASSWORD|MNEMONIC|_KEY$)`)
+
+// Setting is one configuration variable as Load resolved it
+type Setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// settingsRecorder collects the variables read by one Load call
+type settingsRecorder struct {
+	order  []string
+	values map[string]Setting
+}
+
+var (
+	// loadMu serializes Load so each call records only its own reads
+	loadMu    sync.Mutex
+	recording *settingsRecorder
+)
+
+// startRecording begins collecting the variables read by getEnv and its
+// typed variants until stop is called
+func startRecording() *settingsRecorder {
+	loadMu.Lock()
+	recording = &settingsRecorder{values: make(map[string]Setting)}
+	return recording
+}
+
+func (r *settingsRecorder) stop() {
+	recording = nil
+	loadMu.Unlock()
+}
+
+// settings returns the recorded variables in the order first read; a
+// variable read twice keeps its last value
+func (r *settingsRecorder) settings() []Setting {
+	settings := make([]Setting, 0, len(r.order))
+	for _, key := range r.order {
+		settings = append(settings, r.values[key])
+	}
+	return settings
+}
+
+// recordSetting notes the value a variable resolved to during Load; reads
+// outside Load are not recorded
+func recordSetting(key, value string, fromEnv bool) {
+	if recording == nil {
+		return
+	}
+	source := SourceDefault
+	if fromEnv {
+		source = SourceEnv
+	}
+	if _, seen := recording.values[key]; !seen {
+		recording.order = append(recording.order, key)
+	}
+	recording.values[key] = Setting{Key: key, Value: value, Source: source}
+}
+
+// EffectiveSettings returns every variable Load read, with the value it
+// resolved to and whether that came from the environment or a default.
+// Secrets are redacted: values of secret-named variables, configured
+// secrets wherever they appear, and URL passwords.
+func (c *Config) EffectiveSettings() []Setting {
+	var secrets []string
+	for _, secret := range c.Secrets() {
+		if secret != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+
+	settings := make([]Setting, 0, len(c.settings))
+	for _, setting := range c.settings {
+		setting.Value = redactSetting(setting.Key, setting.Value, secrets)
+		settings = append(settings, setting)
+	}
+	return settings
+}
+
+func redactSetting(key, value string, secrets []string) string {
+	if value == "" {
+		return value
+	}
+	if sensitiveKey.MatchString(key) {
+		return redactedValue
+	}
+	for _, secret := range secrets {
+		value = strings.ReplaceAll(value, secret, redactedValue)
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			value = u.Redacted()
+		}
+	}
+	return value
+}
+
+// LogEffectiveConfig logs the resolved configuration, secrets redacted, and
+// which variables were left at their defaults
+func (c *Config) LogEffectiveConfig() {
+	settings := c.EffectiveSettings()
+	values := make(map[string]string, len(settings))
+	var defaults []string
+	for _, setting := range settings {
+		values[setting.Key] = setting.Value
+		if setting.Source == SourceDefault {
+			defaults = append(defaults, setting.Key)
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"config":   values,
+		"defaults": defaults,
+	}).Info("Effective configuration")
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEffectiveSettings(t *testing.T) {
+	t.Setenv("CHAIN_ID", "aura-test")
+	t.Setenv("FAUCET_KEY", "faucet-key")
+	t.Setenv("ADMIN_TOKEN", "s3cret-admin")
+	t.Setenv("DATABASE_URL", "postgres://faucet:hunter2@db:5432/faucet")
+	t.Setenv("CHAINS", `[{"chain_id":"other","faucet_key":"faucet-key"}]`)
+	t.Setenv("AMOUNT_PER_REQUEST", "not-a-number")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	settings := make(map[string]Setting)
+	for _, setting := range cfg.EffectiveSettings() {
+		settings[setting.Key] = setting
+	}
+
+	assert.Equal(t, Setting{Key: "CHAIN_ID", Value: "aura-test", Source: SourceEnv}, settings["CHAIN_ID"])
+	assert.Equal(t, SourceDefault, settings["PORT"].Source)
+	assert.Equal(t, "8080", settings["PORT"].Value)
+	assert.Equal(t, SourceDefault, settings["AMOUNT_PER_REQUEST"].Source, "an unparseable value falls back to the default")
+
+	assert.Equal(t, redactedValue, settings["FAUCET_KEY"].Value)
+	assert.Equal(t, redactedValue, settings["ADMIN_TOKEN"].Value)
+	assert.Equal(t, "postgres://faucet:xxxxx@db:5432/faucet", settings["DATABASE_URL"].Value)
+	assert.NotContains(t, settings["CHAINS"].Value, "faucet-key")
+	assert.Equal(t, SourceEnv, settings["ADMIN_TOKEN"].Source)
+
+	// Unset secrets stay empty so they read as unset
+	assert.Equal(t, "", settings["TURNSTILE_SECRET"].Value)
+}
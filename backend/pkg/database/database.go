@@ -2,16 +2,30 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"net"
 	"time"
 
 	_ "github.com/lib/pq"
 	log "github.com/sirupsen/logrus"
+
+	"github.com/aura-chain/aura/faucet/pkg/units"
 )
 
-// DB wraps the database connection
+// ErrRequestNotFound is returned when a faucet request id does not exist
+var ErrRequestNotFound = errors.New("request not found")
+
+// ErrBonusClaimed is returned when a recipient's first-request bonus is
+// already paid or pending
+var ErrBonusClaimed = errors.New("first-request bonus already claimed")
+
+// DB wraps the database connection. Read-only dashboard queries (stats and
+// recent listings) go to the optional read replica; writes and reads that
+// enforce limits stay on the primary so replica lag can't loosen them.
 type DB struct {
 	conn *sql.DB
+	read *sql.DB
 }
 
 // NewWithConn constructs a DB wrapper from an existing sql.DB.
@@ -27,30 +41,106 @@ func NewWithSQL(conn *sql.DB) *DB {
 
 // FaucetRequest represents a faucet request record
 type FaucetRequest struct {
-	ID          int64     `json:"id"`
-	Recipient   string    `json:"recipient"`
-	Amount      int64     `json:"amount"`
-	TxHash      string    `json:"tx_hash"`
-	IPAddress   string    `json:"ip_address"`
-	Status      string    `json:"status"` // pending, success, failed
-	Error       string    `json:"error,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ID            int64        `json:"id"`
+	Recipient     string       `json:"recipient"`
+	Amount        units.Amount `json:"amount"`
+	TxHash        string       `json:"tx_hash"`
+	IPAddress     string       `json:"ip_address"`
+	Status        string       `json:"status"` // pending, success, failed, timeout, retrying
+	Denom         string       `json:"denom,omitempty"`
+	Source        string       `json:"source,omitempty"`
+	RetryAttempts int          `json:"retry_attempts,omitempty"` // replays of a failed payout
+	Error         string       `json:"error,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	CompletedAt   *time.Time   `json:"completed_at,omitempty"`
 }
 
 // Statistics holds faucet statistics
 type Statistics struct {
-	TotalRequests     int64   `json:"total_requests"`
-	SuccessfulRequests int64   `json:"successful_requests"`
-	FailedRequests    int64   `json:"failed_requests"`
-	TotalDistributed  int64   `json:"total_distributed"`
-	UniqueRecipients  int64   `json:"unique_recipients"`
-	RequestsLast24h   int64   `json:"requests_last_24h"`
-	RequestsLastHour  int64   `json:"requests_last_hour"`
+	TotalRequests      int64 `json:"total_requests"`
+	SuccessfulRequests int64 `json:"successful_requests"`
+	FailedRequests     int64 `json:"failed_requests"`
+	TotalDistributed   int64 `json:"total_distributed"`
+	UniqueRecipients   int64 `json:"unique_recipients"`
+	RequestsLast24h    int64 `json:"requests_last_24h"`
+	RequestsLastHour   int64 `json:"requests_last_hour"`
+}
+
+// DenomStatistics holds request counts and payouts for a single denom
+type DenomStatistics struct {
+	Denom              string `json:"denom"`
+	TotalRequests      int64  `json:"total_requests"`
+	SuccessfulRequests int64  `json:"successful_requests"`
+	TotalDistributed   int64  `json:"total_distributed"`
+}
+
+// SourceTotal holds successful payouts attributed to one request source
+type SourceTotal struct {
+	Source           string `json:"source"`
+	Requests         int64  `json:"requests"`
+	TotalDistributed int64  `json:"total_distributed"`
+}
+
+// WebhookEvent is an undelivered payout webhook waiting in the outbox
+type WebhookEvent struct {
+	ID        int64
+	EventID   string
+	EventType string
+	Payload   []byte
+	Attempts  int
+}
+
+// EmailVerification is a one-time code sent to an email address
+type EmailVerification struct {
+	ID        int64
+	Email     string
+	CodeHash  string
+	Attempts  int
+	ExpiresAt time.Time
 }
 
 // NewPostgresDB creates a new PostgreSQL database connection
 func NewPostgresDB(connectionString string) (*DB, error) {
+	conn, err := openPostgres(connectionString)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("Database connection established")
+
+	return &DB{conn: conn}, nil
+}
+
+// AttachReadReplica connects to a read replica that serves read-only
+// dashboard queries
+func (db *DB) AttachReadReplica(connectionString string) error {
+	conn, err := openPostgres(connectionString)
+	if err != nil {
+		return fmt.Errorf("read replica: %w", err)
+	}
+
+	log.Info("Read replica connection established")
+
+	db.SetReadConn(conn)
+	return nil
+}
+
+// SetReadConn routes read-only queries through conn
+func (db *DB) SetReadConn(conn *sql.DB) {
+	db.read = conn
+}
+
+// reader returns the connection for read-only queries, falling back to
+// the primary when no replica is attached
+func (db *DB) reader() *sql.DB {
+	if db.read != nil {
+		return db.read
+	}
+	return db.conn
+}
+
+// openPostgres opens and pings a pooled PostgreSQL connection
+func openPostgres(connectionString string) (*sql.DB, error) {
 	conn, err := sql.Open("postgres", connectionString)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -63,67 +153,89 @@ func NewPostgresDB(connectionString string) (*DB, error) {
 
 	// Test connection
 	if err := conn.Ping(); err != nil {
+		conn.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	log.Info("Database connection established")
-
-	return &DB{conn: conn}, nil
+	return conn, nil
 }
 
-// Close closes the database connection
+// Close closes the database connections
 func (db *DB) Close() error {
+	if db.read != nil {
+		if err := db.read.Close(); err != nil {
+			log.WithError(err).Warn("Failed to close read replica connection")
+		}
+	}
 	return db.conn.Close()
 }
 
-// Migrate runs database migrations
-func (db *DB) Migrate() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS faucet_requests (
-		id SERIAL PRIMARY KEY,
-		recipient VARCHAR(255) NOT NULL,
-		amount BIGINT NOT NULL,
-		tx_hash VARCHAR(255),
-		ip_address VARCHAR(45) NOT NULL,
-		status VARCHAR(20) NOT NULL DEFAULT 'pending',
-		error TEXT,
-		created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-		completed_at TIMESTAMP WITH TIME ZONE
-	);
+// inetArg binds ipAddress to the ip_inet column, or NULL when it is not
+// an IP
+func inetArg(ipAddress string) interface{} {
+	if net.ParseIP(ipAddress) == nil {
+		return nil
+	}
+	return ipAddress
+}
 
-	CREATE INDEX IF NOT EXISTS idx_recipient ON faucet_requests(recipient);
-	CREATE INDEX IF NOT EXISTS idx_ip_address ON faucet_requests(ip_address);
-	CREATE INDEX IF NOT EXISTS idx_created_at ON faucet_requests(created_at);
-	CREATE INDEX IF NOT EXISTS idx_status ON faucet_requests(status);
+// CreateRequest creates a new faucet request paying amount in denom,
+// attributed to source, which may be empty
+func (db *DB) CreateRequest(recipient, ipAddress string, amount units.Amount, denom, source string) (*FaucetRequest, error) {
+	query := `
+		INSERT INTO faucet_requests (recipient, amount, ip_address, status, denom, source, ip_inet)
+		VALUES ($1, $2, $3, 'pending', $4, $5, $6)
+		RETURNING id, recipient, amount, ip_address, status, denom, source, created_at
 	`
 
-	_, err := db.conn.Exec(query)
+	req := &FaucetRequest{}
+	err := db.conn.QueryRow(query, recipient, amount, ipAddress, denom, source, inetArg(ipAddress)).Scan(
+		&req.ID,
+		&req.Recipient,
+		&req.Amount,
+		&req.IPAddress,
+		&req.Status,
+		&req.Denom,
+		&req.Source,
+		&req.CreatedAt,
+	)
+
 	if err != nil {
-		return fmt.Errorf("failed to run migrations: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	log.Info("Database migrations completed")
-	return nil
+	return req, nil
 }
 
-// CreateRequest creates a new faucet request
-func (db *DB) CreateRequest(recipient, ipAddress string, amount int64) (*FaucetRequest, error) {
+// CreateBonusRequest creates a faucet request that includes recipient's
+// one-time first-request bonus. Concurrent first requests race on a unique
+// index, so only one is recorded; the others, and any request for an
+// address already paid, get ErrBonusClaimed and record nothing.
+func (db *DB) CreateBonusRequest(recipient, ipAddress string, amount units.Amount, denom, source string) (*FaucetRequest, error) {
 	query := `
-		INSERT INTO faucet_requests (recipient, amount, ip_address, status)
-		VALUES ($1, $2, $3, 'pending')
-		RETURNING id, recipient, amount, ip_address, status, created_at
+		INSERT INTO faucet_requests (recipient, amount, ip_address, status, denom, source, bonus_granted, ip_inet)
+		SELECT $1, $2, $3, 'pending', $4, $5, TRUE, $6
+		WHERE NOT EXISTS (
+			SELECT 1 FROM faucet_requests WHERE recipient = $1 AND status = 'success'
+		)
+		ON CONFLICT (recipient) WHERE bonus_granted AND status <> 'failed' DO NOTHING
+		RETURNING id, recipient, amount, ip_address, status, denom, source, created_at
 	`
 
 	req := &FaucetRequest{}
-	err := db.conn.QueryRow(query, recipient, amount, ipAddress).Scan(
+	err := db.conn.QueryRow(query, recipient, amount, ipAddress, denom, source, inetArg(ipAddress)).Scan(
 		&req.ID,
 		&req.Recipient,
 		&req.Amount,
 		&req.IPAddress,
 		&req.Status,
+		&req.Denom,
+		&req.Source,
 		&req.CreatedAt,
 	)
-
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBonusClaimed
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -131,6 +243,24 @@ func (db *DB) CreateRequest(recipient, ipAddress string, amount int64) (*FaucetR
 	return req, nil
 }
 
+// HasClaimedBonus reports whether recipient was ever paid or has a
+// first-request bonus that hasn't failed
+func (db *DB) HasClaimedBonus(recipient string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM faucet_requests
+			WHERE recipient = $1 AND (status = 'success' OR (bonus_granted AND status <> 'failed'))
+		)
+	`
+
+	var exists bool
+	if err := db.conn.QueryRow(query, recipient).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check first-request bonus: %w", err)
+	}
+
+	return exists, nil
+}
+
 // UpdateRequestSuccess updates a request as successful
 func (db *DB) UpdateRequestSuccess(id int64, txHash string) error {
 	query := `
@@ -163,6 +293,236 @@ func (db *DB) UpdateRequestFailed(id int64, errorMsg string) error {
 	return nil
 }
 
+// UpdateRequestTimedOut marks a request whose broadcast outcome is unknown
+// because the signing process exceeded its deadline
+func (db *DB) UpdateRequestTimedOut(id int64, errorMsg string) error {
+	query := `
+		UPDATE faucet_requests
+		SET status = 'timeout', error = $1, completed_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`
+
+	_, err := db.conn.Exec(query, errorMsg, id)
+	if err != nil {
+		return fmt.Errorf("failed to update request: %w", err)
+	}
+
+	return nil
+}
+
+// GetRequest gets a single request by id, returning ErrRequestNotFound if
+// there is none
+func (db *DB) GetRequest(id int64) (*FaucetRequest, error) {
+	query := `
+		SELECT id, recipient, amount, COALESCE(tx_hash, ''), ip_address, status, denom, source, retry_attempts, created_at
+		FROM faucet_requests
+		WHERE id = $1
+	`
+
+	req := &FaucetRequest{}
+	err := db.conn.QueryRow(query, id).Scan(
+		&req.ID,
+		&req.Recipient,
+		&req.Amount,
+		&req.TxHash,
+		&req.IPAddress,
+		&req.Status,
+		&req.Denom,
+		&req.Source,
+		&req.RetryAttempts,
+		&req.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRequestNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get request: %w", err)
+	}
+
+	return req, nil
+}
+
+// GetRequestByTxHash gets the request paid out by txHash
+func (db *DB) GetRequestByTxHash(txHash string) (*FaucetRequest, error) {
+	query := `
+		SELECT id, recipient, amount, tx_hash, status, denom, source, created_at, completed_at
+		FROM faucet_requests
+		WHERE tx_hash = $1
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	req := &FaucetRequest{}
+	err := db.conn.QueryRow(query, txHash).Scan(
+		&req.ID,
+		&req.Recipient,
+		&req.Amount,
+		&req.TxHash,
+		&req.Status,
+		&req.Denom,
+		&req.Source,
+		&req.CreatedAt,
+		&req.CompletedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRequestNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get request: %w", err)
+	}
+
+	return req, nil
+}
+
+// GetRequestStatusByID gets a request by id with the columns
+// GetRequestByTxHash returns. TxHash is empty until the payout is broadcast.
+func (db *DB) GetRequestStatusByID(id int64) (*FaucetRequest, error) {
+	query := `
+		SELECT id, recipient, amount, COALESCE(tx_hash, ''), status, denom, source, created_at, completed_at
+		FROM faucet_requests
+		WHERE id = $1
+	`
+
+	req := &FaucetRequest{}
+	err := db.conn.QueryRow(query, id).Scan(
+		&req.ID,
+		&req.Recipient,
+		&req.Amount,
+		&req.TxHash,
+		&req.Status,
+		&req.Denom,
+		&req.Source,
+		&req.CreatedAt,
+		&req.CompletedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRequestNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get request: %w", err)
+	}
+
+	return req, nil
+}
+
+// ScheduleRequestRetry records a failed payout attempt and parks the request
+// for replay at nextAttempt. Once maxAttempts is reached it stays failed.
+func (db *DB) ScheduleRequestRetry(id int64, errorMsg string, nextAttempt time.Time, maxAttempts int) error {
+	query := `
+		UPDATE faucet_requests
+		SET error = $1,
+			next_retry_at = $2,
+			status = CASE WHEN retry_attempts >= $3 THEN 'failed' ELSE 'retrying' END,
+			completed_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+	`
+
+	_, err := db.conn.Exec(query, errorMsg, nextAttempt, maxAttempts, id)
+	if err != nil {
+		return fmt.Errorf("failed to schedule request retry: %w", err)
+	}
+
+	return nil
+}
+
+// GetDueRetries gets requests in denom whose scheduled replay is due
+func (db *DB) GetDueRetries(denom string, limit int) ([]int64, error) {
+	query := `
+		SELECT id
+		FROM faucet_requests
+		WHERE status = 'retrying' AND denom = $1 AND next_retry_at <= NOW()
+		ORDER BY id
+		LIMIT $2
+	`
+
+	rows, err := db.conn.Query(query, denom, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due retries: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan retry: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// ClaimRequestForReplay moves a failed or retrying request back to pending
+// and counts the attempt. It reports false if another replay got there
+// first or the request is no longer replayable.
+func (db *DB) ClaimRequestForReplay(id int64) (bool, error) {
+	query := `
+		UPDATE faucet_requests
+		SET status = 'pending', retry_attempts = retry_attempts + 1, next_retry_at = NULL
+		WHERE id = $1 AND status IN ('failed', 'retrying')
+	`
+
+	result, err := db.conn.Exec(query, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim request: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim request: %w", err)
+	}
+
+	return affected == 1, nil
+}
+
+// HasSuccessfulPayoutSince reports whether recipient was paid at or after
+// since, e.g. by a resubmitted request
+func (db *DB) HasSuccessfulPayoutSince(recipient string, since time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM faucet_requests
+			WHERE recipient = $1 AND status = 'success' AND created_at >= $2
+		)
+	`
+
+	var exists bool
+	if err := db.conn.QueryRow(query, recipient, since).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check successful payouts: %w", err)
+	}
+
+	return exists, nil
+}
+
+// HasFundedOtherAddress reports whether ipAddress has ever made a
+// non-failed request for a recipient other than address
+func (db *DB) HasFundedOtherAddress(ipAddress, address string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM faucet_requests
+			WHERE ip_address = $1 AND recipient <> $2 AND status <> 'failed'
+		)
+	`
+
+	var exists bool
+	if err := db.conn.QueryRow(query, ipAddress, address).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check requests by IP: %w", err)
+	}
+
+	return exists, nil
+}
+
+// CountUniqueRecipients counts the distinct addresses ever paid
+func (db *DB) CountUniqueRecipients() (int64, error) {
+	query := `SELECT COUNT(DISTINCT recipient) FROM faucet_requests WHERE status = 'success'`
+
+	var count int64
+	if err := db.conn.QueryRow(query).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count unique recipients: %w", err)
+	}
+
+	return count, nil
+}
+
 // GetRecentRequests gets recent successful requests
 func (db *DB) GetRecentRequests(limit int) ([]*FaucetRequest, error) {
 	query := `
@@ -173,12 +533,36 @@ func (db *DB) GetRecentRequests(limit int) ([]*FaucetRequest, error) {
 		LIMIT $1
 	`
 
-	rows, err := db.conn.Query(query, limit)
+	rows, err := db.reader().Query(query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get recent requests: %w", err)
 	}
 	defer rows.Close()
 
+	return scanRecentRequests(rows)
+}
+
+// GetRecentRequestsBySource gets recent successful requests from one source
+func (db *DB) GetRecentRequestsBySource(source string, limit int) ([]*FaucetRequest, error) {
+	query := `
+		SELECT id, recipient, amount, tx_hash, ip_address, status, created_at, completed_at
+		FROM faucet_requests
+		WHERE status = 'success' AND source = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := db.reader().Query(query, source, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent requests: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRecentRequests(rows)
+}
+
+// scanRecentRequests reads rows selected by the recent request queries
+func scanRecentRequests(rows *sql.Rows) ([]*FaucetRequest, error) {
 	var requests []*FaucetRequest
 	for rows.Next() {
 		req := &FaucetRequest{}
@@ -238,10 +622,130 @@ func (db *DB) GetRequestsByAddress(address string, since time.Time) ([]*FaucetRe
 	return requests, nil
 }
 
+// HasPendingRequest reports whether the address has a pending request created
+// since the given time. Older pending rows are treated as abandoned.
+func (db *DB) HasPendingRequest(address string, since time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM faucet_requests
+			WHERE recipient = $1 AND status = 'pending' AND created_at >= $2
+		)
+	`
+
+	var exists bool
+	if err := db.conn.QueryRow(query, address, since).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check pending requests: %w", err)
+	}
+
+	return exists, nil
+}
+
+// CountRecentRequestsByIP counts non-failed requests from an IP since a time
+func (db *DB) CountRecentRequestsByIP(ipAddress string, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM faucet_requests
+		WHERE ip_address = $1 AND created_at >= $2 AND status != 'failed'
+	`
+
+	var count int
+	if err := db.conn.QueryRow(query, ipAddress, since).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count requests by IP: %w", err)
+	}
+
+	return count, nil
+}
+
+// CountDistinctRecipientsByIP counts the distinct recipients of non-failed
+// requests from an IP since a time, leaving out exclude
+func (db *DB) CountDistinctRecipientsByIP(ipAddress, exclude string, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(DISTINCT recipient) FROM faucet_requests
+		WHERE ip_address = $1 AND created_at >= $2 AND status != 'failed' AND recipient != $3
+	`
+
+	var count int
+	if err := db.conn.QueryRow(query, ipAddress, since, exclude).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count recipients by IP: %w", err)
+	}
+
+	return count, nil
+}
+
+// CountSuccessfulPayoutsBySubnet counts successful requests from IPs
+// within subnet, a CIDR such as "192.0.2.0/24", since a time. Requests
+// whose ip_address is not an IP have no ip_inet and are never counted.
+func (db *DB) CountSuccessfulPayoutsBySubnet(subnet string, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM faucet_requests
+		WHERE status = 'success' AND ip_inet <<= $1::inet AND created_at >= $2
+	`
+
+	var count int
+	if err := db.conn.QueryRow(query, subnet, since).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count payouts by subnet: %w", err)
+	}
+
+	return count, nil
+}
+
+// CountRecentRequestsByAddress counts non-failed requests for a recipient since a time
+func (db *DB) CountRecentRequestsByAddress(address string, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM faucet_requests
+		WHERE recipient = $1 AND created_at >= $2 AND status != 'failed'
+	`
+
+	var count int
+	if err := db.conn.QueryRow(query, address, since).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count requests by address: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetRateLimitCounter returns an unexpired rate limit counter and its expiry.
+// Missing or expired counters read as zero.
+func (db *DB) GetRateLimitCounter(key string) (int, time.Time, error) {
+	query := `
+		SELECT count, expires_at FROM rate_limit_counters
+		WHERE key = $1 AND expires_at > NOW()
+	`
+
+	var count int
+	var expiresAt time.Time
+	err := db.conn.QueryRow(query, key).Scan(&count, &expiresAt)
+	if err == sql.ErrNoRows {
+		return 0, time.Time{}, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to get rate limit counter: %w", err)
+	}
+
+	return count, expiresAt, nil
+}
+
+// IncrementRateLimitCounter bumps a counter and extends its expiry; expired
+// counters restart from one
+func (db *DB) IncrementRateLimitCounter(key string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO rate_limit_counters (key, count, expires_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE
+		SET count = CASE WHEN rate_limit_counters.expires_at <= NOW() THEN 1 ELSE rate_limit_counters.count + 1 END,
+			expires_at = EXCLUDED.expires_at
+	`
+
+	if _, err := db.conn.Exec(query, key, expiresAt); err != nil {
+		return fmt.Errorf("failed to increment rate limit counter: %w", err)
+	}
+
+	return nil
+}
+
 // GetRequestsByIP gets requests from a specific IP within a time window
 func (db *DB) GetRequestsByIP(ipAddress string, since time.Time) ([]*FaucetRequest, error) {
 	query := `
-		SELECT id, recipient, amount, tx_hash, ip_address, status, created_at, completed_at
+		SELECT id, recipient, amount, COALESCE(tx_hash, ''), ip_address, status, created_at, completed_at
 		FROM faucet_requests
 		WHERE ip_address = $1 AND created_at >= $2
 		ORDER BY created_at DESC
@@ -277,49 +781,382 @@ func (db *DB) GetRequestsByIP(ipAddress string, since time.Time) ([]*FaucetReque
 
 // GetStatistics gets faucet statistics
 func (db *DB) GetStatistics() (*Statistics, error) {
+	return db.getStatistics("")
+}
+
+// GetStatisticsBySource gets faucet statistics for requests from one source
+func (db *DB) GetStatisticsBySource(source string) (*Statistics, error) {
+	return db.getStatistics(source)
+}
+
+func (db *DB) getStatistics(source string) (*Statistics, error) {
 	stats := &Statistics{}
 
+	// where builds a WHERE clause, narrowing it to source when filtering
+	var args []interface{}
+	where := func(cond string) string {
+		if source != "" {
+			if cond != "" {
+				cond += " AND "
+			}
+			cond += "source = $1"
+		}
+		if cond == "" {
+			return ""
+		}
+		return " WHERE " + cond
+	}
+	if source != "" {
+		args = append(args, source)
+	}
+
 	// Get total requests
-	err := db.conn.QueryRow("SELECT COUNT(*) FROM faucet_requests").Scan(&stats.TotalRequests)
+	err := db.reader().QueryRow("SELECT COUNT(*) FROM faucet_requests"+where(""), args...).Scan(&stats.TotalRequests)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get total requests: %w", err)
 	}
 
 	// Get successful requests
-	err = db.conn.QueryRow("SELECT COUNT(*) FROM faucet_requests WHERE status = 'success'").Scan(&stats.SuccessfulRequests)
+	err = db.reader().QueryRow("SELECT COUNT(*) FROM faucet_requests"+where("status = 'success'"), args...).Scan(&stats.SuccessfulRequests)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get successful requests: %w", err)
 	}
 
 	// Get failed requests
-	err = db.conn.QueryRow("SELECT COUNT(*) FROM faucet_requests WHERE status = 'failed'").Scan(&stats.FailedRequests)
+	err = db.reader().QueryRow("SELECT COUNT(*) FROM faucet_requests"+where("status = 'failed'"), args...).Scan(&stats.FailedRequests)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get failed requests: %w", err)
 	}
 
 	// Get total distributed
-	err = db.conn.QueryRow("SELECT COALESCE(SUM(amount), 0) FROM faucet_requests WHERE status = 'success'").Scan(&stats.TotalDistributed)
+	err = db.reader().QueryRow("SELECT COALESCE(SUM(amount), 0) FROM faucet_requests"+where("status = 'success'"), args...).Scan(saturatingSum{&stats.TotalDistributed})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get total distributed: %w", err)
 	}
 
 	// Get unique recipients
-	err = db.conn.QueryRow("SELECT COUNT(DISTINCT recipient) FROM faucet_requests WHERE status = 'success'").Scan(&stats.UniqueRecipients)
+	err = db.reader().QueryRow("SELECT COUNT(DISTINCT recipient) FROM faucet_requests"+where("status = 'success'"), args...).Scan(&stats.UniqueRecipients)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get unique recipients: %w", err)
 	}
 
 	// Get requests in last 24 hours
-	err = db.conn.QueryRow("SELECT COUNT(*) FROM faucet_requests WHERE created_at >= NOW() - INTERVAL '24 hours'").Scan(&stats.RequestsLast24h)
+	err = db.reader().QueryRow("SELECT COUNT(*) FROM faucet_requests"+where("created_at >= NOW() - INTERVAL '24 hours'"), args...).Scan(&stats.RequestsLast24h)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get requests last 24h: %w", err)
 	}
 
 	// Get requests in last hour
-	err = db.conn.QueryRow("SELECT COUNT(*) FROM faucet_requests WHERE created_at >= NOW() - INTERVAL '1 hour'").Scan(&stats.RequestsLastHour)
+	err = db.reader().QueryRow("SELECT COUNT(*) FROM faucet_requests"+where("created_at >= NOW() - INTERVAL '1 hour'"), args...).Scan(&stats.RequestsLastHour)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get requests last hour: %w", err)
 	}
 
 	return stats, nil
 }
+
+// GetStatisticsByDenom gets request counts and payouts grouped by denom.
+// Requests recorded before the denom column existed are keyed by "".
+func (db *DB) GetStatisticsByDenom() (map[string]*DenomStatistics, error) {
+	query := `
+		SELECT denom,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE status = 'success'),
+			COALESCE(SUM(amount) FILTER (WHERE status = 'success'), 0)
+		FROM faucet_requests
+		GROUP BY denom
+	`
+
+	rows, err := db.reader().Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get statistics by denom: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make(map[string]*DenomStatistics)
+	for rows.Next() {
+		s := &DenomStatistics{}
+		if err := rows.Scan(&s.Denom, &s.TotalRequests, &s.SuccessfulRequests, saturatingSum{&s.TotalDistributed}); err != nil {
+			return nil, fmt.Errorf("failed to scan denom statistics: %w", err)
+		}
+		stats[s.Denom] = s
+	}
+
+	return stats, nil
+}
+
+// saturatingSum scans a NUMERIC sum into an int64, clamping totals of
+// 18-decimal amounts that outgrow it
+type saturatingSum struct {
+	dst *int64
+}
+
+func (s saturatingSum) Scan(src interface{}) error {
+	var sum units.Amount
+	if err := sum.Scan(src); err != nil {
+		return err
+	}
+	*s.dst = sum.Saturated()
+	return nil
+}
+
+// GetSourceTotals sums successful payouts per request source, skipping
+// requests without one
+func (db *DB) GetSourceTotals() ([]*SourceTotal, error) {
+	query := `
+		SELECT source, COUNT(*), COALESCE(SUM(amount), 0)
+		FROM faucet_requests
+		WHERE status = 'success' AND source <> ''
+		GROUP BY source
+		ORDER BY source
+	`
+
+	rows, err := db.reader().Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source totals: %w", err)
+	}
+	defer rows.Close()
+
+	totals := []*SourceTotal{}
+	for rows.Next() {
+		total := &SourceTotal{}
+		if err := rows.Scan(&total.Source, &total.Requests, saturatingSum{&total.TotalDistributed}); err != nil {
+			return nil, fmt.Errorf("failed to scan source total: %w", err)
+		}
+		totals = append(totals, total)
+	}
+
+	return totals, nil
+}
+
+// EnqueueWebhookEvent stores an event in the outbox. Events are keyed by
+// eventID, so enqueueing the same event twice is a no-op.
+func (db *DB) EnqueueWebhookEvent(eventID, eventType string, payload []byte) error {
+	query := `
+		INSERT INTO webhook_outbox (event_id, event_type, payload)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (event_id) DO NOTHING
+	`
+
+	_, err := db.conn.Exec(query, eventID, eventType, string(payload))
+	if err != nil {
+		return fmt.Errorf("failed to enqueue webhook event: %w", err)
+	}
+
+	return nil
+}
+
+// GetPendingWebhookEvents returns outbox events that are due for delivery
+func (db *DB) GetPendingWebhookEvents(limit int) ([]*WebhookEvent, error) {
+	query := `
+		SELECT id, event_id, event_type, payload, attempts
+		FROM webhook_outbox
+		WHERE status = 'pending' AND next_attempt_at <= NOW()
+		ORDER BY id
+		LIMIT $1
+	`
+
+	rows, err := db.conn.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending webhook events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*WebhookEvent
+	for rows.Next() {
+		event := &WebhookEvent{}
+		var payload string
+		if err := rows.Scan(&event.ID, &event.EventID, &event.EventType, &payload, &event.Attempts); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook event: %w", err)
+		}
+		event.Payload = []byte(payload)
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// MarkWebhookDelivered marks an outbox event as delivered
+func (db *DB) MarkWebhookDelivered(id int64) error {
+	query := `
+		UPDATE webhook_outbox
+		SET status = 'delivered', delivered_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	_, err := db.conn.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook delivered: %w", err)
+	}
+
+	return nil
+}
+
+// MarkWebhookFailed records a failed delivery attempt and schedules the next
+// one. Events that reach maxAttempts are parked as 'dead'.
+func (db *DB) MarkWebhookFailed(id int64, errorMsg string, nextAttempt time.Time, maxAttempts int) error {
+	query := `
+		UPDATE webhook_outbox
+		SET attempts = attempts + 1,
+			last_error = $1,
+			next_attempt_at = $2,
+			status = CASE WHEN attempts + 1 >= $3 THEN 'dead' ELSE 'pending' END
+		WHERE id = $4
+	`
+
+	_, err := db.conn.Exec(query, errorMsg, nextAttempt, maxAttempts, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook failed: %w", err)
+	}
+
+	return nil
+}
+
+// CreateEmailVerification records a code sent to email unless max codes
+// were already sent since the given time. It returns false at the limit.
+// The count and insert run under a per-email lock, so concurrent requests
+// can't exceed max.
+func (db *DB) CreateEmailVerification(email, codeHash, ipAddress string, expiresAt, since time.Time, max int) (bool, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to create email verification: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1))`, "email_verifications:"+email); err != nil {
+		return false, fmt.Errorf("failed to lock email verifications: %w", err)
+	}
+
+	query := `
+		INSERT INTO email_verifications (email, code_hash, ip_address, expires_at)
+		SELECT $1, $2, $3, $4
+		WHERE (SELECT COUNT(*) FROM email_verifications WHERE email = $1 AND created_at >= $5) < $6
+	`
+
+	result, err := tx.Exec(query, email, codeHash, ipAddress, expiresAt, since, max)
+	if err != nil {
+		return false, fmt.Errorf("failed to create email verification: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to create email verification: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to create email verification: %w", err)
+	}
+
+	return rows == 1, nil
+}
+
+// GetPendingEmailVerification returns the newest unexpired, unverified
+// code for email, or nil when there is none
+func (db *DB) GetPendingEmailVerification(email string) (*EmailVerification, error) {
+	query := `
+		SELECT id, email, code_hash, attempts, expires_at
+		FROM email_verifications
+		WHERE email = $1 AND verified_at IS NULL AND expires_at > NOW()
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	var v EmailVerification
+	err := db.conn.QueryRow(query, email).Scan(&v.ID, &v.Email, &v.CodeHash, &v.Attempts, &v.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email verification: %w", err)
+	}
+
+	return &v, nil
+}
+
+// RecordEmailVerificationAttempt counts a wrong code entered for a
+// verification
+func (db *DB) RecordEmailVerificationAttempt(id int64) error {
+	query := `UPDATE email_verifications SET attempts = attempts + 1 WHERE id = $1`
+
+	if _, err := db.conn.Exec(query, id); err != nil {
+		return fmt.Errorf("failed to record email verification attempt: %w", err)
+	}
+
+	return nil
+}
+
+// MarkEmailVerified consumes a verification and stores the hash of the
+// token issued for it. It returns false when the code was already used, so
+// of concurrent verifications only one succeeds.
+func (db *DB) MarkEmailVerified(id int64, tokenHash string, tokenExpiresAt time.Time) (bool, error) {
+	query := `
+		UPDATE email_verifications
+		SET verified_at = CURRENT_TIMESTAMP, token_hash = $2, token_expires_at = $3
+		WHERE id = $1 AND verified_at IS NULL
+	`
+
+	result, err := db.conn.Exec(query, id, tokenHash, tokenExpiresAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark email verified: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark email verified: %w", err)
+	}
+
+	return rows == 1, nil
+}
+
+// RedeemEmailToken consumes the unexpired token with the given hash and
+// returns the email it was issued for, or "" when there is none. Of
+// concurrent redemptions only one succeeds.
+func (db *DB) RedeemEmailToken(tokenHash string) (string, error) {
+	query := `
+		UPDATE email_verifications
+		SET token_redeemed_at = CURRENT_TIMESTAMP
+		WHERE token_hash = $1 AND token_redeemed_at IS NULL AND token_expires_at > NOW()
+		RETURNING email
+	`
+
+	var email string
+	err := db.conn.QueryRow(query, tokenHash).Scan(&email)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to redeem email token: %w", err)
+	}
+
+	return email, nil
+}
+
+// SaveMetricsSnapshot stores data as the named tracker's snapshot,
+// replacing the previous one
+func (db *DB) SaveMetricsSnapshot(name string, data []byte) error {
+	query := `
+		INSERT INTO metrics_snapshots (name, data, updated_at)
+		VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (name) DO UPDATE SET data = EXCLUDED.data, updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := db.conn.Exec(query, name, data); err != nil {
+		return fmt.Errorf("failed to save metrics snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// GetMetricsSnapshot returns the named tracker's snapshot, or nil when
+// none has been saved
+func (db *DB) GetMetricsSnapshot(name string) ([]byte, error) {
+	query := `SELECT data FROM metrics_snapshots WHERE name = $1`
+
+	var data []byte
+	err := db.conn.QueryRow(query, name).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metrics snapshot: %w", err)
+	}
+
+	return data, nil
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"math"
 	"regexp"
 	"testing"
 	"time"
@@ -8,6 +9,8 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/aura-chain/aura/faucet/pkg/units"
 )
 
 // setupMockDB returns a DB backed by sqlmock plus cleanup.
@@ -21,7 +24,11 @@ func TestMigrateCreatesTablesAndIndexes(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
 
-	mock.ExpectExec(regexp.QuoteMeta(`
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE IF NOT EXISTS schema_migrations`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT version FROM schema_migrations`)).WillReturnRows(sqlmock.NewRows([]string{"version"}))
+
+	steps := []string{
+		`
 	CREATE TABLE IF NOT EXISTS faucet_requests (
 		id SERIAL PRIMARY KEY,
 		recipient VARCHAR(255) NOT NULL,
@@ -38,30 +45,185 @@ func TestMigrateCreatesTablesAndIndexes(t *testing.T) {
 	CREATE INDEX IF NOT EXISTS idx_ip_address ON faucet_requests(ip_address);
 	CREATE INDEX IF NOT EXISTS idx_created_at ON faucet_requests(created_at);
 	CREATE INDEX IF NOT EXISTS idx_status ON faucet_requests(status);
-	`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	`,
+		`CREATE TABLE IF NOT EXISTS webhook_outbox`,
+		`CREATE TABLE IF NOT EXISTS rate_limit_counters`,
+		`ALTER TABLE faucet_requests ADD COLUMN IF NOT EXISTS source VARCHAR(64) NOT NULL DEFAULT ''`,
+		`ALTER TABLE faucet_requests ADD COLUMN IF NOT EXISTS denom VARCHAR(64) NOT NULL DEFAULT ''`,
+		`ALTER TABLE faucet_requests ADD COLUMN IF NOT EXISTS retry_attempts`,
+		`ALTER TABLE faucet_requests ADD COLUMN IF NOT EXISTS bonus_granted`,
+		`CREATE INDEX IF NOT EXISTS idx_tx_hash`,
+		`CREATE TABLE IF NOT EXISTS email_verifications`,
+		`CREATE TABLE IF NOT EXISTS metrics_snapshots`,
+		`ALTER TABLE faucet_requests ALTER COLUMN amount TYPE NUMERIC(78, 0)`,
+		`ALTER TABLE email_verifications ADD COLUMN IF NOT EXISTS token_hash`,
+		`UPDATE faucet_requests SET bonus_granted = FALSE`,
+		`ALTER TABLE faucet_requests ADD COLUMN IF NOT EXISTS ip_inet INET`,
+	}
+	for i, step := range steps {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(step)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO schema_migrations (version, name)`)).
+			WithArgs(i+1, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
 
 	require.NoError(t, db.Migrate())
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestMigrateSkipsAppliedMigrations(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	steps := []migration{
+		{1, "first", `CREATE TABLE IF NOT EXISTS first_table (id INT)`},
+		{2, "second", `ALTER TABLE first_table ADD COLUMN IF NOT EXISTS second INT`},
+		{3, "third", `CREATE INDEX IF NOT EXISTS idx_second ON first_table(second)`},
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE IF NOT EXISTS schema_migrations`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT version FROM schema_migrations`)).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1).AddRow(2))
+	// Only the new step runs
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE INDEX IF NOT EXISTS idx_second`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO schema_migrations (version, name)`)).
+		WithArgs(3, "third").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, db.migrate(steps))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	// Nothing runs once every step is recorded
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE IF NOT EXISTS schema_migrations`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT version FROM schema_migrations`)).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1).AddRow(2).AddRow(3))
+
+	require.NoError(t, db.migrate(steps))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrateFailedStepIsNotRecorded(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	steps := []migration{
+		{1, "broken", `ALTER TABLE missing ADD COLUMN x INT`},
+		{2, "never reached", `CREATE INDEX IF NOT EXISTS idx_x ON missing(x)`},
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE IF NOT EXISTS schema_migrations`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT version FROM schema_migrations`)).WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`ALTER TABLE missing`)).WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+
+	err := db.migrate(steps)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migration 1 (broken)")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestCreateRequestInsertsRow(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
 
 	now := time.Now()
 	mock.ExpectQuery(regexp.QuoteMeta(`
-		INSERT INTO faucet_requests (recipient, amount, ip_address, status)
-		VALUES ($1, $2, $3, 'pending')
-		RETURNING id, recipient, amount, ip_address, status, created_at
+		INSERT INTO faucet_requests (recipient, amount, ip_address, status, denom, source, ip_inet)
+		VALUES ($1, $2, $3, 'pending', $4, $5, $6)
+		RETURNING id, recipient, amount, ip_address, status, denom, source, created_at
 	`)).
-		WithArgs("addr1", int64(10), "1.1.1.1").
-		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "ip_address", "status", "created_at"}).
-			AddRow(int64(1), "addr1", int64(10), "1.1.1.1", "pending", now))
+		WithArgs("addr1", int64(10), "1.1.1.1", "uaura", "hackathon", "1.1.1.1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "ip_address", "status", "denom", "source", "created_at"}).
+			AddRow(int64(1), "addr1", int64(10), "1.1.1.1", "pending", "uaura", "hackathon", now))
 
-	req, err := db.CreateRequest("addr1", "1.1.1.1", 10)
+	req, err := db.CreateRequest("addr1", "1.1.1.1", units.New(10), "uaura", "hackathon")
 	require.NoError(t, err)
 	assert.Equal(t, int64(1), req.ID)
 	assert.Equal(t, "pending", req.Status)
+	assert.Equal(t, "uaura", req.Denom)
+	assert.Equal(t, "hackathon", req.Source)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateRequestWithoutIPLeavesNetworkNull(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	// Subnet limits never match a request whose address is not an IP
+	mock.ExpectQuery(`INSERT INTO faucet_requests`).
+		WithArgs("addr1", int64(10), "unknown", "uaura", "", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "ip_address", "status", "denom", "source", "created_at"}).
+			AddRow(int64(1), "addr1", int64(10), "unknown", "pending", "uaura", "", time.Now()))
+
+	_, err := db.CreateRequest("addr1", "unknown", units.New(10), "uaura", "")
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateRequestEighteenDecimalAmount(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	// Amounts beyond int64 are bound and read back as NUMERIC strings
+	amount, err := units.Parse("100000000000000000000")
+	require.NoError(t, err)
+	mock.ExpectQuery(`INSERT INTO faucet_requests`).
+		WithArgs("addr1", "100000000000000000000", "1.1.1.1", "aevm", "", "1.1.1.1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "ip_address", "status", "denom", "source", "created_at"}).
+			AddRow(int64(1), "addr1", []byte("100000000000000000000"), "1.1.1.1", "pending", "aevm", "", time.Now()))
+
+	req, err := db.CreateRequest("addr1", "1.1.1.1", amount, "aevm", "")
+	require.NoError(t, err)
+	assert.Equal(t, amount, req.Amount)
+
+	// Sums beyond int64 saturate instead of failing the scan
+	mock.ExpectQuery(`SELECT source, COUNT`).
+		WillReturnRows(sqlmock.NewRows([]string{"source", "count", "sum"}).
+			AddRow("evm", int64(3), []byte("300000000000000000000")))
+	totals, err := db.GetSourceTotals()
+	require.NoError(t, err)
+	assert.Equal(t, []*SourceTotal{{Source: "evm", Requests: 3, TotalDistributed: math.MaxInt64}}, totals)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateBonusRequest(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta(`ON CONFLICT (recipient) WHERE bonus_granted AND status <> 'failed' DO NOTHING`)
+	mock.ExpectQuery(query).
+		WithArgs("addr1", int64(150), "1.1.1.1", "uaura", "", "1.1.1.1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "ip_address", "status", "denom", "source", "created_at"}).
+			AddRow(int64(1), "addr1", int64(150), "1.1.1.1", "pending", "uaura", "", time.Now()))
+	req, err := db.CreateBonusRequest("addr1", "1.1.1.1", units.New(150), "uaura", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), req.ID)
+
+	// A concurrent first request already holds the bonus
+	mock.ExpectQuery(query).
+		WithArgs("addr1", int64(150), "1.1.1.2", "uaura", "", "1.1.1.2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "ip_address", "status", "denom", "source", "created_at"}))
+	_, err = db.CreateBonusRequest("addr1", "1.1.1.2", units.New(150), "uaura", "")
+	assert.ErrorIs(t, err, ErrBonusClaimed)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHasClaimedBonus(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND (status = 'success' OR (bonus_granted AND status <> 'failed'))`)).
+		WithArgs("addr1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	claimed, err := db.HasClaimedBonus("addr1")
+	require.NoError(t, err)
+	assert.True(t, claimed)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -97,6 +259,22 @@ func TestUpdateRequestFailed(t *testing.T) {
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestUpdateRequestTimedOut(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectExec(regexp.QuoteMeta(`
+		UPDATE faucet_requests
+		SET status = 'timeout', error = $1, completed_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`)).
+		WithArgs("deadline", int64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, db.UpdateRequestTimedOut(4, "deadline"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestGetRecentRequests(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
@@ -118,6 +296,27 @@ func TestGetRecentRequests(t *testing.T) {
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestGetRecentRequestsBySource(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"})
+	rows.AddRow(int64(1), "addr1", int64(10), "tx1", "1.1.1.1", "success", time.Now(), time.Now())
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, recipient, amount, tx_hash, ip_address, status, created_at, completed_at
+		FROM faucet_requests
+		WHERE status = 'success' AND source = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`)).WithArgs("hackathon", 5).WillReturnRows(rows)
+
+	reqs, err := db.GetRecentRequestsBySource("hackathon", 5)
+	require.NoError(t, err)
+	assert.Len(t, reqs, 1)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestGetRequestsByAddress(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
@@ -138,15 +337,93 @@ func TestGetRequestsByAddress(t *testing.T) {
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestHasPendingRequest(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT EXISTS (
+			SELECT 1 FROM faucet_requests
+			WHERE recipient = $1 AND status = 'pending' AND created_at >= $2
+		)
+	`)).WithArgs("addr1", sqlmock.AnyArg()).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	pending, err := db.HasPendingRequest("addr1", time.Now().Add(-10*time.Minute))
+	require.NoError(t, err)
+	assert.True(t, pending)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCountRecentRequests(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	since := time.Now().Add(-time.Hour)
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT COUNT(*) FROM faucet_requests
+		WHERE ip_address = $1 AND created_at >= $2 AND status != 'failed'
+	`)).WithArgs("1.1.1.1", since).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT COUNT(*) FROM faucet_requests
+		WHERE recipient = $1 AND created_at >= $2 AND status != 'failed'
+	`)).WithArgs("addr1", since).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	byIP, err := db.CountRecentRequestsByIP("1.1.1.1", since)
+	require.NoError(t, err)
+	assert.Equal(t, 3, byIP)
+
+	byAddress, err := db.CountRecentRequestsByAddress("addr1", since)
+	require.NoError(t, err)
+	assert.Equal(t, 1, byAddress)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRateLimitCounters(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	getQuery := regexp.QuoteMeta(`
+		SELECT count, expires_at FROM rate_limit_counters
+		WHERE key = $1 AND expires_at > NOW()
+	`)
+	expiresAt := time.Now().Add(time.Hour)
+
+	mock.ExpectQuery(getQuery).WithArgs("ratelimit:ip:1.1.1.1").
+		WillReturnRows(sqlmock.NewRows([]string{"count", "expires_at"}))
+	mock.ExpectExec(regexp.QuoteMeta(`
+		INSERT INTO rate_limit_counters (key, count, expires_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE
+		SET count = CASE WHEN rate_limit_counters.expires_at <= NOW() THEN 1 ELSE rate_limit_counters.count + 1 END,
+			expires_at = EXCLUDED.expires_at
+	`)).WithArgs("ratelimit:ip:1.1.1.1", expiresAt).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(getQuery).WithArgs("ratelimit:ip:1.1.1.1").
+		WillReturnRows(sqlmock.NewRows([]string{"count", "expires_at"}).AddRow(1, expiresAt))
+
+	count, _, err := db.GetRateLimitCounter("ratelimit:ip:1.1.1.1")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	require.NoError(t, db.IncrementRateLimitCounter("ratelimit:ip:1.1.1.1", expiresAt))
+
+	count, expiry, err := db.GetRateLimitCounter("ratelimit:ip:1.1.1.1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, expiresAt, expiry)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestGetRequestsByIP(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
 
+	// Pending and failed requests have no tx_hash yet
 	rows := sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"})
 	rows.AddRow(int64(1), "addr1", int64(10), "tx1", "1.1.1.1", "success", time.Now(), time.Now())
+	rows.AddRow(int64(2), "addr2", int64(10), "", "1.1.1.1", "pending", time.Now(), nil)
 
 	mock.ExpectQuery(regexp.QuoteMeta(`
-		SELECT id, recipient, amount, tx_hash, ip_address, status, created_at, completed_at
+		SELECT id, recipient, amount, COALESCE(tx_hash, ''), ip_address, status, created_at, completed_at
 		FROM faucet_requests
 		WHERE ip_address = $1 AND created_at >= $2
 		ORDER BY created_at DESC
@@ -154,7 +431,22 @@ func TestGetRequestsByIP(t *testing.T) {
 
 	reqs, err := db.GetRequestsByIP("1.1.1.1", time.Now().Add(-time.Hour))
 	require.NoError(t, err)
-	assert.Len(t, reqs, 1)
+	assert.Len(t, reqs, 2)
+	assert.Empty(t, reqs[1].TxHash)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHasFundedOtherAddress(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE ip_address = $1 AND recipient <> $2 AND status <> 'failed'`)).
+		WithArgs("1.1.1.1", "addr1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	funded, err := db.HasFundedOtherAddress("1.1.1.1", "addr1")
+	require.NoError(t, err)
+	assert.True(t, funded)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -181,3 +473,448 @@ func TestGetStatistics(t *testing.T) {
 	assert.Equal(t, int64(2), stats.RequestsLastHour)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestGetStatisticsBySource(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	queries := []string{
+		"SELECT COUNT(*) FROM faucet_requests WHERE source = $1",
+		"SELECT COUNT(*) FROM faucet_requests WHERE status = 'success' AND source = $1",
+		"SELECT COUNT(*) FROM faucet_requests WHERE status = 'failed' AND source = $1",
+		"SELECT COALESCE(SUM(amount), 0) FROM faucet_requests WHERE status = 'success' AND source = $1",
+		"SELECT COUNT(DISTINCT recipient) FROM faucet_requests WHERE status = 'success' AND source = $1",
+		"SELECT COUNT(*) FROM faucet_requests WHERE created_at >= NOW() - INTERVAL '24 hours' AND source = $1",
+		"SELECT COUNT(*) FROM faucet_requests WHERE created_at >= NOW() - INTERVAL '1 hour' AND source = $1",
+	}
+	for i, query := range queries {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("docs").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(i + 1)))
+	}
+
+	stats, err := db.GetStatisticsBySource("docs")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.TotalRequests)
+	assert.Equal(t, int64(4), stats.TotalDistributed)
+	assert.Equal(t, int64(7), stats.RequestsLastHour)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetStatisticsByDenom(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT denom,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE status = 'success'),
+			COALESCE(SUM(amount) FILTER (WHERE status = 'success'), 0)
+		FROM faucet_requests
+		GROUP BY denom
+	`)).WillReturnRows(sqlmock.NewRows([]string{"denom", "count", "successful", "sum"}).
+		AddRow("uaura", int64(10), int64(8), int64(800)).
+		AddRow("uosmo", int64(3), int64(2), int64(14)))
+
+	stats, err := db.GetStatisticsByDenom()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*DenomStatistics{
+		"uaura": {Denom: "uaura", TotalRequests: 10, SuccessfulRequests: 8, TotalDistributed: 800},
+		"uosmo": {Denom: "uosmo", TotalRequests: 3, SuccessfulRequests: 2, TotalDistributed: 14},
+	}, stats)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSourceTotals(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT source, COUNT(*), COALESCE(SUM(amount), 0)
+		FROM faucet_requests
+		WHERE status = 'success' AND source <> ''
+		GROUP BY source
+		ORDER BY source
+	`)).WillReturnRows(sqlmock.NewRows([]string{"source", "count", "sum"}).
+		AddRow("docs", int64(2), int64(200)).
+		AddRow("hackathon", int64(5), int64(500)))
+
+	totals, err := db.GetSourceTotals()
+	require.NoError(t, err)
+	assert.Equal(t, []*SourceTotal{
+		{Source: "docs", Requests: 2, TotalDistributed: 200},
+		{Source: "hackathon", Requests: 5, TotalDistributed: 500},
+	}, totals)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnqueueWebhookEvent(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectExec(regexp.QuoteMeta(`
+		INSERT INTO webhook_outbox (event_id, event_type, payload)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (event_id) DO NOTHING
+	`)).
+		WithArgs("payout-1", "payout.success", `{"id":"payout-1"}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	require.NoError(t, db.EnqueueWebhookEvent("payout-1", "payout.success", []byte(`{"id":"payout-1"}`)))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPendingWebhookEventsAndMarkDelivered(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{"id", "event_id", "event_type", "payload", "attempts"}).
+		AddRow(int64(7), "payout-1", "payout.success", `{}`, 2)
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, event_id, event_type, payload, attempts
+		FROM webhook_outbox
+		WHERE status = 'pending' AND next_attempt_at <= NOW()
+		ORDER BY id
+		LIMIT $1
+	`)).WithArgs(10).WillReturnRows(rows)
+	mock.ExpectExec(regexp.QuoteMeta(`
+		UPDATE webhook_outbox
+		SET status = 'delivered', delivered_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`)).WithArgs(int64(7)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	events, err := db.GetPendingWebhookEvents(10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "payout-1", events[0].EventID)
+	assert.Equal(t, 2, events[0].Attempts)
+
+	require.NoError(t, db.MarkWebhookDelivered(events[0].ID))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMarkWebhookFailed(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	next := time.Now().Add(time.Minute)
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE webhook_outbox`)).
+		WithArgs("status 500", next, 5, int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, db.MarkWebhookFailed(7, "status 500", next, 5))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetRequest(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM faucet_requests
+		WHERE id = $1`)).
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "denom", "source", "retry_attempts", "created_at"}).
+			AddRow(int64(7), "addr1", int64(10), "", "1.1.1.1", "retrying", "uaura", "", 2, now))
+
+	req, err := db.GetRequest(7)
+	require.NoError(t, err)
+	assert.Equal(t, "retrying", req.Status)
+	assert.Equal(t, 2, req.RetryAttempts)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE id = $1`)).
+		WithArgs(int64(8)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err = db.GetRequest(8)
+	assert.ErrorIs(t, err, ErrRequestNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPayoutRetryQueue(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	next := time.Now().Add(time.Minute)
+	mock.ExpectExec(regexp.QuoteMeta(`status = CASE WHEN retry_attempts >= $3 THEN 'failed' ELSE 'retrying' END`)).
+		WithArgs("boom", next, 3, int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, db.ScheduleRequestRetry(7, "boom", next, 3))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE status = 'retrying' AND denom = $1 AND next_retry_at <= NOW()`)).
+		WithArgs("uaura", 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(7)).AddRow(int64(9)))
+	ids, err := db.GetDueRetries("uaura", 20)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{7, 9}, ids)
+
+	mock.ExpectExec(regexp.QuoteMeta(`WHERE id = $1 AND status IN ('failed', 'retrying')`)).
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	claimed, err := db.ClaimRequestForReplay(7)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	// A second claim finds the request already pending
+	mock.ExpectExec(regexp.QuoteMeta(`WHERE id = $1 AND status IN ('failed', 'retrying')`)).
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	claimed, err = db.ClaimRequestForReplay(7)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND status = 'success' AND created_at >= $2`)).
+		WithArgs("addr1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	paid, err := db.HasSuccessfulPayoutSince("addr1", time.Now())
+	require.NoError(t, err)
+	assert.False(t, paid)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReadQueriesUseReplica(t *testing.T) {
+	db, primary, cleanup := setupMockDB(t)
+	defer cleanup()
+	replicaConn, replica, err := sqlmock.New()
+	require.NoError(t, err)
+	defer replicaConn.Close()
+	db.SetReadConn(replicaConn)
+
+	replica.ExpectQuery(regexp.QuoteMeta(`WHERE status = 'success'`)).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "created_at", "completed_at"}))
+	for i := 0; i < 7; i++ {
+		replica.ExpectQuery(`SELECT`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(1)))
+	}
+	replica.ExpectQuery(regexp.QuoteMeta(`GROUP BY denom`)).
+		WillReturnRows(sqlmock.NewRows([]string{"denom", "count", "success", "sum"}))
+
+	_, err = db.GetRecentRequests(5)
+	require.NoError(t, err)
+	_, err = db.GetStatistics()
+	require.NoError(t, err)
+	_, err = db.GetStatisticsByDenom()
+	require.NoError(t, err)
+
+	// Writes and limit checks stay on the primary
+	primary.ExpectExec(regexp.QuoteMeta(`SET status = 'success'`)).
+		WithArgs("ABC", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	primary.ExpectQuery(regexp.QuoteMeta(`WHERE recipient = $1 AND status = 'pending'`)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	require.NoError(t, db.UpdateRequestSuccess(1, "ABC"))
+	_, err = db.HasPendingRequest("addr1", time.Now())
+	require.NoError(t, err)
+
+	require.NoError(t, replica.ExpectationsWereMet())
+	require.NoError(t, primary.ExpectationsWereMet())
+}
+
+func TestReadQueriesFallBackToPrimary(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`GROUP BY source`)).
+		WillReturnRows(sqlmock.NewRows([]string{"source", "count", "sum"}))
+
+	_, err := db.GetSourceTotals()
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetRequestByTxHash(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE tx_hash = $1`)).
+		WithArgs("ABC").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "status", "denom", "source", "created_at", "completed_at"}).
+			AddRow(int64(3), "addr1", int64(10), "ABC", "success", "uaura", "", now, now))
+
+	req, err := db.GetRequestByTxHash("ABC")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), req.ID)
+	require.NotNil(t, req.CompletedAt)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE tx_hash = $1`)).
+		WithArgs("NOPE").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err = db.GetRequestByTxHash("NOPE")
+	assert.ErrorIs(t, err, ErrRequestNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetRequestStatusByID(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta(`COALESCE(tx_hash, ''), status, denom, source, created_at, completed_at`)).
+		WithArgs(int64(4)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "status", "denom", "source", "created_at", "completed_at"}).
+			AddRow(int64(4), "addr1", int64(10), "", "pending", "uaura", "", now, nil))
+
+	req, err := db.GetRequestStatusByID(4)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", req.Status)
+	assert.Empty(t, req.TxHash)
+	assert.Nil(t, req.CompletedAt)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE id = $1`)).
+		WithArgs(int64(99)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err = db.GetRequestStatusByID(99)
+	assert.ErrorIs(t, err, ErrRequestNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCountDistinctRecipientsByIP(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	since := time.Now().Add(-time.Hour)
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT COUNT(DISTINCT recipient) FROM faucet_requests
+		WHERE ip_address = $1 AND created_at >= $2 AND status != 'failed' AND recipient != $3
+	`)).WithArgs("1.1.1.1", since, "addr1").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	count, err := db.CountDistinctRecipientsByIP("1.1.1.1", "addr1", since)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCountUniqueRecipients(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(DISTINCT recipient) FROM faucet_requests WHERE status = 'success'`)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+	count, err := db.CountUniqueRecipients()
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), count)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCountSuccessfulPayoutsBySubnet(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	since := time.Now().Add(-time.Hour)
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT COUNT(*) FROM faucet_requests
+		WHERE status = 'success' AND ip_inet <<= $1::inet AND created_at >= $2
+	`)).WithArgs("192.0.2.0/24", since).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+
+	count, err := db.CountSuccessfulPayoutsBySubnet("192.0.2.0/24", since)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEmailVerifications(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	expiresAt := time.Now().Add(10 * time.Minute)
+	since := time.Now().Add(-24 * time.Hour)
+	expectCreate := func(inserted int64) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock(hashtext($1))`)).
+			WithArgs("email_verifications:user@example.com").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO email_verifications (email, code_hash, ip_address, expires_at)`)).
+			WithArgs("user@example.com", "hash", "1.1.1.1", expiresAt, since, 2).
+			WillReturnResult(sqlmock.NewResult(1, inserted))
+		mock.ExpectCommit()
+	}
+	expectCreate(1)
+	created, err := db.CreateEmailVerification("user@example.com", "hash", "1.1.1.1", expiresAt, since, 2)
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	// At the limit nothing is inserted
+	expectCreate(0)
+	created, err = db.CreateEmailVerification("user@example.com", "hash", "1.1.1.1", expiresAt, since, 2)
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE email = $1 AND verified_at IS NULL AND expires_at > NOW()`)).
+		WithArgs("user@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "code_hash", "attempts", "expires_at"}).
+			AddRow(int64(1), "user@example.com", "hash", 1, expiresAt))
+	pending, err := db.GetPendingEmailVerification("user@example.com")
+	require.NoError(t, err)
+	require.NotNil(t, pending)
+	assert.Equal(t, int64(1), pending.ID)
+	assert.Equal(t, 1, pending.Attempts)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE email = $1 AND verified_at IS NULL AND expires_at > NOW()`)).
+		WithArgs("other@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "code_hash", "attempts", "expires_at"}))
+	pending, err = db.GetPendingEmailVerification("other@example.com")
+	require.NoError(t, err)
+	assert.Nil(t, pending)
+
+	mock.ExpectExec(regexp.QuoteMeta(`SET attempts = attempts + 1 WHERE id = $1`)).
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, db.RecordEmailVerificationAttempt(1))
+
+	tokenExpiresAt := time.Now().Add(15 * time.Minute)
+	mock.ExpectExec(regexp.QuoteMeta(`WHERE id = $1 AND verified_at IS NULL`)).
+		WithArgs(int64(1), "tokenhash", tokenExpiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	verified, err := db.MarkEmailVerified(1, "tokenhash", tokenExpiresAt)
+	require.NoError(t, err)
+	assert.True(t, verified)
+
+	// A second verification finds the code already used
+	mock.ExpectExec(regexp.QuoteMeta(`WHERE id = $1 AND verified_at IS NULL`)).
+		WithArgs(int64(1), "tokenhash", tokenExpiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	verified, err = db.MarkEmailVerified(1, "tokenhash", tokenExpiresAt)
+	require.NoError(t, err)
+	assert.False(t, verified)
+
+	redeem := regexp.QuoteMeta(`WHERE token_hash = $1 AND token_redeemed_at IS NULL AND token_expires_at > NOW()`)
+	mock.ExpectQuery(redeem).WithArgs("tokenhash").
+		WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("user@example.com"))
+	email, err := db.RedeemEmailToken("tokenhash")
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com", email)
+
+	// A redeemed token is gone
+	mock.ExpectQuery(redeem).WithArgs("tokenhash").WillReturnRows(sqlmock.NewRows([]string{"email"}))
+	email, err = db.RedeemEmailToken("tokenhash")
+	require.NoError(t, err)
+	assert.Empty(t, email)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMetricsSnapshots(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	data := []byte(`{"total_requests":3}`)
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO metrics_snapshots (name, data, updated_at)`)).
+		WithArgs("tracker", data).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, db.SaveMetricsSnapshot("tracker", data))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT data FROM metrics_snapshots WHERE name = $1`)).
+		WithArgs("tracker").
+		WillReturnRows(sqlmock.NewRows([]string{"data"}).AddRow(data))
+	restored, err := db.GetMetricsSnapshot("tracker")
+	require.NoError(t, err)
+	assert.JSONEq(t, string(data), string(restored))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT data FROM metrics_snapshots WHERE name = $1`)).
+		WithArgs("other").
+		WillReturnRows(sqlmock.NewRows([]string{"data"}))
+	restored, err = db.GetMetricsSnapshot("other")
+	require.NoError(t, err)
+	assert.Nil(t, restored)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,225 @@
+package database
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// migration is one schema change, recorded in schema_migrations by version
+// once applied. Steps must stay idempotent (IF NOT EXISTS): databases
+// created before versioning replay every step once to record it.
+type migration struct {
+	version int
+	name    string
+	query   string
+}
+
+// migrations are applied in order. Append new steps with the next version;
+// never edit or renumber a released one.
+var migrations = []migration{
+	{1, "faucet requests", `
+	CREATE TABLE IF NOT EXISTS faucet_requests (
+		id SERIAL PRIMARY KEY,
+		recipient VARCHAR(255) NOT NULL,
+		amount BIGINT NOT NULL,
+		tx_hash VARCHAR(255),
+		ip_address VARCHAR(45) NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'pending',
+		error TEXT,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+		completed_at TIMESTAMP WITH TIME ZONE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_recipient ON faucet_requests(recipient);
+	CREATE INDEX IF NOT EXISTS idx_ip_address ON faucet_requests(ip_address);
+	CREATE INDEX IF NOT EXISTS idx_created_at ON faucet_requests(created_at);
+	CREATE INDEX IF NOT EXISTS idx_status ON faucet_requests(status);
+	`},
+	{2, "webhook outbox", `
+	CREATE TABLE IF NOT EXISTS webhook_outbox (
+		id SERIAL PRIMARY KEY,
+		event_id VARCHAR(255) NOT NULL UNIQUE,
+		event_type VARCHAR(64) NOT NULL,
+		payload TEXT NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'pending',
+		attempts INT NOT NULL DEFAULT 0,
+		last_error TEXT,
+		next_attempt_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+		delivered_at TIMESTAMP WITH TIME ZONE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_webhook_outbox_pending ON webhook_outbox(status, next_attempt_at);
+	`},
+	{3, "rate limit counters", `
+	CREATE TABLE IF NOT EXISTS rate_limit_counters (
+		key VARCHAR(255) PRIMARY KEY,
+		count INT NOT NULL DEFAULT 0,
+		expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_rate_limit_counters_expires ON rate_limit_counters(expires_at);
+	`},
+	{4, "request source", `
+	ALTER TABLE faucet_requests ADD COLUMN IF NOT EXISTS source VARCHAR(64) NOT NULL DEFAULT '';
+
+	CREATE INDEX IF NOT EXISTS idx_source ON faucet_requests(source);
+	`},
+	{5, "request denom", `
+	ALTER TABLE faucet_requests ADD COLUMN IF NOT EXISTS denom VARCHAR(64) NOT NULL DEFAULT '';
+
+	CREATE INDEX IF NOT EXISTS idx_denom ON faucet_requests(denom);
+	`},
+	{6, "payout retries", `
+	ALTER TABLE faucet_requests ADD COLUMN IF NOT EXISTS retry_attempts INT NOT NULL DEFAULT 0;
+	ALTER TABLE faucet_requests ADD COLUMN IF NOT EXISTS next_retry_at TIMESTAMP WITH TIME ZONE;
+
+	CREATE INDEX IF NOT EXISTS idx_retry_due ON faucet_requests(status, next_retry_at);
+	`},
+	// bonus_granted marks the request that paid an address's one-time
+	// first-request bonus
+	{7, "first-request bonus", `ALTER TABLE faucet_requests ADD COLUMN IF NOT EXISTS bonus_granted BOOLEAN NOT NULL DEFAULT FALSE`},
+	// Receipts and status lookups find requests by transaction hash
+	{8, "tx hash index", `CREATE INDEX IF NOT EXISTS idx_tx_hash ON faucet_requests(tx_hash)`},
+	{9, "email verifications", `
+	CREATE TABLE IF NOT EXISTS email_verifications (
+		id SERIAL PRIMARY KEY,
+		email VARCHAR(255) NOT NULL,
+		code_hash VARCHAR(64) NOT NULL,
+		ip_address VARCHAR(45) NOT NULL,
+		attempts INT NOT NULL DEFAULT 0,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+		expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		verified_at TIMESTAMP WITH TIME ZONE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_email_verifications_email ON email_verifications(email, created_at);
+	`},
+	// One row per tracker, overwritten by each snapshot
+	{10, "metrics snapshots", `
+	CREATE TABLE IF NOT EXISTS metrics_snapshots (
+		name VARCHAR(64) PRIMARY KEY,
+		data JSONB NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+	)
+	`},
+	// 18-decimal denoms send amounts beyond BIGINT; NUMERIC holds any coin
+	// amount and still sums
+	{11, "numeric amounts", `ALTER TABLE faucet_requests ALTER COLUMN amount TYPE NUMERIC(78, 0)`},
+	// A verified code issues a token stored by hash, so any instance can
+	// redeem it once
+	{12, "email tokens", `
+	ALTER TABLE email_verifications ADD COLUMN IF NOT EXISTS token_hash VARCHAR(64);
+	ALTER TABLE email_verifications ADD COLUMN IF NOT EXISTS token_expires_at TIMESTAMP WITH TIME ZONE;
+	ALTER TABLE email_verifications ADD COLUMN IF NOT EXISTS token_redeemed_at TIMESTAMP WITH TIME ZONE;
+
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_email_verifications_token ON email_verifications(token_hash);
+	`},
+	// At most one live first-request bonus per address, so concurrent first
+	// requests can't both claim it. Earlier duplicates keep the oldest.
+	{13, "unique first-request bonus", `
+	UPDATE faucet_requests SET bonus_granted = FALSE
+	WHERE bonus_granted AND status <> 'failed' AND id NOT IN (
+		SELECT MIN(id) FROM faucet_requests
+		WHERE bonus_granted AND status <> 'failed'
+		GROUP BY recipient
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_bonus_granted ON faucet_requests(recipient) WHERE bonus_granted AND status <> 'failed';
+	`},
+	// Subnet limits search payouts by network. ip_address is free text, so
+	// inserts also store the parsed address; older rows stay NULL and age
+	// out of the limit window.
+	{14, "request ip network", `
+	ALTER TABLE faucet_requests ADD COLUMN IF NOT EXISTS ip_inet INET;
+
+	CREATE INDEX IF NOT EXISTS idx_ip_inet_success ON faucet_requests USING gist (ip_inet inet_ops) WHERE status = 'success';
+	`},
+}
+
+// Migrate applies every migration not yet recorded in schema_migrations.
+// Each step and its record commit in one transaction, so a failed step
+// leaves no partial record and is retried on the next start.
+func (db *DB) Migrate() error {
+	return db.migrate(migrations)
+}
+
+func (db *DB) migrate(steps []migration) error {
+	versionQuery := `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+	)
+	`
+	if _, err := db.conn.Exec(versionQuery); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for _, step := range steps {
+		if applied[step.version] {
+			continue
+		}
+		if err := db.applyMigration(step); err != nil {
+			return err
+		}
+		count++
+	}
+
+	log.WithField("applied", count).Info("Database migrations completed")
+	return nil
+}
+
+// appliedMigrations returns the recorded migration versions
+func (db *DB) appliedMigrations() (map[int]bool, error) {
+	rows, err := db.conn.Query(`SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to scan schema migration: %w", err)
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// applyMigration runs step and records it in one transaction. A concurrent
+// instance may apply the same step first; the idempotent step and the
+// conflict-tolerant record make that harmless.
+func (db *DB) applyMigration(step migration) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", step.version, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(step.query); err != nil {
+		return fmt.Errorf("failed to run migration %d (%s): %w", step.version, step.name, err)
+	}
+	record := `INSERT INTO schema_migrations (version, name) VALUES ($1, $2) ON CONFLICT (version) DO NOTHING`
+	if _, err := tx.Exec(record, step.version, step.name); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", step.version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", step.version, err)
+	}
+
+	log.WithFields(log.Fields{
+		"version": step.version,
+		"name":    step.name,
+	}).Info("Applied database migration")
+	return nil
+}
@@ -0,0 +1,227 @@
+package emailotp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/aura-chain/aura/faucet/pkg/database"
+)
+
+// codeDigits is the length of the numeric code sent by email
+const codeDigits = 6
+
+var (
+	// ErrInvalidEmail is returned for addresses that don't parse as a
+	// single plain email address
+	ErrInvalidEmail = errors.New("invalid email address")
+	// ErrTooManyCodes is returned when an email has been sent MaxPerEmail
+	// codes within the window
+	ErrTooManyCodes = errors.New("too many codes sent to this email")
+	// ErrInvalidCode is returned for a wrong, expired or already used code
+	ErrInvalidCode = errors.New("invalid or expired code")
+	// ErrTooManyAttempts is returned once a code has been guessed wrong
+	// MaxAttempts times; a new code must be requested
+	ErrTooManyAttempts = errors.New("too many wrong codes")
+	// ErrInvalidToken is returned for email tokens that were never issued,
+	// have expired or were already redeemed
+	ErrInvalidToken = errors.New("invalid or expired email token")
+	// ErrSendFailed wraps errors from the Mailer
+	ErrSendFailed = errors.New("failed to send code")
+)
+
+// Records persists sent codes and issued tokens, so per-email limits and
+// tokens survive restarts and apply across instances. *database.DB
+// implements it.
+type Records interface {
+	// CreateEmailVerification stores a code unless max codes were sent to
+	// email since the given time, returning false at the limit
+	CreateEmailVerification(email, codeHash, ipAddress string, expiresAt, since time.Time, max int) (bool, error)
+	GetPendingEmailVerification(email string) (*database.EmailVerification, error)
+	RecordEmailVerificationAttempt(id int64) error
+	MarkEmailVerified(id int64, tokenHash string, tokenExpiresAt time.Time) (bool, error)
+	// RedeemEmailToken consumes a token by hash, returning "" when it is
+	// unknown, expired or already redeemed
+	RedeemEmailToken(tokenHash string) (string, error)
+}
+
+// Options configures the verification flow; zero values take defaults
+type Options struct {
+	CodeTTL     time.Duration // how long a sent code is valid (10 minutes)
+	TokenTTL    time.Duration // how long a verified email's token is valid (15 minutes)
+	MaxPerEmail int           // codes sent to one email per Window (5)
+	Window      time.Duration // period MaxPerEmail applies to (24 hours)
+	MaxAttempts int           // wrong codes before a code is void (5)
+}
+
+// Token is a short-lived proof that the caller controls an email, redeemed
+// with one token request
+type Token struct {
+	Token     string    `json:"email_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Service sends one-time codes by email and exchanges correct codes for
+// tokens
+type Service struct {
+	records Records
+	mailer  Mailer
+	opts    Options
+	now     func() time.Time
+}
+
+// New creates an email verification service
+func New(records Records, mailer Mailer, opts Options) *Service {
+	if opts.CodeTTL <= 0 {
+		opts.CodeTTL = 10 * time.Minute
+	}
+	if opts.TokenTTL <= 0 {
+		opts.TokenTTL = 15 * time.Minute
+	}
+	if opts.MaxPerEmail <= 0 {
+		opts.MaxPerEmail = 5
+	}
+	if opts.Window <= 0 {
+		opts.Window = 24 * time.Hour
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	return &Service{records: records, mailer: mailer, opts: opts, now: time.Now}
+}
+
+// Normalize parses email and returns its lowercased address, so limits
+// apply regardless of case or a display name
+func Normalize(email string) (string, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || addr.Name != "" {
+		return "", ErrInvalidEmail
+	}
+	return strings.ToLower(addr.Address), nil
+}
+
+// Start sends a new code to email on behalf of the client at ip and
+// returns when it expires
+func (s *Service) Start(email, ip string) (time.Time, error) {
+	email, err := Normalize(email)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	code, err := randomCode()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to generate code: %w", err)
+	}
+	now := s.now()
+	expiresAt := now.Add(s.opts.CodeTTL)
+	created, err := s.records.CreateEmailVerification(email, hashCode(email, code), ip, expiresAt, now.Add(-s.opts.Window), s.opts.MaxPerEmail)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !created {
+		return time.Time{}, ErrTooManyCodes
+	}
+
+	body := fmt.Sprintf("Your faucet verification code is %s\n\nIt expires in %d minutes. If you did not request it, ignore this email.\n",
+		code, int(s.opts.CodeTTL.Minutes()))
+	if err := s.mailer.Send(email, "Your faucet verification code", body); err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrSendFailed, err)
+	}
+	return expiresAt, nil
+}
+
+// Verify checks code against the newest code sent to email and, when it
+// matches, issues a token for one token request
+func (s *Service) Verify(email, code string) (*Token, error) {
+	email, err := Normalize(email)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := s.records.GetPendingEmailVerification(email)
+	if err != nil {
+		return nil, err
+	}
+	if pending == nil {
+		return nil, ErrInvalidCode
+	}
+	if pending.Attempts >= s.opts.MaxAttempts {
+		return nil, ErrTooManyAttempts
+	}
+
+	expected := []byte(pending.CodeHash)
+	if subtle.ConstantTimeCompare([]byte(hashCode(email, strings.TrimSpace(code))), expected) != 1 {
+		if err := s.records.RecordEmailVerificationAttempt(pending.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCode
+	}
+
+	value, err := randomToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := &Token{Token: value, ExpiresAt: s.now().Add(s.opts.TokenTTL)}
+	consumed, err := s.records.MarkEmailVerified(pending.ID, hashToken(value), token.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidCode
+	}
+	return token, nil
+}
+
+// Redeem consumes token and returns the email it was issued for
+func (s *Service) Redeem(token string) (string, error) {
+	email, err := s.records.RedeemEmailToken(hashToken(token))
+	if err != nil {
+		return "", err
+	}
+	if email == "" {
+		return "", ErrInvalidToken
+	}
+	return email, nil
+}
+
+// hashCode binds a code to its email so stored hashes can't be matched
+// across addresses
+func hashCode(email, code string) string {
+	sum := sha256.Sum256([]byte(email + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashToken is how tokens are stored, so a database read can't be
+// replayed as a token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomCode returns a uniformly random codeDigits-digit code
+func randomCode() (string, error) {
+	limit := big.NewInt(1)
+	for i := 0; i < codeDigits; i++ {
+		limit.Mul(limit, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", codeDigits, n), nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
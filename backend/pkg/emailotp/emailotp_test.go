@@ -0,0 +1,222 @@
+package emailotp
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aura-chain/aura/faucet/pkg/database"
+)
+
+// memoryRecords is an in-memory Records
+type memoryRecords struct {
+	rows []*record
+	now  func() time.Time
+}
+
+type record struct {
+	database.EmailVerification
+	createdAt      time.Time
+	verified       bool
+	tokenHash      string
+	tokenExpiresAt time.Time
+	redeemed       bool
+}
+
+func (m *memoryRecords) CreateEmailVerification(email, codeHash, ipAddress string, expiresAt, since time.Time, max int) (bool, error) {
+	count := 0
+	for _, r := range m.rows {
+		if r.Email == email && !r.createdAt.Before(since) {
+			count++
+		}
+	}
+	if count >= max {
+		return false, nil
+	}
+	m.rows = append(m.rows, &record{
+		EmailVerification: database.EmailVerification{
+			ID: int64(len(m.rows) + 1), Email: email, CodeHash: codeHash, ExpiresAt: expiresAt,
+		},
+		createdAt: m.now(),
+	})
+	return true, nil
+}
+
+func (m *memoryRecords) GetPendingEmailVerification(email string) (*database.EmailVerification, error) {
+	for i := len(m.rows) - 1; i >= 0; i-- {
+		r := m.rows[i]
+		if r.Email == email && !r.verified && r.ExpiresAt.After(m.now()) {
+			v := r.EmailVerification
+			return &v, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryRecords) RecordEmailVerificationAttempt(id int64) error {
+	m.rows[id-1].Attempts++
+	return nil
+}
+
+func (m *memoryRecords) MarkEmailVerified(id int64, tokenHash string, tokenExpiresAt time.Time) (bool, error) {
+	r := m.rows[id-1]
+	if r.verified {
+		return false, nil
+	}
+	r.verified = true
+	r.tokenHash = tokenHash
+	r.tokenExpiresAt = tokenExpiresAt
+	return true, nil
+}
+
+func (m *memoryRecords) RedeemEmailToken(tokenHash string) (string, error) {
+	for _, r := range m.rows {
+		if r.tokenHash == tokenHash && !r.redeemed && r.tokenExpiresAt.After(m.now()) {
+			r.redeemed = true
+			return r.Email, nil
+		}
+	}
+	return "", nil
+}
+
+// stubMailer records sent messages
+type stubMailer struct {
+	sent []string
+	err  error
+}
+
+func (m *stubMailer) Send(to, subject, body string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, body)
+	return nil
+}
+
+var codePattern = regexp.MustCompile(`\b\d{6}\b`)
+
+func (m *stubMailer) lastCode(t *testing.T) string {
+	require.NotEmpty(t, m.sent)
+	code := codePattern.FindString(m.sent[len(m.sent)-1])
+	require.NotEmpty(t, code)
+	return code
+}
+
+func newTestService(t *testing.T, opts Options) (*Service, *stubMailer, *time.Time) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	mailer := &stubMailer{}
+	service := New(&memoryRecords{now: clock}, mailer, opts)
+	service.now = clock
+	return service, mailer, &now
+}
+
+func TestStartAndVerify(t *testing.T) {
+	service, mailer, _ := newTestService(t, Options{})
+
+	_, err := service.Start(" User@Example.com ", "1.1.1.1")
+	require.NoError(t, err)
+	require.Len(t, mailer.sent, 1)
+
+	token, err := service.Verify("user@example.com", mailer.lastCode(t))
+	require.NoError(t, err)
+	assert.Len(t, token.Token, 64)
+
+	email, err := service.Redeem(token.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com", email)
+
+	_, err = service.Redeem(token.Token)
+	assert.ErrorIs(t, err, ErrInvalidToken, "a token is redeemed once")
+}
+
+func TestRedeemExpiredToken(t *testing.T) {
+	service, mailer, now := newTestService(t, Options{TokenTTL: time.Minute})
+
+	_, err := service.Start("user@example.com", "1.1.1.1")
+	require.NoError(t, err)
+	token, err := service.Verify("user@example.com", mailer.lastCode(t))
+	require.NoError(t, err)
+
+	*now = now.Add(2 * time.Minute)
+	_, err = service.Redeem(token.Token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestVerifyWrongCode(t *testing.T) {
+	service, mailer, _ := newTestService(t, Options{MaxAttempts: 2})
+
+	_, err := service.Start("user@example.com", "1.1.1.1")
+	require.NoError(t, err)
+	code := mailer.lastCode(t)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	_, err = service.Verify("user@example.com", wrong)
+	assert.ErrorIs(t, err, ErrInvalidCode)
+	_, err = service.Verify("user@example.com", wrong)
+	assert.ErrorIs(t, err, ErrInvalidCode)
+
+	_, err = service.Verify("user@example.com", code)
+	assert.ErrorIs(t, err, ErrTooManyAttempts, "the code is void after MaxAttempts wrong guesses")
+
+	_, err = service.Verify("other@example.com", code)
+	assert.ErrorIs(t, err, ErrInvalidCode, "codes are bound to their email")
+}
+
+func TestVerifyExpiredAndUsedCode(t *testing.T) {
+	service, mailer, now := newTestService(t, Options{CodeTTL: time.Minute})
+
+	_, err := service.Start("user@example.com", "1.1.1.1")
+	require.NoError(t, err)
+	code := mailer.lastCode(t)
+
+	_, err = service.Verify("user@example.com", code)
+	require.NoError(t, err)
+	_, err = service.Verify("user@example.com", code)
+	assert.ErrorIs(t, err, ErrInvalidCode, "a code is used once")
+
+	_, err = service.Start("user@example.com", "1.1.1.1")
+	require.NoError(t, err)
+	*now = now.Add(2 * time.Minute)
+	_, err = service.Verify("user@example.com", mailer.lastCode(t))
+	assert.ErrorIs(t, err, ErrInvalidCode)
+}
+
+func TestStartPerEmailLimit(t *testing.T) {
+	service, mailer, now := newTestService(t, Options{MaxPerEmail: 2, Window: time.Hour})
+
+	for i := 0; i < 2; i++ {
+		_, err := service.Start("user@example.com", "1.1.1.1")
+		require.NoError(t, err)
+	}
+	_, err := service.Start("USER@example.com", "2.2.2.2")
+	assert.ErrorIs(t, err, ErrTooManyCodes)
+	assert.Len(t, mailer.sent, 2)
+
+	_, err = service.Start("other@example.com", "1.1.1.1")
+	assert.NoError(t, err, "the limit is per email")
+
+	*now = now.Add(2 * time.Hour)
+	_, err = service.Start("user@example.com", "1.1.1.1")
+	assert.NoError(t, err, "the limit resets after the window")
+}
+
+func TestStartInvalidEmailAndSendFailure(t *testing.T) {
+	service, mailer, _ := newTestService(t, Options{})
+
+	for _, email := range []string{"", "not-an-email", "Name <user@example.com>", "a@b.com, c@d.com"} {
+		_, err := service.Start(email, "1.1.1.1")
+		assert.ErrorIs(t, err, ErrInvalidEmail, email)
+	}
+
+	mailer.err = errors.New("smtp unavailable")
+	_, err := service.Start("user@example.com", "1.1.1.1")
+	assert.ErrorIs(t, err, ErrSendFailed)
+}
@@ -0,0 +1,104 @@
+// Package events publishes faucet request outcomes to a message bus as JSON
+// for downstream data pipelines
+package events
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	metrics "github.com/aura-chain/aura/faucet/pkg/prometheus"
+	"github.com/aura-chain/aura/faucet/pkg/units"
+)
+
+// Type names the outcome an event records
+type Type string
+
+const (
+	PayoutSucceeded Type = "payout.succeeded"
+	PayoutFailed    Type = "payout.failed"
+	RequestBlocked  Type = "request.blocked"
+)
+
+// DefaultBufferSize is how many events an Async publisher queues when no
+// size is given
+const DefaultBufferSize = 1000
+
+// Event is one faucet outcome
+type Event struct {
+	Type      Type         `json:"type"`
+	Timestamp time.Time    `json:"timestamp"`
+	ChainID   string       `json:"chain_id"`
+	Denom     string       `json:"denom"`
+	Recipient string       `json:"recipient"`
+	Amount    units.Amount `json:"amount,omitzero"`
+	TxHash    string       `json:"tx_hash,omitempty"`
+	Reason    string       `json:"reason,omitempty"` // why a payout failed or was blocked
+	Source    string       `json:"source,omitempty"`
+}
+
+// Publisher delivers events to a message bus
+type Publisher interface {
+	Publish(event Event) error
+	Close() error
+}
+
+// Async publishes through another Publisher on a background goroutine.
+// Publish never blocks: when the queue is full the event is dropped.
+type Async struct {
+	next  Publisher
+	queue chan Event
+	done  chan struct{}
+
+	closeOnce sync.Once
+}
+
+// NewAsync starts publishing queued events to next; a non-positive buffer
+// uses DefaultBufferSize
+func NewAsync(next Publisher, buffer int) *Async {
+	if buffer <= 0 {
+		buffer = DefaultBufferSize
+	}
+
+	a := &Async{
+		next:  next,
+		queue: make(chan Event, buffer),
+		done:  make(chan struct{}),
+	}
+	go a.run()
+
+	return a
+}
+
+// run publishes queued events until the queue is closed
+func (a *Async) run() {
+	defer close(a.done)
+
+	for event := range a.queue {
+		if err := a.next.Publish(event); err != nil {
+			metrics.EventsPublished.WithLabelValues("failed").Inc()
+			log.WithError(err).WithField("type", event.Type).Warn("Failed to publish event")
+			continue
+		}
+		metrics.EventsPublished.WithLabelValues("published").Inc()
+	}
+}
+
+// Publish queues event without waiting for delivery
+func (a *Async) Publish(event Event) error {
+	select {
+	case a.queue <- event:
+	default:
+		metrics.EventsPublished.WithLabelValues("dropped").Inc()
+	}
+	return nil
+}
+
+// Close publishes the events still queued, then closes the underlying
+// publisher. Publish must not be called after Close.
+func (a *Async) Close() error {
+	a.closeOnce.Do(func() { close(a.queue) })
+	<-a.done
+	return a.next.Close()
+}
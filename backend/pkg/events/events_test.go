@@ -0,0 +1,86 @@
+package events
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubPublisher records events, optionally blocking until released
+type stubPublisher struct {
+	mu      sync.Mutex
+	events  []Event
+	err     error
+	release chan struct{}
+	closed  bool
+}
+
+func (s *stubPublisher) Publish(event Event) error {
+	if s.release != nil {
+		<-s.release
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return s.err
+}
+
+func (s *stubPublisher) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func TestAsyncPublishesInOrder(t *testing.T) {
+	stub := &stubPublisher{}
+	a := NewAsync(stub, 10)
+
+	for _, typ := range []Type{PayoutSucceeded, PayoutFailed, RequestBlocked} {
+		require.NoError(t, a.Publish(Event{Type: typ}))
+	}
+	require.NoError(t, a.Close())
+
+	require.Len(t, stub.events, 3)
+	assert.Equal(t, PayoutSucceeded, stub.events[0].Type)
+	assert.Equal(t, RequestBlocked, stub.events[2].Type)
+	assert.True(t, stub.closed)
+}
+
+func TestAsyncNeverBlocks(t *testing.T) {
+	stub := &stubPublisher{release: make(chan struct{})}
+	a := NewAsync(stub, 1)
+
+	done := make(chan struct{})
+	go func() {
+		// The worker holds one event and the queue one more; the rest
+		// are dropped rather than waiting on the stalled publisher
+		for i := 0; i < 10; i++ {
+			a.Publish(Event{Type: PayoutSucceeded})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a stalled publisher")
+	}
+
+	close(stub.release)
+	require.NoError(t, a.Close())
+	assert.LessOrEqual(t, len(stub.events), 2)
+}
+
+func TestAsyncSwallowsPublishErrors(t *testing.T) {
+	stub := &stubPublisher{err: errors.New("bus down")}
+	a := NewAsync(stub, 10)
+
+	assert.NoError(t, a.Publish(Event{Type: PayoutFailed}))
+	require.NoError(t, a.Close())
+	assert.Len(t, stub.events, 1)
+}
@@ -0,0 +1,250 @@
+package events
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// natsDefaultPort is used when the server URL has no port
+	natsDefaultPort = "4222"
+
+	// natsTimeout bounds connecting and each write to the server
+	natsTimeout = 5 * time.Second
+)
+
+// NATSPublisher publishes events to a NATS subject using the core text
+// protocol. It connects on first use and reconnects after a failed write.
+// Connections are upgraded to TLS for tls:// URLs and whenever the server
+// requires it.
+type NATSPublisher struct {
+	addr    string
+	subject string
+	connect []byte // CONNECT line sent after the server's INFO
+	tls     *tls.Config
+	tlsOnly bool // tls:// URL: refuse servers that don't offer TLS
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// natsConnectOptions is the CONNECT payload; credentials come from the URL
+type natsConnectOptions struct {
+	Verbose  bool   `json:"verbose"`
+	Pedantic bool   `json:"pedantic"`
+	Name     string `json:"name"`
+	User     string `json:"user,omitempty"`
+	Pass     string `json:"pass,omitempty"`
+	Token    string `json:"auth_token,omitempty"`
+}
+
+// natsServerInfo is the part of the server's INFO line the publisher uses
+type natsServerInfo struct {
+	TLSRequired  bool `json:"tls_required"`
+	TLSAvailable bool `json:"tls_available"`
+}
+
+// NewNATSPublisher creates a publisher for a nats:// or tls://
+// [user[:pass]@]host[:port] server URL. A user without a password is sent
+// as an auth token. tlsConfig verifies the server when TLS is used; nil
+// trusts the system roots.
+func NewNATSPublisher(serverURL, subject string, tlsConfig *tls.Config) (*NATSPublisher, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NATS URL: %w", err)
+	}
+	if (u.Scheme != "nats" && u.Scheme != "tls") || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid NATS URL %q: expected nats://host[:port] or tls://host[:port]", serverURL)
+	}
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n") {
+		return nil, fmt.Errorf("invalid NATS subject %q", subject)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = natsDefaultPort
+	}
+
+	opts := natsConnectOptions{Name: "aura-faucet"}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			opts.User, opts.Pass = u.User.Username(), pass
+		} else {
+			opts.Token = u.User.Username()
+		}
+	}
+	connect, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	tlsConfig = tlsConfig.Clone()
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = u.Hostname()
+	}
+	if tlsConfig.MinVersion == 0 {
+		tlsConfig.MinVersion = tls.VersionTLS12
+	}
+
+	return &NATSPublisher{
+		addr:    net.JoinHostPort(u.Hostname(), port),
+		subject: subject,
+		connect: []byte("CONNECT " + string(connect) + "\r\n"),
+		tls:     tlsConfig,
+		tlsOnly: u.Scheme == "tls",
+	}, nil
+}
+
+// NATSTLSConfig trusts the PEM certificates in caFile for NATS connections
+func NATSTLSConfig(caFile string) (*tls.Config, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read NATS CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+	return &tls.Config{RootCAs: pool}, nil
+}
+
+// Publish sends event as a JSON message on the subject
+func (p *NATSPublisher) Publish(event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("PUB %s %d\r\n%s\r\n", p.subject, len(payload), payload)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		if err := p.dial(); err != nil {
+			return err
+		}
+	}
+	if err := p.write(p.conn, msg); err != nil {
+		p.conn.Close()
+		p.conn = nil
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+	return nil
+}
+
+// Close closes the server connection
+func (p *NATSPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn = nil
+	return err
+}
+
+// dial connects and completes the INFO/CONNECT handshake, upgrading to
+// TLS after INFO as the NATS protocol does. Callers hold mu.
+func (p *NATSPublisher) dial() error {
+	conn, err := net.DialTimeout("tcp", p.addr, natsTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(natsTimeout))
+	line, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("NATS server at %s did not send INFO", p.addr)
+	}
+	var info natsServerInfo
+	if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "INFO "))), &info); err != nil {
+		conn.Close()
+		return fmt.Errorf("NATS server at %s sent an invalid INFO: %w", p.addr, err)
+	}
+
+	if p.tlsOnly || info.TLSRequired {
+		if !info.TLSRequired && !info.TLSAvailable {
+			conn.Close()
+			return fmt.Errorf("NATS server at %s does not offer TLS", p.addr)
+		}
+		tlsConn := tls.Client(conn, p.tls)
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return fmt.Errorf("NATS TLS handshake failed: %w", err)
+		}
+		conn = tlsConn
+		reader = bufio.NewReader(conn)
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	if _, err := conn.Write(p.connect); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	p.conn = conn
+	go p.readLoop(conn, reader)
+	return nil
+}
+
+// readLoop answers server PINGs, which keep the connection alive, and logs
+// protocol errors until the connection closes
+func (p *NATSPublisher) readLoop(conn net.Conn, reader *bufio.Reader) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			p.drop(conn)
+			return
+		}
+
+		switch line = strings.TrimSpace(line); {
+		case line == "PING":
+			p.mu.Lock()
+			if p.conn == conn {
+				p.write(conn, "PONG\r\n")
+			}
+			p.mu.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			log.WithField("error", strings.TrimSpace(strings.TrimPrefix(line, "-ERR"))).Warn("NATS server reported an error")
+		}
+	}
+}
+
+// drop forgets conn so the next Publish reconnects
+func (p *NATSPublisher) drop(conn net.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	conn.Close()
+	if p.conn == conn {
+		p.conn = nil
+	}
+}
+
+// write sends msg with a deadline so a stalled server cannot hang publishing
+func (p *NATSPublisher) write(conn net.Conn, msg string) error {
+	conn.SetWriteDeadline(time.Now().Add(natsTimeout))
+	_, err := conn.Write([]byte(msg))
+	return err
+}
+
+var _ Publisher = (*NATSPublisher)(nil)
+var _ Publisher = (*Async)(nil)
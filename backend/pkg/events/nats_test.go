@@ -0,0 +1,162 @@
+package events
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aura-chain/aura/faucet/pkg/units"
+)
+
+// natsMessage is a PUB received by fakeNATS
+type natsMessage struct {
+	subject string
+	payload []byte
+}
+
+// fakeNATS accepts one connection, records its CONNECT line and PUB
+// messages, and pings the client once after the handshake
+func fakeNATS(t *testing.T) (addr string, connect <-chan string, msgs <-chan natsMessage, pong <-chan struct{}) {
+	return fakeNATSWithTLS(t, nil)
+}
+
+// fakeNATSWithTLS is fakeNATS requiring TLS with serverTLS, when non-nil
+func fakeNATSWithTLS(t *testing.T, serverTLS *tls.Config) (addr string, connect <-chan string, msgs <-chan natsMessage, pong <-chan struct{}) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	connectCh := make(chan string, 1)
+	msgCh := make(chan natsMessage, 10)
+	pongCh := make(chan struct{}, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		if serverTLS == nil {
+			conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		} else {
+			conn.Write([]byte("INFO {\"server_id\":\"test\",\"tls_required\":true}\r\n"))
+			conn = tls.Server(conn, serverTLS)
+		}
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			switch {
+			case strings.HasPrefix(line, "CONNECT "):
+				connectCh <- strings.TrimPrefix(line, "CONNECT ")
+				conn.Write([]byte("PING\r\n"))
+			case line == "PONG":
+				pongCh <- struct{}{}
+			case strings.HasPrefix(line, "PUB "):
+				parts := strings.Fields(line)
+				size, _ := strconv.Atoi(parts[2])
+				payload := make([]byte, size+2)
+				if _, err := io.ReadFull(reader, payload); err != nil {
+					return
+				}
+				msgCh <- natsMessage{subject: parts[1], payload: payload[:size]}
+			}
+		}
+	}()
+
+	return ln.Addr().String(), connectCh, msgCh, pongCh
+}
+
+func TestNATSPublisherPublishesJSON(t *testing.T) {
+	addr, connect, msgs, pong := fakeNATS(t)
+	p, err := NewNATSPublisher("nats://faucet:secret@"+addr, "faucet.events", nil)
+	require.NoError(t, err)
+	defer p.Close()
+
+	require.NoError(t, p.Publish(Event{Type: PayoutSucceeded, Recipient: "aura1abc", Amount: units.New(100), TxHash: "TX"}))
+
+	var opts natsConnectOptions
+	require.NoError(t, json.Unmarshal([]byte(<-connect), &opts))
+	assert.Equal(t, "faucet", opts.User)
+	assert.Equal(t, "secret", opts.Pass)
+	assert.False(t, opts.Verbose)
+
+	msg := <-msgs
+	assert.Equal(t, "faucet.events", msg.subject)
+	var event Event
+	require.NoError(t, json.Unmarshal(msg.payload, &event))
+	assert.Equal(t, PayoutSucceeded, event.Type)
+	assert.Equal(t, "100", event.Amount.String())
+
+	// Server pings are answered so the connection stays open
+	<-pong
+}
+
+func TestNATSPublisherUpgradesToTLS(t *testing.T) {
+	// Borrow httptest's self-signed certificate for 127.0.0.1
+	certSrv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer certSrv.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(certSrv.Certificate())
+
+	addr, _, msgs, _ := fakeNATSWithTLS(t, &tls.Config{Certificates: certSrv.TLS.Certificates})
+	p, err := NewNATSPublisher("tls://"+addr, "faucet.events", &tls.Config{RootCAs: roots})
+	require.NoError(t, err)
+	defer p.Close()
+
+	require.NoError(t, p.Publish(Event{Type: RequestBlocked, Reason: "ip_rate_limit"}))
+	msg := <-msgs
+	var event Event
+	require.NoError(t, json.Unmarshal(msg.payload, &event))
+	assert.Equal(t, RequestBlocked, event.Type)
+
+	// Without the CA the server's certificate is refused
+	addr, _, _, _ = fakeNATSWithTLS(t, &tls.Config{Certificates: certSrv.TLS.Certificates})
+	untrusted, err := NewNATSPublisher("nats://"+addr, "faucet.events", nil)
+	require.NoError(t, err)
+	assert.ErrorContains(t, untrusted.Publish(Event{Type: PayoutFailed}), "TLS handshake")
+}
+
+func TestNATSPublisherRequiresTLSForTLSURL(t *testing.T) {
+	addr, _, _, _ := fakeNATS(t)
+	p, err := NewNATSPublisher("tls://"+addr, "faucet.events", nil)
+	require.NoError(t, err)
+	assert.ErrorContains(t, p.Publish(Event{Type: PayoutFailed}), "does not offer TLS")
+}
+
+func TestNATSPublisherFailsWithoutServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	p, err := NewNATSPublisher("nats://"+addr, "faucet.events", nil)
+	require.NoError(t, err)
+	assert.Error(t, p.Publish(Event{Type: PayoutFailed}))
+}
+
+func TestNewNATSPublisherValidates(t *testing.T) {
+	_, err := NewNATSPublisher("http://localhost:4222", "faucet.events", nil)
+	assert.Error(t, err)
+	_, err = NewNATSPublisher("nats://localhost", "bad subject", nil)
+	assert.Error(t, err)
+
+	p, err := NewNATSPublisher("nats://localhost", "faucet.events", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:4222", p.addr)
+}
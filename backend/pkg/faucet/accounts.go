@@ -0,0 +1,89 @@
+package faucet
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxContractAccountCache bounds the account type cache; it is emptied
+// when full rather than tracking recency
+const maxContractAccountCache = 10000
+
+// emptyCodeHash is the keccak256 of empty code, which EVM-compatible
+// chains report for externally owned accounts
+const emptyCodeHash = "0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"
+
+// authAccount is the part of /cosmos/auth/v1beta1/accounts/{address} that
+// identifies the account type
+type authAccount struct {
+	Account struct {
+		Type     string `json:"@type"`
+		CodeHash string `json:"code_hash"`
+	} `json:"account"`
+}
+
+// isContract reports whether the account type is a module account or a
+// contract: a type naming a contract, or an EVM account holding code
+func (a *authAccount) isContract() bool {
+	accountType := strings.ToLower(a.Account.Type)
+	switch {
+	case strings.HasSuffix(accountType, "moduleaccount"):
+		return true
+	case strings.Contains(accountType, "contract"):
+		return true
+	case strings.HasSuffix(accountType, "ethaccount"):
+		return a.Account.CodeHash != "" && !strings.EqualFold(a.Account.CodeHash, emptyCodeHash)
+	default:
+		return false
+	}
+}
+
+// IsContractAccount reports whether address is a contract or module
+// account, judged by its auth account type. Addresses the chain has no
+// account for are ordinary recipients and are not cached, since a contract
+// may later be created there.
+func (s *Service) IsContractAccount(address string) (bool, error) {
+	s.contractAccountsMu.Lock()
+	contract, cached := s.contractAccounts[address]
+	s.contractAccountsMu.Unlock()
+	if cached {
+		return contract, nil
+	}
+
+	restURL := s.cfg.NodeREST
+	if restURL == "" {
+		restURL = s.cfg.NodeRPC // Fallback to RPC if REST not configured
+	}
+	url := fmt.Sprintf("%s/cosmos/auth/v1beta1/accounts/%s", restURL, address)
+
+	resp, err := s.client.Get(url)
+	if err != nil {
+		return false, fmt.Errorf("failed to get account: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return false, fmt.Errorf("failed to get account: status %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var account authAccount
+	if err := decodeNodeJSON(resp, "account", &account); err != nil {
+		return false, err
+	}
+	contract = account.isContract()
+
+	s.contractAccountsMu.Lock()
+	if s.contractAccounts == nil || len(s.contractAccounts) >= maxContractAccountCache {
+		s.contractAccounts = make(map[string]bool)
+	}
+	s.contractAccounts[address] = contract
+	s.contractAccountsMu.Unlock()
+
+	return contract, nil
+}
@@ -0,0 +1,73 @@
+package faucet
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aura-chain/aura/faucet/pkg/config"
+)
+
+func TestIsContractAccount(t *testing.T) {
+	accounts := map[string]string{
+		"aura1user":     `{"account":{"@type":"/cosmos.auth.v1beta1.BaseAccount","address":"aura1user","sequence":"3"}}`,
+		"aura1vesting":  `{"account":{"@type":"/cosmos.vesting.v1beta1.ContinuousVestingAccount"}}`,
+		"aura1module":   `{"account":{"@type":"/cosmos.auth.v1beta1.ModuleAccount","name":"distribution"}}`,
+		"aura1contract": `{"account":{"@type":"/aura.wasm.v1.ContractAccount"}}`,
+		"aura1evm":      `{"account":{"@type":"/ethermint.types.v1.EthAccount","code_hash":"0x1234"}}`,
+		"aura1eoa":      `{"account":{"@type":"/ethermint.types.v1.EthAccount","code_hash":"` + emptyCodeHash + `"}}`,
+	}
+	lookups := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		address := strings.TrimPrefix(r.URL.Path, "/cosmos/auth/v1beta1/accounts/")
+		lookups[address]++
+		body, ok := accounts[address]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":5,"message":"account not found"}`))
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	service := &Service{cfg: &config.Config{NodeREST: server.URL}, client: server.Client()}
+
+	expected := map[string]bool{
+		"aura1user":     false,
+		"aura1vesting":  false,
+		"aura1module":   true,
+		"aura1contract": true,
+		"aura1evm":      true,
+		"aura1eoa":      false,
+		"aura1unknown":  false,
+	}
+	for address, want := range expected {
+		contract, err := service.IsContractAccount(address)
+		require.NoError(t, err, address)
+		assert.Equal(t, want, contract, address)
+	}
+
+	_, err := service.IsContractAccount("aura1contract")
+	require.NoError(t, err)
+	assert.Equal(t, 1, lookups["aura1contract"], "the account type is cached")
+
+	_, err = service.IsContractAccount("aura1unknown")
+	require.NoError(t, err)
+	assert.Equal(t, 2, lookups["aura1unknown"], "missing accounts are looked up again")
+}
+
+func TestIsContractAccountNodeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	service := &Service{cfg: &config.Config{NodeREST: server.URL}, client: server.Client()}
+	_, err := service.IsContractAccount("aura1user")
+	assert.Error(t, err)
+}
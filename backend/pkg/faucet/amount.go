@@ -0,0 +1,40 @@
+package faucet
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/aura-chain/aura/faucet/pkg/units"
+)
+
+// ErrAmountOutOfRange is returned when an amount cannot be sent as a single
+// base-unit coin amount
+var ErrAmountOutOfRange = errors.New("AMOUNT_OUT_OF_RANGE")
+
+// ValidateAmount checks that amount is positive and at most max base units.
+// A max of zero leaves the amount unbounded.
+func ValidateAmount(amount units.Amount, max units.Amount) error {
+	if amount.Sign() <= 0 {
+		return fmt.Errorf("%w: amount %s must be positive", ErrAmountOutOfRange, amount)
+	}
+	if max.Sign() > 0 && amount.Cmp(max) > 0 {
+		return fmt.Errorf("%w: amount %s exceeds maximum %s", ErrAmountOutOfRange, amount, max)
+	}
+	return nil
+}
+
+// parseBalance parses a base-unit balance from the node. Balances beyond
+// int64 are clamped, since callers only compare them against limits.
+func parseBalance(s string) (int64, error) {
+	amount, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		var numErr *strconv.NumError
+		if errors.As(err, &numErr) && errors.Is(numErr.Err, strconv.ErrRange) && s[0] != '-' {
+			return math.MaxInt64, nil
+		}
+		return 0, fmt.Errorf("invalid balance amount %q: %w", s, err)
+	}
+	return amount, nil
+}
@@ -0,0 +1,98 @@
+package faucet
+
+import (
+	"errors"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aura-chain/aura/faucet/pkg/units"
+)
+
+// Batch payout outcomes
+const (
+	// BatchPending means the payout has not been attempted yet
+	BatchPending = "pending"
+	// BatchFunded means the recipient was paid; TxHash is set
+	BatchFunded = "funded"
+	// BatchFailed means the payout was attempted and failed; Reason says why
+	BatchFailed = "failed"
+	// BatchSkipped means no payout was attempted; Reason says why
+	BatchSkipped = "skipped"
+)
+
+// batchReasons maps send errors to the fixed reason codes reported in
+// batch results; anything else is reported as send_failed
+var batchReasons = []struct {
+	err    error
+	reason string
+}{
+	{ErrSupplyGuard, "supply_guard"},
+	{ErrInsufficientFeeBalance, "insufficient_fee_balance"},
+	{ErrBalanceReserve, "balance_reserve"},
+	{ErrAmountOutOfRange, "amount_out_of_range"},
+	{ErrBroadcastTimeout, "broadcast_timeout"},
+	{ErrTxRejected, "tx_rejected"},
+	{ErrSimulationFailed, "simulation_failed"},
+}
+
+// batchReason reports err as a fixed reason code, so batch results never
+// carry node output or other internals; the full error is logged instead
+func batchReason(err error) string {
+	for _, r := range batchReasons {
+		if errors.Is(err, r.err) {
+			return r.reason
+		}
+	}
+	return "send_failed"
+}
+
+// BatchResult is the outcome of one recipient in a batch
+type BatchResult struct {
+	Address string       `json:"address"`
+	Amount  units.Amount `json:"amount"`
+	Status  string       `json:"status"`
+	TxHash  string       `json:"tx_hash,omitempty"`
+	Reason  string       `json:"reason,omitempty"`
+}
+
+// SendBatch pays each request in order as its own recorded payout, so a
+// node failure partway through loses track of nothing: funded recipients
+// keep their rows and tx hashes, and only the failures need retrying.
+// Repeated recipients are skipped. Once the faucet itself cannot pay
+// (supply guard, fee balance, balance reserve) the remaining recipients
+// are skipped rather than attempted.
+func (s *Service) SendBatch(reqs []*SendRequest) []BatchResult {
+	results := make([]BatchResult, 0, len(reqs))
+	seen := make(map[string]bool, len(reqs))
+	halted := ""
+
+	for _, req := range reqs {
+		result := BatchResult{Address: req.Recipient, Amount: req.Amount}
+		switch {
+		case halted != "":
+			result.Status = BatchSkipped
+			result.Reason = halted
+		case seen[req.Recipient]:
+			result.Status = BatchSkipped
+			result.Reason = "duplicate_address"
+		default:
+			seen[req.Recipient] = true
+			resp, err := s.SendTokens(req)
+			if err != nil {
+				result.Status = BatchFailed
+				result.Reason = batchReason(err)
+				log.WithError(err).WithField("recipient", req.Recipient).Warn("Batch payout failed")
+				if errors.Is(err, ErrSupplyGuard) || errors.Is(err, ErrInsufficientFeeBalance) || errors.Is(err, ErrBalanceReserve) {
+					halted = "batch_halted"
+				}
+			} else {
+				result.Status = BatchFunded
+				result.TxHash = resp.TxHash
+			}
+		}
+		results = append(results, result)
+	}
+
+	log.WithField("recipients", len(reqs)).Info("Batch payout finished")
+	return results
+}
@@ -0,0 +1,194 @@
+package faucet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	metrics "github.com/aura-chain/aura/faucet/pkg/prometheus"
+)
+
+const (
+	// minConfirmationSamples is how many confirmations are averaged before
+	// the estimate replaces the configured default
+	minConfirmationSamples = 3
+	// confirmationPollInterval is how often a broadcast transaction is
+	// looked up until it is included in a block
+	confirmationPollInterval = time.Second
+	// confirmationTimeout stops looking for a transaction that never lands
+	confirmationTimeout = 2 * time.Minute
+	// maxTrackedConfirmations bounds the transactions watched at once;
+	// payouts broadcast while it is full are not sampled
+	maxTrackedConfirmations = 16
+)
+
+// pendingConfirmation is a broadcast transaction awaiting inclusion
+type pendingConfirmation struct {
+	txHash      string
+	broadcastAt time.Time
+}
+
+// ConfirmationEstimator keeps a rolling average of the most recent
+// confirmation times, from broadcast to inclusion in a block
+type ConfirmationEstimator struct {
+	mu       sync.Mutex
+	samples  []time.Duration
+	next     int
+	full     bool
+	fallback time.Duration
+}
+
+// NewConfirmationEstimator averages the last size confirmations, answering
+// fallback until minConfirmationSamples have been observed
+func NewConfirmationEstimator(size int, fallback time.Duration) *ConfirmationEstimator {
+	if size < minConfirmationSamples {
+		size = minConfirmationSamples
+	}
+	return &ConfirmationEstimator{samples: make([]time.Duration, size), fallback: fallback}
+}
+
+// Observe records one confirmation time, replacing the oldest once the
+// window is full
+func (e *ConfirmationEstimator) Observe(d time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.samples[e.next] = d
+	e.next = (e.next + 1) % len(e.samples)
+	if e.next == 0 {
+		e.full = true
+	}
+}
+
+// Estimate returns the average of the observed confirmation times, or the
+// fallback while there are too few of them
+func (e *ConfirmationEstimator) Estimate() time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	count := e.next
+	if e.full {
+		count = len(e.samples)
+	}
+	if count < minConfirmationSamples {
+		return e.fallback
+	}
+
+	var total time.Duration
+	for _, d := range e.samples[:count] {
+		total += d
+	}
+	return total / time.Duration(count)
+}
+
+// queueConfirmation hands txHash to RunConfirmations. It never blocks: a
+// payout broadcast while the queue is full simply isn't sampled.
+func (s *Service) queueConfirmation(txHash string, broadcastAt time.Time) {
+	if s.pendingConfirmations == nil {
+		return
+	}
+	select {
+	case s.pendingConfirmations <- pendingConfirmation{txHash: txHash, broadcastAt: broadcastAt}:
+	default:
+	}
+}
+
+// RunConfirmations watches queued payouts until each is included in a
+// block, recording how long that took since broadcast, until ctx is
+// cancelled. One loop polls every tracked transaction; those that don't
+// land within confirmationTimeout are dropped without a sample.
+func (s *Service) RunConfirmations(ctx context.Context) {
+	if s.pendingConfirmations == nil {
+		return
+	}
+	interval := s.confirmPoll
+	if interval <= 0 {
+		interval = confirmationPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var tracked []pendingConfirmation
+	for {
+		// Only take new transactions while there is room to watch them
+		queue := s.pendingConfirmations
+		if len(tracked) >= maxTrackedConfirmations {
+			queue = nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case pending := <-queue:
+			tracked = append(tracked, pending)
+		case <-ticker.C:
+			tracked = s.pollConfirmations(ctx, tracked)
+		}
+	}
+}
+
+// pollConfirmations looks up each tracked transaction once, returning the
+// ones still awaiting inclusion
+func (s *Service) pollConfirmations(ctx context.Context, tracked []pendingConfirmation) []pendingConfirmation {
+	remaining := tracked[:0]
+	for _, pending := range tracked {
+		if ctx.Err() != nil {
+			return remaining
+		}
+		if time.Since(pending.broadcastAt) > confirmationTimeout {
+			log.WithField("tx_hash", pending.txHash).Debug("Gave up waiting for transaction confirmation")
+			continue
+		}
+
+		included, err := s.txIncluded(pending.txHash)
+		if err != nil {
+			log.WithError(err).WithField("tx_hash", pending.txHash).Debug("Failed to look up transaction")
+		}
+		if !included {
+			remaining = append(remaining, pending)
+			continue
+		}
+		elapsed := time.Since(pending.broadcastAt)
+		metrics.TxConfirmationTime.Observe(elapsed.Seconds())
+		s.confirmations.Observe(elapsed)
+	}
+	return remaining
+}
+
+// txIncluded reports whether the node has txHash in a block; the node
+// answers 404 until it does
+func (s *Service) txIncluded(txHash string) (bool, error) {
+	restURL := s.cfg.NodeREST
+	if restURL == "" {
+		restURL = s.cfg.NodeRPC // Fallback to RPC if REST not configured
+	}
+	url := fmt.Sprintf("%s/cosmos/tx/v1beta1/txs/%s", restURL, txHash)
+
+	resp, err := s.client.Get(url)
+	if err != nil {
+		return false, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to get transaction: status %d", resp.StatusCode)
+	}
+}
+
+// EstimatedConfirmation is roughly how long a payout broadcast now takes to
+// land in a block; zero when estimates are disabled
+func (s *Service) EstimatedConfirmation() time.Duration {
+	if s.confirmations == nil {
+		return 0
+	}
+	return s.confirmations.Estimate()
+}
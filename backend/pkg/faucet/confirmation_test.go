@@ -0,0 +1,92 @@
+package faucet
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aura-chain/aura/faucet/pkg/config"
+)
+
+func TestConfirmationEstimatorRollingAverage(t *testing.T) {
+	estimator := NewConfirmationEstimator(4, 6*time.Second)
+
+	estimator.Observe(2 * time.Second)
+	estimator.Observe(4 * time.Second)
+	assert.Equal(t, 6*time.Second, estimator.Estimate(), "the default until enough samples exist")
+
+	estimator.Observe(6 * time.Second)
+	assert.Equal(t, 4*time.Second, estimator.Estimate())
+
+	estimator.Observe(8 * time.Second)
+	assert.Equal(t, 5*time.Second, estimator.Estimate())
+
+	// The window holds the last four; 2s drops out
+	estimator.Observe(10 * time.Second)
+	assert.Equal(t, 7*time.Second, estimator.Estimate())
+}
+
+func TestTrackConfirmationFeedsEstimate(t *testing.T) {
+	var lookups atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/cosmos/tx/v1beta1/txs/ABC", r.URL.Path)
+		// Each transaction is pending on its first lookup
+		if lookups.Add(1)%2 == 1 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"tx_response":{"txhash":"ABC","height":"10"}}`))
+	}))
+	defer server.Close()
+
+	service := &Service{
+		cfg:                  &config.Config{NodeREST: server.URL},
+		client:               server.Client(),
+		confirmations:        NewConfirmationEstimator(20, time.Hour),
+		pendingConfirmations: make(chan pendingConfirmation, maxTrackedConfirmations),
+		confirmPoll:          5 * time.Millisecond,
+	}
+	assert.Equal(t, time.Hour, service.EstimatedConfirmation(), "the default before any confirmation")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		service.RunConfirmations(ctx)
+		close(done)
+	}()
+	for i := 0; i < minConfirmationSamples; i++ {
+		service.queueConfirmation("ABC", time.Now())
+		// One at a time, so each is pending on its first lookup
+		want := int32(2 * (i + 1))
+		require.Eventually(t, func() bool { return lookups.Load() >= want }, time.Second, time.Millisecond)
+	}
+	require.Eventually(t, func() bool { return service.EstimatedConfirmation() < time.Hour }, time.Second, time.Millisecond)
+	cancel()
+	<-done
+
+	assert.Equal(t, int32(2*minConfirmationSamples), lookups.Load())
+	estimate := service.EstimatedConfirmation()
+	assert.Greater(t, estimate, time.Duration(0))
+	assert.Less(t, estimate, time.Second, "observed confirmations replace the default")
+}
+
+func TestQueueConfirmationNeverBlocks(t *testing.T) {
+	service := &Service{pendingConfirmations: make(chan pendingConfirmation, 1)}
+	service.queueConfirmation("A", time.Now())
+	service.queueConfirmation("B", time.Now())
+	assert.Len(t, service.pendingConfirmations, 1, "a full queue drops the sample")
+
+	disabled := &Service{}
+	disabled.queueConfirmation("A", time.Now())
+}
+
+func TestEstimatedConfirmationDisabled(t *testing.T) {
+	service := &Service{cfg: &config.Config{}}
+	assert.Zero(t, service.EstimatedConfirmation())
+}
@@ -4,39 +4,135 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os/exec"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/aura-chain/aura/faucet/pkg/config"
 	"github.com/aura-chain/aura/faucet/pkg/database"
+	metrics "github.com/aura-chain/aura/faucet/pkg/prometheus"
+	"github.com/aura-chain/aura/faucet/pkg/units"
 )
 
+const (
+	// defaultCLITimeout bounds a single CLI transaction when no timeout is configured
+	defaultCLITimeout = 60 * time.Second
+
+	// signerCheckTTL caches the signing key self-check so health probes
+	// don't spawn the binary on every request
+	signerCheckTTL = 5 * time.Minute
+
+	// bech32ChecksumLength is the number of checksum characters ending a bech32 string
+	bech32ChecksumLength = 6
+)
+
+// defaultAddressByteLengths are 20-byte accounts and 32-byte module or
+// contract accounts
+var defaultAddressByteLengths = []int{20, 32}
+
+// ErrBroadcastTimeout is returned when the CLI process does not finish before
+// its deadline. The transaction may still land on chain, so callers should not
+// treat it as a definite failure.
+var ErrBroadcastTimeout = errors.New("BROADCAST_TIMEOUT")
+
+// ErrTxRejected is returned when the chain assigned a transaction a hash
+// but failed it with a nonzero code, e.g. for insufficient fees
+var ErrTxRejected = errors.New("TX_REJECTED")
+
+// TxRejectedError is a transaction that has a hash but a nonzero result code
+type TxRejectedError struct {
+	TxHash string
+	Code   int64
+	RawLog string
+}
+
+func (e *TxRejectedError) Error() string {
+	return fmt.Sprintf("%s: code %d: %s", ErrTxRejected, e.Code, e.RawLog)
+}
+
+// Unwrap makes errors.Is match ErrTxRejected
+func (e *TxRejectedError) Unwrap() error {
+	return ErrTxRejected
+}
+
+// ErrInsufficientFeeBalance is returned when the faucet cannot cover the fee
+// for a transaction in the fee denom
+var ErrInsufficientFeeBalance = errors.New("INSUFFICIENT_FEE_BALANCE")
+
+// ErrBalanceReserve is returned when a payout would spend the faucet
+// balance below BALANCE_RESERVE
+var ErrBalanceReserve = errors.New("BALANCE_RESERVE")
+
+// commandRunner executes an external command and returns its captured output.
+// It is swapped out in tests to simulate slow or failing binaries.
+type commandRunner func(ctx context.Context, name string, args ...string) (stdout, stderr string, err error)
+
 // Service handles faucet operations
 type Service struct {
-	cfg    *config.Config
-	db     *database.DB
-	client *http.Client
+	cfg       *config.Config
+	db        *database.DB
+	client    *http.Client
+	runner    commandRunner
+	cliSlots  chan struct{} // nil when CLI concurrency is unbounded
+	sequences *SequenceManager
+	queue     *BroadcastQueue
+
+	// confirmations is nil when confirmation estimates are disabled;
+	// pendingConfirmations feeds RunConfirmations
+	confirmations        *ConfirmationEstimator
+	pendingConfirmations chan pendingConfirmation
+	confirmPoll          time.Duration
+
+	// postPayouts feeds RunPostPayouts; nil without POST_PAYOUT_COMMAND
+	postPayouts chan postPayout
+
+	signerMu        sync.Mutex
+	signerCheckedAt time.Time
+	signerErr       error
+	signerProbe     chan struct{} // closed when the running probe finishes
+
+	refillMu   sync.Mutex
+	lastRefill time.Time
+	now        func() time.Time
+
+	// contractAccounts caches whether existing accounts are contract or
+	// module accounts; account types don't change once created
+	contractAccountsMu sync.Mutex
+	contractAccounts   map[string]bool
 }
 
 // SendRequest represents a token send request
 type SendRequest struct {
 	Recipient string
-	Amount    int64
+	Amount    units.Amount
 	IPAddress string
+	Source    string
+	Bonus     units.Amount // part of Amount that is the first-request bonus
+	Memo      string       // overrides TRANSACTION_MEMO when set
 }
 
 // SendResponse represents a token send response
 type SendResponse struct {
+	// RequestID and CreatedAt identify the recorded request
+	RequestID int64
+	CreatedAt time.Time
 	TxHash    string
 	Recipient string
-	Amount    int64
+	Amount    units.Amount
+	Bonus     units.Amount // part of Amount that is the first-request bonus
+	// EstimatedConfirmation is roughly how long until the transaction is
+	// in a block; zero when estimates are disabled
+	EstimatedConfirmation time.Duration
 }
 
 // NodeStatus represents blockchain node status
@@ -51,6 +147,17 @@ type NodeStatus struct {
 	} `json:"sync_info"`
 }
 
+// Height parses the latest block height, which CometBFT reports as a
+// decimal string
+func (s *NodeStatus) Height() (int64, error) {
+	raw := strings.TrimSpace(s.SyncInfo.LatestBlockHeight)
+	height, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || height < 0 {
+		return 0, fmt.Errorf("invalid block height %q", s.SyncInfo.LatestBlockHeight)
+	}
+	return height, nil
+}
+
 // RPCResponse wraps CometBFT JSON-RPC response
 type RPCResponse struct {
 	Result NodeStatus `json:"result"`
@@ -64,19 +171,91 @@ type Balance struct {
 	} `json:"balances"`
 }
 
+// AddressBalance is an address's balance of one denom
+type AddressBalance struct {
+	Amount int64
+	// New is set when the node knows no balances for the address at all,
+	// either answering 404 or with an empty balances list, as for an
+	// address that has never received funds
+	New bool
+}
+
 // NewService creates a new faucet service
 func NewService(cfg *config.Config, db *database.DB) (*Service, error) {
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+	client, err := NewHTTPClient(cfg.NodeProxyURL(), 30*time.Second)
+	if err != nil {
+		return nil, err
 	}
 
-	return &Service{
+	s := &Service{
 		cfg:    cfg,
 		db:     db,
 		client: client,
+		runner: execRunner,
+		queue:  NewBroadcastQueue(),
+	}
+	if cfg.MaxConcurrentCLI > 0 {
+		s.cliSlots = make(chan struct{}, cfg.MaxConcurrentCLI)
+	}
+	if cfg.ConfirmationEstimateDefault > 0 {
+		s.confirmations = NewConfirmationEstimator(cfg.ConfirmationEstimateSamples, cfg.ConfirmationEstimateDefault)
+		s.pendingConfirmations = make(chan pendingConfirmation, maxTrackedConfirmations)
+	}
+	if cfg.PostPayoutCommand != "" {
+		s.postPayouts = make(chan postPayout, postPayoutQueueSize)
+	}
+	s.sequences = NewSequenceManager(cfg.FaucetAddress, s.getAccountSequence, cfg.SequenceMismatchRetries)
+
+	return s, nil
+}
+
+// NewHTTPClient creates an HTTP client that routes through proxyURL. An empty
+// proxyURL keeps the standard environment-based proxy behavior.
+func NewHTTPClient(proxyURL string, timeout time.Duration) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxyURL != "" {
+		proxy, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
 	}, nil
 }
 
+// execRunner runs the command with os/exec, killing it when ctx is done
+func execRunner(ctx context.Context, name string, args ...string) (string, string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+
+	// Capture both stdout and stderr
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	return stdout.String(), stderr.String(), err
+}
+
+// acquireCLI waits for a free CLI slot and returns the func that frees it.
+// Callers acquire before starting their command timeout so time spent
+// queued is not counted against the process.
+func (s *Service) acquireCLI() (release func()) {
+	if s.cliSlots != nil {
+		s.cliSlots <- struct{}{}
+	}
+	metrics.CLIProcessesActive.Inc()
+	return func() {
+		metrics.CLIProcessesActive.Dec()
+		if s.cliSlots != nil {
+			<-s.cliSlots
+		}
+	}
+}
+
 // SendTokens sends tokens to a recipient
 func (s *Service) SendTokens(req *SendRequest) (*SendResponse, error) {
 	log.WithFields(log.Fields{
@@ -85,67 +264,350 @@ func (s *Service) SendTokens(req *SendRequest) (*SendResponse, error) {
 		"ip":        req.IPAddress,
 	}).Info("Sending tokens")
 
+	// Reject amounts the chain would refuse before recording the request
+	if err := s.checkPayout(req.Amount); err != nil {
+		return nil, err
+	}
+
+	// Catch doomed transfers before they are recorded or cost gas
+	if s.cfg.SimulateBeforeSend {
+		if err := s.simulate(s.buildTxData(req)); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create database record
-	dbReq, err := s.db.CreateRequest(req.Recipient, req.IPAddress, req.Amount)
+	dbReq, err := s.createRequest(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request record: %w", err)
 	}
 
-	// Prepare transaction
-	txData := map[string]interface{}{
+	return s.deliver(dbReq.ID, dbReq.CreatedAt, req)
+}
+
+// checkPayout runs the checks every payout of amount must pass before it
+// is broadcast, whether a new request or a replay
+func (s *Service) checkPayout(amount units.Amount) error {
+	if err := ValidateAmount(amount, s.cfg.MaxAmountPerRequest); err != nil {
+		return err
+	}
+	if err := s.CheckFeeBalance(); err != nil {
+		return err
+	}
+	if err := s.checkReserve(amount); err != nil {
+		return err
+	}
+	return s.checkSupplyGuard()
+}
+
+// createRequest records req. A request carrying the first-request bonus
+// claims it atomically; if another request got there first, req is paid
+// without it.
+func (s *Service) createRequest(req *SendRequest) (*database.FaucetRequest, error) {
+	if req.Bonus.Sign() > 0 {
+		dbReq, err := s.db.CreateBonusRequest(req.Recipient, req.IPAddress, req.Amount, s.cfg.Denom, req.Source)
+		if !errors.Is(err, database.ErrBonusClaimed) {
+			return dbReq, err
+		}
+		req.Amount = req.Amount.Sub(req.Bonus)
+		req.Bonus = units.Amount{}
+	}
+	return s.db.CreateRequest(req.Recipient, req.IPAddress, req.Amount, s.cfg.Denom, req.Source)
+}
+
+// buildTxData describes the MsgSend paying req
+func (s *Service) buildTxData(req *SendRequest) map[string]interface{} {
+	memo := s.cfg.TransactionMemo
+	if req.Memo != "" {
+		memo = req.Memo
+	}
+	return map[string]interface{}{
 		"chain_id": s.cfg.ChainID,
 		"from":     s.cfg.FaucetAddress,
 		"to":       req.Recipient,
 		"amount": []map[string]string{
 			{
 				"denom":  s.cfg.Denom,
-				"amount": fmt.Sprintf("%d", req.Amount),
+				"amount": req.Amount.String(),
 			},
 		},
 		"gas":       fmt.Sprintf("%d", s.cfg.GasLimit),
-		"gas_price": s.cfg.GasPrice,
-		"memo":      s.cfg.TransactionMemo,
+		"gas_price": s.cfg.GasPrices(),
+		"memo":      memo,
 	}
+}
 
+// deliver broadcasts the payout for a recorded request and updates its status
+func (s *Service) deliver(requestID int64, createdAt time.Time, req *SendRequest) (*SendResponse, error) {
 	// Send transaction to node
-	txHash, err := s.broadcastTransaction(txData)
+	txHash, err := s.queuedBroadcast(s.buildTxData(req))
 	if err != nil {
-		// Update request as failed
-		if updateErr := s.db.UpdateRequestFailed(dbReq.ID, err.Error()); updateErr != nil {
+		// A timed out broadcast has an unknown outcome, so keep it distinct
+		// from failures and never retry it automatically. A rejected
+		// transaction already cost its fee and would fail the same way again.
+		updateStatus := s.db.UpdateRequestFailed
+		if errors.Is(err, ErrBroadcastTimeout) {
+			updateStatus = s.db.UpdateRequestTimedOut
+		} else if s.cfg.PayoutRetryAttempts > 0 && !errors.Is(err, ErrTxRejected) {
+			updateStatus = s.scheduleRetry
+		}
+		if updateErr := updateStatus(requestID, err.Error()); updateErr != nil {
 			log.WithError(updateErr).Error("Failed to update request status")
 		}
 		return nil, fmt.Errorf("failed to broadcast transaction: %w", err)
 	}
 
 	// Update request as successful
-	if err := s.db.UpdateRequestSuccess(dbReq.ID, txHash); err != nil {
+	if err := s.db.UpdateRequestSuccess(requestID, txHash); err != nil {
 		log.WithError(err).Error("Failed to update request status")
 	}
 
+	if s.cfg.PayoutWebhookURL != "" {
+		s.enqueuePayoutEvent(requestID, req, txHash)
+	}
+	s.queuePostPayout(req, txHash)
+
 	log.WithFields(log.Fields{
 		"tx_hash":   txHash,
 		"recipient": req.Recipient,
 		"amount":    req.Amount,
 	}).Info("Tokens sent successfully")
 
+	// Watch for the transaction to land so later estimates reflect the chain
+	s.queueConfirmation(txHash, time.Now())
+
 	return &SendResponse{
-		TxHash:    txHash,
-		Recipient: req.Recipient,
-		Amount:    req.Amount,
+		RequestID:             requestID,
+		CreatedAt:             createdAt,
+		TxHash:                txHash,
+		Recipient:             req.Recipient,
+		Amount:                req.Amount,
+		Bonus:                 req.Bonus,
+		EstimatedConfirmation: s.EstimatedConfirmation(),
 	}, nil
 }
 
+// CheckSigner verifies the configured signer is usable: the CLI key
+// resolves to FAUCET_ADDRESS, or in REST mode the node can serve the
+// faucet account's sequence. The result is cached for signerCheckTTL and
+// the probe runs outside signerMu, so concurrent callers get the last
+// result instead of queuing behind it.
+func (s *Service) CheckSigner() error {
+	s.signerMu.Lock()
+	if !s.signerCheckedAt.IsZero() && time.Since(s.signerCheckedAt) < signerCheckTTL {
+		err := s.signerErr
+		s.signerMu.Unlock()
+		return err
+	}
+	if probe := s.signerProbe; probe != nil {
+		checked, err := !s.signerCheckedAt.IsZero(), s.signerErr
+		s.signerMu.Unlock()
+		if checked {
+			return err
+		}
+		// Nothing cached yet, so wait for the first probe
+		<-probe
+		s.signerMu.Lock()
+		defer s.signerMu.Unlock()
+		return s.signerErr
+	}
+	probe := make(chan struct{})
+	s.signerProbe = probe
+	s.signerMu.Unlock()
+
+	err := s.checkSigner()
+
+	s.signerMu.Lock()
+	s.signerErr = err
+	s.signerCheckedAt = time.Now()
+	s.signerProbe = nil
+	s.signerMu.Unlock()
+	close(probe)
+	return err
+}
+
+// checkSigner probes the signing mode broadcastTransaction will use
+func (s *Service) checkSigner() error {
+	if s.cfg.FaucetBinary != "" && s.cfg.FaucetKey != "" {
+		return s.checkCLISigner()
+	}
+	if s.cfg.FaucetMnemonic != "" {
+		if words := len(strings.Fields(s.cfg.FaucetMnemonic)); words < 12 || words > 24 || words%3 != 0 {
+			return fmt.Errorf("FAUCET_MNEMONIC has %d words, want 12, 15, 18, 21 or 24", words)
+		}
+	}
+	return s.checkRESTSigner()
+}
+
+// checkRESTSigner checks the node serves the faucet account's sequence,
+// which every REST broadcast needs
+func (s *Service) checkRESTSigner() error {
+	if s.cfg.FaucetAddress == "" {
+		return fmt.Errorf("REST signing requires FAUCET_ADDRESS")
+	}
+	if _, err := s.getAccountSequence(s.cfg.FaucetAddress); err != nil {
+		return fmt.Errorf("faucet account unavailable: %w", err)
+	}
+	return nil
+}
+
+// checkCLISigner checks FAUCET_KEY resolves to FAUCET_ADDRESS
+func (s *Service) checkCLISigner() error {
+	args := []string{
+		"keys", "show", s.cfg.FaucetKey,
+		"--address",
+		"--keyring-backend", s.cfg.FaucetKeyring,
+	}
+	if s.cfg.FaucetHome != "" {
+		args = append(args, "--home", s.cfg.FaucetHome)
+	}
+
+	release := s.acquireCLI()
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	runner := s.runner
+	if runner == nil {
+		runner = execRunner
+	}
+	stdout, stderr, err := runner(ctx, s.cfg.FaucetBinary, args...)
+	if err != nil {
+		errMsg := strings.TrimSpace(stderr)
+		if errMsg == "" {
+			errMsg = err.Error()
+		}
+		return fmt.Errorf("signing key unavailable: %s", errMsg)
+	}
+
+	address := strings.TrimSpace(stdout)
+	if address == "" {
+		return fmt.Errorf("signing key resolved to an empty address")
+	}
+	if s.cfg.FaucetAddress != "" && address != s.cfg.FaucetAddress {
+		return fmt.Errorf("signing key address %s does not match FAUCET_ADDRESS %s", address, s.cfg.FaucetAddress)
+	}
+
+	return nil
+}
+
+// enqueuePayoutEvent records a payout.success event in the webhook outbox.
+// The event id is derived from the request id so retries dedup downstream.
+func (s *Service) enqueuePayoutEvent(requestID int64, req *SendRequest, txHash string) {
+	eventID := fmt.Sprintf("payout-%d", requestID)
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":        eventID,
+		"type":      "payout.success",
+		"recipient": req.Recipient,
+		"amount":    req.Amount,
+		"denom":     s.cfg.Denom,
+		"tx_hash":   txHash,
+		"chain_id":  s.cfg.ChainID,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to encode payout webhook event")
+		return
+	}
+
+	if err := s.db.EnqueueWebhookEvent(eventID, "payout.success", payload); err != nil {
+		log.WithError(err).Error("Failed to enqueue payout webhook event")
+	}
+}
+
 // GetBalance returns the faucet account balance
 func (s *Service) GetBalance() (int64, error) {
-	return s.getBalanceForAddress(s.cfg.FaucetAddress)
+	return s.getBalanceForAddress(s.cfg.FaucetAddress, s.cfg.Denom)
+}
+
+// GetFeeBalance returns the faucet account balance in the fee denom
+func (s *Service) GetFeeBalance() (int64, error) {
+	return s.getBalanceForAddress(s.cfg.FaucetAddress, s.cfg.FeeDenom())
+}
+
+// CheckFeeBalance verifies the faucet holds enough of the fee denom for one
+// transaction. It is a no-op when fees are paid in the payout denom, which
+// the faucet balance checks already cover.
+func (s *Service) CheckFeeBalance() error {
+	if s.cfg.FeeDenom() == s.cfg.Denom {
+		return nil
+	}
+
+	balance, err := s.GetFeeBalance()
+	if err != nil {
+		return fmt.Errorf("failed to check fee balance: %w", err)
+	}
+	if required := s.cfg.MinFeeBalance(); balance < required {
+		return fmt.Errorf("%w: have %d%s, need %d%s", ErrInsufficientFeeBalance,
+			balance, s.cfg.FeeDenom(), required, s.cfg.FeeDenom())
+	}
+	return nil
+}
+
+// checkReserve refuses a payout of amount that would leave less than
+// BALANCE_RESERVE, counting the fee when it is paid in the same denom
+func (s *Service) checkReserve(amount units.Amount) error {
+	if s.cfg.BalanceReserve.IsZero() {
+		return nil
+	}
+
+	// Compare exactly; the int64 balance saturates for 18-decimal denoms
+	_, balance, err := s.lookupExactBalance(s.cfg.FaucetAddress, s.cfg.Denom)
+	if err != nil {
+		return fmt.Errorf("failed to check faucet balance: %w", err)
+	}
+	needed := amount
+	if s.cfg.FeeDenom() == s.cfg.Denom {
+		needed = needed.Add(units.New(s.cfg.MinFeeBalance()))
+	}
+	spendable := balance.Sub(s.cfg.BalanceReserve)
+	if spendable.Sign() < 0 {
+		spendable = units.Amount{}
+	}
+	if spendable.Cmp(needed) < 0 {
+		return fmt.Errorf("%w: spendable %s%s above a reserve of %s, need %s", ErrBalanceReserve,
+			spendable, s.cfg.Denom, s.cfg.BalanceReserve, needed)
+	}
+	return nil
 }
 
 // GetAddressBalance returns the balance for a specific address
 func (s *Service) GetAddressBalance(address string) (int64, error) {
-	return s.getBalanceForAddress(address)
+	return s.getBalanceForAddress(address, s.cfg.Denom)
+}
+
+// GetExactAddressBalance returns the balance for a specific address without
+// the int64 clamp GetAddressBalance applies
+func (s *Service) GetExactAddressBalance(address string) (units.Amount, error) {
+	_, balance, err := s.lookupExactBalance(address, s.cfg.Denom)
+	return balance, err
+}
+
+// LookupAddressBalance returns the balance for a specific address and
+// whether the address is new to the chain
+func (s *Service) LookupAddressBalance(address string) (*AddressBalance, error) {
+	return s.lookupBalance(address, s.cfg.Denom)
 }
 
-func (s *Service) getBalanceForAddress(address string) (int64, error) {
+func (s *Service) getBalanceForAddress(address, denom string) (int64, error) {
+	balance, err := s.lookupBalance(address, denom)
+	if err != nil {
+		return 0, err
+	}
+	return balance.Amount, nil
+}
+
+// lookupBalance queries address's balance of denom. A denom missing from
+// the response is a zero balance; an account the node does not know is a
+// zero balance of a new address.
+func (s *Service) lookupBalance(address, denom string) (*AddressBalance, error) {
+	balance, _, err := s.lookupExactBalance(address, denom)
+	return balance, err
+}
+
+// lookupExactBalance is lookupBalance that also returns the exact amount,
+// which AddressBalance clamps to int64
+func (s *Service) lookupExactBalance(address, denom string) (*AddressBalance, units.Amount, error) {
 	// Use REST API endpoint for balance queries
 	restURL := s.cfg.NodeREST
 	if restURL == "" {
@@ -155,38 +617,90 @@ func (s *Service) getBalanceForAddress(address string) (int64, error) {
 
 	resp, err := s.client.Get(url)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get balance: %w", err)
+		return nil, units.Amount{}, fmt.Errorf("failed to get balance: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound && s.cfg.MissingAccountAsZero {
+		return &AddressBalance{New: true}, units.Amount{}, nil
+	}
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("failed to get balance: status %d, body: %s", resp.StatusCode, string(body))
+		return nil, units.Amount{}, fmt.Errorf("failed to get balance: status %d, body: %s", resp.StatusCode, string(body))
 	}
 
 	var balance Balance
-	if err := json.NewDecoder(resp.Body).Decode(&balance); err != nil {
-		return 0, fmt.Errorf("failed to decode balance response: %w", err)
+	if err := decodeNodeJSON(resp, "balance", &balance); err != nil {
+		return nil, units.Amount{}, err
 	}
 
-	// Find the balance for our denom
+	// Find the balance for the requested denom
 	for _, b := range balance.Balances {
-		if b.Denom == s.cfg.Denom {
-			var amount int64
-			fmt.Sscanf(b.Amount, "%d", &amount)
-			return amount, nil
+		if b.Denom == denom {
+			amount, err := parseBalance(b.Amount)
+			if err != nil {
+				return nil, units.Amount{}, err
+			}
+			exact, err := units.Parse(b.Amount)
+			if err != nil {
+				exact = units.New(amount)
+			}
+			return &AddressBalance{Amount: amount}, exact, nil
 		}
 	}
 
-	return 0, nil
+	return &AddressBalance{New: len(balance.Balances) == 0}, units.Amount{}, nil
+}
+
+// getAccountSequence queries the current sequence of an account from the REST API
+func (s *Service) getAccountSequence(address string) (uint64, error) {
+	restURL := s.cfg.NodeREST
+	if restURL == "" {
+		restURL = s.cfg.NodeRPC // Fallback to RPC if REST not configured
+	}
+	url := fmt.Sprintf("%s/cosmos/auth/v1beta1/accounts/%s", restURL, address)
+
+	resp, err := s.client.Get(url)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get account: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("failed to get account: status %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var account struct {
+		Account struct {
+			Sequence string `json:"sequence"`
+		} `json:"account"`
+	}
+	if err := decodeNodeJSON(resp, "account", &account); err != nil {
+		return 0, err
+	}
+
+	if account.Account.Sequence == "" {
+		return 0, nil
+	}
+	sequence, err := strconv.ParseUint(account.Account.Sequence, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid account sequence %q: %w", account.Account.Sequence, err)
+	}
+
+	return sequence, nil
 }
 
-// GetNodeStatus returns the blockchain node status
-func (s *Service) GetNodeStatus() (*NodeStatus, error) {
+// GetNodeStatus returns the blockchain node status, giving up when ctx is done
+func (s *Service) GetNodeStatus(ctx context.Context) (*NodeStatus, error) {
 	// Use CometBFT RPC endpoint (port 26657) for node status
 	url := fmt.Sprintf("%s/status", s.cfg.NodeRPC)
 
-	resp, err := s.client.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node status: %w", err)
+	}
+	resp, err := s.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get node status: %w", err)
 	}
@@ -199,13 +713,34 @@ func (s *Service) GetNodeStatus() (*NodeStatus, error) {
 
 	// CometBFT RPC wraps response in {"jsonrpc":"2.0","result":{...}}
 	var rpcResp RPCResponse
-	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
-		return nil, fmt.Errorf("failed to decode status response: %w", err)
+	if err := decodeNodeJSON(resp, "status", &rpcResp); err != nil {
+		return nil, err
 	}
 
 	return &rpcResp.Result, nil
 }
 
+// queuedBroadcast broadcasts txData while holding a place in the
+// broadcast queue
+func (s *Service) queuedBroadcast(txData map[string]interface{}) (string, error) {
+	if s.queue == nil {
+		return s.broadcastTransaction(txData)
+	}
+
+	ticket := s.queue.Enter()
+	started := time.Now()
+	defer func() { s.queue.Leave(ticket, time.Since(started)) }()
+	return s.broadcastTransaction(txData)
+}
+
+// QueueStatus reports the payouts queued for broadcast
+func (s *Service) QueueStatus() QueueStatus {
+	if s.queue == nil {
+		return QueueStatus{}
+	}
+	return s.queue.Status()
+}
+
 // broadcastTransaction broadcasts a transaction to the blockchain
 func (s *Service) broadcastTransaction(txData map[string]interface{}) (string, error) {
 	// Use CLI binary if configured (preferred method for signing)
@@ -222,11 +757,18 @@ func (s *Service) broadcastViaCLI(txData map[string]interface{}) (string, error)
 	recipient := txData["to"].(string)
 	amount := txData["amount"].([]map[string]string)
 	amountStr := fmt.Sprintf("%s%s", amount[0]["amount"], amount[0]["denom"])
+	memo, _ := txData["memo"].(string)
 
+	return s.cliSend(s.cfg.FaucetKey, recipient, amountStr, memo)
+}
+
+// cliSend signs and broadcasts a bank send from the keyring key fromKey
+// and returns the transaction hash
+func (s *Service) cliSend(fromKey, recipient, amountStr, memo string) (string, error) {
 	// Build command arguments
 	args := []string{
 		"tx", "bank", "send",
-		s.cfg.FaucetKey,
+		fromKey,
 		recipient,
 		amountStr,
 		"--chain-id", s.cfg.ChainID,
@@ -234,7 +776,13 @@ func (s *Service) broadcastViaCLI(txData map[string]interface{}) (string, error)
 		"--yes",
 		"--output", "json",
 		"--gas", fmt.Sprintf("%d", s.cfg.GasLimit),
-		"--gas-prices", s.cfg.GasPrice,
+	}
+
+	// Some chains reject --gas-prices and need an explicit flat fee
+	if s.cfg.FeeMode == config.FeeModeFees {
+		args = append(args, "--fees", s.cfg.Fees())
+	} else {
+		args = append(args, "--gas-prices", s.cfg.GasPrices())
 	}
 
 	// Add home directory if specified
@@ -248,7 +796,7 @@ func (s *Service) broadcastViaCLI(txData map[string]interface{}) (string, error)
 	}
 
 	// Add memo if specified
-	if memo, ok := txData["memo"].(string); ok && memo != "" {
+	if memo != "" {
 		args = append(args, "--note", memo)
 	}
 
@@ -259,20 +807,25 @@ func (s *Service) broadcastViaCLI(txData map[string]interface{}) (string, error)
 		"amount":    amountStr,
 	}).Debug("Executing CLI transaction")
 
-	// Execute with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	// Execute with timeout; CommandContext kills the child process on expiry
+	timeout := s.cfg.CLITimeout
+	if timeout <= 0 {
+		timeout = defaultCLITimeout
+	}
+	queued := time.Now()
+	release := s.acquireCLI()
+	defer release()
+	metrics.BroadcastQueueWait.Observe(time.Since(queued).Seconds())
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, s.cfg.FaucetBinary, args...)
-
-	// Capture both stdout and stderr
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
-	stdoutStr := stdout.String()
-	stderrStr := stderr.String()
+	runner := s.runner
+	if runner == nil {
+		runner = execRunner
+	}
+	started := time.Now()
+	stdoutStr, stderrStr, err := runner(ctx, s.cfg.FaucetBinary, args...)
+	metrics.BroadcastDuration.Observe(time.Since(started).Seconds())
 
 	log.WithFields(log.Fields{
 		"stdout": stdoutStr,
@@ -280,6 +833,15 @@ func (s *Service) broadcastViaCLI(txData map[string]interface{}) (string, error)
 		"error":  err,
 	}).Debug("CLI execution result")
 
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		metrics.BroadcastTimeouts.Inc()
+		log.WithFields(log.Fields{
+			"recipient": recipient,
+			"timeout":   timeout.String(),
+		}).Warn("CLI transaction timed out")
+		return "", fmt.Errorf("%w: CLI did not complete within %s", ErrBroadcastTimeout, timeout)
+	}
+
 	if err != nil {
 		// Check if the error contains useful information
 		errMsg := stderrStr
@@ -293,10 +855,12 @@ func (s *Service) broadcastViaCLI(txData map[string]interface{}) (string, error)
 	}
 
 	// Parse the JSON output to extract tx hash
-	txHash, parseErr := parseTxHashFromOutput(stdoutStr)
+	output := stdoutStr
+	txHash, parseErr := parseTxHashFromOutput(output)
 	if parseErr != nil {
 		// Sometimes the tx hash appears in a different format or in stderr
-		txHash, parseErr = parseTxHashFromOutput(stderrStr)
+		output = stderrStr
+		txHash, parseErr = parseTxHashFromOutput(output)
 		if parseErr != nil {
 			log.WithFields(log.Fields{
 				"stdout": stdoutStr,
@@ -306,9 +870,41 @@ func (s *Service) broadcastViaCLI(txData map[string]interface{}) (string, error)
 		}
 	}
 
+	// A hash alone is not success: the chain can fail the transaction, for
+	// instance on insufficient fees, and still report its hash
+	if code, rawLog := parseTxResultCode(output); code != 0 {
+		log.WithFields(log.Fields{
+			"tx_hash": txHash,
+			"code":    code,
+			"raw_log": rawLog,
+		}).Warn("CLI transaction failed on chain")
+		return "", &TxRejectedError{TxHash: txHash, Code: code, RawLog: rawLog}
+	}
+
 	return txHash, nil
 }
 
+// parseTxResultCode extracts the result code and raw log from CLI JSON
+// output, at the top level or under tx_response. Output that isn't JSON or
+// carries no code reports zero.
+func parseTxResultCode(output string) (int64, string) {
+	var result struct {
+		Code       int64  `json:"code"`
+		RawLog     string `json:"raw_log"`
+		TxResponse *struct {
+			Code   int64  `json:"code"`
+			RawLog string `json:"raw_log"`
+		} `json:"tx_response"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return 0, ""
+	}
+	if result.Code == 0 && result.TxResponse != nil {
+		return result.TxResponse.Code, result.TxResponse.RawLog
+	}
+	return result.Code, result.RawLog
+}
+
 // parseTxHashFromOutput extracts the transaction hash from CLI output
 func parseTxHashFromOutput(output string) (string, error) {
 	// Try to parse as JSON first
@@ -343,15 +939,14 @@ func (s *Service) broadcastViaREST(txData map[string]interface{}) (string, error
 	// For now, return an error suggesting CLI mode should be used
 	log.Warn("REST broadcast requires signed transactions; configure FAUCET_BINARY for CLI mode")
 
-	// Use REST API endpoint (port 1317) for transaction broadcasting via gRPC-gateway
-	restURL := s.cfg.NodeREST
-	if restURL == "" {
-		restURL = s.cfg.NodeRPC // Fallback to RPC if REST not configured
-	}
-	url := fmt.Sprintf("%s/cosmos/tx/v1beta1/txs", restURL)
+	return s.sequences.Do(func(sequence uint64) (string, error) {
+		return s.postTransaction(txData, sequence)
+	})
+}
 
-	// Build transaction body (note: this will fail without proper auth_info and signatures)
-	txBody := map[string]interface{}{
+// restTx builds the JSON transaction the REST endpoints accept for txData
+func restTx(txData map[string]interface{}, sequence uint64) map[string]interface{} {
+	return map[string]interface{}{
 		"body": map[string]interface{}{
 			"messages": []map[string]interface{}{
 				{
@@ -363,8 +958,26 @@ func (s *Service) broadcastViaREST(txData map[string]interface{}) (string, error
 			},
 			"memo": txData["memo"],
 		},
-		"mode": "BROADCAST_MODE_SYNC",
+		"auth_info": map[string]interface{}{
+			"signer_infos": []map[string]interface{}{
+				{"sequence": strconv.FormatUint(sequence, 10)},
+			},
+		},
 	}
+}
+
+// postTransaction posts a transaction built with the given account sequence
+func (s *Service) postTransaction(txData map[string]interface{}, sequence uint64) (string, error) {
+	// Use REST API endpoint (port 1317) for transaction broadcasting via gRPC-gateway
+	restURL := s.cfg.NodeREST
+	if restURL == "" {
+		restURL = s.cfg.NodeRPC // Fallback to RPC if REST not configured
+	}
+	url := fmt.Sprintf("%s/cosmos/tx/v1beta1/txs", restURL)
+
+	// Build transaction body (note: this will fail without proper auth_info and signatures)
+	txBody := restTx(txData, sequence)
+	txBody["mode"] = "BROADCAST_MODE_SYNC"
 
 	jsonData, err := json.Marshal(txBody)
 	if err != nil {
@@ -398,6 +1011,11 @@ func (s *Service) broadcastViaREST(txData map[string]interface{}) (string, error
 
 	// Extract tx hash from response
 	if txResponse, ok := result["tx_response"].(map[string]interface{}); ok {
+		if code, ok := txResponse["code"].(float64); ok && code != 0 {
+			txHash, _ := txResponse["txhash"].(string)
+			rawLog, _ := txResponse["raw_log"].(string)
+			return "", &TxRejectedError{TxHash: txHash, Code: int64(code), RawLog: rawLog}
+		}
 		if txHash, ok := txResponse["txhash"].(string); ok {
 			return txHash, nil
 		}
@@ -406,14 +1024,48 @@ func (s *Service) broadcastViaREST(txData map[string]interface{}) (string, error
 	return "", fmt.Errorf("no transaction hash in response: %s", string(body))
 }
 
+// acceptsAddressSize reports whether a decoded address of size bytes is accepted
+func (s *Service) acceptsAddressSize(size int) bool {
+	lengths := s.cfg.AddressByteLengths
+	if len(lengths) == 0 {
+		lengths = defaultAddressByteLengths
+	}
+	for _, length := range lengths {
+		if size == length {
+			return true
+		}
+	}
+	return false
+}
+
+// bech32DecodedLength returns the bytes encoded by chars 5-bit data
+// characters. Lengths that leave a whole unused character are invalid.
+func bech32DecodedLength(chars int) (int, bool) {
+	if chars <= 0 {
+		return 0, false
+	}
+	bits := chars * 5
+	size := bits / 8
+	return size, bits-size*8 < 5
+}
+
 // ValidateAddress validates a AURA testnet address
 func (s *Service) ValidateAddress(address string) error {
-	if len(address) < 43 || len(address) > 64 {
-		return fmt.Errorf("invalid address length")
+	prefix := s.cfg.AddressPrefix
+	if prefix == "" {
+		prefix = "aura"
 	}
 
-	if !strings.HasPrefix(address, "aura1") {
-		return fmt.Errorf("address must start with aura1")
+	if !strings.HasPrefix(address, prefix+"1") {
+		return fmt.Errorf("address must start with %s1", prefix)
+	}
+
+	// bech32: prefix, separator, data chars and a 6 char checksum. The data
+	// length must decode to one of the accepted account sizes.
+	dataChars := len(address) - len(prefix) - 1 - bech32ChecksumLength
+	size, ok := bech32DecodedLength(dataChars)
+	if !ok || !s.acceptsAddressSize(size) {
+		return fmt.Errorf("invalid address length")
 	}
 
 	// Additional validation could be added here
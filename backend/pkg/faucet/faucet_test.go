@@ -1,19 +1,34 @@
 package faucet
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/aura-chain/aura/faucet/pkg/config"
+	"github.com/aura-chain/aura/faucet/pkg/database"
+	metrics "github.com/aura-chain/aura/faucet/pkg/prometheus"
+	"github.com/aura-chain/aura/faucet/pkg/units"
 )
 
 func TestValidateAddress(t *testing.T) {
 	cfg := &config.Config{
-		NodeRPC:         "http://localhost:26657",
-		ChainID:         "test-chain",
-		FaucetAddress:   "aura1test",
+		NodeRPC:          "http://localhost:26657",
+		ChainID:          "test-chain",
+		FaucetAddress:    "aura1test",
 		AmountPerRequest: 100,
 	}
 
@@ -27,10 +42,20 @@ func TestValidateAddress(t *testing.T) {
 		wantErr bool
 	}{
 		{
-			name:    "valid address",
-			address: "aura1qwertyuiopasdfghjklzxcvbnm123456789test",
+			name:    "valid 20-byte address",
+			address: "aura1qpzry9x8gf2tvdw0s3jn54khce6mua7lqpzry9",
 			wantErr: false,
 		},
+		{
+			name:    "valid 32-byte module account",
+			address: "aura19x8gf2tvdw0s3jn54khce6mua7lqpzry9x8gf2tvdw0s3jn54khce6mua7",
+			wantErr: false,
+		},
+		{
+			name:    "length between account sizes",
+			address: "aura1qpzry9x8gf2tvdw0s3jn54khce6mua7lqpzry9qpzry9",
+			wantErr: true,
+		},
 		{
 			name:    "too short",
 			address: "aura1short",
@@ -38,7 +63,7 @@ func TestValidateAddress(t *testing.T) {
 		},
 		{
 			name:    "wrong prefix",
-			address: "cosmos1qwertyuiopasdfghjklzxcvbnm123456789test",
+			address: "cosmos1qpzry9x8gf2tvdw0s3jn54khce6mua7lqpzry9",
 			wantErr: true,
 		},
 		{
@@ -67,9 +92,9 @@ func TestValidateAddress(t *testing.T) {
 
 func TestNewService(t *testing.T) {
 	cfg := &config.Config{
-		NodeRPC:         "http://localhost:26657",
-		ChainID:         "test-chain",
-		FaucetAddress:   "aura1test",
+		NodeRPC:          "http://localhost:26657",
+		ChainID:          "test-chain",
+		FaucetAddress:    "aura1test",
 		AmountPerRequest: 100,
 	}
 
@@ -79,3 +104,1426 @@ func TestNewService(t *testing.T) {
 	assert.Equal(t, cfg, service.cfg)
 	assert.NotNil(t, service.client)
 }
+
+func TestBroadcastViaCLITimeout(t *testing.T) {
+	cfg := &config.Config{
+		ChainID:      "test-chain",
+		FaucetBinary: "aurad",
+		FaucetKey:    "faucet",
+		Denom:        "uaura",
+		CLITimeout:   20 * time.Millisecond,
+	}
+
+	service := &Service{
+		cfg: cfg,
+		runner: func(ctx context.Context, name string, args ...string) (string, string, error) {
+			// Block until the deadline kills the "process"
+			<-ctx.Done()
+			return "", "", ctx.Err()
+		},
+	}
+
+	txData := map[string]interface{}{
+		"to": "aura1recipient",
+		"amount": []map[string]string{
+			{"denom": "uaura", "amount": "100"},
+		},
+	}
+
+	_, err := service.broadcastViaCLI(txData)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrBroadcastTimeout))
+}
+
+func TestBroadcastViaCLIFailureIsNotTimeout(t *testing.T) {
+	cfg := &config.Config{
+		ChainID:      "test-chain",
+		FaucetBinary: "aurad",
+		FaucetKey:    "faucet",
+		Denom:        "uaura",
+		CLITimeout:   time.Second,
+	}
+
+	service := &Service{
+		cfg: cfg,
+		runner: func(ctx context.Context, name string, args ...string) (string, string, error) {
+			return "", "unknown command", errors.New("exit status 1")
+		},
+	}
+
+	txData := map[string]interface{}{
+		"to": "aura1recipient",
+		"amount": []map[string]string{
+			{"denom": "uaura", "amount": "100"},
+		},
+	}
+
+	_, err := service.broadcastViaCLI(txData)
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrBroadcastTimeout))
+	assert.Contains(t, err.Error(), "unknown command")
+}
+
+func TestCLIConcurrencyIsBounded(t *testing.T) {
+	service, err := NewService(&config.Config{
+		ChainID:          "test-chain",
+		FaucetBinary:     "aurad",
+		FaucetKey:        "faucet",
+		Denom:            "uaura",
+		CLITimeout:       5 * time.Second,
+		MaxConcurrentCLI: 2,
+	}, nil)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	service.runner = func(ctx context.Context, name string, args ...string) (string, string, error) {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return `{"txhash":"ABC","code":0}`, "", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = service.cliSend("faucet", "aura1recipient", "100uaura", "")
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 2, peak, "sends beyond the limit wait for a free slot")
+}
+
+// histogramSample returns a histogram's observation count and sum
+func histogramSample(t *testing.T, h interface{ Write(*dto.Metric) error }) (uint64, float64) {
+	var metric dto.Metric
+	require.NoError(t, h.Write(&metric))
+	return metric.GetHistogram().GetSampleCount(), metric.GetHistogram().GetSampleSum()
+}
+
+func TestBroadcastQueueWaitIsObservedApartFromBroadcast(t *testing.T) {
+	service, err := NewService(&config.Config{
+		ChainID:          "test-chain",
+		FaucetBinary:     "aurad",
+		FaucetKey:        "faucet",
+		Denom:            "uaura",
+		CLITimeout:       5 * time.Second,
+		MaxConcurrentCLI: 1,
+	}, nil)
+	require.NoError(t, err)
+
+	// The worker holds the only CLI slot until released
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	service.runner = func(ctx context.Context, name string, args ...string) (string, string, error) {
+		started <- struct{}{}
+		<-release
+		return `{"txhash":"ABC","code":0}`, "", nil
+	}
+
+	waits, waited := histogramSample(t, metrics.BroadcastQueueWait)
+	runs, _ := histogramSample(t, metrics.BroadcastDuration)
+
+	var wg sync.WaitGroup
+	send := func() {
+		defer wg.Done()
+		_, _ = service.cliSend("faucet", "aura1recipient", "100uaura", "")
+	}
+	wg.Add(2)
+	go send()
+	<-started
+	go send()
+
+	// The second send queues behind the first for as long as it runs
+	time.Sleep(50 * time.Millisecond)
+	release <- struct{}{}
+	<-started
+	release <- struct{}{}
+	wg.Wait()
+
+	count, sum := histogramSample(t, metrics.BroadcastQueueWait)
+	assert.Equal(t, waits+2, count)
+	assert.GreaterOrEqual(t, sum-waited, 0.05, "the queued send's wait is observed")
+	count, _ = histogramSample(t, metrics.BroadcastDuration)
+	assert.Equal(t, runs+2, count, "each broadcast's run is observed separately")
+}
+
+func TestCheckSigner(t *testing.T) {
+	newService := func(derived string) (*Service, *int) {
+		calls := 0
+		return &Service{
+			cfg: &config.Config{
+				FaucetBinary:  "aurad",
+				FaucetKey:     "faucet",
+				FaucetAddress: "aura1faucet",
+			},
+			runner: func(ctx context.Context, name string, args ...string) (string, string, error) {
+				calls++
+				return derived + "\n", "", nil
+			},
+		}, &calls
+	}
+
+	t.Run("matching address", func(t *testing.T) {
+		service, calls := newService("aura1faucet")
+		assert.NoError(t, service.CheckSigner())
+		assert.NoError(t, service.CheckSigner())
+		assert.Equal(t, 1, *calls, "result should be cached")
+	})
+
+	t.Run("mismatching address", func(t *testing.T) {
+		service, _ := newService("aura1other")
+		err := service.CheckSigner()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not match")
+	})
+
+	t.Run("malformed mnemonic", func(t *testing.T) {
+		service := &Service{cfg: &config.Config{FaucetMnemonic: "words", FaucetAddress: "aura1faucet"}}
+		assert.ErrorContains(t, service.CheckSigner(), "FAUCET_MNEMONIC has 1 words")
+	})
+
+	t.Run("REST mode probes the faucet account", func(t *testing.T) {
+		status := http.StatusOK
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/cosmos/auth/v1beta1/accounts/aura1faucet", r.URL.Path)
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"account":{"sequence":"4"}}`))
+		}))
+		defer server.Close()
+		newREST := func(mnemonic string) *Service {
+			return &Service{
+				cfg:    &config.Config{NodeREST: server.URL, FaucetAddress: "aura1faucet", FaucetMnemonic: mnemonic},
+				client: server.Client(),
+			}
+		}
+
+		assert.NoError(t, newREST("").CheckSigner())
+		assert.NoError(t, newREST(strings.TrimSpace(strings.Repeat("word ", 24))).CheckSigner())
+		status = http.StatusBadGateway
+		assert.ErrorContains(t, newREST("").CheckSigner(), "faucet account unavailable")
+	})
+
+	t.Run("concurrent callers get the last result while probing", func(t *testing.T) {
+		release := make(chan struct{})
+		probing := make(chan struct{}, 1)
+		service := &Service{
+			cfg: &config.Config{FaucetBinary: "aurad", FaucetKey: "faucet", FaucetAddress: "aura1faucet"},
+			runner: func(ctx context.Context, name string, args ...string) (string, string, error) {
+				probing <- struct{}{}
+				<-release
+				return "aura1faucet\n", "", nil
+			},
+		}
+		service.signerErr = errors.New("stale")
+		service.signerCheckedAt = time.Now().Add(-2 * signerCheckTTL)
+
+		done := make(chan error)
+		go func() { done <- service.CheckSigner() }()
+		<-probing
+		assert.EqualError(t, service.CheckSigner(), "stale", "a second caller doesn't wait on the probe")
+		close(release)
+		assert.NoError(t, <-done)
+		assert.NoError(t, service.CheckSigner())
+	})
+}
+
+func TestSequenceManagerIncrementsOnSuccess(t *testing.T) {
+	fetches := 0
+	m := NewSequenceManager("aura1faucet", func(address string) (uint64, error) {
+		fetches++
+		return 7, nil
+	}, 1)
+
+	var used []uint64
+	for i := 0; i < 3; i++ {
+		_, err := m.Do(func(sequence uint64) (string, error) {
+			used = append(used, sequence)
+			return "tx", nil
+		})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []uint64{7, 8, 9}, used)
+	assert.Equal(t, 1, fetches)
+	seq, synced := m.Current()
+	assert.Equal(t, uint64(10), seq)
+	assert.True(t, synced)
+}
+
+func TestSequenceManagerResyncsOnMismatch(t *testing.T) {
+	chainSequence := uint64(3)
+	m := NewSequenceManager("aura1faucet", func(address string) (uint64, error) {
+		return chainSequence, nil
+	}, 1)
+
+	_, err := m.Do(func(sequence uint64) (string, error) { return "tx", nil })
+	require.NoError(t, err)
+
+	// Another signer advanced the account behind our back
+	chainSequence = 10
+	var used []uint64
+	_, err = m.Do(func(sequence uint64) (string, error) {
+		used = append(used, sequence)
+		if sequence != chainSequence {
+			return "", errors.New("account sequence mismatch, expected 10, got 4: incorrect account sequence")
+		}
+		return "tx", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{4, 10}, used)
+
+	// Non-mismatch errors don't trigger a resync
+	_, err = m.Do(func(sequence uint64) (string, error) { return "", errors.New("insufficient funds") })
+	require.Error(t, err)
+	_, synced := m.Current()
+	assert.True(t, synced)
+}
+
+func TestSequenceManagerConcurrentAccess(t *testing.T) {
+	m := NewSequenceManager("aura1faucet", func(address string) (uint64, error) {
+		return 0, nil
+	}, 0)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[uint64]bool)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := m.Do(func(sequence uint64) (string, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				seen[sequence] = true
+				return "tx", nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, seen, 50, "every broadcast should get a unique sequence")
+	seq, _ := m.Current()
+	assert.Equal(t, uint64(50), seq)
+}
+
+func TestNodeRequestsUseConfiguredProxy(t *testing.T) {
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost = r.URL.Host
+		_, _ = w.Write([]byte(`{"balances":[{"denom":"uaura","amount":"42"}]}`))
+	}))
+	defer proxy.Close()
+
+	cfg := &config.Config{
+		NodeREST:      "http://node.internal:1317",
+		FaucetAddress: "aura1test",
+		Denom:         "uaura",
+		HTTPProxy:     "http://unused.invalid:3128",
+		NodeProxy:     proxy.URL,
+	}
+	service, err := NewService(cfg, nil)
+	require.NoError(t, err)
+
+	balance, err := service.GetBalance()
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), balance)
+	assert.Equal(t, "node.internal:1317", proxiedHost)
+}
+
+func TestSendTokensRejectsOutOfRangeAmount(t *testing.T) {
+	svc := &Service{cfg: &config.Config{MaxAmountPerRequest: units.New(1000)}}
+
+	_, err := svc.SendTokens(&SendRequest{Recipient: "aura1recipient", Amount: units.New(1001)})
+	assert.ErrorIs(t, err, ErrAmountOutOfRange)
+
+	_, err = svc.SendTokens(&SendRequest{Recipient: "aura1recipient", Amount: units.New(-5)})
+	assert.ErrorIs(t, err, ErrAmountOutOfRange)
+}
+
+func TestParseBalance(t *testing.T) {
+	amount, err := parseBalance("123456")
+	require.NoError(t, err)
+	assert.Equal(t, int64(123456), amount)
+
+	amount, err = parseBalance("100000000000000000000000")
+	require.NoError(t, err)
+	assert.Equal(t, int64(math.MaxInt64), amount)
+
+	_, err = parseBalance("12abc")
+	assert.Error(t, err)
+}
+
+func TestBroadcastViaCLIUsesFeeDenom(t *testing.T) {
+	cfg := &config.Config{
+		ChainID:          "test-chain",
+		FaucetBinary:     "aurad",
+		FaucetKey:        "faucet",
+		Denom:            "uusdc",
+		GasPrice:         "0.025",
+		FeeDenomOverride: "uaura",
+		CLITimeout:       time.Second,
+	}
+
+	var gotArgs []string
+	service := &Service{
+		cfg: cfg,
+		runner: func(ctx context.Context, name string, args ...string) (string, string, error) {
+			gotArgs = args
+			return `{"txhash":"ABC","code":0}`, "", nil
+		},
+	}
+
+	txData := map[string]interface{}{
+		"to": "aura1recipient",
+		"amount": []map[string]string{
+			{"denom": "uusdc", "amount": "100"},
+		},
+	}
+
+	_, err := service.broadcastViaCLI(txData)
+	require.NoError(t, err)
+	assert.Contains(t, gotArgs, "100uusdc")
+	for i, arg := range gotArgs {
+		if arg == "--gas-prices" {
+			require.Less(t, i+1, len(gotArgs))
+			assert.Equal(t, "0.025uaura", gotArgs[i+1])
+			return
+		}
+	}
+	t.Fatal("--gas-prices not passed")
+}
+
+func TestCheckFeeBalance(t *testing.T) {
+	newService := func(feeAmount string) *Service {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/cosmos/bank/v1beta1/balances/aura1faucet", r.URL.Path)
+			w.Write([]byte(`{"balances":[{"denom":"uusdc","amount":"1000000000"},{"denom":"uaura","amount":"` + feeAmount + `"}]}`))
+		}))
+		t.Cleanup(server.Close)
+
+		return &Service{
+			cfg: &config.Config{
+				NodeREST:         server.URL,
+				FaucetAddress:    "aura1faucet",
+				Denom:            "uusdc",
+				GasPrice:         "0.025uaura",
+				GasLimit:         200000,
+				FeeDenomOverride: "uaura",
+			},
+			client: server.Client(),
+		}
+	}
+
+	t.Run("enough fee denom", func(t *testing.T) {
+		assert.NoError(t, newService("5000").CheckFeeBalance())
+	})
+
+	t.Run("payout denom does not count toward fees", func(t *testing.T) {
+		err := newService("4999").CheckFeeBalance()
+		assert.ErrorIs(t, err, ErrInsufficientFeeBalance)
+	})
+
+	t.Run("fees in payout denom skip the check", func(t *testing.T) {
+		service := &Service{cfg: &config.Config{Denom: "uaura", GasPrice: "0.025uaura"}}
+		assert.NoError(t, service.CheckFeeBalance())
+	})
+}
+
+func TestCheckReserve(t *testing.T) {
+	newService := func(balance string) *Service {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"balances":[{"denom":"uaura","amount":"` + balance + `"}]}`))
+		}))
+		t.Cleanup(server.Close)
+
+		return &Service{
+			cfg: &config.Config{
+				NodeREST:       server.URL,
+				FaucetAddress:  "aura1faucet",
+				Denom:          "uaura",
+				GasPrice:       "0.025uaura",
+				GasLimit:       200000,
+				BalanceReserve: units.New(100000),
+			},
+			client: server.Client(),
+		}
+	}
+
+	// A 1000uaura payout plus a 5000uaura fee must leave the 100000 reserve
+	t.Run("just above the threshold", func(t *testing.T) {
+		assert.NoError(t, newService("106000").checkReserve(units.New(1000)))
+	})
+
+	t.Run("just below the threshold", func(t *testing.T) {
+		assert.ErrorIs(t, newService("105999").checkReserve(units.New(1000)), ErrBalanceReserve)
+	})
+
+	t.Run("no reserve skips the check", func(t *testing.T) {
+		service := &Service{cfg: &config.Config{Denom: "uaura"}}
+		assert.NoError(t, service.checkReserve(units.New(1000)))
+	})
+}
+
+func TestLookupAddressBalance(t *testing.T) {
+	newService := func(status int, body string, missingAsZero bool) *Service {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/cosmos/bank/v1beta1/balances/aura1new", r.URL.Path)
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+
+		return &Service{
+			cfg: &config.Config{
+				NodeREST:             server.URL,
+				Denom:                "uaura",
+				MissingAccountAsZero: missingAsZero,
+			},
+			client: server.Client(),
+		}
+	}
+
+	t.Run("404 is a new account with zero balance", func(t *testing.T) {
+		service := newService(http.StatusNotFound, `{"code":5,"message":"account not found"}`, true)
+		balance, err := service.LookupAddressBalance("aura1new")
+		require.NoError(t, err)
+		assert.Equal(t, &AddressBalance{New: true}, balance)
+
+		amount, err := service.GetAddressBalance("aura1new")
+		require.NoError(t, err)
+		assert.Zero(t, amount, "the cap check sees zero")
+	})
+
+	t.Run("404 is an error when not treated as zero", func(t *testing.T) {
+		_, err := newService(http.StatusNotFound, `{}`, false).GetAddressBalance("aura1new")
+		assert.Error(t, err)
+	})
+
+	t.Run("empty balances is a new account", func(t *testing.T) {
+		balance, err := newService(http.StatusOK, `{"balances":[]}`, true).LookupAddressBalance("aura1new")
+		require.NoError(t, err)
+		assert.Equal(t, &AddressBalance{New: true}, balance)
+	})
+
+	t.Run("other denoms only is an existing account", func(t *testing.T) {
+		balance, err := newService(http.StatusOK, `{"balances":[{"denom":"uusdc","amount":"7"}]}`, true).LookupAddressBalance("aura1new")
+		require.NoError(t, err)
+		assert.Equal(t, &AddressBalance{}, balance)
+	})
+
+	t.Run("present nonzero balance", func(t *testing.T) {
+		balance, err := newService(http.StatusOK, `{"balances":[{"denom":"uaura","amount":"1500"}]}`, true).LookupAddressBalance("aura1new")
+		require.NoError(t, err)
+		assert.Equal(t, &AddressBalance{Amount: 1500}, balance)
+	})
+}
+
+func TestCheckNodeVersion(t *testing.T) {
+	compatible := []struct{ version, min, max string }{
+		{"v0.50.3", "0.50.0", "0.50"},
+		{"0.50.0", "0.50.0", ""},
+		{"v0.50.10-rc1", "", "0.50"},
+		{"anything", "", ""},
+	}
+	for _, tc := range compatible {
+		assert.NoError(t, CheckNodeVersion(tc.version, tc.min, tc.max), tc.version)
+	}
+
+	incompatible := []struct{ version, min, max string }{
+		{"v0.47.9", "0.50.0", ""},
+		{"v0.51.0", "0.50.0", "0.50"},
+		{"v1.0.0", "", "0.50"},
+		{"", "0.50.0", ""},
+		{"main-abc123", "0.50.0", ""},
+	}
+	for _, tc := range incompatible {
+		assert.ErrorIs(t, CheckNodeVersion(tc.version, tc.min, tc.max), ErrIncompatibleNodeVersion, tc.version)
+	}
+}
+
+func newRetryService(t *testing.T, runner commandRunner) (*Service, sqlmock.Sqlmock) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return &Service{
+		cfg: &config.Config{
+			ChainID:             "test-chain",
+			FaucetBinary:        "aurad",
+			FaucetKey:           "faucet",
+			Denom:               "uaura",
+			GasPrice:            "0.001uaura",
+			CLITimeout:          time.Second,
+			PayoutRetryAttempts: 3,
+			PayoutRetryBase:     time.Minute,
+		},
+		db:     database.NewWithConn(conn),
+		runner: runner,
+	}, mock
+}
+
+func requestRow(status, txHash string, attempts int, createdAt time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "recipient", "amount", "tx_hash", "ip_address", "status", "denom", "source", "retry_attempts", "created_at"}).
+		AddRow(int64(1), "aura1recipient", int64(100), txHash, "1.1.1.1", status, "uaura", "", attempts, createdAt)
+}
+
+func TestFailedPayoutIsReplayedAutomatically(t *testing.T) {
+	calls := 0
+	service, mock := newRetryService(t, func(ctx context.Context, name string, args ...string) (string, string, error) {
+		calls++
+		if calls == 1 {
+			return "", "connection refused", errors.New("exit status 1")
+		}
+		return `{"txhash":"ABC","code":0}`, "", nil
+	})
+	created := time.Now()
+
+	// First attempt fails and parks the request
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO faucet_requests`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "ip_address", "status", "denom", "source", "created_at"}).
+			AddRow(int64(1), "aura1recipient", int64(100), "1.1.1.1", "pending", "uaura", "", created))
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE id = $1`)).WithArgs(int64(1)).
+		WillReturnRows(requestRow("pending", "", 0, created))
+	mock.ExpectExec(regexp.QuoteMeta(`SET error = $1`)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 3, int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err := service.SendTokens(&SendRequest{Recipient: "aura1recipient", Amount: units.New(100), IPAddress: "1.1.1.1"})
+	require.Error(t, err)
+
+	// The retry loop picks it up and the second attempt succeeds
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE status = 'retrying'`)).WithArgs("uaura", retryBatchSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(1)))
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE id = $1`)).WithArgs(int64(1)).
+		WillReturnRows(requestRow("retrying", "", 0, created))
+	mock.ExpectQuery(regexp.QuoteMeta(`status = 'success' AND created_at >= $2`)).
+		WithArgs("aura1recipient", created).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(regexp.QuoteMeta(`SET status = 'pending', retry_attempts = retry_attempts + 1`)).
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`SET status = 'success', tx_hash = $1`)).
+		WithArgs("ABC", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, service.ProcessRetries(context.Background()))
+	assert.Equal(t, 2, calls)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReplayRequestNeverPaysTwice(t *testing.T) {
+	broadcast := func(ctx context.Context, name string, args ...string) (string, string, error) {
+		t.Fatal("replay must not broadcast")
+		return "", "", nil
+	}
+
+	t.Run("request already succeeded", func(t *testing.T) {
+		service, mock := newRetryService(t, broadcast)
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE id = $1`)).WithArgs(int64(1)).
+			WillReturnRows(requestRow("success", "ABC", 0, time.Now()))
+
+		_, err := service.ReplayRequest(1)
+		assert.ErrorIs(t, err, ErrAlreadyPaid)
+	})
+
+	t.Run("recipient paid by a later request", func(t *testing.T) {
+		service, mock := newRetryService(t, broadcast)
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE id = $1`)).WithArgs(int64(1)).
+			WillReturnRows(requestRow("failed", "", 3, time.Now()))
+		mock.ExpectQuery(regexp.QuoteMeta(`status = 'success' AND created_at >= $2`)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		_, err := service.ReplayRequest(1)
+		assert.ErrorIs(t, err, ErrAlreadyPaid)
+	})
+
+	t.Run("concurrent replay claimed it first", func(t *testing.T) {
+		service, mock := newRetryService(t, broadcast)
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE id = $1`)).WithArgs(int64(1)).
+			WillReturnRows(requestRow("retrying", "", 1, time.Now()))
+		mock.ExpectQuery(regexp.QuoteMeta(`status = 'success' AND created_at >= $2`)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectExec(regexp.QuoteMeta(`SET status = 'pending'`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		_, err := service.ReplayRequest(1)
+		assert.ErrorIs(t, err, ErrNotReplayable)
+	})
+
+	t.Run("balance at the reserve", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"balances":[{"denom":"uaura","amount":"1000"}]}`))
+		}))
+		t.Cleanup(server.Close)
+		service, mock := newRetryService(t, broadcast)
+		service.cfg.NodeREST = server.URL
+		service.cfg.FaucetAddress = "aura1faucet"
+		service.cfg.BalanceReserve = units.New(1000)
+		service.client = server.Client()
+		mock.ExpectQuery(regexp.QuoteMeta(`WHERE id = $1`)).WithArgs(int64(1)).
+			WillReturnRows(requestRow("retrying", "", 1, time.Now()))
+		mock.ExpectQuery(regexp.QuoteMeta(`status = 'success' AND created_at >= $2`)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		_, err := service.ReplayRequest(1)
+		assert.ErrorIs(t, err, ErrBalanceReserve)
+		require.NoError(t, mock.ExpectationsWereMet(), "a refused replay is not claimed")
+	})
+}
+
+func TestRetryBackoff(t *testing.T) {
+	service := &Service{cfg: &config.Config{PayoutRetryBase: time.Minute}}
+	assert.Equal(t, time.Minute, service.retryBackoff(0))
+	assert.Equal(t, 4*time.Minute, service.retryBackoff(2))
+	assert.Equal(t, maxPayoutRetryDelay, service.retryBackoff(10))
+}
+
+func TestMalformedNodeResponses(t *testing.T) {
+	responses := map[string]struct {
+		contentType string
+		body        string
+	}{
+		"html error page": {"text/html; charset=utf-8", "<html><body>502 Bad Gateway</body></html>"},
+		"truncated json":  {"application/json", `{"balances":[{"denom":"uaura","amou`},
+	}
+
+	for name, tc := range responses {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			service := &Service{
+				cfg:    &config.Config{NodeRPC: server.URL, NodeREST: server.URL, FaucetAddress: "aura1faucet", Denom: "uaura"},
+				client: server.Client(),
+			}
+
+			_, err := service.GetBalance()
+			assert.ErrorIs(t, err, ErrUnexpectedNodeResponse)
+			assert.Contains(t, err.Error(), "node returned unexpected response")
+
+			_, err = service.GetNodeStatus(context.Background())
+			assert.ErrorIs(t, err, ErrUnexpectedNodeResponse)
+		})
+	}
+}
+
+func TestNodeJSONServedAsPlainText(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(`{"balances":[{"denom":"uaura","amount":"42"}]}`))
+	}))
+	defer server.Close()
+
+	service := &Service{
+		cfg:    &config.Config{NodeREST: server.URL, FaucetAddress: "aura1faucet", Denom: "uaura"},
+		client: server.Client(),
+	}
+
+	balance, err := service.GetBalance()
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), balance)
+}
+
+func TestValidateAddressConfiguredByteLengths(t *testing.T) {
+	service := &Service{cfg: &config.Config{AddressPrefix: "aura", AddressByteLengths: []int{20}}}
+
+	assert.NoError(t, service.ValidateAddress("aura1qpzry9x8gf2tvdw0s3jn54khce6mua7lqpzry9"))
+	assert.Error(t, service.ValidateAddress("aura19x8gf2tvdw0s3jn54khce6mua7lqpzry9x8gf2tvdw0s3jn54khce6mua7"), "32-byte accounts not accepted")
+}
+
+func TestBech32DecodedLength(t *testing.T) {
+	size, ok := bech32DecodedLength(32)
+	assert.True(t, ok)
+	assert.Equal(t, 20, size)
+
+	size, ok = bech32DecodedLength(52)
+	assert.True(t, ok)
+	assert.Equal(t, 32, size)
+
+	_, ok = bech32DecodedLength(33)
+	assert.False(t, ok)
+	_, ok = bech32DecodedLength(0)
+	assert.False(t, ok)
+}
+
+func TestSupplyGuard(t *testing.T) {
+	newService := func(supply string, maxSharePercent int, minSupply int64) *Service {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/cosmos/bank/v1beta1/supply/by_denom":
+				assert.Equal(t, "uaura", r.URL.Query().Get("denom"))
+				w.Write([]byte(`{"amount":{"denom":"uaura","amount":"` + supply + `"}}`))
+			case "/cosmos/bank/v1beta1/balances/aura1faucet":
+				w.Write([]byte(`{"balances":[{"denom":"uaura","amount":"1000"}]}`))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		t.Cleanup(server.Close)
+
+		return &Service{
+			cfg: &config.Config{
+				NodeREST:                   server.URL,
+				FaucetAddress:              "aura1faucet",
+				Denom:                      "uaura",
+				SupplyGuardMaxSharePercent: maxSharePercent,
+				SupplyGuardMinSupply:       units.New(minSupply),
+			},
+			client: server.Client(),
+		}
+	}
+
+	t.Run("share below the limit", func(t *testing.T) {
+		service := newService("100000", 5, 0)
+		status, err := service.CheckSupply()
+		require.NoError(t, err)
+		assert.InDelta(t, 0.01, status.Share, 1e-9)
+		assert.False(t, status.Paused)
+		assert.NoError(t, service.checkSupplyGuard())
+	})
+
+	t.Run("share above the limit pauses payouts", func(t *testing.T) {
+		service := newService("10000", 5, 0)
+		status, err := service.CheckSupply()
+		require.NoError(t, err)
+		assert.InDelta(t, 0.1, status.Share, 1e-9)
+		assert.True(t, status.Paused)
+		assert.ErrorIs(t, service.checkSupplyGuard(), ErrSupplyGuard)
+	})
+
+	t.Run("supply below the floor pauses payouts", func(t *testing.T) {
+		service := newService("100000", 0, 500000)
+		assert.ErrorIs(t, service.checkSupplyGuard(), ErrSupplyGuard)
+	})
+
+	t.Run("18-decimal supply beyond int64", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/cosmos/bank/v1beta1/supply/by_denom":
+				// 1,000,000 tokens of supply, 1,000 of them in the faucet
+				w.Write([]byte(`{"amount":{"denom":"aevm","amount":"1000000000000000000000000"}}`))
+			case "/cosmos/bank/v1beta1/balances/aura1faucet":
+				w.Write([]byte(`{"balances":[{"denom":"aevm","amount":"1000000000000000000000"}]}`))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		t.Cleanup(server.Close)
+		floor, err := units.Parse("500000000000000000000000")
+		require.NoError(t, err)
+		service := &Service{
+			cfg: &config.Config{
+				NodeREST:                   server.URL,
+				FaucetAddress:              "aura1faucet",
+				Denom:                      "aevm",
+				SupplyGuardMaxSharePercent: 5,
+				SupplyGuardMinSupply:       floor,
+			},
+			client: server.Client(),
+		}
+
+		status, err := service.CheckSupply()
+		require.NoError(t, err)
+		assert.Equal(t, "1000000000000000000000000", status.Supply.String())
+		assert.InDelta(t, 0.001, status.Share, 1e-12)
+		assert.False(t, status.Paused, status.Reason)
+		assert.NoError(t, service.checkSupplyGuard())
+	})
+
+	t.Run("disabled guard skips the query", func(t *testing.T) {
+		service := &Service{cfg: &config.Config{Denom: "uaura"}}
+		assert.NoError(t, service.checkSupplyGuard())
+	})
+
+	t.Run("failed supply query does not pause payouts", func(t *testing.T) {
+		service := newService("not-a-number", 5, 0)
+		assert.NoError(t, service.checkSupplyGuard())
+	})
+}
+
+func TestBroadcastViaCLIFeeFlags(t *testing.T) {
+	broadcast := func(t *testing.T, mutate func(*config.Config)) []string {
+		cfg := &config.Config{
+			ChainID:      "test-chain",
+			FaucetBinary: "aurad",
+			FaucetKey:    "faucet",
+			Denom:        "uaura",
+			GasLimit:     200000,
+			GasPrice:     "0.025uaura",
+			CLITimeout:   time.Second,
+		}
+		mutate(cfg)
+
+		var got []string
+		service := &Service{
+			cfg: cfg,
+			runner: func(ctx context.Context, name string, args ...string) (string, string, error) {
+				got = args
+				return `{"txhash":"` + strings.Repeat("A", 64) + `"}`, "", nil
+			},
+		}
+		_, err := service.broadcastViaCLI(map[string]interface{}{
+			"to":     "aura1recipient",
+			"amount": []map[string]string{{"denom": "uaura", "amount": "100"}},
+		})
+		require.NoError(t, err)
+		return got
+	}
+	flag := func(args []string, name string) (string, bool) {
+		for i, arg := range args {
+			if arg == name && i+1 < len(args) {
+				return args[i+1], true
+			}
+		}
+		return "", false
+	}
+
+	t.Run("gas prices by default", func(t *testing.T) {
+		args := broadcast(t, func(cfg *config.Config) {})
+		value, ok := flag(args, "--gas-prices")
+		assert.True(t, ok)
+		assert.Equal(t, "0.025uaura", value)
+		_, ok = flag(args, "--fees")
+		assert.False(t, ok)
+	})
+
+	t.Run("fees computed from gas and price", func(t *testing.T) {
+		args := broadcast(t, func(cfg *config.Config) { cfg.FeeMode = config.FeeModeFees })
+		value, ok := flag(args, "--fees")
+		assert.True(t, ok)
+		assert.Equal(t, "5000uaura", value)
+		_, ok = flag(args, "--gas-prices")
+		assert.False(t, ok)
+		gas, _ := flag(args, "--gas")
+		assert.Equal(t, "200000", gas)
+	})
+
+	t.Run("fixed fee", func(t *testing.T) {
+		args := broadcast(t, func(cfg *config.Config) {
+			cfg.FeeMode = config.FeeModeFees
+			cfg.FeeAmount = "7500"
+		})
+		value, _ := flag(args, "--fees")
+		assert.Equal(t, "7500uaura", value)
+		_, ok := flag(args, "--gas-prices")
+		assert.False(t, ok)
+	})
+}
+
+func TestAutoRefill(t *testing.T) {
+	newService := func(t *testing.T, balance string) (*Service, *[][]string) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/cosmos/bank/v1beta1/balances/aura1faucet", r.URL.Path)
+			w.Write([]byte(`{"balances":[{"denom":"uaura","amount":"` + balance + `"}]}`))
+		}))
+		t.Cleanup(server.Close)
+
+		var calls [][]string
+		return &Service{
+			cfg: &config.Config{
+				NodeREST:          server.URL,
+				ChainID:           "test-chain",
+				FaucetAddress:     "aura1faucet",
+				FaucetBinary:      "aurad",
+				FaucetKey:         "faucet",
+				Denom:             "uaura",
+				GasPrice:          "0.025uaura",
+				CLITimeout:        time.Second,
+				AutoRefillEnabled: true,
+				RefillTreasuryKey: "treasury",
+				RefillThreshold:   1000,
+				RefillAmount:      50000,
+				RefillMinInterval: time.Hour,
+			},
+			client: server.Client(),
+			runner: func(ctx context.Context, name string, args ...string) (string, string, error) {
+				calls = append(calls, args)
+				return `{"txhash":"` + strings.Repeat("B", 64) + `"}`, "", nil
+			},
+		}, &calls
+	}
+
+	t.Run("refills below the threshold", func(t *testing.T) {
+		service, calls := newService(t, "999")
+		service.MaybeRefill()
+
+		require.Len(t, *calls, 1)
+		args := (*calls)[0]
+		assert.Equal(t, []string{"tx", "bank", "send", "treasury", "aura1faucet", "50000uaura"}, args[:6])
+	})
+
+	t.Run("no refill at or above the threshold", func(t *testing.T) {
+		service, calls := newService(t, "1000")
+		service.MaybeRefill()
+		assert.Empty(t, *calls)
+	})
+
+	t.Run("refills are rate-limited", func(t *testing.T) {
+		service, calls := newService(t, "10")
+		now := time.Now()
+		service.now = func() time.Time { return now }
+
+		service.MaybeRefill()
+		service.MaybeRefill()
+		require.Len(t, *calls, 1, "a second refill within the interval is skipped")
+
+		now = now.Add(time.Hour)
+		service.MaybeRefill()
+		assert.Len(t, *calls, 2)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		service, calls := newService(t, "10")
+		service.cfg.AutoRefillEnabled = false
+		service.MaybeRefill()
+		assert.Empty(t, *calls)
+	})
+}
+
+func TestAutoRefillTopsUpFeeDenom(t *testing.T) {
+	newService := func(t *testing.T, balance, feeBalance string) (*Service, *[][]string) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"balances":[{"denom":"uaura","amount":"` + balance + `"},{"denom":"ufee","amount":"` + feeBalance + `"}]}`))
+		}))
+		t.Cleanup(server.Close)
+
+		var calls [][]string
+		return &Service{
+			cfg: &config.Config{
+				NodeREST:           server.URL,
+				ChainID:            "test-chain",
+				FaucetAddress:      "aura1faucet",
+				FaucetBinary:       "aurad",
+				FaucetKey:          "faucet",
+				Denom:              "uaura",
+				GasPrice:           "0.025ufee",
+				CLITimeout:         time.Second,
+				AutoRefillEnabled:  true,
+				RefillTreasuryKey:  "treasury",
+				RefillThreshold:    1000,
+				RefillAmount:       50000,
+				RefillFeeThreshold: 200,
+				RefillFeeAmount:    7000,
+				RefillMinInterval:  time.Hour,
+			},
+			client: server.Client(),
+			runner: func(ctx context.Context, name string, args ...string) (string, string, error) {
+				calls = append(calls, args)
+				return `{"txhash":"` + strings.Repeat("B", 64) + `"}`, "", nil
+			},
+		}, &calls
+	}
+
+	t.Run("fee balance low", func(t *testing.T) {
+		service, calls := newService(t, "5000", "199")
+		service.MaybeRefill()
+		require.Len(t, *calls, 1)
+		assert.Equal(t, "7000ufee", (*calls)[0][5])
+	})
+
+	t.Run("both low", func(t *testing.T) {
+		service, calls := newService(t, "10", "10")
+		service.MaybeRefill()
+		require.Len(t, *calls, 1)
+		assert.Equal(t, "50000uaura,7000ufee", (*calls)[0][5])
+	})
+
+	t.Run("both funded", func(t *testing.T) {
+		service, calls := newService(t, "5000", "200")
+		service.MaybeRefill()
+		assert.Empty(t, *calls)
+	})
+}
+
+func TestSimulateBeforeSend(t *testing.T) {
+	newService := func(t *testing.T, status int, body string) (*Service, sqlmock.Sqlmock, *int, *int) {
+		simulations := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/cosmos/auth/v1beta1/accounts/aura1faucet":
+				w.Write([]byte(`{"account":{"sequence":"7"}}`))
+			case "/cosmos/tx/v1beta1/simulate":
+				simulations++
+				var payload struct {
+					Tx struct {
+						Body struct {
+							Messages []map[string]interface{} `json:"messages"`
+						} `json:"body"`
+					} `json:"tx"`
+				}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+				require.Len(t, payload.Tx.Body.Messages, 1)
+				assert.Equal(t, "aura1recipient", payload.Tx.Body.Messages[0]["to_address"])
+				w.WriteHeader(status)
+				w.Write([]byte(body))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		t.Cleanup(server.Close)
+
+		broadcasts := 0
+		service, mock := newRetryService(t, func(ctx context.Context, name string, args ...string) (string, string, error) {
+			broadcasts++
+			return `{"txhash":"ABC","code":0}`, "", nil
+		})
+		service.cfg.NodeREST = server.URL
+		service.cfg.FaucetAddress = "aura1faucet"
+		service.cfg.SimulateBeforeSend = true
+		service.client = server.Client()
+		return service, mock, &simulations, &broadcasts
+	}
+	request := &SendRequest{Recipient: "aura1recipient", Amount: units.New(100), IPAddress: "1.1.1.1"}
+
+	t.Run("successful simulation broadcasts", func(t *testing.T) {
+		service, mock, simulations, broadcasts := newService(t, http.StatusOK, `{"gas_info":{"gas_used":"61234"}}`)
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO faucet_requests`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "ip_address", "status", "denom", "source", "created_at"}).
+				AddRow(int64(1), "aura1recipient", int64(100), "1.1.1.1", "pending", "uaura", "", time.Now()))
+		mock.ExpectExec(regexp.QuoteMeta(`SET status = 'success', tx_hash = $1`)).
+			WithArgs("ABC", int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		resp, err := service.SendTokens(request)
+		require.NoError(t, err)
+		assert.Equal(t, "ABC", resp.TxHash)
+		assert.Equal(t, 1, *simulations)
+		assert.Equal(t, 1, *broadcasts)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("failed simulation aborts the broadcast", func(t *testing.T) {
+		service, mock, simulations, broadcasts := newService(t, http.StatusBadRequest,
+			`{"code":5,"message":"failed to execute message; message index: 0: 100uaura is smaller than 200uaura: insufficient funds"}`)
+
+		_, err := service.SendTokens(request)
+		require.ErrorIs(t, err, ErrSimulationFailed)
+		var simErr *SimulationError
+		require.ErrorAs(t, err, &simErr)
+		assert.Equal(t, SimInsufficientFunds, simErr.Category)
+		assert.Equal(t, 1, *simulations)
+		assert.Zero(t, *broadcasts, "nothing is broadcast")
+		require.NoError(t, mock.ExpectationsWereMet(), "nothing is recorded")
+	})
+
+	t.Run("failure categories", func(t *testing.T) {
+		tests := map[string]string{
+			"decoding bech32 failed: invalid checksum":        SimInvalidRecipient,
+			"aura1xyz is not allowed to receive funds":        SimRecipientBlocked,
+			"out of gas in location: WriteFlat; gasWanted: 1": SimOutOfGas,
+		}
+		for message, category := range tests {
+			service, _, _, _ := newService(t, http.StatusBadRequest, `{"code":3,"message":"`+message+`"}`)
+			var simErr *SimulationError
+			require.ErrorAs(t, service.simulate(service.buildTxData(request)), &simErr, message)
+			assert.Equal(t, category, simErr.Category, message)
+		}
+	})
+
+	t.Run("inconclusive failures and missing endpoint proceed", func(t *testing.T) {
+		service, _, _, _ := newService(t, http.StatusBadRequest, `{"code":4,"message":"signature verification failed"}`)
+		assert.NoError(t, service.simulate(service.buildTxData(request)))
+
+		service, _, _, _ = newService(t, http.StatusNotImplemented, `not implemented`)
+		assert.NoError(t, service.simulate(service.buildTxData(request)))
+	})
+}
+
+func TestBroadcastQueueDrains(t *testing.T) {
+	queue := NewBroadcastQueue()
+	first, second, third := queue.Enter(), queue.Enter(), queue.Enter()
+
+	assert.Equal(t, 3, queue.Status().Depth)
+	assert.Zero(t, queue.Status().EstimatedWait(), "no estimate before a broadcast completes")
+
+	queue.Leave(first, 2*time.Second)
+	assert.Equal(t, 2, queue.Status().Depth)
+	assert.Equal(t, 2*time.Second, queue.Status().AverageBroadcast)
+	assert.Equal(t, 4*time.Second, queue.Status().EstimatedWait())
+
+	// Finishing out of order still leaves the rest counted
+	queue.Leave(third, 4*time.Second)
+	assert.Equal(t, 1, queue.Status().Depth)
+	assert.Equal(t, 2400*time.Millisecond, queue.Status().AverageBroadcast)
+
+	queue.Leave(second, 2*time.Second)
+	assert.Equal(t, 0, queue.Status().Depth)
+}
+
+func TestSendTokensHoldsQueuePlace(t *testing.T) {
+	var service *Service
+	var depth int
+	service, mock := newRetryService(t, func(ctx context.Context, name string, args ...string) (string, string, error) {
+		depth = service.QueueStatus().Depth
+		return `{"txhash":"ABC","code":0}`, "", nil
+	})
+	service.queue = NewBroadcastQueue()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO faucet_requests`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "ip_address", "status", "denom", "source", "created_at"}).
+			AddRow(int64(1), "aura1recipient", int64(100), "1.1.1.1", "pending", "uaura", "", time.Now()))
+	mock.ExpectExec(regexp.QuoteMeta(`SET status = 'success', tx_hash = $1`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err := service.SendTokens(&SendRequest{Recipient: "aura1recipient", Amount: units.New(100), IPAddress: "1.1.1.1"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, depth, "the payout is queued while broadcasting")
+	assert.Equal(t, 0, service.QueueStatus().Depth)
+	assert.NotZero(t, service.QueueStatus().AverageBroadcast)
+}
+
+func TestSendTokensDropsBonusClaimedElsewhere(t *testing.T) {
+	var sentAmount string
+	service, mock := newRetryService(t, func(ctx context.Context, name string, args ...string) (string, string, error) {
+		sentAmount = args[5]
+		return `{"txhash":"ABC","code":0}`, "", nil
+	})
+	cols := []string{"id", "recipient", "amount", "ip_address", "status", "denom", "source", "created_at"}
+	// A concurrent first request holds the bonus, so this one is paid without it
+	mock.ExpectQuery(regexp.QuoteMeta(`ON CONFLICT (recipient) WHERE bonus_granted`)).
+		WithArgs("aura1recipient", int64(150), "1.1.1.1", "uaura", "", "1.1.1.1").
+		WillReturnRows(sqlmock.NewRows(cols))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO faucet_requests`)).
+		WithArgs("aura1recipient", int64(100), "1.1.1.1", "uaura", "", "1.1.1.1").
+		WillReturnRows(sqlmock.NewRows(cols).AddRow(int64(2), "aura1recipient", int64(100), "1.1.1.1", "pending", "uaura", "", time.Now()))
+	mock.ExpectExec(regexp.QuoteMeta(`SET status = 'success', tx_hash = $1`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	resp, err := service.SendTokens(&SendRequest{Recipient: "aura1recipient", Amount: units.New(150), Bonus: units.New(50), IPAddress: "1.1.1.1"})
+	require.NoError(t, err)
+	assert.Equal(t, units.New(100), resp.Amount)
+	assert.Zero(t, resp.Bonus)
+	assert.Equal(t, "100uaura", sentAmount)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNodeStatusHeight(t *testing.T) {
+	for raw, want := range map[string]int64{"12345": 12345, " 7 ": 7, "0": 0} {
+		status := &NodeStatus{}
+		status.SyncInfo.LatestBlockHeight = raw
+		height, err := status.Height()
+		require.NoError(t, err, raw)
+		assert.Equal(t, want, height)
+	}
+
+	for _, raw := range []string{"", "abc", "-5", "1.5", "99999999999999999999"} {
+		status := &NodeStatus{}
+		status.SyncInfo.LatestBlockHeight = raw
+		_, err := status.Height()
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestSendBatchRecordsEachPayout(t *testing.T) {
+	service, mock := newRetryService(t, func(ctx context.Context, name string, args ...string) (string, string, error) {
+		// The node rejects the middle recipient
+		if args[4] == "aura1bad" {
+			return "", "rpc error: connection reset", errors.New("exit status 1")
+		}
+		return `{"txhash":"TX-` + args[4] + `","code":0}`, "", nil
+	})
+	service.cfg.PayoutRetryAttempts = 0
+
+	expectInsert := func(id int64, recipient string) {
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO faucet_requests`)).
+			WithArgs(recipient, int64(100), "1.1.1.1", "uaura", "", "1.1.1.1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "ip_address", "status", "denom", "source", "created_at"}).
+				AddRow(id, recipient, int64(100), "1.1.1.1", "pending", "uaura", "", time.Now()))
+	}
+	expectInsert(1, "aura1first")
+	mock.ExpectExec(regexp.QuoteMeta(`SET status = 'success', tx_hash = $1`)).
+		WithArgs("TX-aura1first", int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectInsert(2, "aura1bad")
+	mock.ExpectExec(regexp.QuoteMeta(`SET status = 'failed', error = $1`)).
+		WithArgs(sqlmock.AnyArg(), int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectInsert(3, "aura1last")
+	mock.ExpectExec(regexp.QuoteMeta(`SET status = 'success', tx_hash = $1`)).
+		WithArgs("TX-aura1last", int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var reqs []*SendRequest
+	for _, recipient := range []string{"aura1first", "aura1bad", "aura1first", "aura1last"} {
+		reqs = append(reqs, &SendRequest{Recipient: recipient, Amount: units.New(100), IPAddress: "1.1.1.1"})
+	}
+	results := service.SendBatch(reqs)
+
+	require.Len(t, results, 4)
+	assert.Equal(t, BatchResult{Address: "aura1first", Amount: units.New(100), Status: BatchFunded, TxHash: "TX-aura1first"}, results[0])
+	assert.Equal(t, BatchFailed, results[1].Status)
+	assert.Equal(t, "send_failed", results[1].Reason, "node output stays out of the result")
+	assert.Equal(t, BatchResult{Address: "aura1first", Amount: units.New(100), Status: BatchSkipped, Reason: "duplicate_address"}, results[2])
+	assert.Equal(t, BatchResult{Address: "aura1last", Amount: units.New(100), Status: BatchFunded, TxHash: "TX-aura1last"}, results[3])
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBuildTxDataMemoOverride(t *testing.T) {
+	service := &Service{cfg: &config.Config{TransactionMemo: "AURA Testnet Faucet"}}
+
+	txData := service.buildTxData(&SendRequest{Recipient: "aura1recipient", Amount: units.New(100)})
+	assert.Equal(t, "AURA Testnet Faucet", txData["memo"])
+
+	txData = service.buildTxData(&SendRequest{Recipient: "aura1recipient", Amount: units.New(100), Memo: "invoice-42"})
+	assert.Equal(t, "invoice-42", txData["memo"])
+}
+
+func TestEighteenDecimalAmountIsSentExactly(t *testing.T) {
+	// 100 tokens of an 18-decimal denom is 1e20 base units
+	amount, err := units.Parse("100000000000000000000")
+	require.NoError(t, err)
+
+	service := &Service{cfg: &config.Config{Denom: "aevm"}}
+	txData := service.buildTxData(&SendRequest{Recipient: "aura1recipient", Amount: amount})
+	assert.Equal(t, []map[string]string{{"denom": "aevm", "amount": "100000000000000000000"}}, txData["amount"])
+	assert.NoError(t, ValidateAmount(amount, units.Amount{}))
+	assert.ErrorIs(t, ValidateAmount(amount, units.New(math.MaxInt64)), ErrAmountOutOfRange)
+
+	// The reserve check compares the exact balance, not its int64 view
+	newService := func(balance string) *Service {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"balances":[{"denom":"aevm","amount":"` + balance + `"}]}`))
+		}))
+		t.Cleanup(server.Close)
+		return &Service{
+			cfg:    &config.Config{NodeREST: server.URL, FaucetAddress: "aura1faucet", Denom: "aevm", BalanceReserve: units.New(1_000_000_000_000_000_000)},
+			client: server.Client(),
+		}
+	}
+	assert.NoError(t, newService("101000000000000000000").checkReserve(amount))
+	assert.ErrorIs(t, newService("100999999999999999999").checkReserve(amount), ErrBalanceReserve)
+}
+
+func TestSendTokensChecksTxResultCode(t *testing.T) {
+	const hash = "A1B2C3D4E5F60718293A4B5C6D7E8F90A1B2C3D4E5F60718293A4B5C6D7E8F90"
+	t.Run("nonzero code fails the request despite the hash", func(t *testing.T) {
+		service, mock := newRetryService(t, func(ctx context.Context, name string, args ...string) (string, string, error) {
+			return `{"height":"0","txhash":"` + hash + `","code":13,"raw_log":"insufficient fee; got: 1uaura required: 200uaura"}`, "", nil
+		})
+		expectRequestInsert(mock)
+		// Not parked for retry: the rejection would recur
+		mock.ExpectExec(regexp.QuoteMeta(`SET status = 'failed', error = $1`)).
+			WithArgs("TX_REJECTED: code 13: insufficient fee; got: 1uaura required: 200uaura", int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		_, err := service.SendTokens(&SendRequest{Recipient: "aura1recipient", Amount: units.New(100), IPAddress: "1.1.1.1"})
+		var rejected *TxRejectedError
+		require.ErrorAs(t, err, &rejected)
+		assert.Equal(t, hash, rejected.TxHash)
+		assert.Equal(t, int64(13), rejected.Code)
+		assert.ErrorIs(t, err, ErrTxRejected)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("code zero succeeds", func(t *testing.T) {
+		service, mock := newRetryService(t, func(ctx context.Context, name string, args ...string) (string, string, error) {
+			return `{"height":"0","txhash":"` + hash + `","code":0,"raw_log":"[]"}`, "", nil
+		})
+		expectRequestInsert(mock)
+		mock.ExpectExec(regexp.QuoteMeta(`SET status = 'success', tx_hash = $1`)).
+			WithArgs(hash, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		resp, err := service.SendTokens(&SendRequest{Recipient: "aura1recipient", Amount: units.New(100), IPAddress: "1.1.1.1"})
+		require.NoError(t, err)
+		assert.Equal(t, hash, resp.TxHash)
+		assert.Equal(t, int64(1), resp.RequestID, "the recorded request is identified")
+		assert.False(t, resp.CreatedAt.IsZero())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestParseTxResultCode(t *testing.T) {
+	code, rawLog := parseTxResultCode(`{"tx_response":{"txhash":"ABC","code":5,"raw_log":"insufficient funds"}}`)
+	assert.Equal(t, int64(5), code)
+	assert.Equal(t, "insufficient funds", rawLog)
+
+	code, _ = parseTxResultCode(`{"txhash":"ABC"}`)
+	assert.Zero(t, code)
+
+	code, _ = parseTxResultCode(`txhash: ABC`)
+	assert.Zero(t, code)
+}
+
+// expectRequestInsert expects the record of a single pending request
+func expectRequestInsert(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO faucet_requests`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "amount", "ip_address", "status", "denom", "source", "created_at"}).
+			AddRow(int64(1), "aura1recipient", int64(100), "1.1.1.1", "pending", "uaura", "", time.Now()))
+}
+
+func TestPostPayoutCommand(t *testing.T) {
+	const hash = "A1B2C3D4E5F60718293A4B5C6D7E8F90A1B2C3D4E5F60718293A4B5C6D7E8F90"
+	type call struct {
+		name string
+		args []string
+	}
+
+	send := func(t *testing.T, hookErr error) call {
+		calls := make(chan call, 1)
+		service, mock := newRetryService(t, func(ctx context.Context, name string, args ...string) (string, string, error) {
+			if name != "aurad" {
+				calls <- call{name: name, args: args}
+				return "", "delegation failed", hookErr
+			}
+			return `{"height":"0","txhash":"` + hash + `","code":0}`, "", nil
+		})
+		service.cfg.PostPayoutCommand = "/opt/faucet/after-drip.sh --chain test"
+		service.cfg.PostPayoutTimeout = time.Second
+		service.postPayouts = make(chan postPayout, postPayoutQueueSize)
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		go service.RunPostPayouts(ctx)
+		expectRequestInsert(mock)
+		mock.ExpectExec(regexp.QuoteMeta(`SET status = 'success', tx_hash = $1`)).
+			WithArgs(hash, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		resp, err := service.SendTokens(&SendRequest{Recipient: "aura1recipient", Amount: units.New(100), IPAddress: "1.1.1.1"})
+		require.NoError(t, err)
+		assert.Equal(t, hash, resp.TxHash)
+
+		select {
+		case c := <-calls:
+			return c
+		case <-time.After(time.Second):
+			t.Fatal("post-payout command was not run")
+			return call{}
+		}
+	}
+
+	t.Run("runs with the payout as arguments", func(t *testing.T) {
+		c := send(t, nil)
+		assert.Equal(t, "/opt/faucet/after-drip.sh", c.name)
+		assert.Equal(t, []string{"--chain", "test", "aura1recipient", "100", "uaura", hash}, c.args)
+	})
+
+	t.Run("failure does not fail the payout", func(t *testing.T) {
+		c := send(t, errors.New("exit status 1"))
+		assert.Equal(t, "/opt/faucet/after-drip.sh", c.name)
+	})
+}
+
+func TestPostPayoutQueueIsBounded(t *testing.T) {
+	service := &Service{postPayouts: make(chan postPayout, 1)}
+	service.queuePostPayout(&SendRequest{Recipient: "aura1a"}, "TX1")
+	service.queuePostPayout(&SendRequest{Recipient: "aura1b"}, "TX2")
+	require.Len(t, service.postPayouts, 1, "a full queue skips the command instead of piling up processes")
+	assert.Equal(t, "TX1", (<-service.postPayouts).txHash)
+
+	disabled := &Service{}
+	disabled.queuePostPayout(&SendRequest{Recipient: "aura1a"}, "TX1")
+}
@@ -0,0 +1,108 @@
+package faucet
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	metrics "github.com/aura-chain/aura/faucet/pkg/prometheus"
+)
+
+const (
+	// maxPostPayoutOutput bounds how much of the command's output is logged
+	maxPostPayoutOutput = 4096
+	// postPayoutWorkers is how many post-payout commands run at once
+	postPayoutWorkers = 4
+	// postPayoutQueueSize bounds the payouts waiting for their command;
+	// payouts made while it is full skip the command
+	postPayoutQueueSize = 256
+)
+
+// postPayout is a successful payout awaiting POST_PAYOUT_COMMAND
+type postPayout struct {
+	req    *SendRequest
+	txHash string
+}
+
+// queuePostPayout hands a successful payout to RunPostPayouts. It never
+// blocks the payout: when the queue is full the command is skipped.
+func (s *Service) queuePostPayout(req *SendRequest, txHash string) {
+	if s.postPayouts == nil {
+		return
+	}
+	select {
+	case s.postPayouts <- postPayout{req: req, txHash: txHash}:
+	default:
+		metrics.PostPayoutCommands.WithLabelValues("dropped").Inc()
+		log.WithField("tx_hash", txHash).Warn("Post-payout command queue is full; skipping the command")
+	}
+}
+
+// RunPostPayouts runs POST_PAYOUT_COMMAND for queued payouts on
+// postPayoutWorkers workers until ctx is cancelled
+func (s *Service) RunPostPayouts(ctx context.Context) {
+	if s.postPayouts == nil {
+		return
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < postPayoutWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case payout := <-s.postPayouts:
+					s.runPostPayout(payout.req, payout.txHash)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// runPostPayout runs POST_PAYOUT_COMMAND for a successful payout. It is
+// best effort: the payout has already succeeded, so failures are only
+// logged and counted.
+func (s *Service) runPostPayout(req *SendRequest, txHash string) {
+	fields := strings.Fields(s.cfg.PostPayoutCommand)
+	if len(fields) == 0 {
+		return
+	}
+	args := append([]string{}, fields[1:]...)
+	args = append(args, req.Recipient, req.Amount.String(), s.cfg.Denom, txHash)
+
+	runner := s.runner
+	if runner == nil {
+		runner = execRunner
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.PostPayoutTimeout)
+	defer cancel()
+
+	stdout, stderr, err := runner(ctx, fields[0], args...)
+	entry := log.WithFields(log.Fields{
+		"command": fields[0],
+		"tx_hash": txHash,
+		"stdout":  truncateOutput(stdout),
+		"stderr":  truncateOutput(stderr),
+	})
+	if err != nil {
+		metrics.PostPayoutCommands.WithLabelValues("failed").Inc()
+		entry.WithError(err).Warn("Post-payout command failed")
+		return
+	}
+	metrics.PostPayoutCommands.WithLabelValues("success").Inc()
+	entry.Info("Post-payout command finished")
+}
+
+// truncateOutput trims command output for logging
+func truncateOutput(out string) string {
+	out = strings.TrimSpace(out)
+	if len(out) > maxPostPayoutOutput {
+		return out[:maxPostPayoutOutput] + "..."
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+package faucet
+
+import (
+	"sync"
+	"time"
+)
+
+// queueSmoothing weights the latest broadcast in the running average
+const queueSmoothing = 0.2
+
+// BroadcastQueue tracks the payouts waiting on or in a broadcast and a
+// running average of broadcast time, so callers can be told how many
+// payouts are ahead of them and roughly how long they'll wait
+type BroadcastQueue struct {
+	mu      sync.Mutex
+	next    uint64
+	active  map[uint64]struct{}
+	average time.Duration
+}
+
+// QueueStatus is a snapshot of the broadcast queue
+type QueueStatus struct {
+	// Depth is the number of payouts queued or broadcasting
+	Depth int
+	// AverageBroadcast is the running average broadcast time; zero until
+	// the first broadcast completes
+	AverageBroadcast time.Duration
+}
+
+// EstimatedWait is how long a payout joining the queue now would wait
+// before its own broadcast starts
+func (s QueueStatus) EstimatedWait() time.Duration {
+	return time.Duration(s.Depth) * s.AverageBroadcast
+}
+
+// NewBroadcastQueue creates an empty broadcast queue
+func NewBroadcastQueue() *BroadcastQueue {
+	return &BroadcastQueue{active: make(map[uint64]struct{})}
+}
+
+// Enter joins the queue and returns the ticket to pass to Leave
+func (q *BroadcastQueue) Enter() uint64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ticket := q.next
+	q.next++
+	q.active[ticket] = struct{}{}
+	return ticket
+}
+
+// Leave removes ticket from the queue and folds its broadcast time into the
+// running average
+func (q *BroadcastQueue) Leave(ticket uint64, elapsed time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.active, ticket)
+	if q.average == 0 {
+		q.average = elapsed
+	} else {
+		q.average += time.Duration(queueSmoothing * float64(elapsed-q.average))
+	}
+}
+
+// Status returns the current depth and average broadcast time
+func (q *BroadcastQueue) Status() QueueStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return QueueStatus{Depth: len(q.active), AverageBroadcast: q.average}
+}
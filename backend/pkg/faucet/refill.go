@@ -0,0 +1,92 @@
+package faucet
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	metrics "github.com/aura-chain/aura/faucet/pkg/prometheus"
+)
+
+// refillMemo tags treasury transfers so they stand out from payouts
+const refillMemo = "AURA faucet auto-refill"
+
+// MaybeRefill tops the faucet wallet up from the treasury key when its
+// balance is below the refill threshold, or its fee-denom balance is below
+// the fee refill threshold; both top-ups go out in one transfer. It runs
+// from the balance monitor, never on the payout path. Refills are
+// rate-limited to one attempt per RefillMinInterval, and concurrent
+// callers skip rather than wait. Failures are logged.
+func (s *Service) MaybeRefill() {
+	if !s.cfg.AutoRefillEnabled {
+		return
+	}
+	if !s.refillMu.TryLock() {
+		return
+	}
+	defer s.refillMu.Unlock()
+
+	var coins []string
+	fields := log.Fields{}
+
+	balance, err := s.GetBalance()
+	if err != nil {
+		log.WithError(err).Warn("Failed to check faucet balance for auto-refill")
+		return
+	}
+	fields["balance"] = balance
+	if balance < s.cfg.RefillThreshold {
+		coins = append(coins, fmt.Sprintf("%d%s", s.cfg.RefillAmount, s.cfg.Denom))
+	}
+
+	// Payouts stop once the fee denom runs out, however much of the payout
+	// denom is left
+	if feeDenom := s.cfg.FeeDenom(); feeDenom != s.cfg.Denom && s.cfg.RefillFeeThreshold > 0 {
+		feeBalance, err := s.GetFeeBalance()
+		if err != nil {
+			log.WithError(err).Warn("Failed to check faucet fee balance for auto-refill")
+			return
+		}
+		fields["fee_balance"] = feeBalance
+		if feeBalance < s.cfg.RefillFeeThreshold {
+			coins = append(coins, fmt.Sprintf("%d%s", s.cfg.RefillFeeAmount, feeDenom))
+		}
+	}
+	if len(coins) == 0 {
+		return
+	}
+
+	now := s.clock()
+	if !s.lastRefill.IsZero() && now.Sub(s.lastRefill) < s.cfg.RefillMinInterval {
+		metrics.Refills.WithLabelValues("rate_limited").Inc()
+		fields["next_at"] = s.lastRefill.Add(s.cfg.RefillMinInterval).UTC().Format(time.RFC3339)
+		log.WithFields(fields).Warn("Faucet balance is low but auto-refill is rate-limited")
+		return
+	}
+	// Count the attempt even if it fails so a broken treasury is not
+	// retried on every check
+	s.lastRefill = now
+
+	amount := strings.Join(coins, ",")
+	fields["amount"] = amount
+	txHash, err := s.cliSend(s.cfg.RefillTreasuryKey, s.cfg.FaucetAddress, amount, refillMemo)
+	if err != nil {
+		metrics.Refills.WithLabelValues("failed").Inc()
+		log.WithError(err).WithFields(fields).Error("Auto-refill from treasury failed")
+		return
+	}
+
+	metrics.Refills.WithLabelValues("success").Inc()
+	fields["tx_hash"] = txHash
+	log.WithFields(fields).Info("Refilled faucet wallet from treasury")
+}
+
+// clock returns the current time, overridable in tests
+func (s *Service) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,58 @@
+package faucet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxLoggedBody bounds how much of an unexpected node response is logged
+const maxLoggedBody = 512
+
+// ErrUnexpectedNodeResponse is returned when the node answers with something
+// other than the expected JSON, such as a proxy's HTML error page or a
+// truncated body
+var ErrUnexpectedNodeResponse = errors.New("node returned unexpected response")
+
+// decodeNodeJSON decodes a successful node response into v. Bodies that are
+// clearly not JSON or fail to decode are logged (truncated) and reported as
+// ErrUnexpectedNodeResponse rather than as a raw decode error.
+func decodeNodeJSON(resp *http.Response, what string, v interface{}) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", what, err)
+	}
+
+	// Servers that don't set a JSON type often send text/plain, so only
+	// reject types that are definitely something else
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil &&
+		!strings.Contains(mediaType, "json") && mediaType != "text/plain" {
+		logUnexpectedBody(what, resp, body)
+		return fmt.Errorf("%w: %s response has content type %s", ErrUnexpectedNodeResponse, what, mediaType)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		logUnexpectedBody(what, resp, body)
+		return fmt.Errorf("%w: invalid %s JSON: %v", ErrUnexpectedNodeResponse, what, err)
+	}
+	return nil
+}
+
+func logUnexpectedBody(what string, resp *http.Response, body []byte) {
+	truncated := string(body)
+	if len(truncated) > maxLoggedBody {
+		truncated = truncated[:maxLoggedBody] + "..."
+	}
+	log.WithFields(log.Fields{
+		"query":        what,
+		"url":          resp.Request.URL.String(),
+		"content_type": resp.Header.Get("Content-Type"),
+		"body":         truncated,
+	}).Warn("Node returned unexpected response")
+}
@@ -0,0 +1,142 @@
+package faucet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultPayoutRetryBase is the first replay delay when none is configured
+	defaultPayoutRetryBase = 30 * time.Second
+
+	// maxPayoutRetryDelay caps the exponential replay backoff
+	maxPayoutRetryDelay = 30 * time.Minute
+
+	// retryBatchSize bounds the replays attempted per poll
+	retryBatchSize = 20
+)
+
+var (
+	// ErrAlreadyPaid is returned when replaying a request whose recipient
+	// has already been paid
+	ErrAlreadyPaid = errors.New("ALREADY_PAID")
+
+	// ErrNotReplayable is returned when a request is not in a failed state
+	ErrNotReplayable = errors.New("NOT_REPLAYABLE")
+)
+
+// scheduleRetry parks a failed payout for automatic replay with exponential
+// backoff. Requests out of attempts stay failed.
+func (s *Service) scheduleRetry(requestID int64, errorMsg string) error {
+	req, err := s.db.GetRequest(requestID)
+	if err != nil {
+		return err
+	}
+	next := time.Now().Add(s.retryBackoff(req.RetryAttempts))
+	return s.db.ScheduleRequestRetry(requestID, errorMsg, next, s.cfg.PayoutRetryAttempts)
+}
+
+// retryBackoff returns the delay before replay number attempts+1
+func (s *Service) retryBackoff(attempts int) time.Duration {
+	delay := s.cfg.PayoutRetryBase
+	if delay <= 0 {
+		delay = defaultPayoutRetryBase
+	}
+	for i := 0; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxPayoutRetryDelay {
+			return maxPayoutRetryDelay
+		}
+	}
+	return delay
+}
+
+// ReplayRequest re-broadcasts the payout of a failed request. It refuses if
+// the request already succeeded or its recipient has been paid since, so a
+// replay never pays twice.
+func (s *Service) ReplayRequest(requestID int64) (*SendResponse, error) {
+	dbReq, err := s.db.GetRequest(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if dbReq.Status == "success" || dbReq.TxHash != "" {
+		return nil, fmt.Errorf("%w: request %d has tx %s", ErrAlreadyPaid, requestID, dbReq.TxHash)
+	}
+	if dbReq.Status != "failed" && dbReq.Status != "retrying" {
+		return nil, fmt.Errorf("%w: request %d is %s", ErrNotReplayable, requestID, dbReq.Status)
+	}
+
+	paid, err := s.db.HasSuccessfulPayoutSince(dbReq.Recipient, dbReq.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if paid {
+		return nil, fmt.Errorf("%w: %s was paid by a later request", ErrAlreadyPaid, dbReq.Recipient)
+	}
+
+	// A replay spends like a new payout; refused, it stays parked for the
+	// next attempt
+	if err := s.checkPayout(dbReq.Amount); err != nil {
+		return nil, err
+	}
+
+	claimed, err := s.db.ClaimRequestForReplay(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, fmt.Errorf("%w: request %d is already being replayed", ErrNotReplayable, requestID)
+	}
+
+	log.WithFields(log.Fields{
+		"request_id": requestID,
+		"recipient":  dbReq.Recipient,
+		"attempt":    dbReq.RetryAttempts + 1,
+	}).Info("Replaying failed payout")
+
+	return s.deliver(requestID, dbReq.CreatedAt, &SendRequest{
+		Recipient: dbReq.Recipient,
+		Amount:    dbReq.Amount,
+		IPAddress: dbReq.IPAddress,
+		Source:    dbReq.Source,
+	})
+}
+
+// ProcessRetries replays every parked payout in this service's denom that is due
+func (s *Service) ProcessRetries(ctx context.Context) error {
+	ids, err := s.db.GetDueRetries(s.cfg.Denom, retryBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, err := s.ReplayRequest(id); err != nil {
+			log.WithError(err).WithField("request_id", id).Warn("Payout replay failed")
+		}
+	}
+	return nil
+}
+
+// RunRetries processes due replays every interval until ctx is cancelled
+func (s *Service) RunRetries(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.ProcessRetries(ctx); err != nil {
+				log.WithError(err).Warn("Failed to process payout retries")
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package faucet
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// SequenceFetcher returns the current on-chain sequence for an account
+type SequenceFetcher func(address string) (uint64, error)
+
+// SequenceManager tracks the faucet account sequence locally so each send
+// doesn't need a round-trip to the chain. Broadcasts are serialized through
+// Do, which advances the sequence on success and resyncs on mismatch.
+type SequenceManager struct {
+	mu         sync.Mutex
+	address    string
+	fetch      SequenceFetcher
+	maxRetries int
+	sequence   uint64
+	synced     bool
+}
+
+// NewSequenceManager creates a sequence manager for an account. maxRetries
+// bounds how many times a broadcast is retried after a sequence mismatch.
+func NewSequenceManager(address string, fetch SequenceFetcher, maxRetries int) *SequenceManager {
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+
+	return &SequenceManager{
+		address:    address,
+		fetch:      fetch,
+		maxRetries: maxRetries,
+	}
+}
+
+// Do runs broadcast with the next sequence. The sequence is incremented when
+// broadcast succeeds; on a sequence mismatch it is refetched from the chain
+// and the broadcast retried up to maxRetries times.
+func (m *SequenceManager) Do(broadcast func(sequence uint64) (string, error)) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		if !m.synced {
+			if err := m.resync(); err != nil {
+				return "", err
+			}
+		}
+
+		txHash, err := broadcast(m.sequence)
+		if err == nil {
+			m.sequence++
+			return txHash, nil
+		}
+
+		if !isSequenceMismatch(err) {
+			return "", err
+		}
+
+		// Our view of the sequence is stale; refetch before the next use
+		m.synced = false
+		if attempt >= m.maxRetries {
+			return "", err
+		}
+	}
+}
+
+// Current returns the locally tracked sequence and whether it is in sync
+func (m *SequenceManager) Current() (uint64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sequence, m.synced
+}
+
+// Invalidate forces a resync before the next broadcast
+func (m *SequenceManager) Invalidate() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.synced = false
+}
+
+// resync fetches the sequence from the chain; callers must hold m.mu
+func (m *SequenceManager) resync() error {
+	sequence, err := m.fetch(m.address)
+	if err != nil {
+		return fmt.Errorf("failed to fetch account sequence: %w", err)
+	}
+
+	m.sequence = sequence
+	m.synced = true
+	return nil
+}
+
+// isSequenceMismatch reports whether err is the SDK's incorrect sequence error
+func isSequenceMismatch(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "account sequence mismatch") || strings.Contains(msg, "incorrect account sequence")
+}
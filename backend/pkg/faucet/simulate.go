@@ -0,0 +1,140 @@
+package faucet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrSimulationFailed is returned when a payout's simulation shows the real
+// broadcast would fail
+var ErrSimulationFailed = errors.New("SIMULATION_FAILED")
+
+// Simulation failure categories surfaced to users
+const (
+	SimInsufficientFunds = "insufficient_funds"
+	SimInvalidRecipient  = "invalid_recipient"
+	SimRecipientBlocked  = "recipient_blocked"
+	SimOutOfGas          = "out_of_gas"
+)
+
+// SimulationError is a simulation rejection in one of the Sim* categories
+type SimulationError struct {
+	Category string
+	Log      string
+}
+
+func (e *SimulationError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", ErrSimulationFailed, e.Category, e.Log)
+}
+
+// Unwrap makes errors.Is match ErrSimulationFailed
+func (e *SimulationError) Unwrap() error {
+	return ErrSimulationFailed
+}
+
+// simulationCategories map node error fragments to categories. Only
+// failures that would certainly recur on broadcast are listed; anything
+// else, such as a signature or sequence complaint about the unsigned
+// simulation tx, lets the payout proceed.
+var simulationCategories = []struct {
+	fragment string
+	category string
+}{
+	{"insufficient funds", SimInsufficientFunds},
+	{"insufficient fee", SimInsufficientFunds},
+	{"decoding bech32", SimInvalidRecipient},
+	{"invalid address", SimInvalidRecipient},
+	{"not allowed to receive funds", SimRecipientBlocked},
+	{"blocked address", SimRecipientBlocked},
+	{"out of gas", SimOutOfGas},
+}
+
+// simulateResponse is the body of a successful simulation
+type simulateResponse struct {
+	GasInfo struct {
+		GasUsed string `json:"gas_used"`
+	} `json:"gas_info"`
+}
+
+// simulateErrorResponse is the gRPC-gateway error body
+type simulateErrorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// simulate dry-runs txData on the node. It returns a *SimulationError only
+// when the simulation definitely rejects the transfer; an unreachable node
+// or a chain without the endpoint is logged and the payout proceeds.
+func (s *Service) simulate(txData map[string]interface{}) error {
+	restURL := s.cfg.NodeREST
+	if restURL == "" {
+		restURL = s.cfg.NodeRPC
+	}
+	url := fmt.Sprintf("%s/cosmos/tx/v1beta1/simulate", restURL)
+
+	// A wrong sequence only makes the simulation inconclusive
+	var sequence uint64
+	synced := false
+	if s.sequences != nil {
+		sequence, synced = s.sequences.Current()
+	}
+	if !synced {
+		if fetched, err := s.getAccountSequence(s.cfg.FaucetAddress); err == nil {
+			sequence = fetched
+		}
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{"tx": restTx(txData, sequence)})
+	if err != nil {
+		return fmt.Errorf("failed to marshal simulation: %w", err)
+	}
+
+	resp, err := s.client.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		log.WithError(err).Warn("Payout simulation unavailable; broadcasting without it")
+		return nil
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var result simulateResponse
+		if err := decodeNodeJSON(resp, "simulate", &result); err != nil {
+			log.WithError(err).Warn("Could not read payout simulation result")
+			return nil
+		}
+		log.WithField("gas_used", result.GasInfo.GasUsed).Debug("Payout simulation succeeded")
+		return nil
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		log.WithField("status", resp.StatusCode).Warn("Node does not support payout simulation; broadcasting without it")
+		return nil
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	var failure simulateErrorResponse
+	if err := json.Unmarshal(body, &failure); err != nil || failure.Message == "" {
+		log.WithField("status", resp.StatusCode).Warn("Unexpected payout simulation response; broadcasting without it")
+		return nil
+	}
+
+	message := strings.ToLower(failure.Message)
+	for _, c := range simulationCategories {
+		if strings.Contains(message, c.fragment) {
+			log.WithFields(log.Fields{
+				"category": c.category,
+				"message":  failure.Message,
+			}).Warn("Payout simulation failed; not broadcasting")
+			return &SimulationError{Category: c.category, Log: failure.Message}
+		}
+	}
+
+	log.WithField("message", failure.Message).Warn("Payout simulation failed inconclusively; broadcasting anyway")
+	return nil
+}
@@ -0,0 +1,110 @@
+package faucet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/aura-chain/aura/faucet/pkg/units"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrSupplyGuard is returned when payouts are paused because the faucet
+// holds too large a share of the denom's supply, or the supply is below
+// the configured floor
+var ErrSupplyGuard = errors.New("SUPPLY_GUARD")
+
+// SupplyStatus is the faucet's share of its payout denom's total supply
+type SupplyStatus struct {
+	Supply  units.Amount `json:"supply"`
+	Balance units.Amount `json:"balance"`
+	Share   float64      `json:"share"` // Balance / Supply
+	Paused  bool         `json:"paused"`
+	Reason  string       `json:"reason,omitempty"`
+}
+
+// supplyResponse is the body of /cosmos/bank/v1beta1/supply/by_denom
+type supplyResponse struct {
+	Amount struct {
+		Denom  string `json:"denom"`
+		Amount string `json:"amount"`
+	} `json:"amount"`
+}
+
+// GetTotalSupply returns the total supply of the payout denom, which for
+// 18-decimal denoms is far beyond int64
+func (s *Service) GetTotalSupply() (units.Amount, error) {
+	restURL := s.cfg.NodeREST
+	if restURL == "" {
+		restURL = s.cfg.NodeRPC
+	}
+	query := fmt.Sprintf("%s/cosmos/bank/v1beta1/supply/by_denom?denom=%s", restURL, url.QueryEscape(s.cfg.Denom))
+
+	resp, err := s.client.Get(query)
+	if err != nil {
+		return units.Amount{}, fmt.Errorf("failed to get supply: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return units.Amount{}, fmt.Errorf("failed to get supply: status %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var supply supplyResponse
+	if err := decodeNodeJSON(resp, "supply", &supply); err != nil {
+		return units.Amount{}, err
+	}
+	amount, err := units.Parse(supply.Amount.Amount)
+	if err != nil {
+		return units.Amount{}, fmt.Errorf("invalid supply amount: %w", err)
+	}
+	return amount, nil
+}
+
+// CheckSupply compares the faucet balance with the denom's total supply
+// and reports whether the supply guard pauses payouts
+func (s *Service) CheckSupply() (*SupplyStatus, error) {
+	supply, err := s.GetTotalSupply()
+	if err != nil {
+		return nil, err
+	}
+	_, balance, err := s.lookupExactBalance(s.cfg.FaucetAddress, s.cfg.Denom)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &SupplyStatus{Supply: supply, Balance: balance, Share: balance.Ratio(supply)}
+
+	maxShare := float64(s.cfg.SupplyGuardMaxSharePercent) / 100
+	switch {
+	case !s.cfg.SupplyGuardMinSupply.IsZero() && supply.Cmp(s.cfg.SupplyGuardMinSupply) < 0:
+		status.Paused = true
+		status.Reason = fmt.Sprintf("supply %s below floor %s", supply, s.cfg.SupplyGuardMinSupply)
+	case maxShare > 0 && status.Share > maxShare:
+		status.Paused = true
+		status.Reason = fmt.Sprintf("faucet holds %.2f%% of supply, above %d%%", status.Share*100, s.cfg.SupplyGuardMaxSharePercent)
+	}
+	return status, nil
+}
+
+// checkSupplyGuard returns ErrSupplyGuard while the guard pauses payouts.
+// A failed supply query does not pause payouts, so nodes without the
+// supply endpoint keep working.
+func (s *Service) checkSupplyGuard() error {
+	if !s.cfg.SupplyGuardEnabled() {
+		return nil
+	}
+
+	status, err := s.CheckSupply()
+	if err != nil {
+		log.WithError(err).Warn("Supply guard check failed; allowing payout")
+		return nil
+	}
+	if status.Paused {
+		return fmt.Errorf("%w: %s", ErrSupplyGuard, status.Reason)
+	}
+	return nil
+}
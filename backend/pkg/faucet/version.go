@@ -0,0 +1,85 @@
+package faucet
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrIncompatibleNodeVersion is returned when the node runs a version outside
+// the supported range
+var ErrIncompatibleNodeVersion = errors.New("INCOMPATIBLE_NODE_VERSION")
+
+// CheckNodeVersion verifies version lies within [min, max]. Bounds are
+// inclusive and compared only to the precision given, so a max of "0.50"
+// accepts any 0.50.x. Empty bounds are unbounded.
+func CheckNodeVersion(version, min, max string) error {
+	if min == "" && max == "" {
+		return nil
+	}
+
+	v, err := parseVersion(version)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrIncompatibleNodeVersion, err)
+	}
+	if min != "" {
+		bound, err := parseVersion(min)
+		if err != nil {
+			return fmt.Errorf("invalid minimum node version: %w", err)
+		}
+		if compareVersion(v, bound) < 0 {
+			return fmt.Errorf("%w: %s is older than %s", ErrIncompatibleNodeVersion, version, min)
+		}
+	}
+	if max != "" {
+		bound, err := parseVersion(max)
+		if err != nil {
+			return fmt.Errorf("invalid maximum node version: %w", err)
+		}
+		if compareVersion(v, bound) > 0 {
+			return fmt.Errorf("%w: %s is newer than %s", ErrIncompatibleNodeVersion, version, max)
+		}
+	}
+	return nil
+}
+
+// parseVersion parses "v0.50.1-rc1" style versions into numeric components,
+// ignoring any pre-release or build suffix
+func parseVersion(version string) ([]int, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if idx := strings.IndexAny(s, "-+"); idx >= 0 {
+		s = s[:idx]
+	}
+	if s == "" {
+		return nil, fmt.Errorf("empty version %q", version)
+	}
+
+	parts := strings.Split(s, ".")
+	components := make([]int, 0, len(parts))
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("malformed version %q", version)
+		}
+		components = append(components, n)
+	}
+	return components, nil
+}
+
+// compareVersion compares v against bound over bound's components only
+func compareVersion(v, bound []int) int {
+	for i, b := range bound {
+		var n int
+		if i < len(v) {
+			n = v[i]
+		}
+		switch {
+		case n < b:
+			return -1
+		case n > b:
+			return 1
+		}
+	}
+	return 0
+}
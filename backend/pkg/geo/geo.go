@@ -0,0 +1,87 @@
+package geo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+// CIDRResolver maps IPs to ISO 3166 country codes using a table of CIDR
+// ranges, such as one exported from a GeoIP country database
+type CIDRResolver struct {
+	ranges []cidrCountry
+}
+
+type cidrCountry struct {
+	network *net.IPNet
+	country string
+}
+
+// NewCIDRResolver parses "cidr,country" lines. Blank lines and lines
+// starting with # are skipped.
+func NewCIDRResolver(r io.Reader) (*CIDRResolver, error) {
+	resolver := &CIDRResolver{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		cidr, country, ok := strings.Cut(text, ",")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected cidr,country", line)
+		}
+		_, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		resolver.ranges = append(resolver.ranges, cidrCountry{
+			network: network,
+			country: strings.ToUpper(strings.TrimSpace(country)),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read CIDR table: %w", err)
+	}
+
+	return resolver, nil
+}
+
+// LoadCIDRFile loads a resolver from a "cidr,country" file
+func LoadCIDRFile(path string) (*CIDRResolver, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CIDR table: %w", err)
+	}
+	defer f.Close()
+
+	return NewCIDRResolver(f)
+}
+
+// Country returns the country code for ip, or "" if it is not covered.
+// The most specific matching range wins.
+func (r *CIDRResolver) Country(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+
+	country := ""
+	bestPrefix := -1
+	for _, entry := range r.ranges {
+		if !entry.network.Contains(parsed) {
+			continue
+		}
+		if prefix, _ := entry.network.Mask.Size(); prefix > bestPrefix {
+			bestPrefix = prefix
+			country = entry.country
+		}
+	}
+	return country
+}
@@ -0,0 +1,33 @@
+package geo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCIDRResolver(t *testing.T) {
+	resolver, err := NewCIDRResolver(strings.NewReader(`
+# cidr,country
+10.0.0.0/8,us
+10.1.0.0/16,DE
+2001:db8::/32,FR
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "US", resolver.Country("10.2.3.4"))
+	assert.Equal(t, "DE", resolver.Country("10.1.2.3"), "most specific range wins")
+	assert.Equal(t, "FR", resolver.Country("2001:db8::1"))
+	assert.Equal(t, "", resolver.Country("192.168.1.1"))
+	assert.Equal(t, "", resolver.Country("not-an-ip"))
+}
+
+func TestCIDRResolverRejectsBadLines(t *testing.T) {
+	_, err := NewCIDRResolver(strings.NewReader("10.0.0.0/8"))
+	assert.Error(t, err)
+
+	_, err = NewCIDRResolver(strings.NewReader("10.0.0.0/33,US"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+// Package health rolls named subsystem checks up into one service status.
+// Each subsystem registers its checks with a severity: a failing critical
+// check makes the service unhealthy, a failing warning check degrades it.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Severity is how much a failing check counts against the service
+type Severity string
+
+const (
+	// Critical checks make the service unhealthy when they fail
+	Critical Severity = "critical"
+	// Warning checks make the service degraded when they fail
+	Warning Severity = "warning"
+)
+
+// Status is the rolled-up state of the service
+type Status string
+
+const (
+	StatusHealthy   Status = "healthy"
+	StatusDegraded  Status = "degraded"
+	StatusUnhealthy Status = "unhealthy"
+)
+
+// ErrSkipped is returned by a check that does not apply right now, such
+// as one for a disabled feature. Skipped checks are left out of the report.
+var ErrSkipped = errors.New("check skipped")
+
+// CheckFunc returns nil when the subsystem is healthy
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name     string
+	severity Severity
+	run      CheckFunc
+}
+
+// Registry holds the checks that make up the service's health, in
+// registration order
+type Registry struct {
+	mu     sync.RWMutex
+	checks []check
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a named check. Registering a name again replaces the
+// earlier check in place.
+func (r *Registry) Register(name string, severity Severity, run CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.checks {
+		if r.checks[i].name == name {
+			r.checks[i] = check{name: name, severity: severity, run: run}
+			return
+		}
+	}
+	r.checks = append(r.checks, check{name: name, severity: severity, run: run})
+}
+
+// Report is the outcome of running every registered check
+type Report struct {
+	Status Status
+	// Checks maps each check that ran to whether it passed
+	Checks map[string]bool
+}
+
+// Run runs every check in registration order and rolls the results up
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make([]check, len(r.checks))
+	copy(checks, r.checks)
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusHealthy, Checks: make(map[string]bool, len(checks))}
+	for _, c := range checks {
+		err := c.run(ctx)
+		if errors.Is(err, ErrSkipped) {
+			continue
+		}
+		report.Checks[c.name] = err == nil
+		if err == nil {
+			continue
+		}
+
+		log.WithError(err).WithField("check", c.name).Debug("Health check failed")
+		if c.severity == Critical {
+			report.Status = StatusUnhealthy
+		} else if report.Status == StatusHealthy {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
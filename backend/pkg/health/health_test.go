@@ -0,0 +1,79 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pass(ctx context.Context) error { return nil }
+
+func fail(ctx context.Context) error { return errors.New("down") }
+
+func skip(ctx context.Context) error { return ErrSkipped }
+
+func TestRegistryRollsUpStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		critical CheckFunc
+		warning  CheckFunc
+		want     Status
+	}{
+		{"all passing", pass, pass, StatusHealthy},
+		{"warning failing", pass, fail, StatusDegraded},
+		{"critical failing", fail, pass, StatusUnhealthy},
+		{"both failing", fail, fail, StatusUnhealthy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			registry.Register("node", Critical, tt.critical)
+			registry.Register("cache", Warning, tt.warning)
+
+			report := registry.Run(context.Background())
+			assert.Equal(t, tt.want, report.Status)
+			assert.Len(t, report.Checks, 2)
+		})
+	}
+}
+
+func TestRegistrySkipsAndReplacesChecks(t *testing.T) {
+	registry := NewRegistry()
+	assert.Equal(t, Report{Status: StatusHealthy, Checks: map[string]bool{}}, registry.Run(context.Background()))
+
+	registry.Register("node", Critical, pass)
+	registry.Register("supply", Warning, skip)
+	registry.Register("queue", Warning, fail)
+	report := registry.Run(context.Background())
+	assert.Equal(t, StatusDegraded, report.Status)
+	assert.Equal(t, map[string]bool{"node": true, "queue": false}, report.Checks, "skipped checks are left out")
+
+	// A wrapped ErrSkipped still skips
+	registry.Register("queue", Warning, func(ctx context.Context) error {
+		return errors.Join(errors.New("unbounded"), ErrSkipped)
+	})
+	report = registry.Run(context.Background())
+	assert.Equal(t, StatusHealthy, report.Status)
+	assert.Equal(t, map[string]bool{"node": true}, report.Checks)
+
+	registry.Register("node", Critical, fail)
+	report = registry.Run(context.Background())
+	assert.Equal(t, StatusUnhealthy, report.Status, "registering a name again replaces its check")
+	assert.Len(t, report.Checks, 1)
+}
+
+func TestRegistryPassesContext(t *testing.T) {
+	type key struct{}
+	registry := NewRegistry()
+	var got interface{}
+	registry.Register("ctx", Warning, func(ctx context.Context) error {
+		got = ctx.Value(key{})
+		return nil
+	})
+
+	registry.Run(context.WithValue(context.Background(), key{}, "probe"))
+	assert.Equal(t, "probe", got)
+}
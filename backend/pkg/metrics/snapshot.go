@@ -0,0 +1,226 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// snapshotTopRecipients is how many of the most frequent recipients a
+// snapshot keeps
+const snapshotTopRecipients = 100
+
+// maxSnapshotAge is how old a snapshot may be and still be restored; the
+// tracker covers one reporting day, so anything older is a past day
+const maxSnapshotAge = 24 * time.Hour
+
+// Snapshot holds a tracker's counters for the current reporting day so
+// they can outlive the process. The unique sets hold hashes, not raw IPs
+// or addresses, and response time samples are not kept; only the maximum
+// is.
+type Snapshot struct {
+	SavedAt                time.Time        `json:"saved_at"`
+	TotalRequests          int64            `json:"total_requests"`
+	SuccessfulRequests     int64            `json:"successful_requests"`
+	FailedRequests         int64            `json:"failed_requests"`
+	BlockedRequests        int64            `json:"blocked_requests"`
+	TotalTokensDistributed int64            `json:"total_tokens_distributed"`
+	RequestsPerHour        map[int]int64    `json:"requests_per_hour"`
+	RequestsPerDay         map[string]int64 `json:"requests_per_day"`
+	UniqueAddressHashes    []string         `json:"unique_address_hashes"`
+	TopRecipients          map[string]int64 `json:"top_recipients"`
+	UniqueIPHashes         []string         `json:"unique_ip_hashes"`
+	RequestsByCountry      map[string]int64 `json:"requests_by_country"`
+	ErrorCounts            map[string]int64 `json:"error_counts"`
+	MaxResponseTime        time.Duration    `json:"max_response_time"`
+}
+
+// Snapshot returns a copy of the tracker's counters. Only the copy runs
+// under the lock; trimming and sorting happen after it is released.
+func (m *MetricsTracker) Snapshot() Snapshot {
+	m.mu.RLock()
+	snapshot := Snapshot{
+		SavedAt:                time.Now(),
+		TotalRequests:          m.totalRequests,
+		SuccessfulRequests:     m.successfulRequests,
+		FailedRequests:         m.failedRequests,
+		BlockedRequests:        m.blockedRequests,
+		TotalTokensDistributed: m.totalTokensDistributed,
+		RequestsPerHour:        m.copyHourlyDistribution(),
+		RequestsPerDay:         copyCounts(m.requestsPerDay),
+		UniqueAddressHashes:    setKeys(m.uniqueAddresses),
+		TopRecipients:          copyCounts(m.topRecipients),
+		UniqueIPHashes:         setKeys(m.uniqueIPs),
+		RequestsByCountry:      copyCounts(m.requestsByCountry),
+		ErrorCounts:            m.copyErrorCounts(),
+		MaxResponseTime:        m.maxResponseTime,
+	}
+	m.mu.RUnlock()
+
+	sort.Strings(snapshot.UniqueAddressHashes)
+	sort.Strings(snapshot.UniqueIPHashes)
+	snapshot.TopRecipients = topCounts(snapshot.TopRecipients, snapshotTopRecipients)
+	return snapshot
+}
+
+// Restore adds a snapshot's counters to the tracker, so requests recorded
+// before the restore are kept
+func (m *MetricsTracker) Restore(s Snapshot) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.totalRequests += s.TotalRequests
+	m.successfulRequests += s.SuccessfulRequests
+	m.failedRequests += s.FailedRequests
+	m.blockedRequests += s.BlockedRequests
+	m.totalTokensDistributed += s.TotalTokensDistributed
+	for hour, count := range s.RequestsPerHour {
+		m.requestsPerHour[hour] += count
+	}
+	addCounts(m.requestsPerDay, s.RequestsPerDay)
+	for _, hash := range s.UniqueAddressHashes {
+		if len(m.uniqueAddresses) < maxTrackedKeys {
+			m.uniqueAddresses[hash] = true
+		}
+	}
+	for address, count := range s.TopRecipients {
+		if _, ok := m.topRecipients[address]; ok || len(m.topRecipients) < maxTrackedKeys {
+			m.topRecipients[address] += count
+		}
+	}
+	for _, hash := range s.UniqueIPHashes {
+		if len(m.uniqueIPs) < maxTrackedKeys {
+			m.uniqueIPs[hash] = true
+		}
+	}
+	addCounts(m.requestsByCountry, s.RequestsByCountry)
+	addCounts(m.errorCounts, s.ErrorCounts)
+	if s.MaxResponseTime > m.maxResponseTime {
+		m.maxResponseTime = s.MaxResponseTime
+	}
+
+	if m.successfulRequests > 0 {
+		m.avgTokensPerRequest = float64(m.totalTokensDistributed) / float64(m.successfulRequests)
+	}
+}
+
+// SnapshotStore persists snapshots by name; implemented by *database.DB
+type SnapshotStore interface {
+	SaveMetricsSnapshot(name string, data []byte) error
+	// GetMetricsSnapshot returns nil when no snapshot has been saved
+	GetMetricsSnapshot(name string) ([]byte, error)
+}
+
+// Snapshotter periodically saves a tracker to a SnapshotStore and restores
+// it on startup. Each instance saves under its own name, so replicas don't
+// restore each other's counts.
+type Snapshotter struct {
+	tracker  *MetricsTracker
+	store    SnapshotStore
+	name     string
+	interval time.Duration
+}
+
+// NewSnapshotter creates a snapshotter saving tracker under name every
+// interval
+func NewSnapshotter(tracker *MetricsTracker, store SnapshotStore, name string, interval time.Duration) *Snapshotter {
+	return &Snapshotter{tracker: tracker, store: store, name: name, interval: interval}
+}
+
+// Restore loads the saved snapshot into the tracker, if there is one
+func (s *Snapshotter) Restore() error {
+	data, err := s.store.GetMetricsSnapshot(s.name)
+	if err != nil || data == nil {
+		return err
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("invalid metrics snapshot: %w", err)
+	}
+	if time.Since(snapshot.SavedAt) > maxSnapshotAge {
+		log.WithField("saved_at", snapshot.SavedAt).Info("Metrics snapshot is from a past day; not restoring it")
+		return nil
+	}
+	s.tracker.Restore(snapshot)
+	return nil
+}
+
+// Save stores the tracker's current counters
+func (s *Snapshotter) Save() error {
+	data, err := json.Marshal(s.tracker.Snapshot())
+	if err != nil {
+		return fmt.Errorf("failed to encode metrics snapshot: %w", err)
+	}
+	return s.store.SaveMetricsSnapshot(s.name, data)
+}
+
+// Run saves a snapshot every interval until ctx is cancelled, then saves a
+// final one so a clean shutdown loses nothing
+func (s *Snapshotter) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := s.Save(); err != nil {
+				log.WithError(err).Warn("Failed to save final metrics snapshot")
+			}
+			return
+		case <-ticker.C:
+			if err := s.Save(); err != nil {
+				log.WithError(err).Warn("Failed to save metrics snapshot")
+			}
+		}
+	}
+}
+
+func copyCounts(counts map[string]int64) map[string]int64 {
+	out := make(map[string]int64, len(counts))
+	for key, count := range counts {
+		out[key] = count
+	}
+	return out
+}
+
+func addCounts(dst, src map[string]int64) {
+	for key, count := range src {
+		dst[key] += count
+	}
+}
+
+// setKeys returns a set's members
+func setKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// topCounts returns the limit largest entries of counts
+func topCounts(counts map[string]int64, limit int) map[string]int64 {
+	if len(counts) <= limit {
+		return counts
+	}
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	top := make(map[string]int64, limit)
+	for _, key := range keys[:limit] {
+		top[key] = counts[key]
+	}
+	return top
+}
@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore is an in-memory SnapshotStore
+type memoryStore struct {
+	snapshots map[string][]byte
+	saves     int
+}
+
+func (s *memoryStore) SaveMetricsSnapshot(name string, data []byte) error {
+	s.snapshots[name] = data
+	s.saves++
+	return nil
+}
+
+func (s *memoryStore) GetMetricsSnapshot(name string) ([]byte, error) {
+	return s.snapshots[name], nil
+}
+
+func recordSample(tracker *MetricsTracker, now time.Time) {
+	tracker.RecordRequest(RequestMetrics{
+		IP:           "192.0.2.10",
+		Address:      "aura1first",
+		Amount:       1_000_000,
+		Success:      true,
+		ResponseTime: 20 * time.Millisecond,
+		Timestamp:    now,
+	})
+	tracker.RecordRequest(RequestMetrics{
+		IP:           "192.0.2.11",
+		Address:      "aura1second",
+		Success:      false,
+		ErrorType:    "captcha_failed",
+		ResponseTime: 40 * time.Millisecond,
+		Timestamp:    now,
+	})
+	tracker.RecordBlocked("198.51.100.3")
+}
+
+func TestSnapshotRestoresCounts(t *testing.T) {
+	now := time.Now()
+	store := &memoryStore{snapshots: map[string][]byte{}}
+
+	before := NewMetricsTracker()
+	recordSample(before, now)
+	require.NoError(t, NewSnapshotter(before, store, "tracker", time.Minute).Save())
+
+	// A restarted tracker picks up where the previous one left off
+	after := NewMetricsTracker()
+	after.RecordBlocked("198.51.100.3")
+	snapshotter := NewSnapshotter(after, store, "tracker", time.Minute)
+	require.NoError(t, snapshotter.Restore())
+
+	summary := after.GetSummary()
+	assert.Equal(t, int64(2), summary.TotalRequests)
+	assert.Equal(t, int64(1), summary.SuccessfulRequests)
+	assert.Equal(t, int64(1), summary.FailedRequests)
+	assert.Equal(t, int64(2), summary.BlockedRequests, "requests recorded before the restore are kept")
+	assert.Equal(t, int64(1_000_000), summary.TotalTokensDistributed)
+	assert.Equal(t, 1, summary.UniqueAddresses)
+	assert.Equal(t, 3, summary.UniqueIPs)
+	assert.Equal(t, 40*time.Millisecond, summary.MaxResponseTime)
+	assert.Equal(t, int64(1), summary.ErrorBreakdown["captcha_failed"])
+	assert.Equal(t, int64(2), summary.HourlyDistribution[now.Hour()])
+	assert.Equal(t, int64(2), after.GetDailyStats()[now.Format("2006-01-02")])
+	require.Len(t, summary.TopRecipients, 1)
+	assert.Equal(t, "aura1first", summary.TopRecipients[0].Address)
+
+	// Nothing saved under a name restores nothing
+	empty := NewMetricsTracker()
+	require.NoError(t, NewSnapshotter(empty, store, "other", time.Minute).Restore())
+	assert.Zero(t, empty.GetSummary().TotalRequests)
+
+	store.snapshots["broken"] = []byte("{")
+	assert.Error(t, NewSnapshotter(empty, store, "broken", time.Minute).Restore())
+}
+
+func TestSnapshotterRunSavesOnShutdown(t *testing.T) {
+	store := &memoryStore{snapshots: map[string][]byte{}}
+	tracker := NewMetricsTracker()
+	recordSample(tracker, time.Now())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewSnapshotter(tracker, store, "tracker", time.Hour).Run(ctx)
+		close(done)
+	}()
+	cancel()
+	<-done
+
+	assert.Equal(t, 1, store.saves)
+	assert.Contains(t, string(store.snapshots["tracker"]), `"total_requests":2`)
+}
+
+func TestSnapshotKeepsNoRawIPs(t *testing.T) {
+	store := &memoryStore{snapshots: map[string][]byte{}}
+	tracker := NewMetricsTracker()
+	recordSample(tracker, time.Now())
+	require.NoError(t, NewSnapshotter(tracker, store, "tracker:a", time.Minute).Save())
+
+	data := string(store.snapshots["tracker:a"])
+	assert.NotContains(t, data, "192.0.2.10")
+	assert.NotContains(t, data, "198.51.100.3")
+	assert.Len(t, tracker.Snapshot().UniqueIPHashes, 3)
+}
+
+func TestSnapshotBoundsTopRecipients(t *testing.T) {
+	tracker := NewMetricsTracker()
+	for i := 0; i < snapshotTopRecipients+50; i++ {
+		tracker.RecordRequest(RequestMetrics{Address: fmt.Sprintf("aura1addr%d", i), Success: true, Timestamp: time.Now()})
+	}
+	tracker.RecordRequest(RequestMetrics{Address: "aura1addr7", Success: true, Timestamp: time.Now()})
+
+	snapshot := tracker.Snapshot()
+	assert.Len(t, snapshot.TopRecipients, snapshotTopRecipients)
+	assert.Equal(t, int64(2), snapshot.TopRecipients["aura1addr7"])
+}
+
+func TestSnapshotFromPastDayIsNotRestored(t *testing.T) {
+	store := &memoryStore{snapshots: map[string][]byte{}}
+	before := NewMetricsTracker()
+	recordSample(before, time.Now())
+	snapshot := before.Snapshot()
+	snapshot.SavedAt = time.Now().Add(-2 * maxSnapshotAge)
+	data, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	store.snapshots["tracker"] = data
+
+	after := NewMetricsTracker()
+	require.NoError(t, NewSnapshotter(after, store, "tracker", time.Minute).Restore())
+	assert.Zero(t, after.GetSummary().TotalRequests)
+}
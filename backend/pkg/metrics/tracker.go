@@ -1,43 +1,59 @@
 package metrics
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
 	"sync"
 	"time"
 )
 
+// maxTrackedKeys caps each of the unique address, unique IP and recipient
+// sets. Once a set is full new keys are not added until the next EndDay,
+// so its size is a lower bound on a busy day.
+const maxTrackedKeys = 10000
+
+// maxResponseSamples is how many response times are kept for percentiles;
+// past it the oldest are dropped down to minResponseSamples
+const (
+	maxResponseSamples = 10000
+	minResponseSamples = 1000
+)
+
 // MetricsTracker tracks faucet usage metrics and analytics
 type MetricsTracker struct {
 	mu sync.RWMutex
 
 	// Request metrics
-	totalRequests       int64
-	successfulRequests  int64
-	failedRequests      int64
-	blockedRequests     int64
+	totalRequests      int64
+	successfulRequests int64
+	failedRequests     int64
+	blockedRequests    int64
 
 	// Token metrics
 	totalTokensDistributed int64
 	avgTokensPerRequest    float64
 
 	// Time-based metrics
-	requestsPerHour map[int]int64  // hour -> count
+	requestsPerHour map[int]int64    // hour -> count
 	requestsPerDay  map[string]int64 // date -> count
 
 	// Address metrics
-	uniqueAddresses    map[string]bool
-	topRecipients      map[string]int64  // address -> request count
+	uniqueAddresses map[string]bool
+	topRecipients   map[string]int64 // address -> request count
 
 	// IP metrics
 	uniqueIPs         map[string]bool
 	requestsByCountry map[string]int64
 
-	// Performance metrics
-	avgResponseTime   time.Duration
-	responseTimes     []time.Duration
-	maxResponseTime   time.Duration
+	// Performance metrics; responseTimeSum is the sum of responseTimes
+	avgResponseTime time.Duration
+	responseTimes   []time.Duration
+	responseTimeSum time.Duration
+	maxResponseTime time.Duration
 
 	// Error metrics
-	errorCounts       map[string]int64  // error type -> count
+	errorCounts map[string]int64 // error type -> count
 
 	// Start time
 	startTime time.Time
@@ -45,15 +61,15 @@ type MetricsTracker struct {
 
 // RequestMetrics contains metrics for a single request
 type RequestMetrics struct {
-	IP              string
-	Address         string
-	Amount          int64
-	Success         bool
-	ErrorType       string
-	ResponseTime    time.Duration
-	Timestamp       time.Time
-	CaptchaSolved   bool
-	POWCompleted    bool
+	IP            string
+	Address       string
+	Amount        int64
+	Success       bool
+	ErrorType     string
+	ResponseTime  time.Duration
+	Timestamp     time.Time
+	CaptchaSolved bool
+	POWCompleted  bool
 }
 
 // Summary contains a summary of all metrics
@@ -107,8 +123,10 @@ func (m *MetricsTracker) RecordRequest(metrics RequestMetrics) {
 	if metrics.Success {
 		m.successfulRequests++
 		m.totalTokensDistributed += metrics.Amount
-		m.uniqueAddresses[metrics.Address] = true
-		m.topRecipients[metrics.Address]++
+		addToSet(m.uniqueAddresses, metrics.Address)
+		if _, ok := m.topRecipients[metrics.Address]; ok || len(m.topRecipients) < maxTrackedKeys {
+			m.topRecipients[metrics.Address]++
+		}
 	} else {
 		m.failedRequests++
 		if metrics.ErrorType != "" {
@@ -117,7 +135,7 @@ func (m *MetricsTracker) RecordRequest(metrics RequestMetrics) {
 	}
 
 	// Track IP
-	m.uniqueIPs[metrics.IP] = true
+	addToSet(m.uniqueIPs, metrics.IP)
 
 	// Track time-based metrics
 	hour := metrics.Timestamp.Hour()
@@ -128,23 +146,20 @@ func (m *MetricsTracker) RecordRequest(metrics RequestMetrics) {
 
 	// Track response time
 	m.responseTimes = append(m.responseTimes, metrics.ResponseTime)
+	m.responseTimeSum += metrics.ResponseTime
 	if metrics.ResponseTime > m.maxResponseTime {
 		m.maxResponseTime = metrics.ResponseTime
 	}
 
-	// Calculate average response time
-	if len(m.responseTimes) > 0 {
-		var total time.Duration
-		for _, rt := range m.responseTimes {
-			total += rt
-		}
-		m.avgResponseTime = total / time.Duration(len(m.responseTimes))
-	}
-
 	// Keep response times array manageable
-	if len(m.responseTimes) > 10000 {
-		m.responseTimes = m.responseTimes[len(m.responseTimes)-1000:]
+	if len(m.responseTimes) > maxResponseSamples {
+		dropped := m.responseTimes[:len(m.responseTimes)-minResponseSamples]
+		for _, rt := range dropped {
+			m.responseTimeSum -= rt
+		}
+		m.responseTimes = append(make([]time.Duration, 0, minResponseSamples), m.responseTimes[len(dropped):]...)
 	}
+	m.avgResponseTime = m.responseTimeSum / time.Duration(len(m.responseTimes))
 
 	// Update average tokens per request
 	if m.successfulRequests > 0 {
@@ -158,7 +173,7 @@ func (m *MetricsTracker) RecordBlocked(ip string) {
 	defer m.mu.Unlock()
 
 	m.blockedRequests++
-	m.uniqueIPs[ip] = true
+	addToSet(m.uniqueIPs, ip)
 }
 
 // GetSummary returns a summary of all metrics
@@ -166,6 +181,22 @@ func (m *MetricsTracker) GetSummary() Summary {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	return m.summary()
+}
+
+// EndDay returns the summary of the reporting day that just ended and
+// resets the counters, so each report covers only its own day
+func (m *MetricsTracker) EndDay() Summary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	summary := m.summary()
+	m.reset()
+	return summary
+}
+
+// summary builds a Summary; callers must hold m.mu
+func (m *MetricsTracker) summary() Summary {
 	uptime := time.Since(m.startTime).Hours()
 	requestsPerHour := float64(0)
 	if uptime > 0 {
@@ -186,14 +217,10 @@ func (m *MetricsTracker) GetSummary() Summary {
 		})
 	}
 
-	// Sort by count (simple bubble sort for small dataset)
-	for i := 0; i < len(topRecipients); i++ {
-		for j := i + 1; j < len(topRecipients); j++ {
-			if topRecipients[j].RequestCount > topRecipients[i].RequestCount {
-				topRecipients[i], topRecipients[j] = topRecipients[j], topRecipients[i]
-			}
-		}
-	}
+	// Sort by count
+	sort.Slice(topRecipients, func(i, j int) bool {
+		return topRecipients[i].RequestCount > topRecipients[j].RequestCount
+	})
 
 	// Keep top 10
 	if len(topRecipients) > 10 {
@@ -265,13 +292,9 @@ func (m *MetricsTracker) GetTopRecipients(limit int) []RecipientStat {
 	}
 
 	// Sort by count
-	for i := 0; i < len(recipients); i++ {
-		for j := i + 1; j < len(recipients); j++ {
-			if recipients[j].RequestCount > recipients[i].RequestCount {
-				recipients[i], recipients[j] = recipients[j], recipients[i]
-			}
-		}
-	}
+	sort.Slice(recipients, func(i, j int) bool {
+		return recipients[i].RequestCount > recipients[j].RequestCount
+	})
 
 	// Apply limit
 	if len(recipients) > limit {
@@ -304,6 +327,11 @@ func (m *MetricsTracker) Reset() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.reset()
+}
+
+// reset clears every counter; callers must hold m.mu
+func (m *MetricsTracker) reset() {
 	m.totalRequests = 0
 	m.successfulRequests = 0
 	m.failedRequests = 0
@@ -317,6 +345,7 @@ func (m *MetricsTracker) Reset() {
 	m.uniqueIPs = make(map[string]bool)
 	m.requestsByCountry = make(map[string]int64)
 	m.responseTimes = make([]time.Duration, 0, 1000)
+	m.responseTimeSum = 0
 	m.avgResponseTime = 0
 	m.maxResponseTime = 0
 	m.errorCounts = make(map[string]int64)
@@ -325,6 +354,22 @@ func (m *MetricsTracker) Reset() {
 
 // Helper methods
 
+// hashKey is how IPs and addresses are kept in the unique sets, which
+// only need their size; raw client IPs never reach a snapshot
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
+
+// addToSet adds key's hash to set unless the set is full
+func addToSet(set map[string]bool, key string) {
+	key = hashKey(key)
+	if set[key] || len(set) >= maxTrackedKeys {
+		return
+	}
+	set[key] = true
+}
+
 func (m *MetricsTracker) copyErrorCounts() map[string]int64 {
 	counts := make(map[string]int64)
 	for errType, count := range m.errorCounts {
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -45,3 +46,39 @@ func TestRecordRequestAndSummary(t *testing.T) {
 	assert.Equal(t, "aura1first", summary.TopRecipients[0].Address)
 	assert.Contains(t, summary.ErrorBreakdown, "captcha_failed")
 }
+
+func TestTrackerBoundsSetsAndSamples(t *testing.T) {
+	tracker := NewMetricsTracker()
+
+	for i := 0; i < maxTrackedKeys+10; i++ {
+		tracker.RecordRequest(RequestMetrics{
+			IP:           fmt.Sprintf("ip-%d", i),
+			Address:      fmt.Sprintf("aura1addr%d", i),
+			Success:      true,
+			ResponseTime: time.Duration(i%2+1) * time.Millisecond,
+			Timestamp:    time.Now(),
+		})
+	}
+
+	summary := tracker.GetSummary()
+	assert.Equal(t, int64(maxTrackedKeys+10), summary.TotalRequests)
+	assert.Equal(t, maxTrackedKeys, summary.UniqueIPs)
+	assert.Equal(t, maxTrackedKeys, summary.UniqueAddresses)
+	assert.LessOrEqual(t, tracker.GetPerformanceStats()["total_samples"], maxResponseSamples)
+	assert.InDelta(t, 1.5, float64(summary.AvgResponseTime)/float64(time.Millisecond), 0.01)
+}
+
+func TestEndDayResetsCounters(t *testing.T) {
+	tracker := NewMetricsTracker()
+	tracker.RecordRequest(RequestMetrics{IP: "192.0.2.1", Address: "aura1a", Success: true, Timestamp: time.Now()})
+	tracker.RecordBlocked("192.0.2.2")
+
+	day := tracker.EndDay()
+	assert.Equal(t, int64(1), day.TotalRequests)
+	assert.Equal(t, int64(1), day.BlockedRequests)
+	assert.Equal(t, 2, day.UniqueIPs)
+
+	next := tracker.GetSummary()
+	assert.Zero(t, next.TotalRequests)
+	assert.Zero(t, next.UniqueIPs)
+}
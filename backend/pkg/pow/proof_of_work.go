@@ -8,13 +8,24 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/aura-chain/aura/faucet/pkg/challenge"
 )
 
 // ProofOfWork manages proof-of-work challenges
 type ProofOfWork struct {
-	challenges map[string]*Challenge
+	challenges challenge.Store[*Challenge]
 	mu         sync.RWMutex
 	difficulty int // Number of leading zeros required
+
+	// minSolveTime rejects solutions submitted sooner than this after the
+	// challenge was issued; fast enough solvers are likely automated
+	minSolveTime time.Duration
+
+	// maxSolutionAge rejects solutions to challenges issued longer ago,
+	// however long the store keeps them
+	maxSolutionAge time.Duration
+	now            func() time.Time
 }
 
 // Challenge represents a PoW challenge
@@ -23,6 +34,7 @@ type Challenge struct {
 	Nonce      string
 	Difficulty int
 	CreatedAt  time.Time
+	NotBefore  time.Time // Solutions submitted earlier are rejected
 	ExpiresAt  time.Time
 	Solution   string // Stored for validation
 }
@@ -33,82 +45,89 @@ func NewProofOfWork(difficulty int) *ProofOfWork {
 		difficulty = 4 // Default: 4 leading zeros
 	}
 
-	pow := &ProofOfWork{
-		challenges: make(map[string]*Challenge),
+	return &ProofOfWork{
+		challenges: challenge.NewMemoryStore[*Challenge](0),
 		difficulty: difficulty,
+		now:        time.Now,
 	}
+}
 
-	// Start cleanup goroutine
-	go pow.cleanup()
-
-	return pow
+// Close stops the expired challenge sweep
+func (p *ProofOfWork) Close() {
+	p.challenges.Close()
 }
 
-// cleanup removes expired challenges
-func (p *ProofOfWork) cleanup() {
-	ticker := time.NewTicker(5 * time.Minute)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		p.mu.Lock()
-		now := time.Now()
-		for id, challenge := range p.challenges {
-			if now.After(challenge.ExpiresAt) {
-				delete(p.challenges, id)
-			}
-		}
-		p.mu.Unlock()
-	}
+// SetLimits caps unclaimed challenges per client and in total
+func (p *ProofOfWork) SetLimits(limits challenge.Limits) {
+	p.challenges.SetLimits(limits)
 }
 
 // GenerateChallenge creates a new PoW challenge
 func (p *ProofOfWork) GenerateChallenge() (*Challenge, error) {
+	return p.GenerateChallengeFor("")
+}
+
+// GenerateChallengeFor creates a new PoW challenge on behalf of client
+// (typically its IP). It returns challenge.ErrTooManyOutstanding when the
+// client or the service holds too many unclaimed challenges.
+func (p *ProofOfWork) GenerateChallengeFor(client string) (*Challenge, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	// Generate random nonce
 	nonce := generateNonce()
 
+	now := p.now()
 	challenge := &Challenge{
 		ID:         generateChallengeID(),
 		Nonce:      nonce,
 		Difficulty: p.difficulty,
-		CreatedAt:  time.Now(),
-		ExpiresAt:  time.Now().Add(10 * time.Minute),
+		CreatedAt:  now,
+		NotBefore:  now.Add(p.minSolveTime),
+		ExpiresAt:  now.Add(10 * time.Minute),
 	}
 
-	p.challenges[challenge.ID] = challenge
+	if err := p.challenges.PutFor(client, challenge.ID, challenge, challenge.ExpiresAt); err != nil {
+		return nil, err
+	}
 
 	return challenge, nil
 }
 
 // Verify checks if a solution is valid
 func (p *ProofOfWork) Verify(challengeID, solution string) (bool, error) {
-	p.mu.RLock()
-	challenge, exists := p.challenges[challengeID]
-	p.mu.RUnlock()
-
+	issued, exists := p.challenges.Get(challengeID)
 	if !exists {
 		return false, fmt.Errorf("challenge not found")
 	}
 
 	// Check expiration
-	if time.Now().After(challenge.ExpiresAt) {
-		p.mu.Lock()
-		delete(p.challenges, challengeID)
-		p.mu.Unlock()
+	p.mu.RLock()
+	maxAge := p.maxSolutionAge
+	p.mu.RUnlock()
+	now := p.now()
+	if now.After(issued.ExpiresAt) || challenge.TooOld(issued.CreatedAt, maxAge, now) {
+		p.challenges.Delete(challengeID)
 		return false, fmt.Errorf("challenge expired")
 	}
 
 	// Verify the solution
-	hash := computeHash(challenge.Nonce, solution)
-	valid := verifyHash(hash, challenge.Difficulty)
+	hash := computeHash(issued.Nonce, solution)
+	valid := verifyHash(hash, issued.Difficulty)
+
+	// A correct solution that arrives too fast burns the challenge, so the
+	// client has to solve a fresh one
+	if valid && now.Before(issued.NotBefore) {
+		p.challenges.Delete(challengeID)
+		return false, fmt.Errorf("solution submitted too quickly")
+	}
 
+	// Remove the challenge after successful verification; if a concurrent
+	// request redeemed it first, this one loses
 	if valid {
-		// Remove challenge after successful verification
-		p.mu.Lock()
-		delete(p.challenges, challengeID)
-		p.mu.Unlock()
+		if _, ok := p.challenges.Take(challengeID); !ok {
+			return false, fmt.Errorf("challenge not found")
+		}
 	}
 
 	return valid, nil
@@ -116,10 +135,7 @@ func (p *ProofOfWork) Verify(challengeID, solution string) (bool, error) {
 
 // GetChallenge retrieves challenge info (without solution)
 func (p *ProofOfWork) GetChallenge(challengeID string) (*Challenge, error) {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-
-	challenge, exists := p.challenges[challengeID]
+	challenge, exists := p.challenges.Get(challengeID)
 	if !exists {
 		return nil, fmt.Errorf("challenge not found")
 	}
@@ -134,13 +150,29 @@ func (p *ProofOfWork) SetDifficulty(difficulty int) {
 	p.difficulty = difficulty
 }
 
+// SetMinSolveTime sets how long after issue a challenge's solution is
+// accepted. It applies to challenges generated afterwards.
+func (p *ProofOfWork) SetMinSolveTime(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.minSolveTime = d
+}
+
+// SetMaxSolutionAge sets how long after issue a solution is still
+// accepted, independent of store expiry; zero disables the check
+func (p *ProofOfWork) SetMaxSolutionAge(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.maxSolutionAge = d
+}
+
 // GetStats returns statistics about active challenges
 func (p *ProofOfWork) GetStats() map[string]interface{} {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
 	return map[string]interface{}{
-		"active_challenges": len(p.challenges),
+		"active_challenges": p.challenges.Len(),
 		"difficulty":        p.difficulty,
 	}
 }
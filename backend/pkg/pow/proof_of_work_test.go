@@ -42,6 +42,43 @@ func TestVerifyRejectsExpiredChallenge(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestVerifyRejectsSolutionBeforeMinSolveTime(t *testing.T) {
+	p := NewProofOfWork(1)
+	p.SetMinSolveTime(time.Minute)
+
+	ch, err := p.GenerateChallenge()
+	require.NoError(t, err)
+	solution, err := SolveChallenge(ch.Nonce, ch.Difficulty)
+	require.NoError(t, err)
+
+	valid, err := p.Verify(ch.ID, solution)
+	assert.False(t, valid)
+	assert.EqualError(t, err, "solution submitted too quickly")
+
+	// The challenge is burned, so waiting and resubmitting does not help
+	_, err = p.GetChallenge(ch.ID)
+	assert.Error(t, err)
+}
+
+func TestVerifyAcceptsSolutionAfterMinSolveTime(t *testing.T) {
+	p := NewProofOfWork(1)
+	p.SetMinSolveTime(time.Minute)
+
+	ch, err := p.GenerateChallenge()
+	require.NoError(t, err)
+	solution, err := SolveChallenge(ch.Nonce, ch.Difficulty)
+	require.NoError(t, err)
+
+	// Pretend the floor has passed
+	p.mu.Lock()
+	ch.NotBefore = time.Now().Add(-time.Second)
+	p.mu.Unlock()
+
+	valid, err := p.Verify(ch.ID, solution)
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
 func TestAdaptiveDifficultyAdjusts(t *testing.T) {
 	p := NewProofOfWork(3)
 	ad := NewAdaptiveDifficulty(p, 3)
@@ -52,3 +89,49 @@ func TestAdaptiveDifficultyAdjusts(t *testing.T) {
 	ad.UpdateLoad(10) // low load
 	assert.LessOrEqual(t, ad.GetCurrentDifficulty(), 3)
 }
+
+func TestVerifyRedeemsSolutionOnce(t *testing.T) {
+	p := NewProofOfWork(1)
+	defer p.Close()
+
+	ch, err := p.GenerateChallenge()
+	require.NoError(t, err)
+	solution, err := SolveChallenge(ch.Nonce, ch.Difficulty)
+	require.NoError(t, err)
+
+	valid, err := p.Verify(ch.ID, solution)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = p.Verify(ch.ID, solution)
+	assert.False(t, valid)
+	assert.Error(t, err)
+}
+
+func TestVerifyRejectsSolutionPastMaxAge(t *testing.T) {
+	for _, tc := range []struct {
+		age   time.Duration
+		valid bool
+	}{
+		{age: 59 * time.Second, valid: true},
+		{age: 61 * time.Second, valid: false},
+	} {
+		p := NewProofOfWork(1)
+		p.SetMaxSolutionAge(time.Minute)
+		ch, err := p.GenerateChallenge()
+		require.NoError(t, err)
+		solution, err := SolveChallenge(ch.Nonce, ch.Difficulty)
+		require.NoError(t, err)
+
+		// The challenge is still well within its store expiry
+		issued := ch.CreatedAt
+		p.now = func() time.Time { return issued.Add(tc.age) }
+
+		valid, err := p.Verify(ch.ID, solution)
+		assert.Equal(t, tc.valid, valid, "solved %s after issue", tc.age)
+		if !tc.valid {
+			assert.EqualError(t, err, "challenge expired")
+		}
+		p.Close()
+	}
+}
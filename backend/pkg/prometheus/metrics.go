@@ -1,12 +1,18 @@
 package prometheus
 
 import (
+	"fmt"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
 const namespace = "faucet"
 
+// DefaultRequestDurationBuckets resolve the millisecond-scale rejections at
+// the low end and keep the multi-second buckets for slow broadcasts
+var DefaultRequestDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.5, 1, 2, 5, 10, 30}
+
 var (
 	// Request counters
 	RequestsTotal = promauto.NewCounterVec(
@@ -63,6 +69,81 @@ var (
 		[]string{"reason"},
 	)
 
+	// ValidationRejections counts requests turned away before a payout is
+	// attempted, by the specific check that rejected them
+	ValidationRejections = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "validation_rejections_total",
+			Help:      "Token requests rejected before payout by reason",
+		},
+		[]string{"reason"},
+	)
+
+	// EventsPublished counts message bus events by result: published,
+	// failed or dropped because the queue was full
+	EventsPublished = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "events_published_total",
+			Help:      "Faucet events sent to the message bus by result",
+		},
+		[]string{"result"},
+	)
+
+	// Refills counts treasury auto-refill attempts by result: success,
+	// failed or rate_limited
+	Refills = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "refills_total",
+			Help:      "Faucet wallet auto-refills from the treasury by result",
+		},
+		[]string{"result"},
+	)
+
+	BroadcastTimeouts = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "broadcast_timeouts_total",
+			Help:      "CLI broadcasts killed after exceeding the timeout",
+		},
+	)
+
+	// PostPayoutCommands counts POST_PAYOUT_COMMAND runs by result:
+	// success, failed, or dropped when the queue was full
+	PostPayoutCommands = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "post_payout_commands_total",
+			Help:      "Post-payout command runs by result",
+		},
+		[]string{"result"},
+	)
+
+	// CampaignClosedRequests counts token requests refused outside every
+	// campaign window, by reason: not_open or ended
+	CampaignClosedRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "campaign_closed_requests_total",
+			Help:      "Token requests refused because no campaign is open",
+		},
+		[]string{"reason"},
+	)
+
+	// BalanceCheckFailOpen counts requests let through by
+	// BALANCE_CHECK_FAIL_POLICY=open, by the recipient check that failed:
+	// balance or account_type
+	BalanceCheckFailOpen = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "balance_check_fail_open_total",
+			Help:      "Requests allowed through after a recipient balance or account type query failed",
+		},
+		[]string{"check"},
+	)
+
 	// Operational gauges
 	WalletBalance = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -73,6 +154,14 @@ var (
 		[]string{"denom"},
 	)
 
+	CLIProcessesActive = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "cli_processes_active",
+			Help:      "Chain binary processes currently running",
+		},
+	)
+
 	NodeConnected = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: namespace,
@@ -92,14 +181,7 @@ var (
 	)
 
 	// Histograms
-	RequestDuration = promauto.NewHistogram(
-		prometheus.HistogramOpts{
-			Namespace: namespace,
-			Name:      "request_duration_seconds",
-			Help:      "Request processing duration in seconds",
-			Buckets:   []float64{0.1, 0.5, 1, 2, 5, 10, 30},
-		},
-	)
+	RequestDuration = promauto.NewHistogram(requestDurationOpts(DefaultRequestDurationBuckets))
 
 	TxConfirmationTime = promauto.NewHistogram(
 		prometheus.HistogramOpts{
@@ -110,6 +192,26 @@ var (
 		},
 	)
 
+	// BroadcastQueueWait and BroadcastDuration split a broadcast's latency
+	// into waiting for a CLI slot (contention) and the CLI run (the node)
+	BroadcastQueueWait = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "broadcast_queue_wait_seconds",
+			Help:      "Time a broadcast waited for a free CLI slot in seconds",
+			Buckets:   []float64{0.001, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30},
+		},
+	)
+
+	BroadcastDuration = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "broadcast_duration_seconds",
+			Help:      "Time spent running a broadcast once dequeued in seconds",
+			Buckets:   []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60},
+		},
+	)
+
 	// Info gauge
 	Info = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -121,12 +223,36 @@ var (
 	)
 )
 
+func requestDurationOpts(buckets []float64) prometheus.HistogramOpts {
+	return prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "request_duration_seconds",
+		Help:      "Request processing duration in seconds",
+		Buckets:   buckets,
+	}
+}
+
+// SetRequestDurationBuckets replaces the request duration histogram with one
+// using buckets. Call it during startup, before requests are served.
+func SetRequestDurationBuckets(buckets []float64) error {
+	histogram := prometheus.NewHistogram(requestDurationOpts(buckets))
+
+	prometheus.Unregister(RequestDuration)
+	if err := prometheus.Register(histogram); err != nil {
+		prometheus.MustRegister(RequestDuration)
+		return fmt.Errorf("failed to register request duration histogram: %w", err)
+	}
+
+	RequestDuration = histogram
+	return nil
+}
+
 // RecordRequest records a faucet request with timing
-func RecordRequest(status, denom string, amount int64, duration float64) {
+func RecordRequest(status, denom string, amount float64, duration float64) {
 	RequestsTotal.WithLabelValues(status, denom).Inc()
 	RequestDuration.Observe(duration)
 	if status == "success" {
-		TokensDistributed.WithLabelValues(denom).Add(float64(amount))
+		TokensDistributed.WithLabelValues(denom).Add(amount)
 	}
 }
 
@@ -0,0 +1,47 @@
+package prometheus
+
+import (
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bucketCounts returns the cumulative count per upper bound of RequestDuration
+func bucketCounts(t *testing.T) map[float64]uint64 {
+	var metric dto.Metric
+	require.NoError(t, RequestDuration.Write(&metric))
+
+	counts := make(map[float64]uint64)
+	for _, bucket := range metric.GetHistogram().GetBucket() {
+		counts[bucket.GetUpperBound()] = bucket.GetCumulativeCount()
+	}
+	return counts
+}
+
+func TestFastRequestsLandInLowBuckets(t *testing.T) {
+	require.NoError(t, SetRequestDurationBuckets(DefaultRequestDurationBuckets))
+
+	RecordRequest("failed", "uaura", 0, 0.003)
+	RecordRequest("failed", "uaura", 0, 0.02)
+	RecordRequest("success", "uaura", 1, 4)
+
+	counts := bucketCounts(t)
+	assert.Equal(t, uint64(1), counts[0.005])
+	assert.Equal(t, uint64(1), counts[0.01])
+	assert.Equal(t, uint64(2), counts[0.025])
+	assert.Equal(t, uint64(2), counts[2])
+	assert.Equal(t, uint64(3), counts[5])
+}
+
+func TestSetRequestDurationBuckets(t *testing.T) {
+	require.NoError(t, SetRequestDurationBuckets([]float64{0.001, 1}))
+	defer func() { require.NoError(t, SetRequestDurationBuckets(DefaultRequestDurationBuckets)) }()
+
+	RecordRequest("failed", "uaura", 0, 0.0005)
+
+	counts := bucketCounts(t)
+	assert.Len(t, counts, 2)
+	assert.Equal(t, uint64(1), counts[0.001])
+}
@@ -0,0 +1,183 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Rate limit algorithms
+const (
+	// AlgorithmFixed counts requests in a fixed window per key
+	AlgorithmFixed = "fixed"
+	// AlgorithmTokenBucket allows a burst of requests, then a steady rate
+	AlgorithmTokenBucket = "token_bucket"
+)
+
+// bucketPrefix keeps token buckets apart from fixed-window counters so
+// switching algorithms never reads a counter as a bucket
+const bucketPrefix = "ratelimit:bucket:"
+
+// bucketScript refills a token bucket for the time since it was last
+// touched, optionally takes tokens, and returns whether it took them and
+// the tokens left. A negative take returns tokens, up to the burst. In
+// reserve mode it takes nothing unless all the tokens are there, so a
+// check and its take are one step. A missing bucket is full. Buckets
+// expire once they would have refilled completely.
+//
+// KEYS[1] bucket; ARGV: rate (tokens/ms), burst, now (ms), tokens to take,
+// reserve ("1" or "0")
+var bucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local take = tonumber(ARGV[4])
+local reserve = ARGV[5] == "1"
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) * rate)
+	ts = now
+end
+
+if reserve and tokens < take then
+	return {0, tostring(tokens)}
+end
+if take ~= 0 then
+	tokens = math.min(burst, math.max(tokens - take, 0))
+	redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(ts))
+	redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) / rate) + 1)
+end
+return {1, tostring(tokens)}
+`)
+
+// bucketRate is the steady-state refill of a bucket allowing limit requests
+// per window, in tokens per millisecond
+func bucketRate(limit int, window time.Duration) float64 {
+	return float64(limit) / float64(window.Milliseconds())
+}
+
+// bucketTokens runs bucketScript on key and returns the tokens left
+func (rl *RateLimiter) bucketTokens(ctx context.Context, key string, limit, burst, take int) (float64, error) {
+	tokens, _, err := rl.runBucket(ctx, key, limit, burst, take, false)
+	return tokens, err
+}
+
+// runBucket runs bucketScript on key and returns the tokens left and
+// whether it took the tokens asked for
+func (rl *RateLimiter) runBucket(ctx context.Context, key string, limit, burst, take int, reserve bool) (float64, bool, error) {
+	// A zero limit or burst never refills, like a zero fixed-window limit
+	if limit <= 0 || burst <= 0 {
+		return 0, false, nil
+	}
+	rate := bucketRate(limit, rl.window)
+	now := rl.clock().UnixMilli()
+	mode := "0"
+	if reserve {
+		mode = "1"
+	}
+	result, err := bucketScript.Run(ctx, rl.client, []string{key}, rate, burst, now, take, mode).Slice()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to update token bucket: %w", err)
+	}
+	if len(result) != 2 {
+		return 0, false, fmt.Errorf("invalid token bucket result %v", result)
+	}
+	taken, _ := result[0].(int64)
+	state, _ := result[1].(string)
+	tokens, err := strconv.ParseFloat(state, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid token bucket state %q: %w", state, err)
+	}
+	return tokens, taken == 1, nil
+}
+
+// bucketLimits returns the limit and burst for a fixed-window key such as
+// "ratelimit:ip:1.2.3.4", scaling IP limits by country
+func (rl *RateLimiter) bucketLimits(key string) (limit, burst int) {
+	if ip, ok := strings.CutPrefix(key, "ratelimit:ip:"); ok {
+		return rl.countries.IPLimit(rl.perIP, ip), rl.countries.IPLimit(rl.burstIP, ip)
+	}
+	return rl.perAddress, rl.burstAddress
+}
+
+// checkBucket reports whether key's bucket has less than one token
+func (rl *RateLimiter) checkBucket(ctx context.Context, key string) (bool, error) {
+	limit, burst := rl.bucketLimits(key)
+	tokens, err := rl.bucketTokens(ctx, bucketPrefix+strings.TrimPrefix(key, "ratelimit:"), limit, burst, 0)
+	if err != nil {
+		return false, err
+	}
+	return tokens < 1, nil
+}
+
+// takeBucket takes a token from key's bucket
+func (rl *RateLimiter) takeBucket(ctx context.Context, key string) error {
+	limit, burst := rl.bucketLimits(key)
+	_, err := rl.bucketTokens(ctx, bucketPrefix+strings.TrimPrefix(key, "ratelimit:"), limit, burst, 1)
+	return err
+}
+
+// reserveBucket takes a token from key's bucket if it has one, reporting
+// whether the bucket was empty
+func (rl *RateLimiter) reserveBucket(ctx context.Context, key string) (bool, error) {
+	limit, burst := rl.bucketLimits(key)
+	_, taken, err := rl.runBucket(ctx, bucketPrefix+strings.TrimPrefix(key, "ratelimit:"), limit, burst, 1, true)
+	if err != nil {
+		return false, err
+	}
+	return !taken, nil
+}
+
+// refundBucket returns a reserved token to key's bucket
+func (rl *RateLimiter) refundBucket(ctx context.Context, key string) error {
+	limit, burst := rl.bucketLimits(key)
+	_, _, err := rl.runBucket(ctx, bucketPrefix+strings.TrimPrefix(key, "ratelimit:"), limit, burst, -1, false)
+	return err
+}
+
+// bucketRemaining returns the whole tokens left in key's bucket
+func (rl *RateLimiter) bucketRemaining(ctx context.Context, key string) (int, error) {
+	limit, burst := rl.bucketLimits(key)
+	tokens, err := rl.bucketTokens(ctx, bucketPrefix+strings.TrimPrefix(key, "ratelimit:"), limit, burst, 0)
+	if err != nil {
+		return 0, err
+	}
+	return int(tokens), nil
+}
+
+// bucketWait returns how long until key's bucket holds a token again
+func (rl *RateLimiter) bucketWait(ctx context.Context, key string) (time.Duration, error) {
+	limit, burst := rl.bucketLimits(key)
+	tokens, err := rl.bucketTokens(ctx, bucketPrefix+strings.TrimPrefix(key, "ratelimit:"), limit, burst, 0)
+	if err != nil {
+		return 0, err
+	}
+	if tokens >= 1 {
+		return 0, nil
+	}
+	if limit <= 0 {
+		return rl.window, nil
+	}
+	ms := math.Ceil((1 - tokens) / bucketRate(limit, rl.window))
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// clock returns the current time, overridable in tests
+func (rl *RateLimiter) clock() time.Time {
+	if rl.now != nil {
+		return rl.now()
+	}
+	return time.Now()
+}
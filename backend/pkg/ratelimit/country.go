@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"math"
+	"strings"
+)
+
+// CountryResolver looks up the country of an IP; "" means unknown
+type CountryResolver interface {
+	Country(ip string) string
+}
+
+// CountryLimits scales the per-IP limit by a multiplier for the IP's
+// country. Countries without a multiplier, and IPs that can't be
+// resolved, keep the base limit.
+type CountryLimits struct {
+	resolver    CountryResolver
+	multipliers map[string]float64
+}
+
+// NewCountryLimits creates per-country limits keyed by ISO country code
+func NewCountryLimits(resolver CountryResolver, multipliers map[string]float64) *CountryLimits {
+	normalized := make(map[string]float64, len(multipliers))
+	for country, multiplier := range multipliers {
+		normalized[strings.ToUpper(country)] = multiplier
+	}
+	return &CountryLimits{resolver: resolver, multipliers: normalized}
+}
+
+// IPLimit returns the effective per-IP limit for ip. A nil CountryLimits
+// returns base unchanged. Scaled limits never drop below one request.
+func (c *CountryLimits) IPLimit(base int, ip string) int {
+	if c == nil || c.resolver == nil {
+		return base
+	}
+	multiplier, ok := c.multipliers[c.resolver.Country(ip)]
+	if !ok {
+		return base
+	}
+	limit := int(math.Round(float64(base) * multiplier))
+	if limit < 1 {
+		return 1
+	}
+	return limit
+}
@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CounterStore is the persistence the DB limiter needs; implemented by *database.DB
+type CounterStore interface {
+	CountRecentRequestsByIP(ipAddress string, since time.Time) (int, error)
+	CountRecentRequestsByAddress(address string, since time.Time) (int, error)
+	GetRateLimitCounter(key string) (int, time.Time, error)
+	IncrementRateLimitCounter(key string, expiresAt time.Time) error
+}
+
+// DBLimiter rate limits using Postgres instead of Redis. Counters live in
+// rate_limit_counters; the windowed count over faucet_requests acts as a
+// floor so limits still hold if the counter table is cleared.
+type DBLimiter struct {
+	store      CounterStore
+	perIP      int
+	perAddress int
+	window     time.Duration
+	now        func() time.Time
+	countries  *CountryLimits
+}
+
+// NewDBLimiter creates a database-backed rate limiter
+func NewDBLimiter(store CounterStore, config map[string]interface{}) *DBLimiter {
+	return &DBLimiter{
+		store:      store,
+		perIP:      config["per_ip"].(int),
+		perAddress: config["per_address"].(int),
+		window:     config["window"].(time.Duration),
+		now:        time.Now,
+	}
+}
+
+// SetCountryLimits scales the per-IP limit by the client's country
+func (rl *DBLimiter) SetCountryLimits(countries *CountryLimits) {
+	rl.countries = countries
+}
+
+// CheckIPLimit checks if an IP address has exceeded the rate limit
+func (rl *DBLimiter) CheckIPLimit(ctx context.Context, ip string) (bool, error) {
+	requests, err := rl.store.CountRecentRequestsByIP(ip, rl.now().Add(-rl.window))
+	if err != nil {
+		return false, err
+	}
+	return rl.checkLimit(fmt.Sprintf("ratelimit:ip:%s", ip), requests, rl.countries.IPLimit(rl.perIP, ip))
+}
+
+// CheckAddressLimit checks if an address has exceeded the rate limit
+func (rl *DBLimiter) CheckAddressLimit(ctx context.Context, address string) (bool, error) {
+	requests, err := rl.store.CountRecentRequestsByAddress(address, rl.now().Add(-rl.window))
+	if err != nil {
+		return false, err
+	}
+	return rl.checkLimit(fmt.Sprintf("ratelimit:address:%s", address), requests, rl.perAddress)
+}
+
+// IncrementIPCounter increments the counter for an IP address
+func (rl *DBLimiter) IncrementIPCounter(ctx context.Context, ip string) error {
+	return rl.store.IncrementRateLimitCounter(fmt.Sprintf("ratelimit:ip:%s", ip), rl.now().Add(rl.window))
+}
+
+// IncrementAddressCounter increments the counter for an address
+func (rl *DBLimiter) IncrementAddressCounter(ctx context.Context, address string) error {
+	return rl.store.IncrementRateLimitCounter(fmt.Sprintf("ratelimit:address:%s", address), rl.now().Add(rl.window))
+}
+
+// RemainingIPRequests returns how many more requests ip may make in the
+// current window, counting like CheckIPLimit
+func (rl *DBLimiter) RemainingIPRequests(ctx context.Context, ip string) (int, error) {
+	requests, err := rl.store.CountRecentRequestsByIP(ip, rl.now().Add(-rl.window))
+	if err != nil {
+		return 0, err
+	}
+	count, _, err := rl.store.GetRateLimitCounter(fmt.Sprintf("ratelimit:ip:%s", ip))
+	if err != nil {
+		return 0, err
+	}
+	return max(rl.countries.IPLimit(rl.perIP, ip)-max(count, requests), 0), nil
+}
+
+// GetCurrentCount gets the current count for a key
+func (rl *DBLimiter) GetCurrentCount(ctx context.Context, key string) (int, error) {
+	count, _, err := rl.store.GetRateLimitCounter(key)
+	return count, err
+}
+
+// GetRemainingTime returns the time until the rate limit resets
+func (rl *DBLimiter) GetRemainingTime(ctx context.Context, key string) (time.Duration, error) {
+	count, expiresAt, err := rl.store.GetRateLimitCounter(key)
+	if err != nil || count == 0 {
+		return 0, err
+	}
+
+	remaining := expiresAt.Sub(rl.now())
+	if remaining < 0 {
+		return 0, nil
+	}
+	return remaining, nil
+}
+
+// checkLimit compares the larger of the stored counter and the request
+// history count against limit
+func (rl *DBLimiter) checkLimit(key string, requests, limit int) (bool, error) {
+	count, _, err := rl.store.GetRateLimitCounter(key)
+	if err != nil {
+		return false, err
+	}
+	if requests > count {
+		count = requests
+	}
+	return count >= limit, nil
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// dedupPrefix namespaces burst deduplication keys
+const dedupPrefix = "dedup:"
+
+// dedupPending marks a claimed key whose request is still in flight
+const dedupPending = "pending"
+
+// dedupPendingTTL bounds how long a claim survives if its request never
+// completes, e.g. after a crash mid-broadcast
+const dedupPendingTTL = 2 * time.Minute
+
+// dedupPollInterval is how often a duplicate checks for the first result
+const dedupPollInterval = 100 * time.Millisecond
+
+// Deduper collapses rapid repeats of the same request, such as a double
+// click. The first request claims the key with SETNX; repeats wait for its
+// result, which is kept for the burst window after it completes.
+type Deduper struct {
+	client *redis.Client
+	window time.Duration
+	poll   time.Duration
+}
+
+// NewDeduper creates a deduper that replays a completed result to repeats
+// arriving within window
+func NewDeduper(client *redis.Client, window time.Duration) *Deduper {
+	return &Deduper{client: client, window: window, poll: dedupPollInterval}
+}
+
+// Claim reports whether the caller is the first request for key; false
+// means another request holds it and the caller should Wait
+func (d *Deduper) Claim(ctx context.Context, key string) (bool, error) {
+	claimed, err := d.client.SetNX(ctx, dedupPrefix+key, dedupPending, dedupPendingTTL).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim dedup key: %w", err)
+	}
+	return claimed, nil
+}
+
+// Complete stores the claimed request's result for repeats arriving within
+// the burst window
+func (d *Deduper) Complete(ctx context.Context, key string, result []byte) error {
+	if err := d.client.Set(ctx, dedupPrefix+key, result, d.window).Err(); err != nil {
+		return fmt.Errorf("failed to store dedup result: %w", err)
+	}
+	return nil
+}
+
+// Release drops the claim on key without storing a result, so the next
+// request for it is handled afresh
+func (d *Deduper) Release(ctx context.Context, key string) error {
+	if err := d.client.Del(ctx, dedupPrefix+key).Err(); err != nil {
+		return fmt.Errorf("failed to release dedup key: %w", err)
+	}
+	return nil
+}
+
+// Wait blocks until the request holding key completes and returns its
+// result. It returns nil when the claim disappears without a result.
+func (d *Deduper) Wait(ctx context.Context, key string) ([]byte, error) {
+	ticker := time.NewTicker(d.poll)
+	defer ticker.Stop()
+
+	for {
+		result, err := d.client.Get(ctx, dedupPrefix+key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dedup result: %w", err)
+		}
+		if string(result) != dedupPending {
+			return result, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type memoryCounter struct {
+	count     int
+	expiresAt time.Time
+}
+
+// MemoryLimiter keeps rate limit counters in process memory. Counters are
+// lost on restart and not shared between replicas, so it suits single
+// instance deployments and local development. Expired counters are swept
+// at most once per window as new ones are added, so keys that never come
+// back don't accumulate.
+type MemoryLimiter struct {
+	mu         sync.Mutex
+	counters   map[string]*memoryCounter
+	nextSweep  time.Time
+	perIP      int
+	perAddress int
+	window     time.Duration
+	now        func() time.Time
+	countries  *CountryLimits
+}
+
+// NewMemoryLimiter creates an in-memory rate limiter
+func NewMemoryLimiter(config map[string]interface{}) *MemoryLimiter {
+	return &MemoryLimiter{
+		counters:   make(map[string]*memoryCounter),
+		perIP:      config["per_ip"].(int),
+		perAddress: config["per_address"].(int),
+		window:     config["window"].(time.Duration),
+		now:        time.Now,
+	}
+}
+
+// SetCountryLimits scales the per-IP limit by the client's country
+func (rl *MemoryLimiter) SetCountryLimits(countries *CountryLimits) {
+	rl.countries = countries
+}
+
+// CheckIPLimit checks if an IP address has exceeded the rate limit
+func (rl *MemoryLimiter) CheckIPLimit(ctx context.Context, ip string) (bool, error) {
+	count, _ := rl.GetCurrentCount(ctx, fmt.Sprintf("ratelimit:ip:%s", ip))
+	return count >= rl.countries.IPLimit(rl.perIP, ip), nil
+}
+
+// CheckAddressLimit checks if an address has exceeded the rate limit
+func (rl *MemoryLimiter) CheckAddressLimit(ctx context.Context, address string) (bool, error) {
+	count, _ := rl.GetCurrentCount(ctx, fmt.Sprintf("ratelimit:address:%s", address))
+	return count >= rl.perAddress, nil
+}
+
+// IncrementIPCounter increments the counter for an IP address
+func (rl *MemoryLimiter) IncrementIPCounter(ctx context.Context, ip string) error {
+	rl.increment(fmt.Sprintf("ratelimit:ip:%s", ip))
+	return nil
+}
+
+// IncrementAddressCounter increments the counter for an address
+func (rl *MemoryLimiter) IncrementAddressCounter(ctx context.Context, address string) error {
+	rl.increment(fmt.Sprintf("ratelimit:address:%s", address))
+	return nil
+}
+
+// RemainingIPRequests returns how many more requests ip may make in the
+// current window
+func (rl *MemoryLimiter) RemainingIPRequests(ctx context.Context, ip string) (int, error) {
+	count, _ := rl.GetCurrentCount(ctx, fmt.Sprintf("ratelimit:ip:%s", ip))
+	return max(rl.countries.IPLimit(rl.perIP, ip)-count, 0), nil
+}
+
+// GetCurrentCount gets the current count for a key
+func (rl *MemoryLimiter) GetCurrentCount(ctx context.Context, key string) (int, error) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if counter := rl.live(key); counter != nil {
+		return counter.count, nil
+	}
+	return 0, nil
+}
+
+// GetRemainingTime returns the time until the rate limit resets
+func (rl *MemoryLimiter) GetRemainingTime(ctx context.Context, key string) (time.Duration, error) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if counter := rl.live(key); counter != nil {
+		return counter.expiresAt.Sub(rl.now()), nil
+	}
+	return 0, nil
+}
+
+// increment bumps a counter and extends its expiry, like the Redis limiter
+func (rl *MemoryLimiter) increment(key string) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	if !now.Before(rl.nextSweep) {
+		rl.sweep(now)
+		rl.nextSweep = now.Add(rl.window)
+	}
+
+	counter := rl.live(key)
+	if counter == nil {
+		counter = &memoryCounter{}
+		rl.counters[key] = counter
+	}
+	counter.count++
+	counter.expiresAt = now.Add(rl.window)
+}
+
+// sweep drops every counter expired at now; callers must hold rl.mu
+func (rl *MemoryLimiter) sweep(now time.Time) {
+	for key, counter := range rl.counters {
+		if !now.Before(counter.expiresAt) {
+			delete(rl.counters, key)
+		}
+	}
+}
+
+// live returns the unexpired counter for key, dropping an expired one;
+// callers must hold rl.mu
+func (rl *MemoryLimiter) live(key string) *memoryCounter {
+	counter, ok := rl.counters[key]
+	if !ok {
+		return nil
+	}
+	if !rl.now().Before(counter.expiresAt) {
+		delete(rl.counters, key)
+		return nil
+	}
+	return counter
+}
@@ -3,6 +3,8 @@ package ratelimit
 import (
 	"context"
 	"fmt"
+	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -11,10 +13,19 @@ import (
 
 // RateLimiter manages rate limiting using Redis
 type RateLimiter struct {
-	client      *redis.Client
-	perIP       int
-	perAddress  int
-	window      time.Duration
+	client     *redis.Client
+	perIP      int
+	perAddress int
+	window     time.Duration
+	jitter     float64
+	countries  *CountryLimits
+
+	// With AlgorithmTokenBucket each key holds up to burst tokens and
+	// refills at its limit per window
+	algorithm    string
+	burstIP      int
+	burstAddress int
+	now          func() time.Time
 }
 
 // NewRedisClient creates a new Redis client
@@ -44,19 +55,51 @@ func NewRateLimiter(client *redis.Client, config map[string]interface{}) *RateLi
 	perIP := config["per_ip"].(int)
 	perAddress := config["per_address"].(int)
 	window := config["window"].(time.Duration)
+	jitter, _ := config["jitter"].(float64)
+	algorithm, _ := config["algorithm"].(string)
+	burstIP, _ := config["burst_ip"].(int)
+	burstAddress, _ := config["burst_address"].(int)
+	if burstIP <= 0 {
+		burstIP = perIP
+	}
+	if burstAddress <= 0 {
+		burstAddress = perAddress
+	}
 
 	return &RateLimiter{
-		client:     client,
-		perIP:      perIP,
-		perAddress: perAddress,
-		window:     window,
+		client:       client,
+		perIP:        perIP,
+		perAddress:   perAddress,
+		window:       window,
+		jitter:       jitter,
+		algorithm:    algorithm,
+		burstIP:      burstIP,
+		burstAddress: burstAddress,
+	}
+}
+
+// SetCountryLimits scales the per-IP limit by the client's country
+func (rl *RateLimiter) SetCountryLimits(countries *CountryLimits) {
+	rl.countries = countries
+}
+
+// Jitter lengthens d by a random amount of up to fraction*d. Windows are
+// only ever extended, so jitter never loosens a limit.
+func Jitter(d time.Duration, fraction float64) time.Duration {
+	if d <= 0 || fraction <= 0 {
+		return d
+	}
+	spread := int64(float64(d) * fraction)
+	if spread <= 0 {
+		return d
 	}
+	return d + time.Duration(rand.Int63n(spread+1))
 }
 
 // CheckIPLimit checks if an IP address has exceeded the rate limit
 func (rl *RateLimiter) CheckIPLimit(ctx context.Context, ip string) (bool, error) {
 	key := fmt.Sprintf("ratelimit:ip:%s", ip)
-	return rl.checkLimit(ctx, key, rl.perIP)
+	return rl.checkLimit(ctx, key, rl.countries.IPLimit(rl.perIP, ip))
 }
 
 // CheckAddressLimit checks if an address has exceeded the rate limit
@@ -77,8 +120,102 @@ func (rl *RateLimiter) IncrementAddressCounter(ctx context.Context, address stri
 	return rl.incrementCounter(ctx, key)
 }
 
-// GetRemainingTime returns the time until the rate limit resets
+// ReserveIP checks ip's limit and, when it isn't reached, counts the
+// request in the same step, so concurrent requests can't all pass on the
+// last slot. ReleaseIP gives the slot back if the request isn't served.
+func (rl *RateLimiter) ReserveIP(ctx context.Context, ip string) (bool, error) {
+	key := fmt.Sprintf("ratelimit:ip:%s", ip)
+	return rl.reserve(ctx, key, rl.countries.IPLimit(rl.perIP, ip))
+}
+
+// ReserveAddress is ReserveIP for an address's limit
+func (rl *RateLimiter) ReserveAddress(ctx context.Context, address string) (bool, error) {
+	key := fmt.Sprintf("ratelimit:address:%s", address)
+	return rl.reserve(ctx, key, rl.perAddress)
+}
+
+// ReleaseIP gives back a slot taken by ReserveIP
+func (rl *RateLimiter) ReleaseIP(ctx context.Context, ip string) error {
+	return rl.release(ctx, fmt.Sprintf("ratelimit:ip:%s", ip))
+}
+
+// ReleaseAddress gives back a slot taken by ReserveAddress
+func (rl *RateLimiter) ReleaseAddress(ctx context.Context, address string) error {
+	return rl.release(ctx, fmt.Sprintf("ratelimit:address:%s", address))
+}
+
+// reserveScript counts a request in a fixed window unless the count has
+// reached the limit, and returns 1 when it did
+//
+// KEYS[1] counter; ARGV: limit, expiry (ms)
+var reserveScript = redis.NewScript(`
+local count = tonumber(redis.call("GET", KEYS[1]) or "0")
+if count >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("INCR", KEYS[1])
+redis.call("PEXPIRE", KEYS[1], ARGV[2])
+return 1
+`)
+
+// releaseScript uncounts a reserved request, never below zero
+var releaseScript = redis.NewScript(`
+local count = tonumber(redis.call("GET", KEYS[1]) or "0")
+if count > 0 then
+	redis.call("DECR", KEYS[1])
+end
+return 0
+`)
+
+// reserve checks key against limit and counts the request in one step
+func (rl *RateLimiter) reserve(ctx context.Context, key string, limit int) (bool, error) {
+	if rl.algorithm == AlgorithmTokenBucket {
+		return rl.reserveBucket(ctx, key)
+	}
+
+	expiry := Jitter(rl.window, rl.jitter).Milliseconds()
+	reserved, err := reserveScript.Run(ctx, rl.client, []string{key}, limit, expiry).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve rate limit slot: %w", err)
+	}
+	return reserved == 0, nil
+}
+
+// release gives back a slot reserve took for key
+func (rl *RateLimiter) release(ctx context.Context, key string) error {
+	if rl.algorithm == AlgorithmTokenBucket {
+		return rl.refundBucket(ctx, key)
+	}
+
+	if err := releaseScript.Run(ctx, rl.client, []string{key}).Err(); err != nil {
+		return fmt.Errorf("failed to release rate limit slot: %w", err)
+	}
+	return nil
+}
+
+// RemainingIPRequests returns how many more requests ip may make before
+// it is limited: the tokens left with a token bucket, otherwise what is
+// left of the window's count
+func (rl *RateLimiter) RemainingIPRequests(ctx context.Context, ip string) (int, error) {
+	key := fmt.Sprintf("ratelimit:ip:%s", ip)
+	if rl.algorithm == AlgorithmTokenBucket {
+		return rl.bucketRemaining(ctx, key)
+	}
+
+	count, err := rl.GetCurrentCount(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return max(rl.countries.IPLimit(rl.perIP, ip)-count, 0), nil
+}
+
+// GetRemainingTime returns the time until the rate limit resets, or with
+// a token bucket until the next token
 func (rl *RateLimiter) GetRemainingTime(ctx context.Context, key string) (time.Duration, error) {
+	if rl.algorithm == AlgorithmTokenBucket {
+		return rl.bucketWait(ctx, key)
+	}
+
 	ttl, err := rl.client.TTL(ctx, key).Result()
 	if err != nil {
 		return 0, fmt.Errorf("failed to get TTL: %w", err)
@@ -93,6 +230,10 @@ func (rl *RateLimiter) GetRemainingTime(ctx context.Context, key string) (time.D
 
 // checkLimit checks if a key has exceeded the limit
 func (rl *RateLimiter) checkLimit(ctx context.Context, key string, limit int) (bool, error) {
+	if rl.algorithm == AlgorithmTokenBucket {
+		return rl.checkBucket(ctx, key)
+	}
+
 	count, err := rl.client.Get(ctx, key).Int()
 	if err != nil {
 		if err == redis.Nil {
@@ -107,13 +248,17 @@ func (rl *RateLimiter) checkLimit(ctx context.Context, key string, limit int) (b
 
 // incrementCounter increments the counter for a key
 func (rl *RateLimiter) incrementCounter(ctx context.Context, key string) error {
+	if rl.algorithm == AlgorithmTokenBucket {
+		return rl.takeBucket(ctx, key)
+	}
+
 	pipe := rl.client.Pipeline()
 
 	// Increment counter
 	pipe.Incr(ctx, key)
 
 	// Set expiration if this is the first increment
-	pipe.Expire(ctx, key, rl.window)
+	pipe.Expire(ctx, key, Jitter(rl.window, rl.jitter))
 
 	_, err := pipe.Exec(ctx)
 	if err != nil {
@@ -144,3 +289,75 @@ func (rl *RateLimiter) GetCurrentCount(ctx context.Context, key string) (int, er
 func (rl *RateLimiter) Close() error {
 	return rl.client.Close()
 }
+
+// GlobalLimiter caps faucet-wide requests per minute in memory. After startup
+// it can apply a slow-start ramp: the limit begins at initialPercent of
+// perMinute and rises linearly to the full value over the ramp duration,
+// absorbing the thundering herd that follows a deploy.
+type GlobalLimiter struct {
+	mu             sync.Mutex
+	perMinute      int
+	startTime      time.Time
+	ramp           time.Duration
+	initialPercent int
+	windowStart    time.Time
+	count          int
+	now            func() time.Time
+}
+
+// NewGlobalLimiter creates a global limiter; a zero ramp disables slow start
+func NewGlobalLimiter(perMinute int, ramp time.Duration, initialPercent int) *GlobalLimiter {
+	if initialPercent <= 0 || initialPercent > 100 {
+		initialPercent = 10
+	}
+
+	now := time.Now()
+	return &GlobalLimiter{
+		perMinute:      perMinute,
+		startTime:      now,
+		ramp:           ramp,
+		initialPercent: initialPercent,
+		windowStart:    now,
+		now:            time.Now,
+	}
+}
+
+// EffectiveLimit returns the per-minute limit in force right now
+func (g *GlobalLimiter) EffectiveLimit() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.effectiveLimit(g.now())
+}
+
+func (g *GlobalLimiter) effectiveLimit(now time.Time) int {
+	elapsed := now.Sub(g.startTime)
+	if g.ramp <= 0 || elapsed >= g.ramp {
+		return g.perMinute
+	}
+
+	initial := float64(g.perMinute) * float64(g.initialPercent) / 100
+	progress := float64(elapsed) / float64(g.ramp)
+	limit := int(initial + (float64(g.perMinute)-initial)*progress)
+	if limit < 1 {
+		limit = 1
+	}
+	return limit
+}
+
+// Allow records a request and reports whether it fits in the current minute
+func (g *GlobalLimiter) Allow() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	if now.Sub(g.windowStart) >= time.Minute {
+		g.windowStart = now
+		g.count = 0
+	}
+
+	if g.count >= g.effectiveLimit(now) {
+		return false
+	}
+	g.count++
+	return true
+}
@@ -2,6 +2,9 @@ package ratelimit
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -76,3 +79,488 @@ func TestRateLimiterTTL(t *testing.T) {
 	require.NoError(t, err)
 	assert.False(t, limited)
 }
+
+func TestRateLimiterJittersTTL(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	client, err := NewRedisClient("redis://" + mr.Addr())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	rl := NewRateLimiter(client, map[string]interface{}{
+		"per_ip":      100,
+		"per_address": 100,
+		"window":      time.Hour,
+		"jitter":      0.1,
+	})
+
+	ctx := context.Background()
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("192.0.2.%d", i)
+		require.NoError(t, rl.IncrementIPCounter(ctx, key))
+		ttl := mr.TTL("ratelimit:ip:" + key)
+		assert.GreaterOrEqual(t, ttl, time.Hour)
+		assert.LessOrEqual(t, ttl, 66*time.Minute)
+		seen[ttl] = true
+	}
+	assert.Greater(t, len(seen), 1, "TTLs should vary within the jitter band")
+}
+
+func TestJitter(t *testing.T) {
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 50; i++ {
+		d := Jitter(time.Minute, 0.2)
+		assert.GreaterOrEqual(t, d, time.Minute)
+		assert.LessOrEqual(t, d, 72*time.Second)
+		seen[d] = true
+	}
+	assert.Greater(t, len(seen), 1)
+
+	assert.Equal(t, time.Minute, Jitter(time.Minute, 0))
+	assert.Equal(t, time.Duration(0), Jitter(0, 0.5))
+}
+
+func TestGlobalLimiterSlowStartRamp(t *testing.T) {
+	g := NewGlobalLimiter(100, 10*time.Minute, 10)
+	start := g.startTime
+	clock := start
+	g.now = func() time.Time { return clock }
+
+	assert.Equal(t, 10, g.EffectiveLimit())
+
+	clock = start.Add(5 * time.Minute)
+	assert.Equal(t, 55, g.EffectiveLimit())
+
+	clock = start.Add(10 * time.Minute)
+	assert.Equal(t, 100, g.EffectiveLimit())
+
+	clock = start.Add(time.Hour)
+	assert.Equal(t, 100, g.EffectiveLimit())
+}
+
+func TestGlobalLimiterAllow(t *testing.T) {
+	g := NewGlobalLimiter(2, 0, 0)
+	clock := g.startTime
+	g.now = func() time.Time { return clock }
+
+	assert.True(t, g.Allow())
+	assert.True(t, g.Allow())
+	assert.False(t, g.Allow())
+
+	// Next minute opens a fresh window
+	clock = clock.Add(time.Minute)
+	assert.True(t, g.Allow())
+}
+
+func TestMemoryLimiterExpiresCounters(t *testing.T) {
+	rl := NewMemoryLimiter(map[string]interface{}{
+		"per_ip":      2,
+		"per_address": 1,
+		"window":      time.Minute,
+	})
+	clock := time.Now()
+	rl.now = func() time.Time { return clock }
+	ctx := context.Background()
+
+	require.NoError(t, rl.IncrementIPCounter(ctx, "192.0.2.1"))
+	limited, err := rl.CheckIPLimit(ctx, "192.0.2.1")
+	require.NoError(t, err)
+	assert.False(t, limited)
+
+	require.NoError(t, rl.IncrementIPCounter(ctx, "192.0.2.1"))
+	limited, _ = rl.CheckIPLimit(ctx, "192.0.2.1")
+	assert.True(t, limited)
+
+	remaining, err := rl.GetRemainingTime(ctx, "ratelimit:ip:192.0.2.1")
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, remaining)
+
+	clock = clock.Add(time.Minute)
+	limited, _ = rl.CheckIPLimit(ctx, "192.0.2.1")
+	assert.False(t, limited)
+}
+
+func TestMemoryLimiterSweepsKeysThatNeverReturn(t *testing.T) {
+	rl := NewMemoryLimiter(map[string]interface{}{
+		"per_ip":      2,
+		"per_address": 1,
+		"window":      time.Minute,
+	})
+	clock := time.Now()
+	rl.now = func() time.Time { return clock }
+	ctx := context.Background()
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, rl.IncrementIPCounter(ctx, fmt.Sprintf("192.0.2.%d", i)))
+	}
+	assert.Len(t, rl.counters, 100)
+
+	// None of those callers come back, but the next one sweeps them
+	clock = clock.Add(time.Minute)
+	require.NoError(t, rl.IncrementIPCounter(ctx, "198.51.100.1"))
+	assert.Len(t, rl.counters, 1)
+}
+
+// fakeCounterStore is an in-memory CounterStore
+type fakeCounterStore struct {
+	byIP      int
+	byAddress int
+	counters  map[string]int
+}
+
+func (s *fakeCounterStore) CountRecentRequestsByIP(ip string, since time.Time) (int, error) {
+	return s.byIP, nil
+}
+
+func (s *fakeCounterStore) CountRecentRequestsByAddress(address string, since time.Time) (int, error) {
+	return s.byAddress, nil
+}
+
+func (s *fakeCounterStore) GetRateLimitCounter(key string) (int, time.Time, error) {
+	return s.counters[key], time.Now().Add(time.Minute), nil
+}
+
+func (s *fakeCounterStore) IncrementRateLimitCounter(key string, expiresAt time.Time) error {
+	s.counters[key]++
+	return nil
+}
+
+func TestDBLimiterUsesCounterAndRequestHistory(t *testing.T) {
+	store := &fakeCounterStore{counters: make(map[string]int)}
+	rl := NewDBLimiter(store, map[string]interface{}{
+		"per_ip":      2,
+		"per_address": 1,
+		"window":      time.Hour,
+	})
+	ctx := context.Background()
+
+	limited, err := rl.CheckAddressLimit(ctx, "aura1abc")
+	require.NoError(t, err)
+	assert.False(t, limited)
+
+	// Counter alone trips the limit
+	require.NoError(t, rl.IncrementAddressCounter(ctx, "aura1abc"))
+	limited, _ = rl.CheckAddressLimit(ctx, "aura1abc")
+	assert.True(t, limited)
+
+	// Request history trips it even with an empty counter table
+	store.byIP = 2
+	limited, _ = rl.CheckIPLimit(ctx, "192.0.2.1")
+	assert.True(t, limited)
+}
+
+func TestRemainingIPRequests(t *testing.T) {
+	ctx := context.Background()
+	config := func() map[string]interface{} {
+		return map[string]interface{}{"per_ip": 3, "per_address": 1, "window": time.Hour}
+	}
+
+	t.Run("redis", func(t *testing.T) {
+		mr, err := miniredis.Run()
+		require.NoError(t, err)
+		defer mr.Close()
+		client, err := NewRedisClient("redis://" + mr.Addr())
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = client.Close() })
+
+		rl := NewRateLimiter(client, config())
+		remaining, err := rl.RemainingIPRequests(ctx, "192.0.2.1")
+		require.NoError(t, err)
+		assert.Equal(t, 3, remaining)
+		for i := 0; i < 4; i++ {
+			require.NoError(t, rl.IncrementIPCounter(ctx, "192.0.2.1"))
+		}
+		remaining, err = rl.RemainingIPRequests(ctx, "192.0.2.1")
+		require.NoError(t, err)
+		assert.Equal(t, 0, remaining, "never negative")
+	})
+
+	t.Run("token bucket", func(t *testing.T) {
+		rl, _ := newBucketLimiter(t, map[string]interface{}{"per_ip": 6, "per_address": 1, "burst_ip": 3, "window": time.Hour})
+		require.NoError(t, rl.IncrementIPCounter(ctx, "192.0.2.1"))
+		remaining, err := rl.RemainingIPRequests(ctx, "192.0.2.1")
+		require.NoError(t, err)
+		assert.Equal(t, 2, remaining)
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		rl := NewMemoryLimiter(config())
+		require.NoError(t, rl.IncrementIPCounter(ctx, "192.0.2.1"))
+		remaining, err := rl.RemainingIPRequests(ctx, "192.0.2.1")
+		require.NoError(t, err)
+		assert.Equal(t, 2, remaining)
+	})
+
+	t.Run("db", func(t *testing.T) {
+		store := &fakeCounterStore{counters: make(map[string]int)}
+		rl := NewDBLimiter(store, config())
+		require.NoError(t, rl.IncrementIPCounter(ctx, "192.0.2.1"))
+		remaining, err := rl.RemainingIPRequests(ctx, "192.0.2.1")
+		require.NoError(t, err)
+		assert.Equal(t, 2, remaining)
+
+		// Request history counts when it exceeds the counter
+		store.byIP = 2
+		remaining, err = rl.RemainingIPRequests(ctx, "192.0.2.1")
+		require.NoError(t, err)
+		assert.Equal(t, 1, remaining)
+	})
+}
+
+// stubResolver maps IPs to countries from a fixed table
+type stubResolver map[string]string
+
+func (r stubResolver) Country(ip string) string { return r[ip] }
+
+func TestCountryLimitsIPLimit(t *testing.T) {
+	countries := NewCountryLimits(stubResolver{
+		"192.0.2.1": "US",
+		"192.0.2.2": "DE",
+		"192.0.2.3": "FR",
+	}, map[string]float64{"us": 2, "DE": 0.1})
+
+	assert.Equal(t, 10, countries.IPLimit(5, "192.0.2.1"))
+	assert.Equal(t, 1, countries.IPLimit(5, "192.0.2.2"), "scaled limits keep at least one request")
+	assert.Equal(t, 5, countries.IPLimit(5, "192.0.2.3"), "unlisted country keeps the base limit")
+	assert.Equal(t, 5, countries.IPLimit(5, "198.51.100.1"), "unresolved IP keeps the base limit")
+
+	var none *CountryLimits
+	assert.Equal(t, 5, none.IPLimit(5, "192.0.2.1"))
+}
+
+func TestMemoryLimiterAppliesCountryMultiplier(t *testing.T) {
+	rl := NewMemoryLimiter(map[string]interface{}{
+		"per_ip":      2,
+		"per_address": 1,
+		"window":      time.Minute,
+	})
+	rl.SetCountryLimits(NewCountryLimits(stubResolver{"192.0.2.1": "US"}, map[string]float64{"US": 2}))
+	ctx := context.Background()
+
+	for _, ip := range []string{"192.0.2.1", "192.0.2.9"} {
+		require.NoError(t, rl.IncrementIPCounter(ctx, ip))
+		require.NoError(t, rl.IncrementIPCounter(ctx, ip))
+	}
+
+	// The base limit of 2 applies to the unlisted IP
+	limited, err := rl.CheckIPLimit(ctx, "192.0.2.9")
+	require.NoError(t, err)
+	assert.True(t, limited)
+
+	// The US IP gets double the limit
+	limited, _ = rl.CheckIPLimit(ctx, "192.0.2.1")
+	assert.False(t, limited)
+	require.NoError(t, rl.IncrementIPCounter(ctx, "192.0.2.1"))
+	require.NoError(t, rl.IncrementIPCounter(ctx, "192.0.2.1"))
+	limited, _ = rl.CheckIPLimit(ctx, "192.0.2.1")
+	assert.True(t, limited)
+}
+
+func TestDBLimiterAppliesCountryMultiplier(t *testing.T) {
+	store := &fakeCounterStore{counters: make(map[string]int), byIP: 2}
+	rl := NewDBLimiter(store, map[string]interface{}{
+		"per_ip":      4,
+		"per_address": 1,
+		"window":      time.Hour,
+	})
+	rl.SetCountryLimits(NewCountryLimits(stubResolver{"192.0.2.1": "DE"}, map[string]float64{"DE": 0.5}))
+	ctx := context.Background()
+
+	limited, _ := rl.CheckIPLimit(ctx, "192.0.2.1")
+	assert.True(t, limited, "DE gets half the base limit")
+	limited, _ = rl.CheckIPLimit(ctx, "192.0.2.9")
+	assert.False(t, limited)
+}
+
+func newBucketLimiter(t *testing.T, config map[string]interface{}) (*RateLimiter, *time.Time) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client, err := NewRedisClient("redis://" + mr.Addr())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	config["algorithm"] = AlgorithmTokenBucket
+	rl := NewRateLimiter(client, config)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	rl.now = func() time.Time { return now }
+	return rl, &now
+}
+
+func TestTokenBucketAllowsBurst(t *testing.T) {
+	// 6 per hour steady state, bursts of 3
+	rl, _ := newBucketLimiter(t, map[string]interface{}{
+		"per_ip":      6,
+		"per_address": 1,
+		"burst_ip":    3,
+		"window":      time.Hour,
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		limited, err := rl.CheckIPLimit(ctx, "192.0.2.1")
+		require.NoError(t, err)
+		require.False(t, limited, "request %d is within the burst", i+1)
+		require.NoError(t, rl.IncrementIPCounter(ctx, "192.0.2.1"))
+	}
+
+	limited, err := rl.CheckIPLimit(ctx, "192.0.2.1")
+	require.NoError(t, err)
+	assert.True(t, limited, "the burst is spent")
+
+	wait, err := rl.GetRemainingTime(ctx, "ratelimit:ip:192.0.2.1")
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, wait, "one token refills every 10 minutes")
+
+	// Other keys have their own buckets; the address burst defaults to its limit
+	limited, err = rl.CheckIPLimit(ctx, "192.0.2.2")
+	require.NoError(t, err)
+	assert.False(t, limited)
+	require.NoError(t, rl.IncrementAddressCounter(ctx, "aura1addr"))
+	limited, err = rl.CheckAddressLimit(ctx, "aura1addr")
+	require.NoError(t, err)
+	assert.True(t, limited)
+}
+
+func TestTokenBucketRefillsAtSteadyRate(t *testing.T) {
+	rl, now := newBucketLimiter(t, map[string]interface{}{
+		"per_ip":      6,
+		"per_address": 1,
+		"burst_ip":    3,
+		"window":      time.Hour,
+	})
+	ctx := context.Background()
+	ip := "192.0.2.1"
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, rl.IncrementIPCounter(ctx, ip))
+	}
+	limited, err := rl.CheckIPLimit(ctx, ip)
+	require.NoError(t, err)
+	require.True(t, limited)
+
+	*now = now.Add(9 * time.Minute)
+	limited, err = rl.CheckIPLimit(ctx, ip)
+	require.NoError(t, err)
+	assert.True(t, limited, "not yet a whole token")
+
+	*now = now.Add(time.Minute)
+	limited, err = rl.CheckIPLimit(ctx, ip)
+	require.NoError(t, err)
+	assert.False(t, limited, "one token after 10 minutes")
+	require.NoError(t, rl.IncrementIPCounter(ctx, ip))
+	limited, err = rl.CheckIPLimit(ctx, ip)
+	require.NoError(t, err)
+	assert.True(t, limited, "and only one")
+
+	// A long pause refills up to the burst, never beyond
+	*now = now.Add(24 * time.Hour)
+	for i := 0; i < 3; i++ {
+		limited, err := rl.CheckIPLimit(ctx, ip)
+		require.NoError(t, err)
+		require.False(t, limited)
+		require.NoError(t, rl.IncrementIPCounter(ctx, ip))
+	}
+	limited, err = rl.CheckIPLimit(ctx, ip)
+	require.NoError(t, err)
+	assert.True(t, limited)
+}
+
+func TestDeduperClaimCompleteWait(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	client, err := NewRedisClient("redis://" + mr.Addr())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	d := NewDeduper(client, 5*time.Second)
+	d.poll = time.Millisecond
+	ctx := context.Background()
+
+	claimed, err := d.Claim(ctx, "aura-test:aura1addr")
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	claimed, err = d.Claim(ctx, "aura-test:aura1addr")
+	require.NoError(t, err)
+	assert.False(t, claimed, "a repeat while the first is in flight")
+
+	// A repeat waiting on the first gets its result once stored
+	results := make(chan []byte, 1)
+	go func() {
+		result, err := d.Wait(ctx, "aura-test:aura1addr")
+		assert.NoError(t, err)
+		results <- result
+	}()
+	require.NoError(t, d.Complete(ctx, "aura-test:aura1addr", []byte("first")))
+	assert.Equal(t, []byte("first"), <-results)
+
+	// The result is replayed within the window, then forgotten
+	claimed, err = d.Claim(ctx, "aura-test:aura1addr")
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	mr.FastForward(6 * time.Second)
+	result, err := d.Wait(ctx, "aura-test:aura1addr")
+	require.NoError(t, err)
+	assert.Nil(t, result)
+	claimed, err = d.Claim(ctx, "aura-test:aura1addr")
+	require.NoError(t, err)
+	assert.True(t, claimed)
+}
+
+func TestReserveChecksAndCountsInOneStep(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmFixed, AlgorithmTokenBucket} {
+		t.Run(algorithm, func(t *testing.T) {
+			mr, err := miniredis.Run()
+			require.NoError(t, err)
+			defer mr.Close()
+			client, err := NewRedisClient("redis://" + mr.Addr())
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = client.Close() })
+			rl := NewRateLimiter(client, map[string]interface{}{
+				"per_ip":      2,
+				"per_address": 1,
+				"window":      time.Hour,
+				"algorithm":   algorithm,
+			})
+			ctx := context.Background()
+
+			// Concurrent requests can't all pass on the last slots
+			var passed int32
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					limited, err := rl.ReserveIP(ctx, "192.0.2.1")
+					assert.NoError(t, err)
+					if !limited {
+						atomic.AddInt32(&passed, 1)
+					}
+				}()
+			}
+			wg.Wait()
+			assert.Equal(t, int32(2), passed)
+
+			require.NoError(t, rl.ReleaseIP(ctx, "192.0.2.1"))
+			limited, err := rl.ReserveIP(ctx, "192.0.2.1")
+			require.NoError(t, err)
+			assert.False(t, limited, "a released slot can be reserved again")
+			limited, err = rl.CheckIPLimit(ctx, "192.0.2.1")
+			require.NoError(t, err)
+			assert.True(t, limited)
+
+			limited, err = rl.ReserveAddress(ctx, "aura1addr")
+			require.NoError(t, err)
+			assert.False(t, limited)
+			limited, err = rl.ReserveAddress(ctx, "aura1addr")
+			require.NoError(t, err)
+			assert.True(t, limited)
+		})
+	}
+}
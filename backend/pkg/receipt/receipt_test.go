@@ -0,0 +1,81 @@
+package receipt
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aura-chain/aura/faucet/pkg/units"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	signer, err := NewSigner([]byte("secret"))
+	require.NoError(t, err)
+
+	r := New("aura1recipient", units.New(100), "uaura", "ABC", "aura-test", time.Date(2026, 1, 2, 3, 4, 5, 600, time.UTC))
+	assert.Equal(t, "2026-01-02T03:04:05Z", r.Timestamp)
+
+	signed, err := signer.Sign(r)
+	require.NoError(t, err)
+	assert.Equal(t, Algorithm, signed.Algorithm)
+	require.NoError(t, signer.Verify(signed))
+
+	// Signing is deterministic, so a receipt can be re-issued later
+	again, err := signer.Sign(r)
+	require.NoError(t, err)
+	assert.Equal(t, signed.Signature, again.Signature)
+
+	// Survives a JSON round trip
+	raw, err := json.Marshal(signed)
+	require.NoError(t, err)
+	var decoded Signed
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	assert.NoError(t, signer.Verify(&decoded))
+}
+
+func TestTamperedReceiptFailsVerification(t *testing.T) {
+	signer, err := NewSigner([]byte("secret"))
+	require.NoError(t, err)
+	signed, err := signer.Sign(New("aura1recipient", units.New(100), "uaura", "ABC", "aura-test", time.Now()))
+	require.NoError(t, err)
+
+	tampered := *signed
+	tampered.Receipt.Amount = units.New(1000)
+	assert.ErrorIs(t, signer.Verify(&tampered), ErrInvalidSignature)
+
+	tampered = *signed
+	tampered.Receipt.Recipient = "aura1attacker"
+	assert.ErrorIs(t, signer.Verify(&tampered), ErrInvalidSignature)
+
+	tampered = *signed
+	tampered.Signature = "not-base64!"
+	assert.ErrorIs(t, signer.Verify(&tampered), ErrInvalidSignature)
+
+	other, err := NewSigner([]byte("other"))
+	require.NoError(t, err)
+	assert.ErrorIs(t, other.Verify(signed), ErrInvalidSignature)
+}
+
+func TestNewSignerRejectsEmptyKey(t *testing.T) {
+	_, err := NewSigner(nil)
+	assert.Error(t, err)
+}
+
+func TestVerifyRejectsReceiptsFromTheFuture(t *testing.T) {
+	signer, err := NewSigner([]byte("secret"))
+	require.NoError(t, err)
+	signer.SetMaxClockSkew(30 * time.Second)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	signer.now = func() time.Time { return now }
+
+	within, err := signer.Sign(New("aura1recipient", units.New(100), "uaura", "ABC", "aura-test", now.Add(29*time.Second)))
+	require.NoError(t, err)
+	assert.NoError(t, signer.Verify(within))
+
+	beyond, err := signer.Sign(New("aura1recipient", units.New(100), "uaura", "ABC", "aura-test", now.Add(31*time.Second)))
+	require.NoError(t, err)
+	assert.ErrorIs(t, signer.Verify(beyond), ErrFutureTimestamp)
+}
@@ -0,0 +1,168 @@
+// Package redact masks sensitive values in log output: signing key names and
+// other secrets are replaced, addresses truncated, and IPs hashed
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Mask replaces secret values
+const Mask = "[REDACTED]"
+
+// minAnywhereSecret is the length from which a secret is masked wherever it
+// appears. Shorter ones, such as a keyring key named "faucet", are ordinary
+// words too, so they are only masked where a key name is expected.
+const minAnywhereSecret = 16
+
+// addressFields, ipFields and keyFields name the log fields holding
+// addresses, IPs and keyring key names
+var (
+	addressFields = map[string]bool{"address": true, "recipient": true, "faucet_address": true}
+	ipFields      = map[string]bool{"ip": true, "ip_address": true, "client_ip": true, "remote_ip": true, "remote_addr": true}
+	keyFields     = map[string]bool{"key": true, "key_name": true, "from_key": true}
+)
+
+// bech32Address matches addresses such as aura1... embedded in text
+var bech32Address = regexp.MustCompile(`\b[a-z][a-z0-9]{0,82}1[02-9ac-hj-np-z]{38,}\b`)
+
+// Redactor masks sensitive values. IPs hash to the same token for the
+// lifetime of a Redactor, so one client's log lines can still be correlated.
+type Redactor struct {
+	secrets []string
+	// keyPositions match short secrets where a key name is expected: after
+	// --from, as the sender of "bank send", or after "key"
+	keyPositions []*regexp.Regexp
+	salt         []byte
+}
+
+// New creates a Redactor that masks each non-empty secret: long ones
+// anywhere, short ones only where a key name is expected
+func New(secrets ...string) *Redactor {
+	r := &Redactor{salt: make([]byte, 16)}
+	if _, err := rand.Read(r.salt); err != nil {
+		panic(fmt.Sprintf("redact: failed to generate salt: %v", err))
+	}
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		r.secrets = append(r.secrets, secret)
+		if len(secret) < minAnywhereSecret {
+			r.keyPositions = append(r.keyPositions,
+				regexp.MustCompile(`(--from[ =]|bank send |\bkey:? )`+regexp.QuoteMeta(secret)+`($|[\s"',:;)])`))
+		}
+	}
+	return r
+}
+
+// Secrets masks the configured secrets in s
+func (r *Redactor) Secrets(s string) string {
+	for _, secret := range r.secrets {
+		if len(secret) >= minAnywhereSecret {
+			s = strings.ReplaceAll(s, secret, Mask)
+		}
+	}
+	for _, position := range r.keyPositions {
+		s = position.ReplaceAllString(s, "${1}"+Mask+"${2}")
+	}
+	return s
+}
+
+// Text masks secrets in free text, such as messages and errors, and
+// truncates any addresses in it
+func (r *Redactor) Text(s string) string {
+	return bech32Address.ReplaceAllStringFunc(r.Secrets(s), r.Address)
+}
+
+// isSecret reports whether s is exactly a configured secret
+func (r *Redactor) isSecret(s string) bool {
+	for _, secret := range r.secrets {
+		if s == secret {
+			return true
+		}
+	}
+	return false
+}
+
+// Address keeps the human-readable prefix and the first and last few
+// characters of a bech32 address, enough to tell addresses apart
+func (r *Redactor) Address(address string) string {
+	sep := strings.LastIndex(address, "1")
+	if sep < 0 || len(address)-sep-1 <= 10 {
+		return r.Secrets(address)
+	}
+	data := address[sep+1:]
+	return address[:sep+1] + data[:4] + "..." + data[len(data)-4:]
+}
+
+// IP replaces an IP with a salted hash. A host:port address hashes its
+// host, so it matches the same client's other lines.
+func (r *Redactor) IP(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	mac := hmac.New(sha256.New, r.salt)
+	mac.Write([]byte(ip))
+	return "ip-" + hex.EncodeToString(mac.Sum(nil))[:12]
+}
+
+// Fields returns a redacted copy of log fields
+func (r *Redactor) Fields(fields log.Fields) log.Fields {
+	redacted := make(log.Fields, len(fields))
+	for key, value := range fields {
+		switch v := value.(type) {
+		case string:
+			switch {
+			case addressFields[key]:
+				redacted[key] = r.Address(v)
+			case ipFields[key]:
+				redacted[key] = r.IP(v)
+			case keyFields[key] && r.isSecret(v):
+				redacted[key] = Mask
+			default:
+				redacted[key] = r.Text(v)
+			}
+		case error:
+			// Errors keep their full text apart from secrets and addresses,
+			// so failures stay debuggable
+			redacted[key] = r.Text(v.Error())
+		default:
+			redacted[key] = value
+		}
+	}
+	return redacted
+}
+
+// Formatter redacts entries before passing them to the next formatter
+type Formatter struct {
+	redactor *Redactor
+	next     log.Formatter
+}
+
+// NewFormatter wraps next so every entry is redacted by r
+func NewFormatter(r *Redactor, next log.Formatter) *Formatter {
+	return &Formatter{redactor: r, next: next}
+}
+
+// Format implements logrus.Formatter
+func (f *Formatter) Format(entry *log.Entry) ([]byte, error) {
+	redacted := entry.Dup()
+	redacted.Level = entry.Level
+	redacted.Message = f.redactor.Text(entry.Message)
+	redacted.Caller = entry.Caller
+	redacted.Buffer = entry.Buffer
+	redacted.Data = f.redactor.Fields(entry.Data)
+	return f.next.Format(redacted)
+}
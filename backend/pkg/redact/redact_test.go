@@ -0,0 +1,106 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logEntry logs through a redacting formatter and returns the decoded line
+func logEntry(t *testing.T, r *Redactor, fields log.Fields, msg string) map[string]interface{} {
+	var buf bytes.Buffer
+	logger := log.New()
+	logger.SetOutput(&buf)
+	logger.SetFormatter(NewFormatter(r, &log.JSONFormatter{}))
+	logger.WithFields(fields).Info(msg)
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	return line
+}
+
+func TestFormatterMasksSensitiveFields(t *testing.T) {
+	r := New("faucet-key", "")
+	line := logEntry(t, r, log.Fields{
+		"args":      "tx bank send faucet-key aura1qpzry9x8gf2tvdw0s3jn54khce6mua7lqpzry9 100uaura",
+		"recipient": "aura1qpzry9x8gf2tvdw0s3jn54khce6mua7lqpzry9",
+		"ip":        "192.0.2.1",
+		"error":     errors.New("key faucet-key not found in keyring"),
+		"amount":    100,
+	}, "Executing CLI transaction --from faucet-key")
+
+	assert.NotContains(t, line["args"], "faucet-key")
+	assert.Contains(t, line["args"], Mask)
+	assert.Equal(t, "aura1qpzr...zry9", line["recipient"])
+	assert.NotEqual(t, "192.0.2.1", line["ip"])
+	assert.Regexp(t, `^ip-[0-9a-f]{12}$`, line["ip"])
+	assert.Equal(t, "key [REDACTED] not found in keyring", line["error"], "errors keep their text for debugging")
+	assert.Equal(t, float64(100), line["amount"])
+	assert.Equal(t, "Executing CLI transaction --from [REDACTED]", line["msg"])
+	assert.Equal(t, "tx bank send [REDACTED] aura1qpzr...zry9 100uaura", line["args"], "addresses in text are truncated too")
+}
+
+func TestShortSecretsOnlyMaskedAsKeyNames(t *testing.T) {
+	// The documented default FAUCET_KEY is an ordinary word
+	r := New("faucet")
+	line := logEntry(t, r, log.Fields{
+		"args":  "tx bank send faucet aura1qpzry9x8gf2tvdw0s3jn54khce6mua7lqpzry9 100uaura --from=faucet",
+		"key":   "faucet",
+		"error": errors.New("key faucet not found"),
+	}, "Starting faucet service")
+
+	assert.Equal(t, "Starting faucet service", line["msg"])
+	assert.Equal(t, "tx bank send [REDACTED] aura1qpzr...zry9 100uaura --from=[REDACTED]", line["args"])
+	assert.Equal(t, Mask, line["key"])
+	assert.Equal(t, "key [REDACTED] not found", line["error"])
+}
+
+func TestLongSecretsMaskedAnywhere(t *testing.T) {
+	r := New("s3cr3t-admin-token-value")
+	assert.Equal(t, "bad token [REDACTED] from client", r.Text("bad token s3cr3t-admin-token-value from client"))
+}
+
+func TestTextTruncatesEmbeddedAddresses(t *testing.T) {
+	r := New()
+	line := logEntry(t, r, log.Fields{
+		"error": errors.New("account aura1qpzry9x8gf2tvdw0s3jn54khce6mua7lqpzry9 not found"),
+	}, "Paid aura1qpzry9x8gf2tvdw0s3jn54khce6mua7lqpzry9, tx ABC123")
+
+	assert.Equal(t, "Paid aura1qpzr...zry9, tx ABC123", line["msg"])
+	assert.Equal(t, "account aura1qpzr...zry9 not found", line["error"])
+}
+
+func TestIPHashIsStable(t *testing.T) {
+	r := New()
+	assert.Equal(t, r.IP("192.0.2.1"), r.IP("192.0.2.1"), "the same client correlates across lines")
+	assert.NotEqual(t, r.IP("192.0.2.1"), r.IP("192.0.2.2"))
+	assert.NotEqual(t, r.IP("192.0.2.1"), New().IP("192.0.2.1"), "hashes are salted per redactor")
+	assert.Equal(t, r.IP("192.0.2.1"), r.IP("192.0.2.1:51234"), "a connecting address hashes its host")
+	assert.Equal(t, r.IP("2001:db8::1"), r.IP("[2001:db8::1]:443"))
+}
+
+func TestFormatterHashesRemoteAddr(t *testing.T) {
+	r := New()
+	line := logEntry(t, r, log.Fields{"remote_addr": "192.0.2.1:51234"}, "Rejected request")
+	assert.Equal(t, r.IP("192.0.2.1"), line["remote_addr"])
+}
+
+func TestFormatterLeavesEntryUntouched(t *testing.T) {
+	r := New("faucet-key")
+	entry := log.WithFields(log.Fields{"ip": "192.0.2.1"})
+	entry.Message = "faucet-key"
+
+	_, err := NewFormatter(r, &log.JSONFormatter{}).Format(entry)
+	require.NoError(t, err)
+	assert.Equal(t, "192.0.2.1", entry.Data["ip"])
+	assert.Equal(t, "faucet-key", entry.Message)
+}
+
+func TestAddressShortValuesKeptWhole(t *testing.T) {
+	assert.Equal(t, "aura1abc", New().Address("aura1abc"))
+}
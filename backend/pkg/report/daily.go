@@ -0,0 +1,231 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aura-chain/aura/faucet/pkg/database"
+	"github.com/aura-chain/aura/faucet/pkg/metrics"
+)
+
+// topErrorLimit caps the error types listed in a report
+const topErrorLimit = 5
+
+// StatisticsSource provides lifetime request statistics; implemented by
+// *database.DB
+type StatisticsSource interface {
+	GetStatistics() (*database.Statistics, error)
+}
+
+// Config configures the daily report
+type Config struct {
+	URL string
+	// Hour and Minute are the time of day in Location the report is sent
+	Hour     int
+	Minute   int
+	Location *time.Location
+	Denom    string
+	// AmountPerRequest estimates the daily outflow for the runway
+	AmountPerRequest int64
+	// Reserve is the balance payouts never spend, left out of the runway
+	Reserve int64
+}
+
+// ErrorCount is the number of failed payouts of one error type
+type ErrorCount struct {
+	Type  string `json:"type"`
+	Count int64  `json:"count"`
+}
+
+// DayStats counts the requests this instance saw since the previous report
+type DayStats struct {
+	Requests        int64 `json:"requests"`
+	Successful      int64 `json:"successful"`
+	Failed          int64 `json:"failed"`
+	Blocked         int64 `json:"blocked"`
+	UniqueAddresses int   `json:"unique_addresses"`
+	UniqueIPs       int   `json:"unique_ips"`
+}
+
+// Report is the daily digest posted to the webhook. Request counts are
+// lifetime totals from the database; Day and errors cover only the time
+// since the previous report.
+type Report struct {
+	GeneratedAt       time.Time    `json:"generated_at"`
+	Denom             string       `json:"denom"`
+	TotalRequests     int64        `json:"total_requests"`
+	RequestsLast24h   int64        `json:"requests_last_24h"`
+	SuccessRate       float64      `json:"success_rate"`
+	TokensDistributed int64        `json:"tokens_distributed"`
+	UniqueRecipients  int64        `json:"unique_recipients"`
+	TopErrors         []ErrorCount `json:"top_errors"`
+	// Day is omitted without a tracker
+	Day *DayStats `json:"day,omitempty"`
+	// Balance and RunwayDays are omitted when the balance is unavailable;
+	// RunwayDays is also omitted when there were no requests in 24h
+	Balance    *int64   `json:"balance,omitempty"`
+	RunwayDays *float64 `json:"runway_days,omitempty"`
+}
+
+// Reporter posts a Report to a webhook once a day
+type Reporter struct {
+	stats   StatisticsSource
+	tracker *metrics.MetricsTracker
+	balance func() (int64, error)
+	config  Config
+	client  *http.Client
+
+	// now and after are the clock, overridable in tests
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+}
+
+// NewReporter creates a daily reporter. tracker and balance may be nil.
+func NewReporter(stats StatisticsSource, tracker *metrics.MetricsTracker, balance func() (int64, error), config Config) *Reporter {
+	if config.Location == nil {
+		config.Location = time.UTC
+	}
+
+	return &Reporter{
+		stats:   stats,
+		tracker: tracker,
+		balance: balance,
+		config:  config,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		now:     time.Now,
+		after:   time.After,
+	}
+}
+
+// Run sends a report at the configured time every day until ctx is cancelled
+func (r *Reporter) Run(ctx context.Context) {
+	for {
+		wait := r.nextRun(r.now()).Sub(r.now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.after(wait):
+		}
+
+		if err := r.Send(ctx); err != nil {
+			log.WithError(err).Warn("Failed to send daily report")
+		}
+	}
+}
+
+// nextRun returns the first report time strictly after now
+func (r *Reporter) nextRun(now time.Time) time.Time {
+	local := now.In(r.config.Location)
+	next := time.Date(local.Year(), local.Month(), local.Day(), r.config.Hour, r.config.Minute, 0, 0, r.config.Location)
+	if !next.After(local) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Send builds a report and posts it to the webhook
+func (r *Reporter) Send(ctx context.Context) error {
+	report, err := r.Build()
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.config.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post report: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("report endpoint returned status %d", resp.StatusCode)
+	}
+	log.WithField("total_requests", report.TotalRequests).Info("Sent daily report")
+	return nil
+}
+
+// Build assembles the current report. It ends the tracker's day, so the
+// day's counts and errors start over for the next report.
+func (r *Reporter) Build() (*Report, error) {
+	stats, err := r.stats.GetStatistics()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get statistics: %w", err)
+	}
+
+	report := &Report{
+		GeneratedAt:       r.now().UTC(),
+		Denom:             r.config.Denom,
+		TotalRequests:     stats.TotalRequests,
+		RequestsLast24h:   stats.RequestsLast24h,
+		TokensDistributed: stats.TotalDistributed,
+		UniqueRecipients:  stats.UniqueRecipients,
+		TopErrors:         []ErrorCount{},
+	}
+	if r.tracker != nil {
+		day := r.tracker.EndDay()
+		report.Day = &DayStats{
+			Requests:        day.TotalRequests,
+			Successful:      day.SuccessfulRequests,
+			Failed:          day.FailedRequests,
+			Blocked:         day.BlockedRequests,
+			UniqueAddresses: day.UniqueAddresses,
+			UniqueIPs:       day.UniqueIPs,
+		}
+		report.TopErrors = topErrors(day.ErrorBreakdown)
+	}
+	if stats.TotalRequests > 0 {
+		report.SuccessRate = float64(stats.SuccessfulRequests) / float64(stats.TotalRequests)
+	}
+
+	if r.balance != nil {
+		balance, err := r.balance()
+		if err != nil {
+			log.WithError(err).Warn("Daily report omits the faucet balance")
+		} else {
+			report.Balance = &balance
+			if daily := stats.RequestsLast24h * r.config.AmountPerRequest; daily > 0 {
+				runway := float64(max(balance-r.config.Reserve, 0)) / float64(daily)
+				report.RunwayDays = &runway
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// topErrors returns the most frequent error types, most frequent first
+func topErrors(counts map[string]int64) []ErrorCount {
+	errors := []ErrorCount{}
+	for errorType, count := range counts {
+		if count > 0 {
+			errors = append(errors, ErrorCount{Type: errorType, Count: count})
+		}
+	}
+
+	sort.Slice(errors, func(i, j int) bool {
+		if errors[i].Count != errors[j].Count {
+			return errors[i].Count > errors[j].Count
+		}
+		return errors[i].Type < errors[j].Type
+	})
+	if len(errors) > topErrorLimit {
+		errors = errors[:topErrorLimit]
+	}
+	return errors
+}
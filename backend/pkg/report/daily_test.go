@@ -0,0 +1,177 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aura-chain/aura/faucet/pkg/database"
+	"github.com/aura-chain/aura/faucet/pkg/metrics"
+)
+
+type stubStatistics struct {
+	stats *database.Statistics
+	err   error
+}
+
+func (s *stubStatistics) GetStatistics() (*database.Statistics, error) {
+	return s.stats, s.err
+}
+
+func newStats() *stubStatistics {
+	return &stubStatistics{stats: &database.Statistics{
+		TotalRequests:      200,
+		SuccessfulRequests: 150,
+		FailedRequests:     50,
+		TotalDistributed:   15000,
+		UniqueRecipients:   120,
+		RequestsLast24h:    40,
+	}}
+}
+
+func recordFailures(tracker *metrics.MetricsTracker, errorType string, n int) {
+	for i := 0; i < n; i++ {
+		tracker.RecordRequest(metrics.RequestMetrics{ErrorType: errorType, Timestamp: time.Now()})
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	tracker := metrics.NewMetricsTracker()
+	recordFailures(tracker, "send_failed", 3)
+	recordFailures(tracker, "supply_guard", 1)
+	recordFailures(tracker, "broadcast_timeout", 5)
+
+	balance := func() (int64, error) { return 8000, nil }
+	reporter := NewReporter(newStats(), tracker, balance, Config{Denom: "uaura", AmountPerRequest: 100})
+
+	report, err := reporter.Build()
+	require.NoError(t, err)
+	assert.Equal(t, "uaura", report.Denom)
+	assert.Equal(t, int64(200), report.TotalRequests)
+	assert.InDelta(t, 0.75, report.SuccessRate, 1e-9)
+	assert.Equal(t, int64(15000), report.TokensDistributed)
+	assert.Equal(t, int64(120), report.UniqueRecipients)
+	assert.Equal(t, []ErrorCount{
+		{Type: "broadcast_timeout", Count: 5},
+		{Type: "send_failed", Count: 3},
+		{Type: "supply_guard", Count: 1},
+	}, report.TopErrors)
+	require.NotNil(t, report.Day)
+	assert.Equal(t, int64(9), report.Day.Requests)
+	assert.Equal(t, int64(9), report.Day.Failed)
+	require.NotNil(t, report.Balance)
+	assert.Equal(t, int64(8000), *report.Balance)
+	require.NotNil(t, report.RunwayDays)
+	assert.InDelta(t, 2.0, *report.RunwayDays, 1e-9, "8000 at 40 requests of 100 a day")
+
+	// The next report only covers errors since this one
+	recordFailures(tracker, "send_failed", 2)
+	report, err = reporter.Build()
+	require.NoError(t, err)
+	assert.Equal(t, []ErrorCount{{Type: "send_failed", Count: 2}}, report.TopErrors)
+	assert.Equal(t, int64(2), report.Day.Requests, "day counts start over")
+}
+
+func TestBuildReportRunwayExcludesReserve(t *testing.T) {
+	balance := func() (int64, error) { return 8000, nil }
+	reporter := NewReporter(newStats(), nil, balance, Config{AmountPerRequest: 100, Reserve: 4000})
+
+	report, err := reporter.Build()
+	require.NoError(t, err)
+	require.NotNil(t, report.Balance)
+	assert.Equal(t, int64(8000), *report.Balance, "the balance is reported in full")
+	require.NotNil(t, report.RunwayDays)
+	assert.InDelta(t, 1.0, *report.RunwayDays, 1e-9, "4000 spendable at 4000 a day")
+
+	reporter = NewReporter(newStats(), nil, balance, Config{AmountPerRequest: 100, Reserve: 9000})
+	report, err = reporter.Build()
+	require.NoError(t, err)
+	require.NotNil(t, report.RunwayDays)
+	assert.Zero(t, *report.RunwayDays, "nothing spendable below the reserve")
+}
+
+func TestBuildReportWithoutBalanceOrTracker(t *testing.T) {
+	balance := func() (int64, error) { return 0, errors.New("node down") }
+	reporter := NewReporter(newStats(), nil, balance, Config{AmountPerRequest: 100})
+
+	report, err := reporter.Build()
+	require.NoError(t, err)
+	assert.Nil(t, report.Balance)
+	assert.Nil(t, report.RunwayDays)
+	assert.Empty(t, report.TopErrors)
+	assert.Nil(t, report.Day)
+
+	reporter = NewReporter(&stubStatistics{err: errors.New("db down")}, nil, nil, Config{})
+	_, err = reporter.Build()
+	assert.Error(t, err)
+}
+
+func TestNextRun(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	reporter := NewReporter(newStats(), nil, nil, Config{Hour: 9, Minute: 30, Location: newYork})
+
+	morning := time.Date(2026, 3, 2, 8, 0, 0, 0, newYork)
+	assert.Equal(t, time.Date(2026, 3, 2, 9, 30, 0, 0, newYork), reporter.nextRun(morning))
+
+	exactly := time.Date(2026, 3, 2, 9, 30, 0, 0, newYork)
+	assert.Equal(t, time.Date(2026, 3, 3, 9, 30, 0, 0, newYork), reporter.nextRun(exactly), "the next day once due")
+
+	// 13:00 UTC is 08:00 in New York
+	assert.Equal(t, time.Date(2026, 3, 2, 9, 30, 0, 0, newYork), reporter.nextRun(time.Date(2026, 3, 2, 13, 0, 0, 0, time.UTC)))
+}
+
+func TestRunSendsReportOnSchedule(t *testing.T) {
+	received := make(chan Report, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var report Report
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&report))
+		received <- report
+	}))
+	defer server.Close()
+
+	reporter := NewReporter(newStats(), nil, nil, Config{URL: server.URL, Hour: 9})
+	now := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	reporter.now = func() time.Time { return now }
+	waits := make(chan time.Duration, 1)
+	fire := make(chan time.Time)
+	reporter.after = func(d time.Duration) <-chan time.Time {
+		waits <- d
+		return fire
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		reporter.Run(ctx)
+		close(done)
+	}()
+
+	assert.Equal(t, time.Hour, <-waits, "waits until 09:00")
+	select {
+	case <-received:
+		t.Fatal("report sent before the scheduled time")
+	default:
+	}
+
+	now = now.Add(time.Hour)
+	fire <- now
+	select {
+	case report := <-received:
+		assert.Equal(t, int64(200), report.TotalRequests)
+	case <-time.After(5 * time.Second):
+		t.Fatal("report was not sent")
+	}
+
+	assert.Equal(t, 24*time.Hour, <-waits, "then waits a day")
+	cancel()
+	<-done
+}
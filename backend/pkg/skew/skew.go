@@ -0,0 +1,24 @@
+// Package skew applies one tolerance for clock drift between the faucet and
+// the clients or services whose timestamps it validates
+package skew
+
+import "time"
+
+// Default is the tolerance used when none is configured
+const Default = 30 * time.Second
+
+// Expired reports whether deadline passed more than tolerance before now
+func Expired(deadline, now time.Time, tolerance time.Duration) bool {
+	return now.After(deadline.Add(tolerance))
+}
+
+// InFuture reports whether ts is more than tolerance ahead of now
+func InFuture(ts, now time.Time, tolerance time.Duration) bool {
+	return ts.After(now.Add(tolerance))
+}
+
+// Within reports whether ts lies in [now-maxAge, now], widened on both
+// sides by tolerance
+func Within(ts, now time.Time, maxAge, tolerance time.Duration) bool {
+	return !InFuture(ts, now, tolerance) && !Expired(ts.Add(maxAge), now, tolerance)
+}
@@ -0,0 +1,18 @@
+package skew
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithin(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tolerance := 30 * time.Second
+
+	assert.True(t, Within(now.Add(29*time.Second), now, time.Minute, tolerance), "ahead within skew")
+	assert.False(t, Within(now.Add(31*time.Second), now, time.Minute, tolerance), "ahead beyond skew")
+	assert.True(t, Within(now.Add(-89*time.Second), now, time.Minute, tolerance), "old within skew")
+	assert.False(t, Within(now.Add(-91*time.Second), now, time.Minute, tolerance), "old beyond skew")
+}
@@ -0,0 +1,234 @@
+// Package units holds base-unit coin amounts of any size. Most denoms fit
+// in int64, but 18-decimal denoms pass 9.2 tokens' worth of base units
+// beyond it, so amounts on the send path use Amount.
+package units
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// maxDigits bounds parsed amounts to the 256-bit range Cosmos SDK coins use
+const maxDigits = 78
+
+// Amount is an immutable base-unit amount; the zero value is zero
+type Amount struct {
+	v *big.Int
+}
+
+// New returns n base units
+func New(n int64) Amount {
+	return wrap(big.NewInt(n))
+}
+
+// wrap keeps zero as the zero value, so equal amounts are also deeply equal
+func wrap(v *big.Int) Amount {
+	if v.Sign() == 0 {
+		return Amount{}
+	}
+	return Amount{v: v}
+}
+
+// Parse parses a non-negative decimal integer such as "100000000000000000000"
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Amount{}, errors.New("empty amount")
+	}
+	if len(s) > maxDigits || !isDigits(s) {
+		return Amount{}, fmt.Errorf("invalid amount %q", s)
+	}
+	v, _ := new(big.Int).SetString(s, 10)
+	return wrap(v), nil
+}
+
+// ParseDisplay parses a non-negative display amount such as "1.5" into base
+// units of a denom with the given exponent, rejecting fractions finer than
+// one base unit
+func ParseDisplay(display string, exponent int) (Amount, error) {
+	if exponent < 0 {
+		return Amount{}, fmt.Errorf("denom exponent %d out of range", exponent)
+	}
+	wholePart, fracPart, hasFrac := strings.Cut(strings.TrimSpace(display), ".")
+	if (wholePart == "" && fracPart == "") || (hasFrac && fracPart == "") {
+		return Amount{}, fmt.Errorf("invalid amount %q", display)
+	}
+	if len(fracPart) > exponent {
+		return Amount{}, fmt.Errorf("amount %q has more than %d decimal places", display, exponent)
+	}
+	if wholePart == "" {
+		wholePart = "0"
+	}
+	return Parse(wholePart + fracPart + strings.Repeat("0", exponent-len(fracPart)))
+}
+
+func (a Amount) big() *big.Int {
+	if a.v == nil {
+		return new(big.Int)
+	}
+	return a.v
+}
+
+// String returns the amount in base units
+func (a Amount) String() string {
+	return a.big().String()
+}
+
+// Display returns the exact amount in display units of a denom with the
+// given exponent, e.g. 1500000 with exponent 6 is "1.5"
+func (a Amount) Display(exponent int) string {
+	if exponent <= 0 {
+		return a.String()
+	}
+	digits := new(big.Int).Abs(a.big()).String()
+	sign := ""
+	if a.Sign() < 0 {
+		sign = "-"
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	whole, frac := digits[:len(digits)-exponent], strings.TrimRight(digits[len(digits)-exponent:], "0")
+	if frac == "" {
+		return sign + whole
+	}
+	return sign + whole + "." + frac
+}
+
+// Int64 returns the amount and whether it fits in int64
+func (a Amount) Int64() (int64, bool) {
+	v := a.big()
+	if !v.IsInt64() {
+		return 0, false
+	}
+	return v.Int64(), true
+}
+
+// Saturated returns the amount clamped to the int64 range, for limits and
+// analytics that only need to compare
+func (a Amount) Saturated() int64 {
+	if n, ok := a.Int64(); ok {
+		return n
+	}
+	if a.Sign() < 0 {
+		return math.MinInt64
+	}
+	return math.MaxInt64
+}
+
+// Float64 returns the nearest float64, for metrics
+func (a Amount) Float64() float64 {
+	f, _ := new(big.Float).SetInt(a.big()).Float64()
+	return f
+}
+
+// Ratio returns a / b as the nearest float64, computed exactly first so
+// amounts beyond int64 keep their proportion; zero when b is zero
+func (a Amount) Ratio(b Amount) float64 {
+	if b.IsZero() {
+		return 0
+	}
+	f, _ := new(big.Rat).SetFrac(a.big(), b.big()).Float64()
+	return f
+}
+
+// Sign returns -1, 0 or +1
+func (a Amount) Sign() int {
+	return a.big().Sign()
+}
+
+// IsZero reports whether the amount is zero
+func (a Amount) IsZero() bool {
+	return a.Sign() == 0
+}
+
+// Cmp returns -1, 0 or +1 as a is less than, equal to or greater than b
+func (a Amount) Cmp(b Amount) int {
+	return a.big().Cmp(b.big())
+}
+
+// Add returns a + b
+func (a Amount) Add(b Amount) Amount {
+	return wrap(new(big.Int).Add(a.big(), b.big()))
+}
+
+// Sub returns a - b
+func (a Amount) Sub(b Amount) Amount {
+	return wrap(new(big.Int).Sub(a.big(), b.big()))
+}
+
+// MarshalJSON encodes the amount as a JSON number, so amounts that fit in
+// int64 encode as they always have
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a decimal string
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	data = bytes.Trim(data, `"`)
+	if string(data) == "null" {
+		return nil
+	}
+	parsed, err := Parse(string(data))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Value binds amounts that fit in int64 as before and larger ones as a
+// decimal string, which NUMERIC columns accept at any size
+func (a Amount) Value() (driver.Value, error) {
+	if n, ok := a.Int64(); ok {
+		return n, nil
+	}
+	return a.String(), nil
+}
+
+// Scan reads an integer, NUMERIC or text column
+func (a *Amount) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = Amount{}
+		return nil
+	case int64:
+		*a = New(v)
+		return nil
+	case []byte:
+		return a.scanString(string(v))
+	case string:
+		return a.scanString(v)
+	default:
+		return fmt.Errorf("cannot scan %T into an amount", src)
+	}
+}
+
+// scanString accepts what Parse does plus the minus sign a signed column
+// may hold
+func (a *Amount) scanString(s string) error {
+	negative := strings.HasPrefix(s, "-")
+	parsed, err := Parse(strings.TrimPrefix(s, "-"))
+	if err != nil {
+		return err
+	}
+	if negative && parsed.v != nil {
+		parsed = Amount{v: new(big.Int).Neg(parsed.v)}
+	}
+	*a = parsed
+	return nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package units
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEighteenDecimalAmountBeyondInt64(t *testing.T) {
+	// 100 tokens of an 18-decimal denom is 1e20 base units
+	amount, err := ParseDisplay("100", 18)
+	require.NoError(t, err)
+	assert.Equal(t, "100000000000000000000", amount.String())
+	assert.Equal(t, "100", amount.Display(18))
+
+	_, fits := amount.Int64()
+	assert.False(t, fits)
+	assert.Equal(t, int64(math.MaxInt64), amount.Saturated())
+	assert.Equal(t, 1e20, amount.Float64())
+
+	parsed, err := Parse("100000000000000000000")
+	require.NoError(t, err)
+	assert.Zero(t, amount.Cmp(parsed))
+	assert.Equal(t, "100000000000000000001", amount.Add(New(1)).String())
+	assert.Equal(t, "99999999999999999999", amount.Sub(New(1)).String())
+
+	half, err := ParseDisplay("0.5", 18)
+	require.NoError(t, err)
+	assert.Equal(t, "500000000000000000", half.String())
+	assert.Equal(t, "0.5", half.Display(18))
+	assert.Equal(t, 0.005, half.Ratio(amount))
+	assert.Zero(t, amount.Ratio(Amount{}))
+}
+
+func TestParseRejectsInvalidAmounts(t *testing.T) {
+	for _, input := range []string{"", "-1", "1.5", "1e20", "0x10", "12a"} {
+		_, err := Parse(input)
+		assert.Error(t, err, input)
+	}
+	_, err := Parse(strings.Repeat("9", maxDigits+1))
+	assert.Error(t, err, "beyond 256 bits")
+
+	_, err = ParseDisplay("1.0000001", 6)
+	assert.Error(t, err, "finer than one base unit")
+	_, err = ParseDisplay("1.", 6)
+	assert.Error(t, err)
+}
+
+func TestInt64Amounts(t *testing.T) {
+	amount := New(1_500_000)
+	n, fits := amount.Int64()
+	assert.True(t, fits)
+	assert.Equal(t, int64(1_500_000), n)
+	assert.Equal(t, "1.5", amount.Display(6))
+	assert.Equal(t, "1500000", amount.Display(0))
+	assert.Equal(t, "0.000001", New(1).Display(6))
+	assert.Equal(t, "-0.5", New(-500_000).Display(6))
+
+	var zero Amount
+	assert.True(t, zero.IsZero())
+	assert.Equal(t, "0", zero.String())
+	assert.Equal(t, int64(math.MinInt64), zero.Sub(amountOf(t, "100000000000000000000")).Saturated())
+}
+
+func TestAmountJSON(t *testing.T) {
+	var body struct {
+		Amount Amount `json:"amount"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":100000000000000000000}`), &body))
+	assert.Equal(t, "100000000000000000000", body.Amount.String())
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":"250"}`), &body))
+	assert.Equal(t, "250", body.Amount.String())
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":-1}`), &body))
+
+	encoded, err := json.Marshal(map[string]Amount{"amount": New(100)})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":100}`, string(encoded), "int64 amounts encode as before")
+}
+
+func TestAmountSQL(t *testing.T) {
+	big := amountOf(t, "100000000000000000000")
+	value, err := big.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "100000000000000000000", value)
+	value, err = New(42).Value()
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), value)
+
+	var scanned Amount
+	require.NoError(t, scanned.Scan([]byte("100000000000000000000")))
+	assert.Zero(t, scanned.Cmp(big))
+	require.NoError(t, scanned.Scan(int64(42)))
+	assert.Equal(t, "42", scanned.String())
+	require.NoError(t, scanned.Scan("-7"))
+	assert.Equal(t, "-7", scanned.String())
+	assert.Error(t, scanned.Scan(1.5))
+}
+
+func amountOf(t *testing.T, s string) Amount {
+	amount, err := Parse(s)
+	require.NoError(t, err)
+	return amount
+}
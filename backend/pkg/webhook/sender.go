@@ -0,0 +1,150 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aura-chain/aura/faucet/pkg/database"
+)
+
+// Outbox is the persistence the sender drains; implemented by *database.DB
+type Outbox interface {
+	GetPendingWebhookEvents(limit int) ([]*database.WebhookEvent, error)
+	MarkWebhookDelivered(id int64) error
+	MarkWebhookFailed(id int64, errorMsg string, nextAttempt time.Time, maxAttempts int) error
+}
+
+// SenderConfig configures webhook delivery
+type SenderConfig struct {
+	URL          string
+	MaxAttempts  int
+	RetryBase    time.Duration
+	MaxRetry     time.Duration
+	PollInterval time.Duration
+	BatchSize    int
+}
+
+// Sender delivers outbox events with at-least-once semantics. Every request
+// carries the event id in X-Webhook-Event-Id so receivers can dedup retries.
+type Sender struct {
+	outbox Outbox
+	client *http.Client
+	config SenderConfig
+	now    func() time.Time
+}
+
+// NewSender creates a webhook sender
+func NewSender(outbox Outbox, config SenderConfig) *Sender {
+	if config.MaxAttempts == 0 {
+		config.MaxAttempts = 5
+	}
+	if config.RetryBase == 0 {
+		config.RetryBase = 10 * time.Second
+	}
+	if config.MaxRetry == 0 {
+		config.MaxRetry = time.Hour
+	}
+	if config.PollInterval == 0 {
+		config.PollInterval = 5 * time.Second
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = 50
+	}
+
+	return &Sender{
+		outbox: outbox,
+		client: &http.Client{Timeout: 10 * time.Second},
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Run drains the outbox every PollInterval until ctx is cancelled
+func (s *Sender) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.DrainOnce(ctx); err != nil {
+			log.WithError(err).Warn("Failed to drain webhook outbox")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DrainOnce attempts delivery of every event that is currently due
+func (s *Sender) DrainOnce(ctx context.Context) error {
+	events, err := s.outbox.GetPendingWebhookEvents(s.config.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := s.deliver(ctx, event); err != nil {
+			next := s.now().Add(s.backoff(event.Attempts))
+			log.WithFields(log.Fields{
+				"event_id": event.EventID,
+				"attempts": event.Attempts + 1,
+			}).WithError(err).Warn("Webhook delivery failed")
+			if markErr := s.outbox.MarkWebhookFailed(event.ID, err.Error(), next, s.config.MaxAttempts); markErr != nil {
+				log.WithError(markErr).Error("Failed to record webhook failure")
+			}
+			continue
+		}
+
+		if err := s.outbox.MarkWebhookDelivered(event.ID); err != nil {
+			log.WithError(err).Error("Failed to mark webhook delivered")
+		}
+	}
+
+	return nil
+}
+
+// deliver posts a single event to the configured URL
+func (s *Sender) deliver(ctx context.Context, event *database.WebhookEvent) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(event.Payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event-Id", event.EventID)
+	req.Header.Set("X-Webhook-Event-Type", event.EventType)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook endpoint returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// backoff returns the exponential delay before the next attempt
+func (s *Sender) backoff(attempts int) time.Duration {
+	delay := s.config.RetryBase
+	for i := 0; i < attempts; i++ {
+		delay *= 2
+		if delay >= s.config.MaxRetry {
+			return s.config.MaxRetry
+		}
+	}
+	return delay
+}
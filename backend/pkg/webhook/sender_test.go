@@ -0,0 +1,134 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aura-chain/aura/faucet/pkg/database"
+)
+
+// memoryOutbox is an in-memory Outbox that honors next-attempt scheduling
+type memoryOutbox struct {
+	mu        sync.Mutex
+	events    map[int64]*database.WebhookEvent
+	nextAt    map[int64]time.Time
+	delivered map[int64]bool
+	dead      map[int64]bool
+	now       func() time.Time
+}
+
+func newMemoryOutbox(now func() time.Time, events ...*database.WebhookEvent) *memoryOutbox {
+	o := &memoryOutbox{
+		events:    make(map[int64]*database.WebhookEvent),
+		nextAt:    make(map[int64]time.Time),
+		delivered: make(map[int64]bool),
+		dead:      make(map[int64]bool),
+		now:       now,
+	}
+	for _, e := range events {
+		o.events[e.ID] = e
+	}
+	return o
+}
+
+func (o *memoryOutbox) GetPendingWebhookEvents(limit int) ([]*database.WebhookEvent, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var out []*database.WebhookEvent
+	for id, e := range o.events {
+		if o.delivered[id] || o.dead[id] || o.nextAt[id].After(o.now()) {
+			continue
+		}
+		copied := *e
+		out = append(out, &copied)
+	}
+	return out, nil
+}
+
+func (o *memoryOutbox) MarkWebhookDelivered(id int64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.delivered[id] = true
+	return nil
+}
+
+func (o *memoryOutbox) MarkWebhookFailed(id int64, errorMsg string, nextAttempt time.Time, maxAttempts int) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events[id].Attempts++
+	o.nextAt[id] = nextAttempt
+	if o.events[id].Attempts >= maxAttempts {
+		o.dead[id] = true
+	}
+	return nil
+}
+
+func TestSenderRetriesUntilDelivered(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	var eventIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		eventIDs = append(eventIDs, r.Header.Get("X-Webhook-Event-Id"))
+		if calls == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	clock := func() time.Time { return now }
+	outbox := newMemoryOutbox(clock, &database.WebhookEvent{ID: 1, EventID: "payout-1", EventType: "payout.success", Payload: []byte(`{}`)})
+
+	sender := NewSender(outbox, SenderConfig{URL: server.URL, RetryBase: time.Minute})
+	sender.now = clock
+
+	// First attempt fails and is scheduled a minute out
+	require.NoError(t, sender.DrainOnce(context.Background()))
+	assert.False(t, outbox.delivered[1])
+	assert.Equal(t, now.Add(time.Minute), outbox.nextAt[1])
+
+	// Not yet due
+	require.NoError(t, sender.DrainOnce(context.Background()))
+	assert.Equal(t, 1, calls)
+
+	now = now.Add(2 * time.Minute)
+	require.NoError(t, sender.DrainOnce(context.Background()))
+	assert.True(t, outbox.delivered[1])
+	assert.Equal(t, []string{"payout-1", "payout-1"}, eventIDs)
+}
+
+func TestSenderParksEventAfterMaxAttempts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	outbox := newMemoryOutbox(func() time.Time { return time.Now().Add(24 * time.Hour) },
+		&database.WebhookEvent{ID: 1, EventID: "payout-1", Payload: []byte(`{}`)})
+	sender := NewSender(outbox, SenderConfig{URL: server.URL, MaxAttempts: 2})
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, sender.DrainOnce(context.Background()))
+	}
+	assert.True(t, outbox.dead[1])
+	assert.Equal(t, 2, outbox.events[1].Attempts)
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	sender := NewSender(nil, SenderConfig{RetryBase: time.Second, MaxRetry: 5 * time.Second})
+	assert.Equal(t, time.Second, sender.backoff(0))
+	assert.Equal(t, 4*time.Second, sender.backoff(2))
+	assert.Equal(t, 5*time.Second, sender.backoff(10))
+}
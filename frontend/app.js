@@ -1,10 +1,12 @@
 // AURA Testnet Faucet - Frontend Application
 // Handles UI interactions, API calls, and validation
 
+// Resolve the API against the page's directory so the faucet also works when
+// served from a sub-path (BASE_PATH)
 const API_BASE_URL =
   window.location.hostname === "localhost"
     ? "http://localhost:8080/api/v1"
-    : "/api/v1";
+    : `${window.location.pathname.replace(/\/[^/]*$/, "")}/api/v1`;
 
 let captchaToken = null;
 
@@ -92,15 +94,29 @@ async function loadFaucetInfo() {
 }
 
 function updateFaucetInfo(data) {
-  // Update faucet amount
-  document.getElementById("faucetAmount").textContent = formatAmount(
-    data.amount_per_request,
-  );
+  // Update faucet amount, preferring the server's rounding policy
+  document.getElementById("faucetAmount").textContent =
+    data.amount_display ?? formatAmount(data.amount_per_request);
 
   // Update statistics
   document.getElementById("faucetBalance").textContent = formatAmount(
     data.balance,
   );
+
+  // Distribution stats and recent transactions are only served when
+  // PUBLIC_STATS is on; hide them otherwise
+  const statsPublic = statsAvailable(data);
+  for (const id of ["totalDistributed", "uniqueRecipients", "last24h"]) {
+    document.getElementById(id).closest(".info-card").style.display =
+      statsPublic ? "" : "none";
+  }
+  document.getElementById("recentTransactionsCard").style.display = statsPublic
+    ? ""
+    : "none";
+  if (!statsPublic) {
+    return;
+  }
+
   document.getElementById("totalDistributed").textContent = formatAmount(
     data.total_distributed,
   );
@@ -110,9 +126,20 @@ function updateFaucetInfo(data) {
     data.requests_last_24h.toLocaleString();
 }
 
+function statsAvailable(data) {
+  return (
+    data.total_distributed != null &&
+    data.unique_recipients != null &&
+    data.requests_last_24h != null
+  );
+}
+
 // Load recent transactions
 async function loadRecentTransactions() {
   const container = document.getElementById("recentTransactions");
+  if (!state.faucetInfo || !statsAvailable(state.faucetInfo)) {
+    return;
+  }
 
   try {
     const response = await fetch(`${API_BASE_URL}/faucet/recent`);
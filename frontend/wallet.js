@@ -6438,7 +6438,7 @@ zoo`.split(`
           (s.value = h.address),
           (i.value = h.publicKey),
           (c.textContent = h.derivationPath),
-          (f.href = `/?address=${encodeURIComponent(h.address)}`),
+          (f.href = `./?address=${encodeURIComponent(h.address)}`),
           (n.style.display = "block"),
           pe("Wallet generated locally in your browser.", "success");
       } catch (p) {